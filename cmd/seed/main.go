@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/config"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/seed"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	_ "github.com/lib/pq"
+)
+
+// seed コマンドは学年・所属組織・場所のマスターデータを YAML または CSV から読み込む
+func main() {
+	path := flag.String("file", "db/seeds/master_data.yaml", "シードファイルのパス（.yaml / .yml / .csv）")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	data, err := seed.Load(*path)
+	if err != nil {
+		log.Fatalf("Failed to load seed file: %v", err)
+	}
+
+	db, err := sql.Open("postgres", cfg.Database.DSN())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	masterDataService := service.NewMasterDataService(repository.NewMasterDataRepository(db), repository.NewTransactor(db))
+	result, err := masterDataService.Seed(context.Background(), data)
+	if err != nil {
+		log.Fatalf("Failed to seed master data: %v", err)
+	}
+
+	log.Printf("Seeded master data from %s: created=%d updated=%d unchanged=%d",
+		*path, result.Created, result.Updated, result.Unchanged)
+}
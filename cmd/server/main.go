@@ -1,15 +1,28 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"time"
+	// 実行環境にタイムゾーンのデータがなくても自動退室と集計のタイムゾーンを読み込めるようにする
+	_ "time/tzdata"
 
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
 	"github.com/StepByCode/TSUNAGU-Link-back/internal/config"
 	"github.com/StepByCode/TSUNAGU-Link-back/internal/handler"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/mail"
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/nonce"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/oidc"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/password"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/presence"
 	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/secretbox"
 	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -54,11 +67,261 @@ func main() {
 	log.Println("Successfully connected to database")
 
 	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo, cfg.JWT.Secret, cfg.JWT.ExpiryHours)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	tokenRevocationRepo := repository.NewTokenRevocationRepository(db)
+	passwordResetRepo := repository.NewPasswordResetTokenRepository(db)
+	emailVerificationRepo := repository.NewEmailVerificationTokenRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
+	profileRepo := repository.NewProfileRepository(db)
+	masterDataRepo := repository.NewMasterDataRepository(db)
+	attendanceRepo := repository.NewAttendanceRepository(db)
+	nfcRepo := repository.NewNFCRepository(db)
+	deviceRepo := repository.NewDeviceRepository(db)
+	transactor := repository.NewTransactor(db)
+
+	var mailer mail.Sender
+	switch cfg.Mail.Sender {
+	case "file":
+		fileSender, err := mail.NewFileSender(cfg.Mail.FileDir)
+		if err != nil {
+			log.Fatalf("Failed to initialize mail sender: %v", err)
+		}
+		mailer = fileSender
+	case "log":
+		mailer = mail.NewLogSender(nil)
+	default:
+		log.Fatalf("Unknown MAIL_SENDER: %q", cfg.Mail.Sender)
+	}
+
+	var attemptStore lockout.Store
+	switch cfg.LoginLockout.Store {
+	case "postgres":
+		attemptStore = repository.NewLoginAttemptRepository(db)
+	case "memory":
+		attemptStore = lockout.NewMemoryStore()
+	default:
+		log.Fatalf("Unknown LOGIN_ATTEMPT_STORE: %q", cfg.LoginLockout.Store)
+	}
+	lockoutDuration := time.Duration(cfg.LoginLockout.LockoutMinutes) * time.Minute
+	loginLockouts := service.LoginLockouts{
+		Account: lockout.NewLimiter(attemptStore, "account", lockout.Policy{
+			MaxFailures: cfg.LoginLockout.MaxFailures,
+			Lockout:     lockoutDuration,
+			BaseDelay:   time.Duration(cfg.LoginLockout.BackoffBaseSeconds) * time.Second,
+			MaxDelay:    time.Duration(cfg.LoginLockout.BackoffMaxSeconds) * time.Second,
+		}),
+		// 同じ IP を共有する利用者を巻き込まないよう、IP 単位では段階的な待ち時間を設けない
+		IP: lockout.NewLimiter(attemptStore, "ip", lockout.Policy{
+			MaxFailures: cfg.LoginLockout.IPMaxFailures,
+			Lockout:     lockoutDuration,
+		}),
+	}
+
+	tokenManager := auth.NewTokenManager(cfg.JWT.Secret, time.Duration(cfg.JWT.AccessExpiryMinutes)*time.Minute)
+	authService := service.NewAuthService(
+		userRepo,
+		refreshTokenRepo,
+		tokenRevocationRepo,
+		mfaRepo,
+		tokenManager,
+		time.Duration(cfg.JWT.RefreshExpiryHours)*time.Hour,
+		time.Duration(cfg.JWT.RevocationCacheSeconds)*time.Second,
+		loginLockouts,
+	)
+	passwordPolicy := password.Policy{
+		MinLength:     cfg.PasswordPolicy.MinLength,
+		RequireUpper:  cfg.PasswordPolicy.RequireUpper,
+		RequireLower:  cfg.PasswordPolicy.RequireLower,
+		RequireDigit:  cfg.PasswordPolicy.RequireDigit,
+		RequireSymbol: cfg.PasswordPolicy.RequireSymbol,
+		RejectCommon:  cfg.PasswordPolicy.RejectCommon,
+	}
+	resendInterval := time.Duration(cfg.EmailVerify.ResendIntervalSeconds) * time.Second
+	emailVerificationService := service.NewEmailVerificationService(
+		userRepo,
+		emailVerificationRepo,
+		transactor,
+		mailer,
+		service.EmailVerificationConfig{
+			VerifyURL: cfg.EmailVerify.URL,
+			Expiry:    time.Duration(cfg.EmailVerify.ExpiryHours) * time.Hour,
+			MailFrom:  cfg.Mail.From,
+			Sends: lockout.NewLimiter(attemptStore, "email-verification", lockout.Policy{
+				MaxFailures: cfg.EmailVerify.MaxSends,
+				Lockout:     time.Hour,
+				BaseDelay:   resendInterval,
+				MaxDelay:    resendInterval,
+			}),
+		},
+	)
+	profileService := service.NewProfileService(profileRepo, userRepo, masterDataRepo, transactor)
+	masterDataService := service.NewMasterDataService(masterDataRepo, transactor)
+	nfcService := service.NewNFCService(
+		nfcRepo,
+		profileRepo,
+		masterDataRepo,
+		transactor,
+		time.Duration(cfg.NFC.EnrollmentExpiryMinutes)*time.Minute,
+	)
+	var nonceStore nonce.Store
+	switch cfg.Device.NonceStore {
+	case "postgres":
+		nonceStore = repository.NewDeviceNonceRepository(db)
+	case "memory":
+		nonceStore = nonce.NewMemoryStore()
+	default:
+		log.Fatalf("Unknown DEVICE_NONCE_STORE: %q", cfg.Device.NonceStore)
+	}
+	if cfg.Device.EncryptionKey == "" {
+		log.Fatalf("DEVICE_ENCRYPTION_KEY is required")
+	}
+	deviceSecrets, err := secretbox.New(cfg.Device.EncryptionKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize device secret encryption: %v", err)
+	}
+	deviceService := service.NewDeviceService(deviceRepo, masterDataRepo, service.DeviceConfig{
+		Secrets:            deviceSecrets,
+		SignatureTolerance: time.Duration(cfg.Device.SignatureToleranceSeconds) * time.Second,
+		Nonces:             nonceStore,
+	})
+	var presenceBus presence.Bus
+	switch cfg.Presence.EventBus {
+	case "postgres":
+		postgresBus, err := presence.NewPostgresBus(db, cfg.Database.DSN())
+		if err != nil {
+			log.Fatalf("Failed to initialize presence event bus: %v", err)
+		}
+		go postgresBus.Run(context.Background())
+		presenceBus = postgresBus
+	case "memory":
+		presenceBus = presence.NewMemoryBus()
+	default:
+		log.Fatalf("Unknown PRESENCE_EVENT_BUS: %q", cfg.Presence.EventBus)
+	}
+	attendanceService := service.NewAttendanceService(attendanceRepo, profileRepo, userRepo, masterDataRepo, nfcService, transactor, presenceBus)
+	presenceService := service.NewPresenceService(attendanceRepo, profileRepo, masterDataRepo, presenceBus)
+	correctionService := service.NewCorrectionService(
+		repository.NewCorrectionRepository(db),
+		attendanceRepo,
+		userRepo,
+		masterDataRepo,
+		transactor,
+		presenceBus,
+	)
+	reportLocation, err := time.LoadLocation(cfg.Report.Timezone)
+	if err != nil {
+		log.Fatalf("Invalid REPORT_TIMEZONE: %q", cfg.Report.Timezone)
+	}
+	reportService := service.NewReportService(repository.NewReportRepository(db), masterDataRepo, service.ReportConfig{
+		Location: reportLocation,
+	})
+	autoCheckoutTime, err := time.Parse("15:04", cfg.AutoCheckout.Time)
+	if err != nil {
+		log.Fatalf("Invalid AUTO_CHECKOUT_TIME: %q", cfg.AutoCheckout.Time)
+	}
+	autoCheckoutLocation, err := time.LoadLocation(cfg.AutoCheckout.Timezone)
+	if err != nil {
+		log.Fatalf("Invalid AUTO_CHECKOUT_TIMEZONE: %q", cfg.AutoCheckout.Timezone)
+	}
+	autoCheckoutService := service.NewAutoCheckoutService(
+		attendanceRepo,
+		userRepo,
+		repository.NewAdvisoryLocker(db),
+		transactor,
+		presenceBus,
+		service.AutoCheckoutConfig{
+			Hour:     autoCheckoutTime.Hour(),
+			Minute:   autoCheckoutTime.Minute(),
+			Location: autoCheckoutLocation,
+		},
+	)
+	userService := service.NewUserService(userRepo, mfaRepo, authService, emailVerificationService, profileService, transactor, passwordPolicy)
+	passwordResetInterval := time.Duration(cfg.PasswordReset.ResendIntervalSeconds) * time.Second
+	passwordResetService := service.NewPasswordResetService(
+		userRepo,
+		passwordResetRepo,
+		transactor,
+		authService,
+		mailer,
+		service.PasswordResetConfig{
+			ResetURL: cfg.PasswordReset.URL,
+			Expiry:   time.Duration(cfg.PasswordReset.ExpiryMinutes) * time.Minute,
+			MailFrom: cfg.Mail.From,
+			Policy:   passwordPolicy,
+			Sends: service.PasswordResetLimits{
+				Account: lockout.NewLimiter(attemptStore, "password-reset", lockout.Policy{
+					MaxFailures: cfg.PasswordReset.MaxSends,
+					Lockout:     time.Hour,
+					BaseDelay:   passwordResetInterval,
+					MaxDelay:    passwordResetInterval,
+				}),
+				IP: lockout.NewLimiter(attemptStore, "password-reset-ip", lockout.Policy{
+					MaxFailures: cfg.PasswordReset.IPMaxSends,
+					Lockout:     time.Hour,
+				}),
+			},
+		},
+	)
+
+	if cfg.MFA.EncryptionKey == "" {
+		log.Fatalf("MFA_ENCRYPTION_KEY is required")
+	}
+	mfaSecrets, err := secretbox.New(cfg.MFA.EncryptionKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize MFA secret encryption: %v", err)
+	}
+	mfaService := service.NewMFAService(
+		userRepo,
+		mfaRepo,
+		transactor,
+		tokenManager,
+		authService,
+		service.MFAConfig{
+			Issuer:  cfg.MFA.Issuer,
+			Secrets: mfaSecrets,
+			Attempts: lockout.NewLimiter(attemptStore, "mfa", lockout.Policy{
+				MaxFailures: cfg.LoginLockout.MaxFailures,
+				Lockout:     lockoutDuration,
+			}),
+		},
+	)
+
+	authHandler := handler.NewAuthHandler(authService)
+	mfaHandler := handler.NewMFAHandler(mfaService)
 	userHandler := handler.NewUserHandler(userService)
+	passwordResetHandler := handler.NewPasswordResetHandler(passwordResetService)
+	emailVerificationHandler := handler.NewEmailVerificationHandler(emailVerificationService)
+	profileHandler := handler.NewProfileHandler(profileService)
+	masterDataHandler := handler.NewMasterDataHandler(masterDataService)
+	attendanceHandler := handler.NewAttendanceHandler(attendanceService)
+	correctionHandler := handler.NewCorrectionHandler(correctionService)
+	autoCheckoutHandler := handler.NewAutoCheckoutHandler(autoCheckoutService)
+	presenceHandler := handler.NewPresenceHandler(presenceService)
+	reportHandler := handler.NewReportHandler(reportService)
+	nfcHandler := handler.NewNFCHandler(nfcService)
+	deviceHandler := handler.NewDeviceHandler(deviceService)
 
 	e := echo.New()
 
+	// 接続元IPはログイン試行の制限に使うため、信頼するプロキシを経由した場合のみ X-Forwarded-For を参照する
+	if len(cfg.Server.TrustedProxies) == 0 {
+		e.IPExtractor = echo.ExtractIPDirect()
+	} else {
+		trustOptions := []echo.TrustOption{
+			echo.TrustLoopback(false),
+			echo.TrustLinkLocal(false),
+			echo.TrustPrivateNet(false),
+		}
+		for _, cidr := range cfg.Server.TrustedProxies {
+			_, ipRange, err := net.ParseCIDR(cidr)
+			if err != nil {
+				log.Fatalf("Invalid TRUSTED_PROXIES: %q", cidr)
+			}
+			trustOptions = append(trustOptions, echo.TrustIPRange(ipRange))
+		}
+		e.IPExtractor = echo.ExtractIPFromXFFHeader(trustOptions...)
+	}
+
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
@@ -69,7 +332,44 @@ func main() {
 		})
 	})
 
-	userHandler.RegisterRoutes(e)
+	var validator auth.TokenValidator = authService
+	if cfg.Zitadel.Enabled() {
+		oidcClient := oidc.NewClient(oidc.Config{
+			Issuer:       cfg.Zitadel.URL,
+			ClientID:     cfg.Zitadel.ClientID,
+			ClientSecret: cfg.Zitadel.ClientSecret,
+			RedirectURL:  cfg.Zitadel.RedirectURL,
+		})
+		oidcService := service.NewOIDCService(oidcClient, userService, authService, cfg.JWT.Secret)
+		handler.NewOIDCHandler(oidcService).RegisterRoutes(e)
+
+		// 自前の JWT を優先し、検証できなかった場合に Zitadel 発行のトークンとして検証する
+		validator = auth.ChainValidators(authService, oidcService)
+		log.Printf("Zitadel OIDC login enabled: issuer=%s", cfg.Zitadel.URL)
+	}
+
+	authMiddleware := authmw.JWTAuth(validator)
+	deviceMiddleware := authmw.DeviceAuth(deviceService)
+	signedDeviceMiddleware := authmw.Chain(deviceMiddleware, authmw.DeviceSignature(deviceService))
+	authHandler.RegisterRoutes(e, authMiddleware)
+	mfaHandler.RegisterRoutes(e, authMiddleware)
+	userHandler.RegisterRoutes(e, authMiddleware)
+	passwordResetHandler.RegisterRoutes(e)
+	emailVerificationHandler.RegisterRoutes(e, authMiddleware)
+	profileHandler.RegisterRoutes(e, authMiddleware)
+	masterDataHandler.RegisterRoutes(e, authMiddleware)
+	attendanceHandler.RegisterRoutes(e, authMiddleware, signedDeviceMiddleware)
+	correctionHandler.RegisterRoutes(e, authMiddleware)
+	autoCheckoutHandler.RegisterRoutes(e, authMiddleware)
+	presenceHandler.RegisterRoutes(e, authMiddleware)
+	reportHandler.RegisterRoutes(e, authMiddleware)
+	nfcHandler.RegisterRoutes(e, authMiddleware)
+	deviceHandler.RegisterRoutes(e, authMiddleware, deviceMiddleware)
+
+	if cfg.AutoCheckout.Enabled {
+		go autoCheckoutService.Start(context.Background())
+		log.Printf("Auto checkout enabled: daily at %s (%s)", cfg.AutoCheckout.Time, cfg.AutoCheckout.Timezone)
+	}
 
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
 	log.Printf("Starting server on %s", addr)
@@ -0,0 +1,8 @@
+-- reverse: create index "idx_refresh_tokens_user_id" to table: "refresh_tokens"
+DROP INDEX "public"."idx_refresh_tokens_user_id";
+-- reverse: create index "idx_refresh_tokens_family_id" to table: "refresh_tokens"
+DROP INDEX "public"."idx_refresh_tokens_family_id";
+-- reverse: create index "refresh_tokens_token_hash_key" to table: "refresh_tokens"
+DROP INDEX "public"."refresh_tokens_token_hash_key";
+-- reverse: create "refresh_tokens" table
+DROP TABLE "public"."refresh_tokens";
@@ -0,0 +1,6 @@
+-- reverse: create "user_session_revocations" table
+DROP TABLE "public"."user_session_revocations";
+-- reverse: create index "idx_revoked_tokens_expires_at" to table: "revoked_tokens"
+DROP INDEX "public"."idx_revoked_tokens_expires_at";
+-- reverse: create "revoked_tokens" table
+DROP TABLE "public"."revoked_tokens";
@@ -0,0 +1,18 @@
+-- create "revoked_tokens" table
+CREATE TABLE "public"."revoked_tokens" (
+  "jti" character varying(64) NOT NULL,
+  "user_id" uuid NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "revoked_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("jti"),
+  CONSTRAINT "revoked_tokens_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- create index "idx_revoked_tokens_expires_at" to table: "revoked_tokens"
+CREATE INDEX "idx_revoked_tokens_expires_at" ON "public"."revoked_tokens" ("expires_at");
+-- create "user_session_revocations" table
+CREATE TABLE "public"."user_session_revocations" (
+  "user_id" uuid NOT NULL,
+  "revoked_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("user_id"),
+  CONSTRAINT "user_session_revocations_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
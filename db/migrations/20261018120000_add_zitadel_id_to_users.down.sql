@@ -0,0 +1,4 @@
+-- reverse: create index "users_zitadel_id_key" to table: "users"
+DROP INDEX "public"."users_zitadel_id_key";
+-- reverse: modify "users" table
+ALTER TABLE "public"."users" DROP COLUMN "zitadel_id";
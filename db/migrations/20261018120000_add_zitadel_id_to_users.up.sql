@@ -0,0 +1,4 @@
+-- modify "users" table
+ALTER TABLE "public"."users" ADD COLUMN "zitadel_id" character varying(255) NULL;
+-- create index "users_zitadel_id_key" to table: "users"
+CREATE UNIQUE INDEX "users_zitadel_id_key" ON "public"."users" ("zitadel_id");
@@ -0,0 +1,4 @@
+-- reverse: modify "users" table
+-- パスワードを持たないユーザーは空文字にしてログインできない状態で残す
+UPDATE "public"."users" SET "password" = '' WHERE "password" IS NULL;
+ALTER TABLE "public"."users" DROP CONSTRAINT "users_password_required_check", DROP CONSTRAINT "users_auth_source_check", DROP COLUMN "auth_source", ALTER COLUMN "password" SET NOT NULL;
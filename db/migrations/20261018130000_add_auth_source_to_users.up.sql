@@ -0,0 +1,2 @@
+-- modify "users" table
+ALTER TABLE "public"."users" ALTER COLUMN "password" DROP NOT NULL, ADD COLUMN "auth_source" character varying(16) NOT NULL DEFAULT 'local', ADD CONSTRAINT "users_auth_source_check" CHECK ((auth_source)::text = ANY ((ARRAY['local'::character varying, 'zitadel'::character varying])::text[])), ADD CONSTRAINT "users_password_required_check" CHECK (((auth_source)::text <> 'local'::text) OR (password IS NOT NULL));
@@ -0,0 +1,2 @@
+-- reverse: modify "users" table
+ALTER TABLE "public"."users" DROP CONSTRAINT "users_role_check", DROP COLUMN "role";
@@ -0,0 +1,2 @@
+-- modify "users" table
+ALTER TABLE "public"."users" ADD COLUMN "role" character varying(16) NOT NULL DEFAULT 'member', ADD CONSTRAINT "users_role_check" CHECK ((role)::text = ANY ((ARRAY['member'::character varying, 'staff'::character varying, 'admin'::character varying])::text[]));
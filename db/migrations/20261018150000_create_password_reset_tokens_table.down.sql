@@ -0,0 +1,6 @@
+-- reverse: create index "idx_password_reset_tokens_user_id" to table: "password_reset_tokens"
+DROP INDEX "public"."idx_password_reset_tokens_user_id";
+-- reverse: create index "password_reset_tokens_token_hash_key" to table: "password_reset_tokens"
+DROP INDEX "public"."password_reset_tokens_token_hash_key";
+-- reverse: create "password_reset_tokens" table
+DROP TABLE "public"."password_reset_tokens";
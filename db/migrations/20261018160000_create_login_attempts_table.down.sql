@@ -0,0 +1,4 @@
+-- reverse: create index "idx_login_attempts_last_failure_at" to table: "login_attempts"
+DROP INDEX "public"."idx_login_attempts_last_failure_at";
+-- reverse: create "login_attempts" table
+DROP TABLE "public"."login_attempts";
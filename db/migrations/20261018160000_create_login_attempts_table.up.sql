@@ -0,0 +1,10 @@
+-- create "login_attempts" table
+CREATE TABLE "public"."login_attempts" (
+  "key" character varying(320) NOT NULL,
+  "failures" integer NOT NULL DEFAULT 0,
+  "last_failure_at" timestamp NOT NULL,
+  "locked_until" timestamp NULL,
+  PRIMARY KEY ("key")
+);
+-- create index "idx_login_attempts_last_failure_at" to table: "login_attempts"
+CREATE INDEX "idx_login_attempts_last_failure_at" ON "public"."login_attempts" ("last_failure_at");
@@ -0,0 +1,6 @@
+-- reverse: create index "mfa_recovery_codes_user_id_code_hash_key" to table: "mfa_recovery_codes"
+DROP INDEX "public"."mfa_recovery_codes_user_id_code_hash_key";
+-- reverse: create "mfa_recovery_codes" table
+DROP TABLE "public"."mfa_recovery_codes";
+-- reverse: create "user_mfa" table
+DROP TABLE "public"."user_mfa";
@@ -0,0 +1,22 @@
+-- create "user_mfa" table
+CREATE TABLE "public"."user_mfa" (
+  "user_id" uuid NOT NULL,
+  "secret" text NOT NULL,
+  "enabled_at" timestamp NULL,
+  "last_used_step" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("user_id"),
+  CONSTRAINT "user_mfa_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- create "mfa_recovery_codes" table
+CREATE TABLE "public"."mfa_recovery_codes" (
+  "id" uuid NOT NULL,
+  "user_id" uuid NOT NULL,
+  "code_hash" character varying(64) NOT NULL,
+  "used_at" timestamp NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "mfa_recovery_codes_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- create index "mfa_recovery_codes_user_id_code_hash_key" to table: "mfa_recovery_codes"
+CREATE UNIQUE INDEX "mfa_recovery_codes_user_id_code_hash_key" ON "public"."mfa_recovery_codes" ("user_id", "code_hash");
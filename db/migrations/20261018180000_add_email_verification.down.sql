@@ -0,0 +1,8 @@
+-- reverse: create index "idx_email_verification_tokens_user_id" to table: "email_verification_tokens"
+DROP INDEX "public"."idx_email_verification_tokens_user_id";
+-- reverse: create index "email_verification_tokens_token_hash_key" to table: "email_verification_tokens"
+DROP INDEX "public"."email_verification_tokens_token_hash_key";
+-- reverse: create "email_verification_tokens" table
+DROP TABLE "public"."email_verification_tokens";
+-- reverse: modify "users" table
+ALTER TABLE "public"."users" DROP COLUMN "pending_email", DROP COLUMN "email_verified_at";
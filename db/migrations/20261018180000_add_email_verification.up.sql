@@ -0,0 +1,20 @@
+-- modify "users" table
+ALTER TABLE "public"."users" ADD COLUMN "email_verified_at" timestamp NULL, ADD COLUMN "pending_email" character varying(255) NULL;
+-- Zitadel のユーザーは IdP で検証済みのメールアドレスでのみ作成・同期している
+UPDATE "public"."users" SET "email_verified_at" = CURRENT_TIMESTAMP WHERE "auth_source" = 'zitadel';
+-- create "email_verification_tokens" table
+CREATE TABLE "public"."email_verification_tokens" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "user_id" uuid NOT NULL,
+  "email" character varying(255) NOT NULL,
+  "token_hash" character varying(64) NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "used_at" timestamp NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "email_verification_tokens_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- create index "email_verification_tokens_token_hash_key" to table: "email_verification_tokens"
+CREATE UNIQUE INDEX "email_verification_tokens_token_hash_key" ON "public"."email_verification_tokens" ("token_hash");
+-- create index "idx_email_verification_tokens_user_id" to table: "email_verification_tokens"
+CREATE INDEX "idx_email_verification_tokens_user_id" ON "public"."email_verification_tokens" ("user_id");
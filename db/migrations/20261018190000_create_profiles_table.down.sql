@@ -0,0 +1,14 @@
+-- reverse: create index "idx_profiles_org_id" to table: "profiles"
+DROP INDEX "public"."idx_profiles_org_id";
+-- reverse: create index "idx_profiles_grade_id" to table: "profiles"
+DROP INDEX "public"."idx_profiles_grade_id";
+-- reverse: create index "profiles_nfc_serial_key" to table: "profiles"
+DROP INDEX "public"."profiles_nfc_serial_key";
+-- reverse: create index "profiles_display_id_key" to table: "profiles"
+DROP INDEX "public"."profiles_display_id_key";
+-- reverse: create "profiles" table
+DROP TABLE "public"."profiles";
+-- reverse: create "organizations" table
+DROP TABLE "public"."organizations";
+-- reverse: create "grades" table
+DROP TABLE "public"."grades";
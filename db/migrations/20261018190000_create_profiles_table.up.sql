@@ -0,0 +1,37 @@
+-- create "grades" table
+CREATE TABLE "public"."grades" (
+  "id" serial NOT NULL,
+  "label" character varying(100) NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id")
+);
+-- create "organizations" table
+CREATE TABLE "public"."organizations" (
+  "id" serial NOT NULL,
+  "name" character varying(255) NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id")
+);
+-- create "profiles" table
+CREATE TABLE "public"."profiles" (
+  "user_id" uuid NOT NULL,
+  "display_id" character varying(32) NOT NULL,
+  "name" character varying(255) NOT NULL,
+  "nfc_serial" character varying(64) NULL,
+  "grade_id" integer NULL,
+  "org_id" integer NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("user_id"),
+  CONSTRAINT "profiles_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "profiles_grade_id_fkey" FOREIGN KEY ("grade_id") REFERENCES "public"."grades" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION,
+  CONSTRAINT "profiles_org_id_fkey" FOREIGN KEY ("org_id") REFERENCES "public"."organizations" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION
+);
+-- create index "profiles_display_id_key" to table: "profiles"
+CREATE UNIQUE INDEX "profiles_display_id_key" ON "public"."profiles" ("display_id");
+-- create index "profiles_nfc_serial_key" to table: "profiles"
+CREATE UNIQUE INDEX "profiles_nfc_serial_key" ON "public"."profiles" ("nfc_serial");
+-- create index "idx_profiles_grade_id" to table: "profiles"
+CREATE INDEX "idx_profiles_grade_id" ON "public"."profiles" ("grade_id");
+-- create index "idx_profiles_org_id" to table: "profiles"
+CREATE INDEX "idx_profiles_org_id" ON "public"."profiles" ("org_id");
@@ -0,0 +1,12 @@
+-- reverse: create index "locations_name_key" to table: "locations"
+DROP INDEX "public"."locations_name_key";
+-- reverse: create "locations" table
+DROP TABLE "public"."locations";
+-- reverse: create index "organizations_name_key" to table: "organizations"
+DROP INDEX "public"."organizations_name_key";
+-- reverse: modify "organizations" table
+ALTER TABLE "public"."organizations" DROP COLUMN "updated_at", DROP COLUMN "archived_at", DROP COLUMN "sort_order";
+-- reverse: create index "grades_label_key" to table: "grades"
+DROP INDEX "public"."grades_label_key";
+-- reverse: modify "grades" table
+ALTER TABLE "public"."grades" DROP COLUMN "updated_at", DROP COLUMN "archived_at", DROP COLUMN "sort_order";
@@ -0,0 +1,20 @@
+-- modify "grades" table
+ALTER TABLE "public"."grades" ADD COLUMN "sort_order" integer NOT NULL DEFAULT 0, ADD COLUMN "archived_at" timestamp NULL, ADD COLUMN "updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP;
+-- create index "grades_label_key" to table: "grades"
+CREATE UNIQUE INDEX "grades_label_key" ON "public"."grades" ("label");
+-- modify "organizations" table
+ALTER TABLE "public"."organizations" ADD COLUMN "sort_order" integer NOT NULL DEFAULT 0, ADD COLUMN "archived_at" timestamp NULL, ADD COLUMN "updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP;
+-- create index "organizations_name_key" to table: "organizations"
+CREATE UNIQUE INDEX "organizations_name_key" ON "public"."organizations" ("name");
+-- create "locations" table
+CREATE TABLE "public"."locations" (
+  "id" serial NOT NULL,
+  "name" character varying(255) NOT NULL,
+  "sort_order" integer NOT NULL DEFAULT 0,
+  "archived_at" timestamp NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id")
+);
+-- create index "locations_name_key" to table: "locations"
+CREATE UNIQUE INDEX "locations_name_key" ON "public"."locations" ("name");
@@ -0,0 +1,8 @@
+-- reverse: create index "idx_attendance_logs_recorded_at" to table: "attendance_logs"
+DROP INDEX "public"."idx_attendance_logs_recorded_at";
+-- reverse: create index "idx_attendance_logs_location_id" to table: "attendance_logs"
+DROP INDEX "public"."idx_attendance_logs_location_id";
+-- reverse: create index "idx_attendance_logs_user_location_recorded_at" to table: "attendance_logs"
+DROP INDEX "public"."idx_attendance_logs_user_location_recorded_at";
+-- reverse: create "attendance_logs" table
+DROP TABLE "public"."attendance_logs";
@@ -0,0 +1,18 @@
+-- create "attendance_logs" table
+CREATE TABLE "public"."attendance_logs" (
+  "id" bigserial NOT NULL,
+  "user_id" uuid NOT NULL,
+  "location_id" integer NOT NULL,
+  "type" character varying(16) NOT NULL,
+  "recorded_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "attendance_logs_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "attendance_logs_location_id_fkey" FOREIGN KEY ("location_id") REFERENCES "public"."locations" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION,
+  CONSTRAINT "attendance_logs_type_check" CHECK ((type)::text = ANY ((ARRAY['check-in'::character varying, 'check-out'::character varying])::text[]))
+);
+-- create index "idx_attendance_logs_user_location_recorded_at" to table: "attendance_logs"
+CREATE INDEX "idx_attendance_logs_user_location_recorded_at" ON "public"."attendance_logs" ("user_id", "location_id", "recorded_at");
+-- create index "idx_attendance_logs_location_id" to table: "attendance_logs"
+CREATE INDEX "idx_attendance_logs_location_id" ON "public"."attendance_logs" ("location_id");
+-- create index "idx_attendance_logs_recorded_at" to table: "attendance_logs"
+CREATE INDEX "idx_attendance_logs_recorded_at" ON "public"."attendance_logs" ("recorded_at");
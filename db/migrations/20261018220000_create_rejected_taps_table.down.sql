@@ -0,0 +1,6 @@
+-- reverse: create index "idx_rejected_taps_tapped_at" to table: "rejected_taps"
+DROP INDEX "public"."idx_rejected_taps_tapped_at";
+-- reverse: create index "idx_rejected_taps_nfc_serial" to table: "rejected_taps"
+DROP INDEX "public"."idx_rejected_taps_nfc_serial";
+-- reverse: create "rejected_taps" table
+DROP TABLE "public"."rejected_taps";
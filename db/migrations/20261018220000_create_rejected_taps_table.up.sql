@@ -0,0 +1,15 @@
+-- create "rejected_taps" table
+CREATE TABLE "public"."rejected_taps" (
+  "id" bigserial NOT NULL,
+  "nfc_serial" character varying(64) NOT NULL,
+  "location_id" integer NOT NULL,
+  "reason" character varying(32) NOT NULL,
+  "tapped_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "rejected_taps_location_id_fkey" FOREIGN KEY ("location_id") REFERENCES "public"."locations" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION,
+  CONSTRAINT "rejected_taps_reason_check" CHECK ((reason)::text = ANY ((ARRAY['unknown_card'::character varying, 'inactive_user'::character varying])::text[]))
+);
+-- create index "idx_rejected_taps_nfc_serial" to table: "rejected_taps"
+CREATE INDEX "idx_rejected_taps_nfc_serial" ON "public"."rejected_taps" ("nfc_serial");
+-- create index "idx_rejected_taps_tapped_at" to table: "rejected_taps"
+CREATE INDEX "idx_rejected_taps_tapped_at" ON "public"."rejected_taps" ("tapped_at");
@@ -0,0 +1,12 @@
+-- reverse: create index "idx_nfc_card_events_nfc_serial" to table: "nfc_card_events"
+DROP INDEX "public"."idx_nfc_card_events_nfc_serial";
+-- reverse: create index "idx_nfc_card_events_user_id_created_at" to table: "nfc_card_events"
+DROP INDEX "public"."idx_nfc_card_events_user_id_created_at";
+-- reverse: create "nfc_card_events" table
+DROP TABLE "public"."nfc_card_events";
+-- reverse: create index "idx_nfc_enrollments_user_id" to table: "nfc_enrollments"
+DROP INDEX "public"."idx_nfc_enrollments_user_id";
+-- reverse: create index "nfc_enrollments_pending_location_key" to table: "nfc_enrollments"
+DROP INDEX "public"."nfc_enrollments_pending_location_key";
+-- reverse: create "nfc_enrollments" table
+DROP TABLE "public"."nfc_enrollments";
@@ -0,0 +1,37 @@
+-- create "nfc_enrollments" table
+CREATE TABLE "public"."nfc_enrollments" (
+  "id" bigserial NOT NULL,
+  "user_id" uuid NOT NULL,
+  "location_id" integer NOT NULL,
+  "armed_by" uuid NULL,
+  "nfc_serial" character varying(64) NULL,
+  "expires_at" timestamp NOT NULL,
+  "completed_at" timestamp NULL,
+  "cancelled_at" timestamp NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "nfc_enrollments_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "nfc_enrollments_location_id_fkey" FOREIGN KEY ("location_id") REFERENCES "public"."locations" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION,
+  CONSTRAINT "nfc_enrollments_armed_by_fkey" FOREIGN KEY ("armed_by") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE SET NULL
+);
+-- create index "nfc_enrollments_pending_location_key" to table: "nfc_enrollments"
+CREATE UNIQUE INDEX "nfc_enrollments_pending_location_key" ON "public"."nfc_enrollments" ("location_id") WHERE ((completed_at IS NULL) AND (cancelled_at IS NULL));
+-- create index "idx_nfc_enrollments_user_id" to table: "nfc_enrollments"
+CREATE INDEX "idx_nfc_enrollments_user_id" ON "public"."nfc_enrollments" ("user_id");
+-- create "nfc_card_events" table
+CREATE TABLE "public"."nfc_card_events" (
+  "id" bigserial NOT NULL,
+  "user_id" uuid NOT NULL,
+  "nfc_serial" character varying(64) NOT NULL,
+  "event" character varying(16) NOT NULL,
+  "actor_id" uuid NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "nfc_card_events_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "nfc_card_events_actor_id_fkey" FOREIGN KEY ("actor_id") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE SET NULL,
+  CONSTRAINT "nfc_card_events_event_check" CHECK ((event)::text = ANY ((ARRAY['bound'::character varying, 'unbound'::character varying, 'lost'::character varying, 'replaced'::character varying])::text[]))
+);
+-- create index "idx_nfc_card_events_user_id_created_at" to table: "nfc_card_events"
+CREATE INDEX "idx_nfc_card_events_user_id_created_at" ON "public"."nfc_card_events" ("user_id", "created_at");
+-- create index "idx_nfc_card_events_nfc_serial" to table: "nfc_card_events"
+CREATE INDEX "idx_nfc_card_events_nfc_serial" ON "public"."nfc_card_events" ("nfc_serial");
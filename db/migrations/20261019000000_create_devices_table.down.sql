@@ -0,0 +1,6 @@
+-- reverse: create index "idx_devices_location_id" to table: "devices"
+DROP INDEX "public"."idx_devices_location_id";
+-- reverse: create index "devices_key_hash_key" to table: "devices"
+DROP INDEX "public"."devices_key_hash_key";
+-- reverse: create "devices" table
+DROP TABLE "public"."devices";
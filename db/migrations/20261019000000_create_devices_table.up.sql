@@ -0,0 +1,21 @@
+-- create "devices" table
+CREATE TABLE "public"."devices" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "name" character varying(255) NOT NULL,
+  "location_id" integer NOT NULL,
+  "key_prefix" character varying(16) NOT NULL,
+  "key_hash" character varying(64) NOT NULL,
+  "key_rotated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "last_seen_at" timestamp NULL,
+  "revoked_at" timestamp NULL,
+  "created_by" uuid NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "devices_location_id_fkey" FOREIGN KEY ("location_id") REFERENCES "public"."locations" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION,
+  CONSTRAINT "devices_created_by_fkey" FOREIGN KEY ("created_by") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE SET NULL
+);
+-- create index "devices_key_hash_key" to table: "devices"
+CREATE UNIQUE INDEX "devices_key_hash_key" ON "public"."devices" ("key_hash");
+-- create index "idx_devices_location_id" to table: "devices"
+CREATE INDEX "idx_devices_location_id" ON "public"."devices" ("location_id");
@@ -0,0 +1,6 @@
+-- reverse: create index "idx_device_nonces_expires_at" to table: "device_nonces"
+DROP INDEX "public"."idx_device_nonces_expires_at";
+-- reverse: create "device_nonces" table
+DROP TABLE "public"."device_nonces";
+-- reverse: modify "devices" table
+ALTER TABLE "public"."devices" DROP COLUMN "signing_secret";
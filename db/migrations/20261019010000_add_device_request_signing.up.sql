@@ -0,0 +1,10 @@
+-- modify "devices" table
+ALTER TABLE "public"."devices" ADD COLUMN "signing_secret" text NULL;
+-- create "device_nonces" table
+CREATE TABLE "public"."device_nonces" (
+  "key" character varying(200) NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  PRIMARY KEY ("key")
+);
+-- create index "idx_device_nonces_expires_at" to table: "device_nonces"
+CREATE INDEX "idx_device_nonces_expires_at" ON "public"."device_nonces" ("expires_at");
@@ -0,0 +1,2 @@
+-- reverse: create "device_taps" table
+DROP TABLE "public"."device_taps";
@@ -0,0 +1,13 @@
+-- create "device_taps" table
+CREATE TABLE "public"."device_taps" (
+  "device_id" uuid NOT NULL,
+  "client_tap_id" character varying(128) NOT NULL,
+  "tapped_at" timestamp NOT NULL,
+  "status" character varying(16) NOT NULL,
+  "attendance_log_id" bigint NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("device_id", "client_tap_id"),
+  CONSTRAINT "device_taps_device_id_fkey" FOREIGN KEY ("device_id") REFERENCES "public"."devices" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "device_taps_attendance_log_id_fkey" FOREIGN KEY ("attendance_log_id") REFERENCES "public"."attendance_logs" ("id") ON UPDATE NO ACTION ON DELETE SET NULL,
+  CONSTRAINT "device_taps_status_check" CHECK ((status)::text = ANY ((ARRAY['recorded'::character varying, 'rejected'::character varying])::text[]))
+);
@@ -0,0 +1,2 @@
+-- reverse: modify "attendance_logs" table
+ALTER TABLE "public"."attendance_logs" DROP COLUMN "auto";
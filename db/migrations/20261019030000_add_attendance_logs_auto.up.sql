@@ -0,0 +1,2 @@
+-- modify "attendance_logs" table
+ALTER TABLE "public"."attendance_logs" ADD COLUMN "auto" boolean NOT NULL DEFAULT false;
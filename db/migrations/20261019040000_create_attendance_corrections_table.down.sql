@@ -0,0 +1,8 @@
+-- reverse: create index "idx_attendance_corrections_user_id" to table: "attendance_corrections"
+DROP INDEX "public"."idx_attendance_corrections_user_id";
+-- reverse: create index "idx_attendance_corrections_status_requested_at" to table: "attendance_corrections"
+DROP INDEX "public"."idx_attendance_corrections_status_requested_at";
+-- reverse: create "attendance_corrections" table
+DROP TABLE "public"."attendance_corrections";
+-- reverse: modify "attendance_logs" table
+ALTER TABLE "public"."attendance_logs" DROP COLUMN "manual";
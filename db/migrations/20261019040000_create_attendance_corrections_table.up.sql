@@ -0,0 +1,30 @@
+-- modify "attendance_logs" table
+ALTER TABLE "public"."attendance_logs" ADD COLUMN "manual" boolean NOT NULL DEFAULT false;
+-- create "attendance_corrections" table
+CREATE TABLE "public"."attendance_corrections" (
+  "id" bigserial NOT NULL,
+  "user_id" uuid NOT NULL,
+  "location_id" integer NOT NULL,
+  "type" character varying(16) NOT NULL,
+  "recorded_at" timestamp NOT NULL,
+  "reason" text NOT NULL,
+  "status" character varying(16) NOT NULL DEFAULT 'pending',
+  "requested_by" uuid NULL,
+  "requested_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "reviewed_by" uuid NULL,
+  "reviewed_at" timestamp NULL,
+  "review_comment" text NOT NULL DEFAULT '',
+  "attendance_log_id" bigint NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "attendance_corrections_user_id_fkey" FOREIGN KEY ("user_id") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "attendance_corrections_location_id_fkey" FOREIGN KEY ("location_id") REFERENCES "public"."locations" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION,
+  CONSTRAINT "attendance_corrections_requested_by_fkey" FOREIGN KEY ("requested_by") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE SET NULL,
+  CONSTRAINT "attendance_corrections_reviewed_by_fkey" FOREIGN KEY ("reviewed_by") REFERENCES "public"."users" ("id") ON UPDATE NO ACTION ON DELETE SET NULL,
+  CONSTRAINT "attendance_corrections_attendance_log_id_fkey" FOREIGN KEY ("attendance_log_id") REFERENCES "public"."attendance_logs" ("id") ON UPDATE NO ACTION ON DELETE SET NULL,
+  CONSTRAINT "attendance_corrections_type_check" CHECK ((type)::text = ANY ((ARRAY['check-in'::character varying, 'check-out'::character varying])::text[])),
+  CONSTRAINT "attendance_corrections_status_check" CHECK ((status)::text = ANY ((ARRAY['pending'::character varying, 'approved'::character varying, 'rejected'::character varying])::text[]))
+);
+-- create index "idx_attendance_corrections_status_requested_at" to table: "attendance_corrections"
+CREATE INDEX "idx_attendance_corrections_status_requested_at" ON "public"."attendance_corrections" ("status", "requested_at");
+-- create index "idx_attendance_corrections_user_id" to table: "attendance_corrections"
+CREATE INDEX "idx_attendance_corrections_user_id" ON "public"."attendance_corrections" ("user_id");
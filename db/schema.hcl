@@ -25,8 +25,41 @@ table "users" {
     type = varchar(255)
   }
 
+  // 外部 IdP で管理するユーザー（auth_source が local 以外）は NULL
   column "password" {
-    null = false
+    null = true
+    type = varchar(255)
+  }
+
+  column "auth_source" {
+    null    = false
+    type    = varchar(16)
+    default = "local"
+  }
+
+  // member: 自分自身の参照・更新のみ / staff: 全ユーザーの参照 / admin: 全ユーザーの管理
+  column "role" {
+    null    = false
+    type    = varchar(16)
+    default = "member"
+  }
+
+  // ZitadelのユーザーID（sub）。db/docs/future_schema_draft.sql では UUID だが、
+  // Zitadelのsubは数値文字列のためvarcharで保持する
+  column "zitadel_id" {
+    null = true
+    type = varchar(255)
+  }
+
+  // メールアドレスの所有確認が済んだ日時。未確認の場合は NULL
+  column "email_verified_at" {
+    null = true
+    type = timestamp
+  }
+
+  // 確認待ちの新しいメールアドレス。確認が済むと email に反映する
+  column "pending_email" {
+    null = true
     type = varchar(255)
   }
 
@@ -56,6 +89,11 @@ table "users" {
     columns = [column.email]
   }
 
+  index "users_zitadel_id_key" {
+    unique  = true
+    columns = [column.zitadel_id]
+  }
+
   index "idx_users_email" {
     columns = [column.email]
     where   = "deleted_at IS NULL"
@@ -64,4 +102,1218 @@ table "users" {
   index "idx_users_deleted_at" {
     columns = [column.deleted_at]
   }
+
+  check "users_auth_source_check" {
+    expr = "((auth_source)::text = ANY ((ARRAY['local'::character varying, 'zitadel'::character varying])::text[]))"
+  }
+
+  check "users_password_required_check" {
+    expr = "(((auth_source)::text <> 'local'::text) OR (password IS NOT NULL))"
+  }
+
+  check "users_role_check" {
+    expr = "((role)::text = ANY ((ARRAY['member'::character varying, 'staff'::character varying, 'admin'::character varying])::text[]))"
+  }
+}
+
+// refresh_tokensテーブル（リフレッシュトークンのローテーション管理）
+table "refresh_tokens" {
+  schema = schema.public
+  column "id" {
+    null    = false
+    type    = uuid
+    default = sql("gen_random_uuid()")
+  }
+
+  column "user_id" {
+    null = false
+    type = uuid
+  }
+
+  // 同一ログインから派生したトークンの系列。再利用検知時に系列ごと失効させる
+  column "family_id" {
+    null = false
+    type = uuid
+  }
+
+  // トークン本体は保存せず、SHA-256ハッシュ（hex）のみ保存する
+  column "token_hash" {
+    null = false
+    type = varchar(64)
+  }
+
+  column "expires_at" {
+    null = false
+    type = timestamp
+  }
+
+  column "used_at" {
+    null = true
+    type = timestamp
+  }
+
+  column "revoked_at" {
+    null = true
+    type = timestamp
+  }
+
+  column "created_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "refresh_tokens_user_id_fkey" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "refresh_tokens_token_hash_key" {
+    unique  = true
+    columns = [column.token_hash]
+  }
+
+  index "idx_refresh_tokens_family_id" {
+    columns = [column.family_id]
+  }
+
+  index "idx_refresh_tokens_user_id" {
+    columns = [column.user_id]
+  }
+}
+
+// revoked_tokensテーブル（有効期限前に失効させたアクセストークンのjti）
+table "revoked_tokens" {
+  schema = schema.public
+  column "jti" {
+    null = false
+    type = varchar(64)
+  }
+
+  column "user_id" {
+    null = false
+    type = uuid
+  }
+
+  // 元のトークンの有効期限。これを過ぎた行は削除してよい
+  column "expires_at" {
+    null = false
+    type = timestamp
+  }
+
+  column "revoked_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.jti]
+  }
+
+  foreign_key "revoked_tokens_user_id_fkey" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_revoked_tokens_expires_at" {
+    columns = [column.expires_at]
+  }
+}
+
+// user_session_revocationsテーブル（revoked_at以前に発行されたトークンを全て無効とする）
+table "user_session_revocations" {
+  schema = schema.public
+  column "user_id" {
+    null = false
+    type = uuid
+  }
+
+  column "revoked_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.user_id]
+  }
+
+  foreign_key "user_session_revocations_user_id_fkey" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+}
+
+// password_reset_tokensテーブル（パスワード再設定用の一回限りのトークン）
+table "password_reset_tokens" {
+  schema = schema.public
+  column "id" {
+    null    = false
+    type    = uuid
+    default = sql("gen_random_uuid()")
+  }
+
+  column "user_id" {
+    null = false
+    type = uuid
+  }
+
+  // トークン本体は保存せず、SHA-256ハッシュ（hex）のみ保存する
+  column "token_hash" {
+    null = false
+    type = varchar(64)
+  }
+
+  column "expires_at" {
+    null = false
+    type = timestamp
+  }
+
+  column "used_at" {
+    null = true
+    type = timestamp
+  }
+
+  column "created_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "password_reset_tokens_user_id_fkey" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "password_reset_tokens_token_hash_key" {
+    unique  = true
+    columns = [column.token_hash]
+  }
+
+  index "idx_password_reset_tokens_user_id" {
+    columns = [column.user_id]
+  }
+}
+
+// email_verification_tokensテーブル（メールアドレス確認用の一回限りのトークン）
+table "email_verification_tokens" {
+  schema = schema.public
+  column "id" {
+    null    = false
+    type    = uuid
+    default = sql("gen_random_uuid()")
+  }
+
+  column "user_id" {
+    null = false
+    type = uuid
+  }
+
+  // 確認するメールアドレス。登録時は users.email、変更時は users.pending_email と同じ値
+  column "email" {
+    null = false
+    type = varchar(255)
+  }
+
+  // トークン本体は保存せず、SHA-256ハッシュ（hex）のみ保存する
+  column "token_hash" {
+    null = false
+    type = varchar(64)
+  }
+
+  column "expires_at" {
+    null = false
+    type = timestamp
+  }
+
+  column "used_at" {
+    null = true
+    type = timestamp
+  }
+
+  column "created_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "email_verification_tokens_user_id_fkey" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "email_verification_tokens_token_hash_key" {
+    unique  = true
+    columns = [column.token_hash]
+  }
+
+  index "idx_email_verification_tokens_user_id" {
+    columns = [column.user_id]
+  }
+}
+
+// login_attemptsテーブル（アカウント・接続元IPごとのログイン失敗回数とロック期限）
+table "login_attempts" {
+  schema = schema.public
+  // "account:<email>" または "ip:<address>"
+  column "key" {
+    null = false
+    type = varchar(320)
+  }
+
+  column "failures" {
+    null    = false
+    type    = integer
+    default = 0
+  }
+
+  column "last_failure_at" {
+    null = false
+    type = timestamp
+  }
+
+  column "locked_until" {
+    null = true
+    type = timestamp
+  }
+
+  primary_key {
+    columns = [column.key]
+  }
+
+  index "idx_login_attempts_last_failure_at" {
+    columns = [column.last_failure_at]
+  }
+}
+
+// user_mfaテーブル（TOTPの共有鍵。enabled_atが入るまでは登録途中）
+table "user_mfa" {
+  schema = schema.public
+  column "user_id" {
+    null = false
+    type = uuid
+  }
+
+  // secretboxで暗号化した共有鍵
+  column "secret" {
+    null = false
+    type = text
+  }
+
+  column "enabled_at" {
+    null = true
+    type = timestamp
+  }
+
+  // 最後に受け付けたコードの時間ステップ。同じコードの再利用を防ぐ
+  column "last_used_step" {
+    null    = false
+    type    = bigint
+    default = 0
+  }
+
+  column "created_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.user_id]
+  }
+
+  foreign_key "user_mfa_user_id_fkey" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+}
+
+// mfa_recovery_codesテーブル（リカバリーコードのSHA-256ハッシュ）
+table "mfa_recovery_codes" {
+  schema = schema.public
+  column "id" {
+    null = false
+    type = uuid
+  }
+
+  column "user_id" {
+    null = false
+    type = uuid
+  }
+
+  column "code_hash" {
+    null = false
+    type = varchar(64)
+  }
+
+  column "used_at" {
+    null = true
+    type = timestamp
+  }
+
+  column "created_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "mfa_recovery_codes_user_id_fkey" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "mfa_recovery_codes_user_id_code_hash_key" {
+    unique  = true
+    columns = [column.user_id, column.code_hash]
+  }
+}
+
+// gradesテーブル（学年のマスターデータ。参照中の行は削除せずアーカイブする）
+table "grades" {
+  schema = schema.public
+  column "id" {
+    null = false
+    type = serial
+  }
+
+  column "label" {
+    null = false
+    type = varchar(100)
+  }
+
+  column "sort_order" {
+    null    = false
+    type    = integer
+    default = 0
+  }
+
+  column "archived_at" {
+    null = true
+    type = timestamp
+  }
+
+  column "created_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  column "updated_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "grades_label_key" {
+    unique  = true
+    columns = [column.label]
+  }
+}
+
+// organizationsテーブル（所属組織のマスターデータ。参照中の行は削除せずアーカイブする）
+table "organizations" {
+  schema = schema.public
+  column "id" {
+    null = false
+    type = serial
+  }
+
+  column "name" {
+    null = false
+    type = varchar(255)
+  }
+
+  column "sort_order" {
+    null    = false
+    type    = integer
+    default = 0
+  }
+
+  column "archived_at" {
+    null = true
+    type = timestamp
+  }
+
+  column "created_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  column "updated_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "organizations_name_key" {
+    unique  = true
+    columns = [column.name]
+  }
+}
+
+// locationsテーブル（入退室を記録する場所のマスターデータ。参照中の行は削除せずアーカイブする）
+table "locations" {
+  schema = schema.public
+  column "id" {
+    null = false
+    type = serial
+  }
+
+  column "name" {
+    null = false
+    type = varchar(255)
+  }
+
+  column "sort_order" {
+    null    = false
+    type    = integer
+    default = 0
+  }
+
+  column "archived_at" {
+    null = true
+    type = timestamp
+  }
+
+  column "created_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  column "updated_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "locations_name_key" {
+    unique  = true
+    columns = [column.name]
+  }
+}
+
+// profilesテーブル（表示用ID・学年・所属などユーザーのプロフィール）
+table "profiles" {
+  schema = schema.public
+  column "user_id" {
+    null = false
+    type = uuid
+  }
+
+  column "display_id" {
+    null = false
+    type = varchar(32)
+  }
+
+  column "name" {
+    null = false
+    type = varchar(255)
+  }
+
+  column "nfc_serial" {
+    null = true
+    type = varchar(64)
+  }
+
+  column "grade_id" {
+    null = true
+    type = integer
+  }
+
+  column "org_id" {
+    null = true
+    type = integer
+  }
+
+  column "created_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  column "updated_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.user_id]
+  }
+
+  foreign_key "profiles_user_id_fkey" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "profiles_grade_id_fkey" {
+    columns     = [column.grade_id]
+    ref_columns = [table.grades.column.id]
+    on_update   = NO_ACTION
+    on_delete   = NO_ACTION
+  }
+
+  foreign_key "profiles_org_id_fkey" {
+    columns     = [column.org_id]
+    ref_columns = [table.organizations.column.id]
+    on_update   = NO_ACTION
+    on_delete   = NO_ACTION
+  }
+
+  index "profiles_display_id_key" {
+    unique  = true
+    columns = [column.display_id]
+  }
+
+  index "profiles_nfc_serial_key" {
+    unique  = true
+    columns = [column.nfc_serial]
+  }
+
+  index "idx_profiles_grade_id" {
+    columns = [column.grade_id]
+  }
+
+  index "idx_profiles_org_id" {
+    columns = [column.org_id]
+  }
+}
+
+// 入退室ログ。場所ごとに最後のログが check-in であれば入室中とみなす
+table "attendance_logs" {
+  schema = schema.public
+
+  column "id" {
+    null = false
+    type = bigserial
+  }
+
+  column "user_id" {
+    null = false
+    type = uuid
+  }
+
+  column "location_id" {
+    null = false
+    type = integer
+  }
+
+  column "type" {
+    null = false
+    type = varchar(16)
+  }
+
+  column "recorded_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  // 締め時刻に自動で追加した退室
+  column "auto" {
+    null    = false
+    type    = boolean
+    default = false
+  }
+
+  // 承認された修正申請で追加した記録
+  column "manual" {
+    null    = false
+    type    = boolean
+    default = false
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "attendance_logs_user_id_fkey" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "attendance_logs_location_id_fkey" {
+    columns     = [column.location_id]
+    ref_columns = [table.locations.column.id]
+    on_update   = NO_ACTION
+    on_delete   = NO_ACTION
+  }
+
+  check "attendance_logs_type_check" {
+    expr = "((type)::text = ANY ((ARRAY['check-in'::character varying, 'check-out'::character varying])::text[]))"
+  }
+
+  index "idx_attendance_logs_user_location_recorded_at" {
+    columns = [column.user_id, column.location_id, column.recorded_at]
+  }
+
+  index "idx_attendance_logs_location_id" {
+    columns = [column.location_id]
+  }
+
+  index "idx_attendance_logs_recorded_at" {
+    columns = [column.recorded_at]
+  }
+}
+
+// 入退室の修正申請。承認すると attendance_logs に manual の記録を追加し、既存の記録は変更しない
+table "attendance_corrections" {
+  schema = schema.public
+
+  column "id" {
+    null = false
+    type = bigserial
+  }
+
+  column "user_id" {
+    null = false
+    type = uuid
+  }
+
+  column "location_id" {
+    null = false
+    type = integer
+  }
+
+  column "type" {
+    null = false
+    type = varchar(16)
+  }
+
+  column "recorded_at" {
+    null = false
+    type = timestamp
+  }
+
+  column "reason" {
+    null = false
+    type = text
+  }
+
+  column "status" {
+    null    = false
+    type    = varchar(16)
+    default = "pending"
+  }
+
+  column "requested_by" {
+    null = true
+    type = uuid
+  }
+
+  column "requested_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  column "reviewed_by" {
+    null = true
+    type = uuid
+  }
+
+  column "reviewed_at" {
+    null = true
+    type = timestamp
+  }
+
+  column "review_comment" {
+    null    = false
+    type    = text
+    default = ""
+  }
+
+  // 承認して追加した記録
+  column "attendance_log_id" {
+    null = true
+    type = bigint
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "attendance_corrections_user_id_fkey" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "attendance_corrections_location_id_fkey" {
+    columns     = [column.location_id]
+    ref_columns = [table.locations.column.id]
+    on_update   = NO_ACTION
+    on_delete   = NO_ACTION
+  }
+
+  foreign_key "attendance_corrections_requested_by_fkey" {
+    columns     = [column.requested_by]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  foreign_key "attendance_corrections_reviewed_by_fkey" {
+    columns     = [column.reviewed_by]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  foreign_key "attendance_corrections_attendance_log_id_fkey" {
+    columns     = [column.attendance_log_id]
+    ref_columns = [table.attendance_logs.column.id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  check "attendance_corrections_type_check" {
+    expr = "((type)::text = ANY ((ARRAY['check-in'::character varying, 'check-out'::character varying])::text[]))"
+  }
+
+  check "attendance_corrections_status_check" {
+    expr = "((status)::text = ANY ((ARRAY['pending'::character varying, 'approved'::character varying, 'rejected'::character varying])::text[]))"
+  }
+
+  index "idx_attendance_corrections_status_requested_at" {
+    columns = [column.status, column.requested_at]
+  }
+
+  index "idx_attendance_corrections_user_id" {
+    columns = [column.user_id]
+  }
+}
+
+// 受け付けなかった NFC タップ。未登録のカードを後から登録するために残す
+table "rejected_taps" {
+  schema = schema.public
+
+  column "id" {
+    null = false
+    type = bigserial
+  }
+
+  column "nfc_serial" {
+    null = false
+    type = varchar(64)
+  }
+
+  column "location_id" {
+    null = false
+    type = integer
+  }
+
+  column "reason" {
+    null = false
+    type = varchar(32)
+  }
+
+  column "tapped_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "rejected_taps_location_id_fkey" {
+    columns     = [column.location_id]
+    ref_columns = [table.locations.column.id]
+    on_update   = NO_ACTION
+    on_delete   = NO_ACTION
+  }
+
+  check "rejected_taps_reason_check" {
+    expr = "((reason)::text = ANY ((ARRAY['unknown_card'::character varying, 'inactive_user'::character varying])::text[]))"
+  }
+
+  index "idx_rejected_taps_nfc_serial" {
+    columns = [column.nfc_serial]
+  }
+
+  index "idx_rejected_taps_tapped_at" {
+    columns = [column.tapped_at]
+  }
+}
+
+// NFC カードの登録待ち。場所ごとに待機中の登録は1件まで
+table "nfc_enrollments" {
+  schema = schema.public
+
+  column "id" {
+    null = false
+    type = bigserial
+  }
+
+  column "user_id" {
+    null = false
+    type = uuid
+  }
+
+  column "location_id" {
+    null = false
+    type = integer
+  }
+
+  column "armed_by" {
+    null = true
+    type = uuid
+  }
+
+  column "nfc_serial" {
+    null = true
+    type = varchar(64)
+  }
+
+  column "expires_at" {
+    null = false
+    type = timestamp
+  }
+
+  column "completed_at" {
+    null = true
+    type = timestamp
+  }
+
+  column "cancelled_at" {
+    null = true
+    type = timestamp
+  }
+
+  column "created_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "nfc_enrollments_user_id_fkey" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "nfc_enrollments_location_id_fkey" {
+    columns     = [column.location_id]
+    ref_columns = [table.locations.column.id]
+    on_update   = NO_ACTION
+    on_delete   = NO_ACTION
+  }
+
+  foreign_key "nfc_enrollments_armed_by_fkey" {
+    columns     = [column.armed_by]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  index "nfc_enrollments_pending_location_key" {
+    unique  = true
+    columns = [column.location_id]
+    where   = "((completed_at IS NULL) AND (cancelled_at IS NULL))"
+  }
+
+  index "idx_nfc_enrollments_user_id" {
+    columns = [column.user_id]
+  }
+}
+
+// NFC カードの登録・解除の履歴
+table "nfc_card_events" {
+  schema = schema.public
+
+  column "id" {
+    null = false
+    type = bigserial
+  }
+
+  column "user_id" {
+    null = false
+    type = uuid
+  }
+
+  column "nfc_serial" {
+    null = false
+    type = varchar(64)
+  }
+
+  column "event" {
+    null = false
+    type = varchar(16)
+  }
+
+  column "actor_id" {
+    null = true
+    type = uuid
+  }
+
+  column "created_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "nfc_card_events_user_id_fkey" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "nfc_card_events_actor_id_fkey" {
+    columns     = [column.actor_id]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  check "nfc_card_events_event_check" {
+    expr = "((event)::text = ANY ((ARRAY['bound'::character varying, 'unbound'::character varying, 'lost'::character varying, 'replaced'::character varying])::text[]))"
+  }
+
+  index "idx_nfc_card_events_user_id_created_at" {
+    columns = [column.user_id, column.created_at]
+  }
+
+  index "idx_nfc_card_events_nfc_serial" {
+    columns = [column.nfc_serial]
+  }
+}
+
+// NFC リーダーなどの端末。API キーは SHA-256 のハッシュのみを保存する
+table "devices" {
+  schema = schema.public
+
+  column "id" {
+    null    = false
+    type    = uuid
+    default = sql("gen_random_uuid()")
+  }
+
+  column "name" {
+    null = false
+    type = varchar(255)
+  }
+
+  column "location_id" {
+    null = false
+    type = integer
+  }
+
+  column "key_prefix" {
+    null = false
+    type = varchar(16)
+  }
+
+  column "key_hash" {
+    null = false
+    type = varchar(64)
+  }
+
+  column "key_rotated_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  // secretboxで暗号化したリクエスト署名の鍵
+  column "signing_secret" {
+    null = true
+    type = text
+  }
+
+  column "last_seen_at" {
+    null = true
+    type = timestamp
+  }
+
+  column "revoked_at" {
+    null = true
+    type = timestamp
+  }
+
+  column "created_by" {
+    null = true
+    type = uuid
+  }
+
+  column "created_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  column "updated_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  foreign_key "devices_location_id_fkey" {
+    columns     = [column.location_id]
+    ref_columns = [table.locations.column.id]
+    on_update   = NO_ACTION
+    on_delete   = NO_ACTION
+  }
+
+  foreign_key "devices_created_by_fkey" {
+    columns     = [column.created_by]
+    ref_columns = [table.users.column.id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  index "devices_key_hash_key" {
+    unique  = true
+    columns = [column.key_hash]
+  }
+
+  index "idx_devices_location_id" {
+    columns = [column.location_id]
+  }
+}
+
+// 端末のリクエスト署名で使用済みの nonce。期限が切れたら削除する
+table "device_nonces" {
+  schema = schema.public
+
+  column "key" {
+    null = false
+    type = varchar(200)
+  }
+
+  column "expires_at" {
+    null = false
+    type = timestamp
+  }
+
+  primary_key {
+    columns = [column.key]
+  }
+
+  index "idx_device_nonces_expires_at" {
+    columns = [column.expires_at]
+  }
+}
+
+// 端末がオフラインの間に保存して後から送ったタップ。同じタップを二度記録しないために残す
+table "device_taps" {
+  schema = schema.public
+
+  column "device_id" {
+    null = false
+    type = uuid
+  }
+
+  column "client_tap_id" {
+    null = false
+    type = varchar(128)
+  }
+
+  column "tapped_at" {
+    null = false
+    type = timestamp
+  }
+
+  column "status" {
+    null = false
+    type = varchar(16)
+  }
+
+  column "attendance_log_id" {
+    null = true
+    type = bigint
+  }
+
+  column "created_at" {
+    null    = false
+    type    = timestamp
+    default = sql("CURRENT_TIMESTAMP")
+  }
+
+  primary_key {
+    columns = [column.device_id, column.client_tap_id]
+  }
+
+  foreign_key "device_taps_device_id_fkey" {
+    columns     = [column.device_id]
+    ref_columns = [table.devices.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "device_taps_attendance_log_id_fkey" {
+    columns     = [column.attendance_log_id]
+    ref_columns = [table.attendance_logs.column.id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  check "device_taps_status_check" {
+    expr = "((status)::text = ANY ((ARRAY['recorded'::character varying, 'rejected'::character varying])::text[]))"
+  }
 }
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/google/uuid"
+)
+
+// Identity は認証済みリクエストの呼び出し元を表す
+type Identity struct {
+	UserID    uuid.UUID
+	Email     string
+	Role      model.Role
+	TokenID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// HasRole は呼び出し元が roles のいずれかを持つかを返す
+func (i *Identity) HasRole(roles ...model.Role) bool {
+	for _, role := range roles {
+		if i.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *Identity) IsAdmin() bool {
+	return i.Role == model.RoleAdmin
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok && identity != nil
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/signature"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidDeviceKey = errors.New("invalid device key")
+	ErrRevokedDevice    = errors.New("device revoked")
+	// ErrInvalidSignature は署名がない、または一致しない場合に返す
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrStaleSignature は署名の時刻が許容範囲から外れている場合に返す
+	ErrStaleSignature = errors.New("signature timestamp out of range")
+	// ErrReplayedRequest は使用済みの nonce が再び送られた場合に返す
+	ErrReplayedRequest = errors.New("nonce already used")
+)
+
+// Device は API キーで認証された端末を表す。端末は登録された場所でのみ記録できる
+type Device struct {
+	DeviceID   uuid.UUID
+	LocationID int
+}
+
+type DeviceAuthenticator interface {
+	AuthenticateDevice(ctx context.Context, apiKey string) (*Device, error)
+}
+
+// DeviceRequestVerifier は端末からのリクエストの署名と nonce を検証する
+type DeviceRequestVerifier interface {
+	VerifyDeviceRequest(ctx context.Context, device *Device, req *signature.Request, sig string) error
+}
+
+type deviceKey struct{}
+
+func WithDevice(ctx context.Context, device *Device) context.Context {
+	return context.WithValue(ctx, deviceKey{}, device)
+}
+
+func DeviceFromContext(ctx context.Context) (*Device, bool) {
+	device, ok := ctx.Value(deviceKey{}).(*Device)
+	return device, ok && device != nil
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token expired")
+	ErrRevokedToken = errors.New("token revoked")
+	// ErrUnavailable は検証に必要な外部の IdP に問い合わせられなかったことを表す
+	ErrUnavailable = errors.New("token validation unavailable")
+)
+
+type Claims struct {
+	UserID string     `json:"user_id"`
+	Email  string     `json:"email"`
+	Role   model.Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// TokenManager は Login で発行する HS256 トークンの生成と検証を担う
+type TokenManager struct {
+	secret []byte
+	expiry time.Duration
+}
+
+func NewTokenManager(secret string, expiry time.Duration) *TokenManager {
+	return &TokenManager{
+		secret: []byte(secret),
+		expiry: expiry,
+	}
+}
+
+func (m *TokenManager) Expiry() time.Duration {
+	return m.expiry
+}
+
+func (m *TokenManager) Generate(user *model.User) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID: user.ID.String(),
+		Email:  user.Email,
+		Role:   user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.expiry)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(m.secret)
+}
+
+func (m *TokenManager) Parse(tokenString string) (*Identity, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user_id claim", ErrInvalidToken)
+	}
+
+	if claims.ID == "" {
+		return nil, fmt.Errorf("%w: missing jti claim", ErrInvalidToken)
+	}
+
+	// role クレームを持たない古いトークンは最小権限として扱う
+	role := claims.Role
+	if role == "" {
+		role = model.RoleMember
+	}
+	if !role.Valid() {
+		return nil, fmt.Errorf("%w: invalid role claim", ErrInvalidToken)
+	}
+
+	identity := &Identity{
+		UserID:    userID,
+		Email:     claims.Email,
+		Role:      role,
+		TokenID:   claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+	if claims.IssuedAt != nil {
+		identity.IssuedAt = claims.IssuedAt.Time
+	}
+
+	return identity, nil
+}
+
+const mfaChallengeAudience = "mfa-challenge"
+
+// GenerateMFAChallenge はパスワード認証を通過したユーザーに、二段階認証の完了までの間だけ有効なトークンを発行する。
+// user_id クレームを持たないため、アクセストークンとしては使えない
+func (m *TokenManager) GenerateMFAChallenge(userID uuid.UUID, expiry time.Duration) (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		Subject:   userID.String(),
+		Audience:  jwt.ClaimStrings{mfaChallengeAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(m.secret)
+}
+
+// ParseMFAChallenge は GenerateMFAChallenge で発行したトークンを検証し、ユーザーIDを返す
+func (m *TokenManager) ParseMFAChallenge(tokenString string) (uuid.UUID, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(mfaChallengeAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return uuid.Nil, ErrExpiredToken
+		}
+		return uuid.Nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: invalid subject", ErrInvalidToken)
+	}
+
+	return userID, nil
+}
+
+func (m *TokenManager) ValidateAccessToken(_ context.Context, tokenString string) (*Identity, error) {
+	return m.Parse(tokenString)
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenManager_GenerateAndParse(t *testing.T) {
+	manager := NewTokenManager("test-secret", time.Hour)
+	user := &model.User{
+		ID:    uuid.New(),
+		Email: "test@example.com",
+	}
+
+	token, err := manager.Generate(user)
+	require.NoError(t, err)
+
+	identity, err := manager.Parse(token)
+
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, identity.UserID)
+	assert.Equal(t, user.Email, identity.Email)
+	assert.NotEmpty(t, identity.TokenID)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), identity.ExpiresAt, 2*time.Second)
+}
+
+func TestTokenManager_Generate_UniqueTokenIDs(t *testing.T) {
+	manager := NewTokenManager("test-secret", time.Hour)
+	user := &model.User{ID: uuid.New(), Email: "test@example.com"}
+
+	first, err := manager.Generate(user)
+	require.NoError(t, err)
+	second, err := manager.Generate(user)
+	require.NoError(t, err)
+
+	firstIdentity, err := manager.Parse(first)
+	require.NoError(t, err)
+	secondIdentity, err := manager.Parse(second)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, firstIdentity.TokenID, secondIdentity.TokenID)
+}
+
+func TestTokenManager_RoleClaim(t *testing.T) {
+	manager := NewTokenManager("test-secret", time.Hour)
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Role: model.RoleAdmin}
+
+	token, err := manager.Generate(user)
+	require.NoError(t, err)
+
+	identity, err := manager.Parse(token)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, identity.Role)
+	assert.True(t, identity.IsAdmin())
+}
+
+func TestTokenManager_Parse_RoleClaim(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     interface{}
+		wantRole model.Role
+		wantErr  bool
+	}{
+		{
+			name:     "missing role defaults to member",
+			role:     nil,
+			wantRole: model.RoleMember,
+		},
+		{
+			name:    "unknown role",
+			role:    "owner",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := jwt.MapClaims{
+				"user_id": uuid.New().String(),
+				"email":   "test@example.com",
+				"jti":     uuid.NewString(),
+				"exp":     time.Now().Add(time.Hour).Unix(),
+			}
+			if tt.role != nil {
+				claims["role"] = tt.role
+			}
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+			require.NoError(t, err)
+
+			identity, err := NewTokenManager("test-secret", time.Hour).Parse(token)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidToken)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRole, identity.Role)
+		})
+	}
+}
+
+func TestTokenManager_Parse_MissingTokenID(t *testing.T) {
+	claims := jwt.MapClaims{
+		"user_id": uuid.New().String(),
+		"email":   "test@example.com",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+	require.NoError(t, err)
+
+	identity, err := NewTokenManager("test-secret", time.Hour).Parse(token)
+
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Nil(t, identity)
+}
+
+func TestTokenManager_Parse_Expired(t *testing.T) {
+	manager := NewTokenManager("test-secret", -time.Minute)
+	user := &model.User{ID: uuid.New(), Email: "test@example.com"}
+
+	token, err := manager.Generate(user)
+	require.NoError(t, err)
+
+	identity, err := manager.Parse(token)
+
+	assert.ErrorIs(t, err, ErrExpiredToken)
+	assert.Nil(t, identity)
+}
+
+func TestTokenManager_Parse_WrongSecret(t *testing.T) {
+	user := &model.User{ID: uuid.New(), Email: "test@example.com"}
+
+	token, err := NewTokenManager("other-secret", time.Hour).Generate(user)
+	require.NoError(t, err)
+
+	identity, err := NewTokenManager("test-secret", time.Hour).Parse(token)
+
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Nil(t, identity)
+}
+
+func TestTokenManager_Parse_RejectsOtherAlgorithms(t *testing.T) {
+	claims := Claims{
+		UserID: uuid.New().String(),
+		Email:  "test@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	identity, err := NewTokenManager("test-secret", time.Hour).Parse(token)
+
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Nil(t, identity)
+}
+
+func TestTokenManager_Parse_MissingExpiry(t *testing.T) {
+	claims := jwt.MapClaims{
+		"user_id": uuid.New().String(),
+		"email":   "test@example.com",
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+	require.NoError(t, err)
+
+	identity, err := NewTokenManager("test-secret", time.Hour).Parse(token)
+
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Nil(t, identity)
+}
+
+func TestTokenManager_MFAChallenge(t *testing.T) {
+	manager := NewTokenManager("test-secret", time.Hour)
+	userID := uuid.New()
+
+	challenge, err := manager.GenerateMFAChallenge(userID, 5*time.Minute)
+	require.NoError(t, err)
+
+	got, err := manager.ParseMFAChallenge(challenge)
+	require.NoError(t, err)
+	assert.Equal(t, userID, got)
+
+	// チャレンジトークンはアクセストークンとして使えない
+	_, err = manager.Parse(challenge)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestTokenManager_ParseMFAChallenge_Rejected(t *testing.T) {
+	manager := NewTokenManager("test-secret", time.Hour)
+
+	accessToken, err := manager.Generate(&model.User{ID: uuid.New(), Email: "test@example.com"})
+	require.NoError(t, err)
+	_, err = manager.ParseMFAChallenge(accessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	expired, err := manager.GenerateMFAChallenge(uuid.New(), -time.Minute)
+	require.NoError(t, err)
+	_, err = manager.ParseMFAChallenge(expired)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+}
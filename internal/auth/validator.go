@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+type TokenValidator interface {
+	ValidateAccessToken(ctx context.Context, token string) (*Identity, error)
+}
+
+type validatorChain []TokenValidator
+
+// ChainValidators は先頭の検証器から順に試し、ErrInvalidToken（このトークン形式を扱えない）の
+// 場合のみ次の検証器に委ねる。期限切れや失効はその時点で確定させる
+func ChainValidators(validators ...TokenValidator) TokenValidator {
+	return validatorChain(validators)
+}
+
+func (c validatorChain) ValidateAccessToken(ctx context.Context, token string) (*Identity, error) {
+	err := ErrInvalidToken
+	for _, validator := range c {
+		var identity *Identity
+		identity, err = validator.ValidateAccessToken(ctx, token)
+		if err == nil {
+			return identity, nil
+		}
+		if !errors.Is(err, ErrInvalidToken) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validatorFunc func(ctx context.Context, token string) (*Identity, error)
+
+func (f validatorFunc) ValidateAccessToken(ctx context.Context, token string) (*Identity, error) {
+	return f(ctx, token)
+}
+
+func TestChainValidators_FallsThroughOnInvalidToken(t *testing.T) {
+	identity := &Identity{UserID: uuid.New()}
+	chain := ChainValidators(
+		validatorFunc(func(context.Context, string) (*Identity, error) { return nil, ErrInvalidToken }),
+		validatorFunc(func(context.Context, string) (*Identity, error) { return identity, nil }),
+	)
+
+	got, err := chain.ValidateAccessToken(context.Background(), "token")
+
+	require.NoError(t, err)
+	assert.Equal(t, identity, got)
+}
+
+func TestChainValidators_StopsOnDefinitiveError(t *testing.T) {
+	called := false
+	chain := ChainValidators(
+		validatorFunc(func(context.Context, string) (*Identity, error) { return nil, ErrExpiredToken }),
+		validatorFunc(func(context.Context, string) (*Identity, error) {
+			called = true
+			return &Identity{}, nil
+		}),
+	)
+
+	got, err := chain.ValidateAccessToken(context.Background(), "token")
+
+	assert.ErrorIs(t, err, ErrExpiredToken)
+	assert.Nil(t, got)
+	assert.False(t, called)
+}
+
+func TestChainValidators_ReturnsLastError(t *testing.T) {
+	last := errors.Join(ErrInvalidToken, errors.New("unknown key"))
+	chain := ChainValidators(
+		validatorFunc(func(context.Context, string) (*Identity, error) { return nil, ErrInvalidToken }),
+		validatorFunc(func(context.Context, string) (*Identity, error) { return nil, last }),
+	)
+
+	got, err := chain.ValidateAccessToken(context.Background(), "token")
+
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Equal(t, last, err)
+	assert.Nil(t, got)
+}
@@ -4,17 +4,31 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Zitadel  ZitadelConfig
+	Server         ServerConfig
+	Database       DatabaseConfig
+	JWT            JWTConfig
+	Zitadel        ZitadelConfig
+	Mail           MailConfig
+	PasswordReset  PasswordResetConfig
+	EmailVerify    EmailVerificationConfig
+	PasswordPolicy PasswordPolicyConfig
+	LoginLockout   LoginLockoutConfig
+	MFA            MFAConfig
+	NFC            NFCConfig
+	Device         DeviceConfig
+	AutoCheckout   AutoCheckoutConfig
+	Presence       PresenceConfig
+	Report         ReportConfig
 }
 
 type ServerConfig struct {
 	Port int
+	// TrustedProxies は X-Forwarded-For を信頼するプロキシの CIDR。空であれば接続元のアドレスをそのまま使う
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -27,14 +41,109 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	Secret      string
-	ExpiryHours int
+	Secret                 string
+	AccessExpiryMinutes    int
+	RefreshExpiryHours     int
+	RevocationCacheSeconds int
 }
 
 type ZitadelConfig struct {
 	URL          string
 	ClientID     string
 	ClientSecret string
+	RedirectURL  string
+}
+
+// Enabled は Zitadel 連携に必要な設定が揃っているかを返す
+func (c *ZitadelConfig) Enabled() bool {
+	return c.URL != "" && c.ClientID != "" && c.RedirectURL != ""
+}
+
+type MailConfig struct {
+	// Sender は "log"（ログ出力）または "file"（FileDir に .eml を書き出す）
+	Sender  string
+	From    string
+	FileDir string
+}
+
+type PasswordResetConfig struct {
+	// URL はメールに記載する再設定画面の URL。token クエリパラメーターが付与される
+	URL           string
+	ExpiryMinutes int
+	// ResendIntervalSeconds は同じメールアドレスに再設定メールを再送できるまでの間隔
+	ResendIntervalSeconds int
+	// MaxSends 回続けて同じメールアドレスに送信すると1時間再送できなくなる
+	MaxSends int
+	// IPMaxSends 回続けて同じ接続元から要求すると1時間要求できなくなる
+	IPMaxSends int
+}
+
+type EmailVerificationConfig struct {
+	// URL はメールに記載する確認画面の URL。token クエリパラメーターが付与される
+	URL         string
+	ExpiryHours int
+	// ResendIntervalSeconds は確認メールを再送できるまでの間隔
+	ResendIntervalSeconds int
+	// MaxSends 回続けて送信すると1時間再送できなくなる
+	MaxSends int
+}
+
+type PasswordPolicyConfig struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	RejectCommon  bool
+}
+
+type LoginLockoutConfig struct {
+	// Store は "postgres"（レプリカ間で共有）または "memory"
+	Store              string
+	MaxFailures        int
+	IPMaxFailures      int
+	BackoffBaseSeconds int
+	BackoffMaxSeconds  int
+	LockoutMinutes     int
+}
+
+type MFAConfig struct {
+	// Issuer は認証アプリに表示されるサービス名
+	Issuer string
+	// EncryptionKey は TOTP の共有鍵の暗号化に使う。必須
+	EncryptionKey string
+}
+
+type NFCConfig struct {
+	// EnrollmentExpiryMinutes はカード登録のためにリーダーを待機させておく時間
+	EnrollmentExpiryMinutes int
+}
+
+type DeviceConfig struct {
+	// EncryptionKey は端末の署名鍵の暗号化に使う。必須
+	EncryptionKey string
+	// SignatureToleranceSeconds は署名の時刻とサーバーの時刻のずれの許容範囲
+	SignatureToleranceSeconds int
+	// NonceStore は "postgres"（レプリカ間で共有）または "memory"
+	NonceStore string
+}
+
+type AutoCheckoutConfig struct {
+	Enabled bool
+	// Time は退室し忘れた入室を閉じる締め時刻（HH:MM）
+	Time string
+	// Timezone は締め時刻のタイムゾーン（例: Asia/Tokyo）
+	Timezone string
+}
+
+type PresenceConfig struct {
+	// EventBus は "postgres"（LISTEN / NOTIFY でレプリカ間に通知）または "memory"
+	EventBus string
+}
+
+type ReportConfig struct {
+	// Timezone は集計で日付を区切るタイムゾーンの既定値（例: Asia/Tokyo）
+	Timezone string
 }
 
 func Load() (*Config, error) {
@@ -48,14 +157,130 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid DB_PORT: %w", err)
 	}
 
-	jwtExpiryHours, err := strconv.Atoi(getEnv("JWT_EXPIRY_HOURS", "24"))
+	// JWT_EXPIRY_HOURS はアクセストークンとリフレッシュトークンの設定に分けたため、
+	// 設定されたまま黙って無視しないよう起動を止める
+	if _, ok := os.LookupEnv("JWT_EXPIRY_HOURS"); ok {
+		return nil, fmt.Errorf("JWT_EXPIRY_HOURS is no longer supported: use JWT_ACCESS_EXPIRY_MINUTES and JWT_REFRESH_EXPIRY_HOURS")
+	}
+
+	jwtAccessExpiryMinutes, err := strconv.Atoi(getEnv("JWT_ACCESS_EXPIRY_MINUTES", "15"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_ACCESS_EXPIRY_MINUTES: %w", err)
+	}
+	if jwtAccessExpiryMinutes <= 0 {
+		return nil, fmt.Errorf("invalid JWT_ACCESS_EXPIRY_MINUTES: must be positive")
+	}
+
+	jwtRefreshExpiryHours, err := strconv.Atoi(getEnv("JWT_REFRESH_EXPIRY_HOURS", "720"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_REFRESH_EXPIRY_HOURS: %w", err)
+	}
+	if jwtRefreshExpiryHours <= 0 {
+		return nil, fmt.Errorf("invalid JWT_REFRESH_EXPIRY_HOURS: must be positive")
+	}
+
+	jwtRevocationCacheSeconds, err := strconv.Atoi(getEnv("JWT_REVOCATION_CACHE_SECONDS", "30"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_REVOCATION_CACHE_SECONDS: %w", err)
+	}
+
+	passwordReset := PasswordResetConfig{URL: getEnv("PASSWORD_RESET_URL", "http://localhost:3000/password/reset")}
+	for _, setting := range []struct {
+		key          string
+		defaultValue string
+		dest         *int
+	}{
+		{"PASSWORD_RESET_EXPIRY_MINUTES", "30", &passwordReset.ExpiryMinutes},
+		{"PASSWORD_RESET_RESEND_SECONDS", "60", &passwordReset.ResendIntervalSeconds},
+		{"PASSWORD_RESET_MAX_SENDS", "5", &passwordReset.MaxSends},
+		{"PASSWORD_RESET_IP_MAX_SENDS", "20", &passwordReset.IPMaxSends},
+	} {
+		value, err := strconv.Atoi(getEnv(setting.key, setting.defaultValue))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", setting.key, err)
+		}
+		*setting.dest = value
+	}
+
+	emailVerify := EmailVerificationConfig{URL: getEnv("EMAIL_VERIFICATION_URL", "http://localhost:3000/email/verify")}
+	for _, setting := range []struct {
+		key          string
+		defaultValue string
+		dest         *int
+	}{
+		{"EMAIL_VERIFICATION_EXPIRY_HOURS", "24", &emailVerify.ExpiryHours},
+		{"EMAIL_VERIFICATION_RESEND_SECONDS", "60", &emailVerify.ResendIntervalSeconds},
+		{"EMAIL_VERIFICATION_MAX_SENDS", "5", &emailVerify.MaxSends},
+	} {
+		value, err := strconv.Atoi(getEnv(setting.key, setting.defaultValue))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", setting.key, err)
+		}
+		*setting.dest = value
+	}
+
+	passwordMinLength, err := strconv.Atoi(getEnv("PASSWORD_MIN_LENGTH", "8"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PASSWORD_MIN_LENGTH: %w", err)
+	}
+
+	passwordPolicy := PasswordPolicyConfig{MinLength: passwordMinLength}
+	for _, flag := range []struct {
+		key          string
+		defaultValue string
+		dest         *bool
+	}{
+		{"PASSWORD_REQUIRE_UPPER", "false", &passwordPolicy.RequireUpper},
+		{"PASSWORD_REQUIRE_LOWER", "false", &passwordPolicy.RequireLower},
+		{"PASSWORD_REQUIRE_DIGIT", "false", &passwordPolicy.RequireDigit},
+		{"PASSWORD_REQUIRE_SYMBOL", "false", &passwordPolicy.RequireSymbol},
+		{"PASSWORD_REJECT_COMMON", "true", &passwordPolicy.RejectCommon},
+	} {
+		value, err := strconv.ParseBool(getEnv(flag.key, flag.defaultValue))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", flag.key, err)
+		}
+		*flag.dest = value
+	}
+
+	loginLockout := LoginLockoutConfig{Store: getEnv("LOGIN_ATTEMPT_STORE", "postgres")}
+	for _, setting := range []struct {
+		key          string
+		defaultValue string
+		dest         *int
+	}{
+		{"LOGIN_MAX_FAILURES", "5", &loginLockout.MaxFailures},
+		{"LOGIN_IP_MAX_FAILURES", "50", &loginLockout.IPMaxFailures},
+		{"LOGIN_BACKOFF_BASE_SECONDS", "1", &loginLockout.BackoffBaseSeconds},
+		{"LOGIN_BACKOFF_MAX_SECONDS", "60", &loginLockout.BackoffMaxSeconds},
+		{"LOGIN_LOCKOUT_MINUTES", "15", &loginLockout.LockoutMinutes},
+	} {
+		value, err := strconv.Atoi(getEnv(setting.key, setting.defaultValue))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", setting.key, err)
+		}
+		*setting.dest = value
+	}
+
+	nfcEnrollmentExpiryMinutes, err := strconv.Atoi(getEnv("NFC_ENROLLMENT_EXPIRY_MINUTES", "5"))
 	if err != nil {
-		return nil, fmt.Errorf("invalid JWT_EXPIRY_HOURS: %w", err)
+		return nil, fmt.Errorf("invalid NFC_ENROLLMENT_EXPIRY_MINUTES: %w", err)
+	}
+
+	deviceSignatureToleranceSeconds, err := strconv.Atoi(getEnv("DEVICE_SIGNATURE_TOLERANCE_SECONDS", "300"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DEVICE_SIGNATURE_TOLERANCE_SECONDS: %w", err)
+	}
+
+	autoCheckoutEnabled, err := strconv.ParseBool(getEnv("AUTO_CHECKOUT_ENABLED", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTO_CHECKOUT_ENABLED: %w", err)
 	}
 
 	return &Config{
 		Server: ServerConfig{
-			Port: serverPort,
+			Port:           serverPort,
+			TrustedProxies: splitList(getEnv("TRUSTED_PROXIES", "")),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DATABASE_HOST", getEnv("DB_HOST", "localhost")),
@@ -66,13 +291,48 @@ func Load() (*Config, error) {
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
 		JWT: JWTConfig{
-			Secret:      getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-			ExpiryHours: jwtExpiryHours,
+			Secret:                 getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+			AccessExpiryMinutes:    jwtAccessExpiryMinutes,
+			RefreshExpiryHours:     jwtRefreshExpiryHours,
+			RevocationCacheSeconds: jwtRevocationCacheSeconds,
 		},
 		Zitadel: ZitadelConfig{
 			URL:          getEnv("ZITADEL_URL", ""),
 			ClientID:     getEnv("ZITADEL_CLIENT_ID", ""),
 			ClientSecret: getEnv("ZITADEL_CLIENT_SECRET", ""),
+			RedirectURL:  getEnv("ZITADEL_REDIRECT_URL", ""),
+		},
+		Mail: MailConfig{
+			Sender:  getEnv("MAIL_SENDER", "log"),
+			From:    getEnv("MAIL_FROM", "noreply@tsunagu.local"),
+			FileDir: getEnv("MAIL_FILE_DIR", "tmp/mail"),
+		},
+		PasswordReset:  passwordReset,
+		EmailVerify:    emailVerify,
+		PasswordPolicy: passwordPolicy,
+		LoginLockout:   loginLockout,
+		MFA: MFAConfig{
+			Issuer:        getEnv("MFA_ISSUER", "TSUNAGU Link"),
+			EncryptionKey: getEnv("MFA_ENCRYPTION_KEY", ""),
+		},
+		NFC: NFCConfig{
+			EnrollmentExpiryMinutes: nfcEnrollmentExpiryMinutes,
+		},
+		Device: DeviceConfig{
+			EncryptionKey:             getEnv("DEVICE_ENCRYPTION_KEY", ""),
+			SignatureToleranceSeconds: deviceSignatureToleranceSeconds,
+			NonceStore:                getEnv("DEVICE_NONCE_STORE", "postgres"),
+		},
+		AutoCheckout: AutoCheckoutConfig{
+			Enabled:  autoCheckoutEnabled,
+			Time:     getEnv("AUTO_CHECKOUT_TIME", "23:59"),
+			Timezone: getEnv("AUTO_CHECKOUT_TIMEZONE", "Asia/Tokyo"),
+		},
+		Presence: PresenceConfig{
+			EventBus: getEnv("PRESENCE_EVENT_BUS", "postgres"),
+		},
+		Report: ReportConfig{
+			Timezone: getEnv("REPORT_TIMEZONE", "Asia/Tokyo"),
 		},
 	}, nil
 }
@@ -84,6 +344,17 @@ func (c *DatabaseConfig) DSN() string {
 	)
 }
 
+// splitList はカンマ区切りの値を分割し、空の要素を除く
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
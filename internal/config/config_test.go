@@ -31,13 +31,68 @@ func TestLoad(t *testing.T) {
 					SSLMode:  "disable",
 				},
 				JWT: JWTConfig{
-					Secret:      "your-secret-key-change-in-production",
-					ExpiryHours: 24,
+					Secret:                 "your-secret-key-change-in-production",
+					AccessExpiryMinutes:    15,
+					RefreshExpiryHours:     720,
+					RevocationCacheSeconds: 30,
 				},
 				Zitadel: ZitadelConfig{
 					URL:          "",
 					ClientID:     "",
 					ClientSecret: "",
+					RedirectURL:  "",
+				},
+				Mail: MailConfig{
+					Sender:  "log",
+					From:    "noreply@tsunagu.local",
+					FileDir: "tmp/mail",
+				},
+				PasswordReset: PasswordResetConfig{
+					URL:                   "http://localhost:3000/password/reset",
+					ExpiryMinutes:         30,
+					ResendIntervalSeconds: 60,
+					MaxSends:              5,
+					IPMaxSends:            20,
+				},
+				EmailVerify: EmailVerificationConfig{
+					URL:                   "http://localhost:3000/email/verify",
+					ExpiryHours:           24,
+					ResendIntervalSeconds: 60,
+					MaxSends:              5,
+				},
+				PasswordPolicy: PasswordPolicyConfig{
+					MinLength:    8,
+					RejectCommon: true,
+				},
+				LoginLockout: LoginLockoutConfig{
+					Store:              "postgres",
+					MaxFailures:        5,
+					IPMaxFailures:      50,
+					BackoffBaseSeconds: 1,
+					BackoffMaxSeconds:  60,
+					LockoutMinutes:     15,
+				},
+				MFA: MFAConfig{
+					Issuer:        "TSUNAGU Link",
+					EncryptionKey: "",
+				},
+				NFC: NFCConfig{
+					EnrollmentExpiryMinutes: 5,
+				},
+				Device: DeviceConfig{
+					SignatureToleranceSeconds: 300,
+					NonceStore:                "postgres",
+				},
+				AutoCheckout: AutoCheckoutConfig{
+					Enabled:  true,
+					Time:     "23:59",
+					Timezone: "Asia/Tokyo",
+				},
+				Presence: PresenceConfig{
+					EventBus: "postgres",
+				},
+				Report: ReportConfig{
+					Timezone: "Asia/Tokyo",
 				},
 			},
 			wantErr: false,
@@ -45,22 +100,62 @@ func TestLoad(t *testing.T) {
 		{
 			name: "custom values",
 			envVars: map[string]string{
-				"SERVER_PORT":           "3000",
-				"DB_HOST":               "db.example.com",
-				"DB_PORT":               "5433",
-				"DB_USER":               "customuser",
-				"DB_PASSWORD":           "custompass",
-				"DB_NAME":               "customdb",
-				"DB_SSLMODE":            "require",
-				"JWT_SECRET":            "custom-secret",
-				"JWT_EXPIRY_HOURS":      "48",
-				"ZITADEL_URL":           "https://zitadel.example.com",
-				"ZITADEL_CLIENT_ID":     "client123",
-				"ZITADEL_CLIENT_SECRET": "secret123",
+				"SERVER_PORT":                        "3000",
+				"TRUSTED_PROXIES":                    "10.0.0.0/8, 192.168.1.10/32",
+				"DB_HOST":                            "db.example.com",
+				"DB_PORT":                            "5433",
+				"DB_USER":                            "customuser",
+				"DB_PASSWORD":                        "custompass",
+				"DB_NAME":                            "customdb",
+				"DB_SSLMODE":                         "require",
+				"JWT_SECRET":                         "custom-secret",
+				"JWT_ACCESS_EXPIRY_MINUTES":          "30",
+				"JWT_REFRESH_EXPIRY_HOURS":           "168",
+				"JWT_REVOCATION_CACHE_SECONDS":       "10",
+				"ZITADEL_URL":                        "https://zitadel.example.com",
+				"ZITADEL_CLIENT_ID":                  "client123",
+				"ZITADEL_CLIENT_SECRET":              "secret123",
+				"ZITADEL_REDIRECT_URL":               "https://api.example.com/api/v1/auth/oidc/callback",
+				"MAIL_SENDER":                        "file",
+				"MAIL_FROM":                          "noreply@example.com",
+				"MAIL_FILE_DIR":                      "/var/mail/tsunagu",
+				"PASSWORD_RESET_URL":                 "https://app.example.com/reset",
+				"PASSWORD_RESET_EXPIRY_MINUTES":      "15",
+				"PASSWORD_RESET_RESEND_SECONDS":      "90",
+				"PASSWORD_RESET_MAX_SENDS":           "3",
+				"PASSWORD_RESET_IP_MAX_SENDS":        "10",
+				"EMAIL_VERIFICATION_URL":             "https://app.example.com/verify",
+				"EMAIL_VERIFICATION_EXPIRY_HOURS":    "48",
+				"EMAIL_VERIFICATION_RESEND_SECONDS":  "120",
+				"EMAIL_VERIFICATION_MAX_SENDS":       "3",
+				"PASSWORD_MIN_LENGTH":                "12",
+				"PASSWORD_REQUIRE_UPPER":             "true",
+				"PASSWORD_REQUIRE_LOWER":             "true",
+				"PASSWORD_REQUIRE_DIGIT":             "true",
+				"PASSWORD_REQUIRE_SYMBOL":            "true",
+				"PASSWORD_REJECT_COMMON":             "false",
+				"LOGIN_ATTEMPT_STORE":                "memory",
+				"LOGIN_MAX_FAILURES":                 "3",
+				"LOGIN_IP_MAX_FAILURES":              "100",
+				"LOGIN_BACKOFF_BASE_SECONDS":         "2",
+				"LOGIN_BACKOFF_MAX_SECONDS":          "30",
+				"LOGIN_LOCKOUT_MINUTES":              "60",
+				"MFA_ISSUER":                         "TSUNAGU Link (staging)",
+				"MFA_ENCRYPTION_KEY":                 "mfa-key",
+				"NFC_ENROLLMENT_EXPIRY_MINUTES":      "10",
+				"DEVICE_ENCRYPTION_KEY":              "device-key",
+				"DEVICE_SIGNATURE_TOLERANCE_SECONDS": "60",
+				"DEVICE_NONCE_STORE":                 "memory",
+				"AUTO_CHECKOUT_ENABLED":              "false",
+				"AUTO_CHECKOUT_TIME":                 "04:00",
+				"AUTO_CHECKOUT_TIMEZONE":             "UTC",
+				"PRESENCE_EVENT_BUS":                 "memory",
+				"REPORT_TIMEZONE":                    "UTC",
 			},
 			want: &Config{
 				Server: ServerConfig{
-					Port: 3000,
+					Port:           3000,
+					TrustedProxies: []string{"10.0.0.0/8", "192.168.1.10/32"},
 				},
 				Database: DatabaseConfig{
 					Host:     "db.example.com",
@@ -71,13 +166,72 @@ func TestLoad(t *testing.T) {
 					SSLMode:  "require",
 				},
 				JWT: JWTConfig{
-					Secret:      "custom-secret",
-					ExpiryHours: 48,
+					Secret:                 "custom-secret",
+					AccessExpiryMinutes:    30,
+					RefreshExpiryHours:     168,
+					RevocationCacheSeconds: 10,
 				},
 				Zitadel: ZitadelConfig{
 					URL:          "https://zitadel.example.com",
 					ClientID:     "client123",
 					ClientSecret: "secret123",
+					RedirectURL:  "https://api.example.com/api/v1/auth/oidc/callback",
+				},
+				Mail: MailConfig{
+					Sender:  "file",
+					From:    "noreply@example.com",
+					FileDir: "/var/mail/tsunagu",
+				},
+				PasswordReset: PasswordResetConfig{
+					URL:                   "https://app.example.com/reset",
+					ExpiryMinutes:         15,
+					ResendIntervalSeconds: 90,
+					MaxSends:              3,
+					IPMaxSends:            10,
+				},
+				EmailVerify: EmailVerificationConfig{
+					URL:                   "https://app.example.com/verify",
+					ExpiryHours:           48,
+					ResendIntervalSeconds: 120,
+					MaxSends:              3,
+				},
+				PasswordPolicy: PasswordPolicyConfig{
+					MinLength:     12,
+					RequireUpper:  true,
+					RequireLower:  true,
+					RequireDigit:  true,
+					RequireSymbol: true,
+					RejectCommon:  false,
+				},
+				LoginLockout: LoginLockoutConfig{
+					Store:              "memory",
+					MaxFailures:        3,
+					IPMaxFailures:      100,
+					BackoffBaseSeconds: 2,
+					BackoffMaxSeconds:  30,
+					LockoutMinutes:     60,
+				},
+				MFA: MFAConfig{
+					Issuer:        "TSUNAGU Link (staging)",
+					EncryptionKey: "mfa-key",
+				},
+				NFC: NFCConfig{
+					EnrollmentExpiryMinutes: 10,
+				},
+				Device: DeviceConfig{
+					EncryptionKey:             "device-key",
+					SignatureToleranceSeconds: 60,
+					NonceStore:                "memory",
+				},
+				AutoCheckout: AutoCheckoutConfig{
+					Time:     "04:00",
+					Timezone: "UTC",
+				},
+				Presence: PresenceConfig{
+					EventBus: "memory",
+				},
+				Report: ReportConfig{
+					Timezone: "UTC",
 				},
 			},
 			wantErr: false,
@@ -99,9 +253,137 @@ func TestLoad(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "invalid jwt expiry hours",
+			name: "invalid jwt access expiry minutes",
 			envVars: map[string]string{
-				"JWT_EXPIRY_HOURS": "invalid",
+				"JWT_ACCESS_EXPIRY_MINUTES": "invalid",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid jwt revocation cache seconds",
+			envVars: map[string]string{
+				"JWT_REVOCATION_CACHE_SECONDS": "invalid",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid password reset expiry minutes",
+			envVars: map[string]string{
+				"PASSWORD_RESET_EXPIRY_MINUTES": "invalid",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid password reset max sends",
+			envVars: map[string]string{
+				"PASSWORD_RESET_MAX_SENDS": "invalid",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid email verification expiry hours",
+			envVars: map[string]string{
+				"EMAIL_VERIFICATION_EXPIRY_HOURS": "invalid",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid email verification resend seconds",
+			envVars: map[string]string{
+				"EMAIL_VERIFICATION_RESEND_SECONDS": "invalid",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid password min length",
+			envVars: map[string]string{
+				"PASSWORD_MIN_LENGTH": "invalid",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid password policy flag",
+			envVars: map[string]string{
+				"PASSWORD_REQUIRE_SYMBOL": "sometimes",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid login max failures",
+			envVars: map[string]string{
+				"LOGIN_MAX_FAILURES": "invalid",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid login lockout minutes",
+			envVars: map[string]string{
+				"LOGIN_LOCKOUT_MINUTES": "invalid",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid nfc enrollment expiry minutes",
+			envVars: map[string]string{
+				"NFC_ENROLLMENT_EXPIRY_MINUTES": "invalid",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid auto checkout enabled",
+			envVars: map[string]string{
+				"AUTO_CHECKOUT_ENABLED": "sometimes",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid device signature tolerance seconds",
+			envVars: map[string]string{
+				"DEVICE_SIGNATURE_TOLERANCE_SECONDS": "invalid",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "invalid jwt refresh expiry hours",
+			envVars: map[string]string{
+				"JWT_REFRESH_EXPIRY_HOURS": "invalid",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "zero jwt access expiry minutes",
+			envVars: map[string]string{
+				"JWT_ACCESS_EXPIRY_MINUTES": "0",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "negative jwt refresh expiry hours",
+			envVars: map[string]string{
+				"JWT_REFRESH_EXPIRY_HOURS": "-1",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "removed jwt expiry hours",
+			envVars: map[string]string{
+				"JWT_EXPIRY_HOURS": "24",
 			},
 			want:    nil,
 			wantErr: true,
@@ -128,6 +410,43 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestZitadelConfig_Enabled(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ZitadelConfig
+		want bool
+	}{
+		{
+			name: "not configured",
+			cfg:  ZitadelConfig{},
+			want: false,
+		},
+		{
+			name: "missing redirect url",
+			cfg: ZitadelConfig{
+				URL:      "https://zitadel.example.com",
+				ClientID: "client123",
+			},
+			want: false,
+		},
+		{
+			name: "configured",
+			cfg: ZitadelConfig{
+				URL:         "https://zitadel.example.com",
+				ClientID:    "client123",
+				RedirectURL: "https://api.example.com/api/v1/auth/oidc/callback",
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.cfg.Enabled())
+		})
+	}
+}
+
 func TestDatabaseConfig_DSN(t *testing.T) {
 	tests := []struct {
 		name string
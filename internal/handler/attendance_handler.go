@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type AttendanceHandler struct {
+	attendanceService service.AttendanceService
+}
+
+func NewAttendanceHandler(attendanceService service.AttendanceService) *AttendanceHandler {
+	return &AttendanceHandler{
+		attendanceService: attendanceService,
+	}
+}
+
+func respondAttendanceError(c echo.Context, err error) error {
+	if handled, respErr := respondValidationError(c, err); handled {
+		return respErr
+	}
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+	case errors.Is(err, service.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+	case errors.Is(err, service.ErrUnknownCard):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "unknown card"})
+	case errors.Is(err, service.ErrAlreadyCheckedIn), errors.Is(err, service.ErrNotCheckedIn), errors.Is(err, service.ErrCardAlreadyEnrolled),
+		errors.Is(err, service.ErrTapBatchConflict):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+type recordAttendanceFunc func(ctx context.Context, caller *auth.Identity, req *model.AttendanceRequest) (*model.AttendanceLog, error)
+
+// record は入室・退室で共通の処理
+func (h *AttendanceHandler) record(c echo.Context, recordFn recordAttendanceFunc) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	var req model.AttendanceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	log, err := recordFn(c.Request().Context(), identity, &req)
+	if err != nil {
+		return respondAttendanceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, log)
+}
+
+func (h *AttendanceHandler) CheckIn(c echo.Context) error {
+	return h.record(c, h.attendanceService.CheckIn)
+}
+
+func (h *AttendanceHandler) CheckOut(c echo.Context) error {
+	return h.record(c, h.attendanceService.CheckOut)
+}
+
+// Tap はカードリーダーからのタップを受け付け、キオスクに表示する名前とタップ後の状態を返す。
+// 端末の API キーと staff / admin のトークンのどちらでも呼び出せる
+func (h *AttendanceHandler) Tap(c echo.Context) error {
+	device, isDevice := currentDevice(c)
+	identity, ok := currentIdentity(c)
+	if !isDevice && !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	var req model.TapRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	var result *model.TapResult
+	var err error
+	if isDevice {
+		result, err = h.attendanceService.DeviceTap(c.Request().Context(), device, &req)
+	} else {
+		result, err = h.attendanceService.Tap(c.Request().Context(), identity, &req)
+	}
+	if err != nil {
+		return respondAttendanceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, result)
+}
+
+// TapBatch は端末がオフラインの間に保存したタップをまとめて受け付け、タップごとの結果を送られた順に返す
+func (h *AttendanceHandler) TapBatch(c echo.Context) error {
+	device, ok := currentDevice(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	var req model.TapBatchRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	result, err := h.attendanceService.DeviceTapBatch(c.Request().Context(), device, &req)
+	if err != nil {
+		return respondAttendanceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *AttendanceHandler) ListRejectedTaps(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit <= 0 {
+		limit = 10
+	}
+
+	offset, _ := strconv.Atoi(c.QueryParam("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	taps, err := h.attendanceService.ListRejectedTaps(c.Request().Context(), identity, limit, offset)
+	if err != nil {
+		return respondAttendanceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, taps)
+}
+
+// parseAttendanceFilter は一覧の絞り込み条件を読み取る。from と to は RFC 3339 で指定する
+func parseAttendanceFilter(c echo.Context) (model.AttendanceFilter, error) {
+	filter := model.AttendanceFilter{}
+
+	if raw := c.QueryParam("user_id"); raw != "" {
+		userID, err := uuid.Parse(raw)
+		if err != nil {
+			return filter, errors.New("invalid user_id")
+		}
+		filter.UserID = &userID
+	}
+
+	if raw := c.QueryParam("location_id"); raw != "" {
+		locationID, err := strconv.Atoi(raw)
+		if err != nil {
+			return filter, errors.New("invalid location_id")
+		}
+		filter.LocationID = &locationID
+	}
+
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		raw := c.QueryParam(param.name)
+		if raw == "" {
+			continue
+		}
+		value, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, errors.New("invalid " + param.name)
+		}
+		*param.dest = &value
+	}
+
+	filter.Limit, _ = strconv.Atoi(c.QueryParam("limit"))
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+
+	filter.Offset, _ = strconv.Atoi(c.QueryParam("offset"))
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return filter, nil
+}
+
+func (h *AttendanceHandler) ListLogs(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	filter, err := parseAttendanceFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	logs, err := h.attendanceService.ListLogs(c.Request().Context(), identity, filter)
+	if err != nil {
+		return respondAttendanceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, logs)
+}
+
+func (h *AttendanceHandler) ListSessions(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	filter, err := parseAttendanceFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	sessions, err := h.attendanceService.ListSessions(c.Request().Context(), identity, filter)
+	if err != nil {
+		return respondAttendanceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, sessions)
+}
+
+func (h *AttendanceHandler) RegisterRoutes(e *echo.Echo, authMiddleware, deviceMiddleware echo.MiddlewareFunc) {
+	api := e.Group("/api/v1")
+	staffOrAdmin := authmw.RequireRole(model.RoleStaff, model.RoleAdmin)
+
+	api.GET("/attendance", h.ListLogs, authMiddleware)
+	api.GET("/attendance/sessions", h.ListSessions, authMiddleware)
+	api.POST("/attendance/check-in", h.CheckIn, authMiddleware, staffOrAdmin)
+	api.POST("/attendance/check-out", h.CheckOut, authMiddleware, staffOrAdmin)
+	api.POST("/attendance/tap", h.Tap, authmw.DeviceOr(deviceMiddleware, authMiddleware, staffOrAdmin))
+	// コロンはパスパラメーターと区別するためにエスケープする
+	api.POST("/attendance/taps\\:batch", h.TapBatch, deviceMiddleware)
+	api.GET("/attendance/rejected-taps", h.ListRejectedTaps, authMiddleware, staffOrAdmin)
+}
@@ -0,0 +1,344 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAttendanceService struct {
+	mock.Mock
+}
+
+func (m *MockAttendanceService) CheckIn(ctx context.Context, caller *auth.Identity, req *model.AttendanceRequest) (*model.AttendanceLog, error) {
+	args := m.Called(ctx, caller, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AttendanceLog), args.Error(1)
+}
+
+func (m *MockAttendanceService) CheckOut(ctx context.Context, caller *auth.Identity, req *model.AttendanceRequest) (*model.AttendanceLog, error) {
+	args := m.Called(ctx, caller, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AttendanceLog), args.Error(1)
+}
+
+func (m *MockAttendanceService) ListLogs(ctx context.Context, caller *auth.Identity, filter model.AttendanceFilter) ([]*model.AttendanceLog, error) {
+	args := m.Called(ctx, caller, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.AttendanceLog), args.Error(1)
+}
+
+func (m *MockAttendanceService) Tap(ctx context.Context, caller *auth.Identity, req *model.TapRequest) (*model.TapResult, error) {
+	args := m.Called(ctx, caller, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TapResult), args.Error(1)
+}
+
+func (m *MockAttendanceService) DeviceTap(ctx context.Context, device *auth.Device, req *model.TapRequest) (*model.TapResult, error) {
+	args := m.Called(ctx, device, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TapResult), args.Error(1)
+}
+
+func (m *MockAttendanceService) DeviceTapBatch(ctx context.Context, device *auth.Device, req *model.TapBatchRequest) (*model.TapBatchResult, error) {
+	args := m.Called(ctx, device, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TapBatchResult), args.Error(1)
+}
+
+func (m *MockAttendanceService) ListSessions(ctx context.Context, caller *auth.Identity, filter model.AttendanceFilter) ([]*model.AttendanceSession, error) {
+	args := m.Called(ctx, caller, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.AttendanceSession), args.Error(1)
+}
+
+func (m *MockAttendanceService) ListRejectedTaps(ctx context.Context, caller *auth.Identity, limit, offset int) ([]*model.RejectedTap, error) {
+	args := m.Called(ctx, caller, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.RejectedTap), args.Error(1)
+}
+
+func serveAttendanceRoutes(mockService *MockAttendanceService, identity *auth.Identity, method, path, body string) *httptest.ResponseRecorder {
+	return serveAttendanceRoutesAs(mockService, identity, nil, method, path, body)
+}
+
+// serveAttendanceRoutesAs は device を指定すると端末の API キー付きのリクエストとして送る
+func serveAttendanceRoutesAs(mockService *MockAttendanceService, identity *auth.Identity, device *auth.Device, method, path, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	authMiddleware := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			withIdentity(c, identity)
+			return next(c)
+		}
+	}
+	deviceMiddleware := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.SetRequest(c.Request().WithContext(auth.WithDevice(c.Request().Context(), device)))
+			return next(c)
+		}
+	}
+	NewAttendanceHandler(mockService).RegisterRoutes(e, authMiddleware, deviceMiddleware)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	if device != nil {
+		req.Header.Set(authmw.HeaderDeviceKey, "tld_key")
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAttendanceHandler_CheckIn(t *testing.T) {
+	mockService := new(MockAttendanceService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	expected := &model.AttendanceLog{ID: 1, UserID: identity.UserID, LocationID: 2, Type: model.AttendanceCheckIn, RecordedAt: time.Now()}
+	mockService.On("CheckIn", mock.Anything, identity, &model.AttendanceRequest{LocationID: 2}).Return(expected, nil)
+
+	rec := serveAttendanceRoutes(mockService, identity, http.MethodPost, "/api/v1/attendance/check-in", `{"location_id":2}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var body model.AttendanceLog
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, model.AttendanceCheckIn, body.Type)
+	mockService.AssertExpectations(t)
+}
+
+func TestAttendanceHandler_Record_MemberForbidden(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+
+	for _, path := range []string{"/api/v1/attendance/check-in", "/api/v1/attendance/check-out"} {
+		mockService := new(MockAttendanceService)
+
+		rec := serveAttendanceRoutes(mockService, identity, http.MethodPost, path, `{"location_id":2}`)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code, path)
+		mockService.AssertNotCalled(t, "CheckIn", mock.Anything, mock.Anything, mock.Anything)
+		mockService.AssertNotCalled(t, "CheckOut", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func TestAttendanceHandler_Record_Errors(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	invalid := &service.ValidationError{}
+	invalid.Add("location_id", "is archived")
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		err        error
+		wantStatus int
+	}{
+		{name: "duplicate check-in", method: "CheckIn", path: "/api/v1/attendance/check-in", err: service.ErrAlreadyCheckedIn, wantStatus: http.StatusConflict},
+		{name: "check-out without check-in", method: "CheckOut", path: "/api/v1/attendance/check-out", err: service.ErrNotCheckedIn, wantStatus: http.StatusConflict},
+		{name: "archived location", method: "CheckIn", path: "/api/v1/attendance/check-in", err: invalid, wantStatus: http.StatusBadRequest},
+		{name: "unknown user", method: "CheckIn", path: "/api/v1/attendance/check-in", err: service.ErrUserNotFound, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAttendanceService)
+			mockService.On(tt.method, mock.Anything, identity, mock.Anything).Return(nil, tt.err)
+
+			rec := serveAttendanceRoutes(mockService, identity, http.MethodPost, tt.path, `{"location_id":2}`)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestAttendanceHandler_ListLogs(t *testing.T) {
+	mockService := new(MockAttendanceService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	userID := uuid.New()
+	locationID := 2
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	filter := model.AttendanceFilter{UserID: &userID, LocationID: &locationID, From: &from, Limit: 50}
+	mockService.On("ListLogs", mock.Anything, identity, filter).Return([]*model.AttendanceLog{{ID: 1}}, nil)
+
+	rec := serveAttendanceRoutes(mockService, identity, http.MethodGet,
+		"/api/v1/attendance?user_id="+userID.String()+"&location_id=2&from=2026-10-01T00:00:00Z&limit=50", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAttendanceHandler_ListSessions(t *testing.T) {
+	mockService := new(MockAttendanceService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)
+	duration := int64(3600)
+	sessions := []*model.AttendanceSession{{UserID: identity.UserID, LocationID: 2, DurationSeconds: &duration}}
+	mockService.On("ListSessions", mock.Anything, identity, model.AttendanceFilter{From: &from, To: &to, Limit: 10}).Return(sessions, nil)
+
+	rec := serveAttendanceRoutes(mockService, identity, http.MethodGet,
+		"/api/v1/attendance/sessions?from=2026-10-01T00:00:00Z&to=2026-10-02T00:00:00Z", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"duration_seconds":3600`)
+	mockService.AssertExpectations(t)
+}
+
+func TestAttendanceHandler_ListSessions_Forbidden(t *testing.T) {
+	mockService := new(MockAttendanceService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	mockService.On("ListSessions", mock.Anything, identity, mock.Anything).Return(nil, service.ErrForbidden)
+
+	rec := serveAttendanceRoutes(mockService, identity, http.MethodGet, "/api/v1/attendance/sessions?user_id="+uuid.NewString(), "")
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestAttendanceHandler_ListLogs_InvalidFilter(t *testing.T) {
+	for _, query := range []string{"user_id=abc", "location_id=abc", "from=yesterday", "to=2026-10-01"} {
+		t.Run(query, func(t *testing.T) {
+			mockService := new(MockAttendanceService)
+			identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+
+			rec := serveAttendanceRoutes(mockService, identity, http.MethodGet, "/api/v1/attendance?"+query, "")
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Empty(t, mockService.Calls)
+		})
+	}
+}
+
+func TestAttendanceHandler_Tap(t *testing.T) {
+	mockService := new(MockAttendanceService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	result := &model.TapResult{
+		UserID:    uuid.New(),
+		DisplayID: "taro",
+		Name:      "山田太郎",
+		CheckedIn: true,
+		Log:       &model.AttendanceLog{ID: 1, Type: model.AttendanceCheckIn},
+	}
+	mockService.On("Tap", mock.Anything, identity, &model.TapRequest{NFCSerial: "04A3B2C1", LocationID: 2}).Return(result, nil)
+
+	rec := serveAttendanceRoutes(mockService, identity, http.MethodPost, "/api/v1/attendance/tap", `{"nfc_serial":"04A3B2C1","location_id":2}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var body model.TapResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "山田太郎", body.Name)
+	assert.True(t, body.CheckedIn)
+}
+
+func TestAttendanceHandler_Tap_UnknownCard(t *testing.T) {
+	mockService := new(MockAttendanceService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	mockService.On("Tap", mock.Anything, identity, mock.Anything).Return(nil, service.ErrUnknownCard)
+
+	rec := serveAttendanceRoutes(mockService, identity, http.MethodPost, "/api/v1/attendance/tap", `{"nfc_serial":"FFFF","location_id":2}`)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "unknown card")
+}
+
+func TestAttendanceHandler_Tap_RequiresStaff(t *testing.T) {
+	mockService := new(MockAttendanceService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+
+	rec := serveAttendanceRoutes(mockService, identity, http.MethodPost, "/api/v1/attendance/tap", `{"nfc_serial":"04A3B2C1","location_id":2}`)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Empty(t, mockService.Calls)
+}
+
+func TestAttendanceHandler_Tap_Device(t *testing.T) {
+	mockService := new(MockAttendanceService)
+	device := &auth.Device{DeviceID: uuid.New(), LocationID: 2}
+	result := &model.TapResult{UserID: uuid.New(), DisplayID: "taro", Name: "Taro", CheckedIn: true}
+	mockService.On("DeviceTap", mock.Anything, device, &model.TapRequest{NFCSerial: "04A3B2C1"}).Return(result, nil)
+
+	rec := serveAttendanceRoutesAs(mockService, nil, device, http.MethodPost, "/api/v1/attendance/tap", `{"nfc_serial":"04A3B2C1"}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "Tap", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAttendanceHandler_TapBatch(t *testing.T) {
+	mockService := new(MockAttendanceService)
+	device := &auth.Device{DeviceID: uuid.New(), LocationID: 2}
+	tappedAt := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	req := &model.TapBatchRequest{Taps: []model.BatchTap{{ClientTapID: "tap-1", NFCSerial: "04A3B2C1", TappedAt: tappedAt}}}
+	result := &model.TapBatchResult{Results: []*model.BatchTapResult{{ClientTapID: "tap-1", Status: model.BatchTapDuplicate}}}
+	mockService.On("DeviceTapBatch", mock.Anything, device, req).Return(result, nil)
+
+	rec := serveAttendanceRoutesAs(mockService, nil, device, http.MethodPost, "/api/v1/attendance/taps:batch",
+		`{"taps":[{"client_tap_id":"tap-1","nfc_serial":"04A3B2C1","tapped_at":"2026-10-19T09:00:00Z"}]}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"duplicate"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestAttendanceHandler_TapBatch_Errors(t *testing.T) {
+	device := &auth.Device{DeviceID: uuid.New(), LocationID: 2}
+
+	t.Run("conflict", func(t *testing.T) {
+		mockService := new(MockAttendanceService)
+		mockService.On("DeviceTapBatch", mock.Anything, device, mock.Anything).Return(nil, service.ErrTapBatchConflict)
+
+		rec := serveAttendanceRoutesAs(mockService, nil, device, http.MethodPost, "/api/v1/attendance/taps:batch", `{"taps":[]}`)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("requires device", func(t *testing.T) {
+		mockService := new(MockAttendanceService)
+		identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+
+		rec := serveAttendanceRoutes(mockService, identity, http.MethodPost, "/api/v1/attendance/taps:batch", `{"taps":[]}`)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, mockService.Calls)
+	})
+}
+
+func TestAttendanceHandler_ListRejectedTaps(t *testing.T) {
+	mockService := new(MockAttendanceService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	taps := []*model.RejectedTap{{ID: 1, NFCSerial: "FFFF", LocationID: 2, Reason: model.RejectedTapUnknownCard}}
+	mockService.On("ListRejectedTaps", mock.Anything, identity, 20, 0).Return(taps, nil)
+
+	rec := serveAttendanceRoutes(mockService, identity, http.MethodGet, "/api/v1/attendance/rejected-taps?limit=20", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "unknown_card")
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type AuthHandler struct {
+	authService service.AuthService
+}
+
+func NewAuthHandler(authService service.AuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+func (h *AuthHandler) Login(c echo.Context) error {
+	var req model.LoginRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	response, err := h.authService.Login(c.Request().Context(), &req, c.RealIP())
+	if err != nil {
+		if handled, respErr := respondLocked(c, err, "too many failed login attempts"); handled {
+			return respErr
+		}
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid credentials"})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *AuthHandler) Refresh(c echo.Context) error {
+	var req model.RefreshTokenRequest
+	if err := c.Bind(&req); err != nil || req.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	response, err := h.authService.Refresh(c.Request().Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRefreshTokenReused):
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "refresh token reuse detected"})
+		case errors.Is(err, service.ErrInvalidRefreshToken):
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid refresh token"})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *AuthHandler) Logout(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	var req model.LogoutRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+	}
+
+	if err := h.authService.Logout(c.Request().Context(), identity, &req); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *AuthHandler) RevokeUserSessions(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+
+	if identity.UserID != id && !identity.IsAdmin() {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+	}
+
+	if err := h.authService.RevokeAllSessions(c.Request().Context(), id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *AuthHandler) UnlockAccount(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+
+	if err := h.authService.UnlockAccount(c.Request().Context(), id); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *AuthHandler) RegisterRoutes(e *echo.Echo, authMiddleware echo.MiddlewareFunc) {
+	api := e.Group("/api/v1")
+
+	api.POST("/auth/login", h.Login)
+	api.POST("/auth/refresh", h.Refresh)
+	api.POST("/auth/logout", h.Logout, authMiddleware)
+	api.DELETE("/users/:id/sessions", h.RevokeUserSessions, authMiddleware)
+	api.DELETE("/users/:id/lockout", h.UnlockAccount, authMiddleware, authmw.RequireRole(model.RoleAdmin))
+}
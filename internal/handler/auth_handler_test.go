@@ -0,0 +1,429 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAuthService struct {
+	mock.Mock
+}
+
+func (m *MockAuthService) Login(ctx context.Context, req *model.LoginRequest, clientIP string) (*model.LoginResponse, error) {
+	args := m.Called(ctx, req, clientIP)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.LoginResponse), args.Error(1)
+}
+
+func (m *MockAuthService) Refresh(ctx context.Context, req *model.RefreshTokenRequest) (*model.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.LoginResponse), args.Error(1)
+}
+
+func (m *MockAuthService) Logout(ctx context.Context, identity *auth.Identity, req *model.LogoutRequest) error {
+	args := m.Called(ctx, identity, req)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ValidateAccessToken(ctx context.Context, token string) (*auth.Identity, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.Identity), args.Error(1)
+}
+
+func (m *MockAuthService) IssueSession(ctx context.Context, user *model.User) (*model.LoginResponse, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.LoginResponse), args.Error(1)
+}
+
+func (m *MockAuthService) CheckRevocation(ctx context.Context, identity *auth.Identity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) UnlockAccount(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func withIdentity(c echo.Context, identity *auth.Identity) {
+	req := c.Request()
+	c.SetRequest(req.WithContext(auth.WithIdentity(req.Context(), identity)))
+}
+
+func TestAuthHandler_Login(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	e := echo.New()
+	reqBody := `{"email":"test@example.com","password":"password123"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	expectedResponse := &model.LoginResponse{
+		Token:        "test-token",
+		RefreshToken: "test-refresh-token",
+		ExpiresIn:    900,
+		User: &model.User{
+			ID:        uuid.New(),
+			Email:     "test@example.com",
+			Name:      "Test User",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+	}
+
+	mockService.On("Login", mock.Anything, mock.AnythingOfType("*model.LoginRequest"), mock.Anything).Return(expectedResponse, nil)
+
+	err := handler.Login(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response model.LoginResponse
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, expectedResponse.Token, response.Token)
+	assert.Equal(t, expectedResponse.RefreshToken, response.RefreshToken)
+	assert.Equal(t, expectedResponse.User.Email, response.User.Email)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_Login_InvalidCredentials(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	e := echo.New()
+	reqBody := `{"email":"test@example.com","password":"wrongpassword"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	mockService.On("Login", mock.Anything, mock.AnythingOfType("*model.LoginRequest"), mock.Anything).Return(nil, fmt.Errorf("invalid credentials"))
+
+	err := handler.Login(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_Login_Locked(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	e := echo.New()
+	reqBody := `{"email":"test@example.com","password":"wrongpassword"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.RemoteAddr = "192.0.2.10:54321"
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	mockService.On("Login", mock.Anything, mock.AnythingOfType("*model.LoginRequest"), "192.0.2.10").
+		Return(nil, &lockout.LockedError{RetryAfter: 1500 * time.Millisecond})
+
+	err := handler.Login(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_Refresh(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	e := echo.New()
+	reqBody := `{"refresh_token":"old-refresh-token"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	expectedResponse := &model.LoginResponse{
+		Token:        "new-token",
+		RefreshToken: "new-refresh-token",
+		ExpiresIn:    900,
+	}
+
+	mockService.On("Refresh", mock.Anything, &model.RefreshTokenRequest{RefreshToken: "old-refresh-token"}).Return(expectedResponse, nil)
+
+	err := handler.Refresh(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response model.LoginResponse
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, "new-refresh-token", response.RefreshToken)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_Refresh_MissingToken(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", strings.NewReader(`{}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := handler.Refresh(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestAuthHandler_Refresh_Reused(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	e := echo.New()
+	reqBody := `{"refresh_token":"replayed"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	mockService.On("Refresh", mock.Anything, mock.AnythingOfType("*model.RefreshTokenRequest")).Return(nil, service.ErrRefreshTokenReused)
+
+	err := handler.Refresh(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "reuse")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	e := echo.New()
+	reqBody := `{"refresh_token":"refresh-token"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	identity := &auth.Identity{UserID: uuid.New(), TokenID: uuid.NewString()}
+	withIdentity(c, identity)
+
+	mockService.On("Logout", mock.Anything, identity, &model.LogoutRequest{RefreshToken: "refresh-token"}).Return(nil)
+
+	err := handler.Logout(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_Logout_WithoutBody(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	identity := &auth.Identity{UserID: uuid.New(), TokenID: uuid.NewString()}
+	withIdentity(c, identity)
+
+	mockService.On("Logout", mock.Anything, identity, &model.LogoutRequest{}).Return(nil)
+
+	err := handler.Logout(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_RevokeUserSessions_Self(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	e := echo.New()
+	userID := uuid.New()
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+userID.String()+"/sessions", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(userID.String())
+	withIdentity(c, &auth.Identity{UserID: userID})
+
+	mockService.On("RevokeAllSessions", mock.Anything, userID).Return(nil)
+
+	err := handler.RevokeUserSessions(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_RevokeUserSessions_OtherUser(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	e := echo.New()
+	userID := uuid.New()
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+userID.String()+"/sessions", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(userID.String())
+	withIdentity(c, &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff})
+
+	err := handler.RevokeUserSessions(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	mockService.AssertNotCalled(t, "RevokeAllSessions", mock.Anything, mock.Anything)
+}
+
+func TestAuthHandler_RevokeUserSessions_Admin(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	e := echo.New()
+	userID := uuid.New()
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+userID.String()+"/sessions", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(userID.String())
+	withIdentity(c, &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin})
+
+	mockService.On("RevokeAllSessions", mock.Anything, userID).Return(nil)
+
+	err := handler.RevokeUserSessions(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_UnlockAccount(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	e := echo.New()
+	userID := uuid.New()
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+userID.String()+"/lockout", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(userID.String())
+
+	mockService.On("UnlockAccount", mock.Anything, userID).Return(nil)
+
+	err := handler.UnlockAccount(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthHandler_UnlockAccount_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "user not found", err: service.ErrUserNotFound, wantStatus: http.StatusNotFound},
+		{name: "internal error", err: errors.New("connection refused"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAuthService)
+			handler := NewAuthHandler(mockService)
+
+			e := echo.New()
+			userID := uuid.New()
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+userID.String()+"/lockout", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(userID.String())
+
+			mockService.On("UnlockAccount", mock.Anything, userID).Return(tt.err)
+
+			err := handler.UnlockAccount(c)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_UnlockAccount_RequiresAdmin(t *testing.T) {
+	mockService := new(MockAuthService)
+	handler := NewAuthHandler(mockService)
+
+	e := echo.New()
+	handler.RegisterRoutes(e, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			withIdentity(c, &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff})
+			return next(c)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+uuid.NewString()+"/lockout", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockService.AssertNotCalled(t, "UnlockAccount", mock.Anything, mock.Anything)
+}
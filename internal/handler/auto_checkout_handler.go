@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type AutoCheckoutHandler struct {
+	autoCheckoutService service.AutoCheckoutService
+}
+
+func NewAutoCheckoutHandler(autoCheckoutService service.AutoCheckoutService) *AutoCheckoutHandler {
+	return &AutoCheckoutHandler{
+		autoCheckoutService: autoCheckoutService,
+	}
+}
+
+// Trigger は締め時刻を待たずに自動退室を実行する
+func (h *AutoCheckoutHandler) Trigger(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	result, err := h.autoCheckoutService.Trigger(c.Request().Context(), identity)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrForbidden):
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		case errors.Is(err, service.ErrAutoCheckoutRunning):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *AutoCheckoutHandler) RegisterRoutes(e *echo.Echo, authMiddleware echo.MiddlewareFunc) {
+	api := e.Group("/api/v1")
+
+	api.POST("/attendance/auto-checkout", h.Trigger, authMiddleware, authmw.RequireRole(model.RoleAdmin))
+}
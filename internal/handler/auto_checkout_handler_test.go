@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAutoCheckoutService struct {
+	mock.Mock
+}
+
+func (m *MockAutoCheckoutService) Run(ctx context.Context) (*model.AutoCheckoutResult, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AutoCheckoutResult), args.Error(1)
+}
+
+func (m *MockAutoCheckoutService) Trigger(ctx context.Context, caller *auth.Identity) (*model.AutoCheckoutResult, error) {
+	args := m.Called(ctx, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AutoCheckoutResult), args.Error(1)
+}
+
+func (m *MockAutoCheckoutService) Start(ctx context.Context) {
+	m.Called(ctx)
+}
+
+func serveAutoCheckoutRoutes(mockService *MockAutoCheckoutService, identity *auth.Identity) *httptest.ResponseRecorder {
+	e := echo.New()
+	authMiddleware := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			withIdentity(c, identity)
+			return next(c)
+		}
+	}
+	NewAutoCheckoutHandler(mockService).RegisterRoutes(e, authMiddleware)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/attendance/auto-checkout", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAutoCheckoutHandler_Trigger(t *testing.T) {
+	admin := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+
+	tests := []struct {
+		name       string
+		identity   *auth.Identity
+		result     *model.AutoCheckoutResult
+		err        error
+		wantStatus int
+	}{
+		{name: "closes open check-ins", identity: admin, result: &model.AutoCheckoutResult{Closed: 1, Logs: []*model.AttendanceLog{{ID: 1, Auto: true}}}, wantStatus: http.StatusOK},
+		{name: "already running", identity: admin, err: service.ErrAutoCheckoutRunning, wantStatus: http.StatusConflict},
+		{name: "requires admin", identity: &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAutoCheckoutService)
+			if tt.identity.IsAdmin() {
+				mockService.On("Trigger", mock.Anything, tt.identity).Return(tt.result, tt.err)
+			}
+
+			rec := serveAutoCheckoutRoutes(mockService, tt.identity)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type CorrectionHandler struct {
+	correctionService service.CorrectionService
+}
+
+func NewCorrectionHandler(correctionService service.CorrectionService) *CorrectionHandler {
+	return &CorrectionHandler{
+		correctionService: correctionService,
+	}
+}
+
+func respondCorrectionError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrCorrectionNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "attendance correction not found"})
+	case errors.Is(err, service.ErrSelfReview):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrCorrectionReviewed):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		return respondAttendanceError(c, err)
+	}
+}
+
+func (h *CorrectionHandler) Submit(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	var req model.CorrectionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	correction, err := h.correctionService.Submit(c.Request().Context(), identity, &req)
+	if err != nil {
+		return respondCorrectionError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, correction)
+}
+
+func (h *CorrectionHandler) List(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	filter := model.CorrectionFilter{}
+	if raw := c.QueryParam("user_id"); raw != "" {
+		userID, err := uuid.Parse(raw)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user_id"})
+		}
+		filter.UserID = &userID
+	}
+	if raw := c.QueryParam("status"); raw != "" {
+		status := model.CorrectionStatus(raw)
+		filter.Status = &status
+	}
+
+	filter.Limit, _ = strconv.Atoi(c.QueryParam("limit"))
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+
+	filter.Offset, _ = strconv.Atoi(c.QueryParam("offset"))
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	corrections, err := h.correctionService.List(c.Request().Context(), identity, filter)
+	if err != nil {
+		return respondCorrectionError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, corrections)
+}
+
+func (h *CorrectionHandler) Get(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+
+	correction, err := h.correctionService.Get(c.Request().Context(), identity, id)
+	if err != nil {
+		return respondCorrectionError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, correction)
+}
+
+type reviewCorrectionFunc func(ctx context.Context, caller *auth.Identity, id int64, req *model.CorrectionReviewRequest) (*model.AttendanceCorrection, error)
+
+// review は承認・却下で共通の処理。コメントは省略できる
+func (h *CorrectionHandler) review(c echo.Context, reviewFn reviewCorrectionFunc) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+
+	var req model.CorrectionReviewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	correction, err := reviewFn(c.Request().Context(), identity, id, &req)
+	if err != nil {
+		return respondCorrectionError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, correction)
+}
+
+func (h *CorrectionHandler) Approve(c echo.Context) error {
+	return h.review(c, h.correctionService.Approve)
+}
+
+func (h *CorrectionHandler) Reject(c echo.Context) error {
+	return h.review(c, h.correctionService.Reject)
+}
+
+func (h *CorrectionHandler) RegisterRoutes(e *echo.Echo, authMiddleware echo.MiddlewareFunc) {
+	api := e.Group("/api/v1")
+	staffOrAdmin := authmw.RequireRole(model.RoleStaff, model.RoleAdmin)
+
+	api.GET("/attendance/corrections", h.List, authMiddleware)
+	api.POST("/attendance/corrections", h.Submit, authMiddleware)
+	api.GET("/attendance/corrections/:id", h.Get, authMiddleware)
+	api.POST("/attendance/corrections/:id/approve", h.Approve, authMiddleware, staffOrAdmin)
+	api.POST("/attendance/corrections/:id/reject", h.Reject, authMiddleware, staffOrAdmin)
+}
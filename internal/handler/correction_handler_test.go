@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockCorrectionService struct {
+	mock.Mock
+}
+
+func (m *MockCorrectionService) Submit(ctx context.Context, caller *auth.Identity, req *model.CorrectionRequest) (*model.AttendanceCorrection, error) {
+	args := m.Called(ctx, caller, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AttendanceCorrection), args.Error(1)
+}
+
+func (m *MockCorrectionService) List(ctx context.Context, caller *auth.Identity, filter model.CorrectionFilter) ([]*model.AttendanceCorrection, error) {
+	args := m.Called(ctx, caller, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.AttendanceCorrection), args.Error(1)
+}
+
+func (m *MockCorrectionService) Get(ctx context.Context, caller *auth.Identity, id int64) (*model.AttendanceCorrection, error) {
+	args := m.Called(ctx, caller, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AttendanceCorrection), args.Error(1)
+}
+
+func (m *MockCorrectionService) Approve(ctx context.Context, caller *auth.Identity, id int64, req *model.CorrectionReviewRequest) (*model.AttendanceCorrection, error) {
+	args := m.Called(ctx, caller, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AttendanceCorrection), args.Error(1)
+}
+
+func (m *MockCorrectionService) Reject(ctx context.Context, caller *auth.Identity, id int64, req *model.CorrectionReviewRequest) (*model.AttendanceCorrection, error) {
+	args := m.Called(ctx, caller, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AttendanceCorrection), args.Error(1)
+}
+
+func serveCorrectionRoutes(mockService *MockCorrectionService, identity *auth.Identity, method, path, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	authMiddleware := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			withIdentity(c, identity)
+			return next(c)
+		}
+	}
+	NewCorrectionHandler(mockService).RegisterRoutes(e, authMiddleware)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCorrectionHandler_Submit(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	recordedAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	mockService := new(MockCorrectionService)
+	mockService.On("Submit", mock.Anything, identity, mock.MatchedBy(func(req *model.CorrectionRequest) bool {
+		return req.LocationID == 2 && req.Type == model.AttendanceCheckIn && req.RecordedAt.Equal(recordedAt) && req.Reason == "カードを忘れた"
+	})).Return(&model.AttendanceCorrection{ID: 7, UserID: identity.UserID, Status: model.CorrectionPending}, nil)
+
+	rec := serveCorrectionRoutes(mockService, identity, http.MethodPost, "/api/v1/attendance/corrections",
+		`{"location_id":2,"type":"check-in","recorded_at":"2026-10-01T09:00:00Z","reason":"カードを忘れた"}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var body model.AttendanceCorrection
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, int64(7), body.ID)
+	assert.Equal(t, model.CorrectionPending, body.Status)
+	mockService.AssertExpectations(t)
+}
+
+func TestCorrectionHandler_List(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	status := model.CorrectionPending
+
+	mockService := new(MockCorrectionService)
+	mockService.On("List", mock.Anything, identity, model.CorrectionFilter{Status: &status, Limit: 20}).Return([]*model.AttendanceCorrection{{ID: 7}}, nil)
+
+	rec := serveCorrectionRoutes(mockService, identity, http.MethodGet, "/api/v1/attendance/corrections?status=pending&limit=20", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestCorrectionHandler_Approve(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	logID := int64(42)
+
+	mockService := new(MockCorrectionService)
+	mockService.On("Approve", mock.Anything, identity, int64(7), &model.CorrectionReviewRequest{Comment: "確認済み"}).
+		Return(&model.AttendanceCorrection{ID: 7, Status: model.CorrectionApproved, ReviewedBy: &identity.UserID, AttendanceLogID: &logID}, nil)
+
+	rec := serveCorrectionRoutes(mockService, identity, http.MethodPost, "/api/v1/attendance/corrections/7/approve", `{"comment":"確認済み"}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body model.AttendanceCorrection
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, model.CorrectionApproved, body.Status)
+	assert.Equal(t, &logID, body.AttendanceLogID)
+	mockService.AssertExpectations(t)
+}
+
+func TestCorrectionHandler_Reject_WithoutBody(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+
+	mockService := new(MockCorrectionService)
+	mockService.On("Reject", mock.Anything, identity, int64(7), &model.CorrectionReviewRequest{}).
+		Return(&model.AttendanceCorrection{ID: 7, Status: model.CorrectionRejected}, nil)
+
+	rec := serveCorrectionRoutes(mockService, identity, http.MethodPost, "/api/v1/attendance/corrections/7/reject", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestCorrectionHandler_Errors(t *testing.T) {
+	staff := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	member := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+
+	tests := []struct {
+		name       string
+		identity   *auth.Identity
+		method     string
+		path       string
+		expect     func(m *MockCorrectionService)
+		wantStatus int
+	}{
+		{name: "member cannot approve", identity: member, method: http.MethodPost, path: "/api/v1/attendance/corrections/7/approve", wantStatus: http.StatusForbidden},
+		{name: "invalid id", identity: staff, method: http.MethodGet, path: "/api/v1/attendance/corrections/abc", wantStatus: http.StatusBadRequest},
+		{name: "invalid user_id", identity: staff, method: http.MethodGet, path: "/api/v1/attendance/corrections?user_id=abc", wantStatus: http.StatusBadRequest},
+		{
+			name: "not found", identity: staff, method: http.MethodGet, path: "/api/v1/attendance/corrections/8",
+			expect: func(m *MockCorrectionService) {
+				m.On("Get", mock.Anything, staff, int64(8)).Return(nil, service.ErrCorrectionNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "already reviewed", identity: staff, method: http.MethodPost, path: "/api/v1/attendance/corrections/7/reject",
+			expect: func(m *MockCorrectionService) {
+				m.On("Reject", mock.Anything, staff, int64(7), mock.Anything).Return(nil, service.ErrCorrectionReviewed)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "own request", identity: staff, method: http.MethodPost, path: "/api/v1/attendance/corrections/7/approve",
+			expect: func(m *MockCorrectionService) {
+				m.On("Approve", mock.Anything, staff, int64(7), mock.Anything).Return(nil, service.ErrSelfReview)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "conflicts with attendance", identity: staff, method: http.MethodPost, path: "/api/v1/attendance/corrections/7/approve",
+			expect: func(m *MockCorrectionService) {
+				m.On("Approve", mock.Anything, staff, int64(7), mock.Anything).Return(nil, service.ErrAlreadyCheckedIn)
+			},
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockCorrectionService)
+			if tt.expect != nil {
+				tt.expect(mockService)
+			}
+
+			rec := serveCorrectionRoutes(mockService, tt.identity, tt.method, tt.path, "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type DeviceHandler struct {
+	deviceService service.DeviceService
+}
+
+func NewDeviceHandler(deviceService service.DeviceService) *DeviceHandler {
+	return &DeviceHandler{
+		deviceService: deviceService,
+	}
+}
+
+func respondDeviceError(c echo.Context, err error) error {
+	if handled, respErr := respondValidationError(c, err); handled {
+		return respErr
+	}
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+	case errors.Is(err, service.ErrDeviceNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "device not found"})
+	case errors.Is(err, service.ErrDeviceRevoked):
+		return c.JSON(http.StatusConflict, map[string]string{"error": "device revoked"})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+// Register は端末を登録する。API キーはこのレスポンスでのみ返す
+func (h *DeviceHandler) Register(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	var req model.DeviceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	credentials, err := h.deviceService.RegisterDevice(c.Request().Context(), identity, &req)
+	if err != nil {
+		return respondDeviceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, credentials)
+}
+
+func (h *DeviceHandler) List(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	var locationID *int
+	if raw := c.QueryParam("location_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid location_id"})
+		}
+		locationID = &id
+	}
+
+	devices, err := h.deviceService.ListDevices(c.Request().Context(), identity, locationID)
+	if err != nil {
+		return respondDeviceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, devices)
+}
+
+func (h *DeviceHandler) Get(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+
+	device, err := h.deviceService.GetDevice(c.Request().Context(), identity, id)
+	if err != nil {
+		return respondDeviceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, device)
+}
+
+func (h *DeviceHandler) Update(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+
+	var req model.DeviceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	device, err := h.deviceService.UpdateDevice(c.Request().Context(), identity, id, &req)
+	if err != nil {
+		return respondDeviceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, device)
+}
+
+func (h *DeviceHandler) RotateKey(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+
+	credentials, err := h.deviceService.RotateKey(c.Request().Context(), identity, id)
+	if err != nil {
+		return respondDeviceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, credentials)
+}
+
+func (h *DeviceHandler) Revoke(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+
+	device, err := h.deviceService.RevokeDevice(c.Request().Context(), identity, id)
+	if err != nil {
+		return respondDeviceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, device)
+}
+
+// Me は端末が自身の登録内容（設置場所など）を確認するためのエンドポイント
+func (h *DeviceHandler) Me(c echo.Context) error {
+	current, ok := currentDevice(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	device, err := h.deviceService.CurrentDevice(c.Request().Context(), current)
+	if err != nil {
+		return respondDeviceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, device)
+}
+
+func (h *DeviceHandler) RegisterRoutes(e *echo.Echo, authMiddleware, deviceMiddleware echo.MiddlewareFunc) {
+	api := e.Group("/api/v1")
+	adminOnly := authmw.RequireRole(model.RoleAdmin)
+
+	api.GET("/devices/me", h.Me, deviceMiddleware)
+	api.POST("/devices", h.Register, authMiddleware, adminOnly)
+	api.GET("/devices", h.List, authMiddleware, adminOnly)
+	api.GET("/devices/:id", h.Get, authMiddleware, adminOnly)
+	api.PUT("/devices/:id", h.Update, authMiddleware, adminOnly)
+	api.POST("/devices/:id/rotate-key", h.RotateKey, authMiddleware, adminOnly)
+	api.POST("/devices/:id/revoke", h.Revoke, authMiddleware, adminOnly)
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/signature"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockDeviceService struct {
+	mock.Mock
+}
+
+func (m *MockDeviceService) AuthenticateDevice(ctx context.Context, apiKey string) (*auth.Device, error) {
+	args := m.Called(ctx, apiKey)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.Device), args.Error(1)
+}
+
+func (m *MockDeviceService) VerifyDeviceRequest(ctx context.Context, device *auth.Device, req *signature.Request, sig string) error {
+	args := m.Called(ctx, device, req, sig)
+	return args.Error(0)
+}
+
+func (m *MockDeviceService) RegisterDevice(ctx context.Context, caller *auth.Identity, req *model.DeviceRequest) (*model.DeviceCredentials, error) {
+	args := m.Called(ctx, caller, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DeviceCredentials), args.Error(1)
+}
+
+func (m *MockDeviceService) ListDevices(ctx context.Context, caller *auth.Identity, locationID *int) ([]*model.Device, error) {
+	args := m.Called(ctx, caller, locationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Device), args.Error(1)
+}
+
+func (m *MockDeviceService) GetDevice(ctx context.Context, caller *auth.Identity, id uuid.UUID) (*model.Device, error) {
+	args := m.Called(ctx, caller, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Device), args.Error(1)
+}
+
+func (m *MockDeviceService) UpdateDevice(ctx context.Context, caller *auth.Identity, id uuid.UUID, req *model.DeviceRequest) (*model.Device, error) {
+	args := m.Called(ctx, caller, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Device), args.Error(1)
+}
+
+func (m *MockDeviceService) RotateKey(ctx context.Context, caller *auth.Identity, id uuid.UUID) (*model.DeviceCredentials, error) {
+	args := m.Called(ctx, caller, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DeviceCredentials), args.Error(1)
+}
+
+func (m *MockDeviceService) RevokeDevice(ctx context.Context, caller *auth.Identity, id uuid.UUID) (*model.Device, error) {
+	args := m.Called(ctx, caller, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Device), args.Error(1)
+}
+
+func (m *MockDeviceService) CurrentDevice(ctx context.Context, device *auth.Device) (*model.Device, error) {
+	args := m.Called(ctx, device)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Device), args.Error(1)
+}
+
+func serveDeviceRoutes(mockService *MockDeviceService, identity *auth.Identity, device *auth.Device, method, path, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	authMiddleware := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			withIdentity(c, identity)
+			return next(c)
+		}
+	}
+	deviceMiddleware := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.SetRequest(c.Request().WithContext(auth.WithDevice(c.Request().Context(), device)))
+			return next(c)
+		}
+	}
+	NewDeviceHandler(mockService).RegisterRoutes(e, authMiddleware, deviceMiddleware)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDeviceHandler_Register(t *testing.T) {
+	mockService := new(MockDeviceService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	device := &model.Device{ID: uuid.New(), Name: "入口", LocationID: 2, KeyPrefix: "tld_abcdefgh", KeyHash: "hash"}
+	mockService.On("RegisterDevice", mock.Anything, identity, &model.DeviceRequest{Name: "入口", LocationID: 2}).
+		Return(&model.DeviceCredentials{Device: device, APIKey: "tld_abcdefghijk"}, nil)
+
+	rec := serveDeviceRoutes(mockService, identity, nil, http.MethodPost, "/api/v1/devices", `{"name":"入口","location_id":2}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "tld_abcdefghijk", body["api_key"])
+	assert.Equal(t, "入口", body["name"])
+	assert.NotContains(t, body, "key_hash")
+}
+
+func TestDeviceHandler_List_ByLocation(t *testing.T) {
+	mockService := new(MockDeviceService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	locationID := 2
+	mockService.On("ListDevices", mock.Anything, identity, &locationID).Return([]*model.Device{{ID: uuid.New(), LocationID: 2}}, nil)
+
+	rec := serveDeviceRoutes(mockService, identity, nil, http.MethodGet, "/api/v1/devices?location_id=2", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestDeviceHandler_RotateKey(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	id := uuid.New()
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "rotated", wantStatus: http.StatusOK},
+		{name: "not found", err: service.ErrDeviceNotFound, wantStatus: http.StatusNotFound},
+		{name: "revoked", err: service.ErrDeviceRevoked, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockDeviceService)
+			if tt.err != nil {
+				mockService.On("RotateKey", mock.Anything, identity, id).Return(nil, tt.err)
+			} else {
+				mockService.On("RotateKey", mock.Anything, identity, id).Return(&model.DeviceCredentials{Device: &model.Device{ID: id}, APIKey: "tld_new"}, nil)
+			}
+
+			rec := serveDeviceRoutes(mockService, identity, nil, http.MethodPost, "/api/v1/devices/"+id.String()+"/rotate-key", "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestDeviceHandler_Revoke_InvalidID(t *testing.T) {
+	mockService := new(MockDeviceService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+
+	rec := serveDeviceRoutes(mockService, identity, nil, http.MethodPost, "/api/v1/devices/not-a-uuid/revoke", "")
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Empty(t, mockService.Calls)
+}
+
+func TestDeviceHandler_Me(t *testing.T) {
+	mockService := new(MockDeviceService)
+	device := &auth.Device{DeviceID: uuid.New(), LocationID: 2}
+	mockService.On("CurrentDevice", mock.Anything, device).Return(&model.Device{ID: device.DeviceID, Name: "入口", LocationID: 2}, nil)
+
+	rec := serveDeviceRoutes(mockService, nil, device, http.MethodGet, "/api/v1/devices/me", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), device.DeviceID.String())
+}
+
+func TestDeviceHandler_AdminRoutesRequireAdmin(t *testing.T) {
+	id := uuid.NewString()
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: http.MethodPost, path: "/api/v1/devices", body: `{"name":"入口","location_id":2}`},
+		{method: http.MethodGet, path: "/api/v1/devices"},
+		{method: http.MethodGet, path: "/api/v1/devices/" + id},
+		{method: http.MethodPut, path: "/api/v1/devices/" + id, body: `{"name":"入口","location_id":2}`},
+		{method: http.MethodPost, path: "/api/v1/devices/" + id + "/rotate-key"},
+		{method: http.MethodPost, path: "/api/v1/devices/" + id + "/revoke"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			mockService := new(MockDeviceService)
+			identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+
+			rec := serveDeviceRoutes(mockService, identity, nil, tt.method, tt.path, tt.body)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Empty(t, mockService.Calls)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type EmailVerificationHandler struct {
+	verificationService service.EmailVerificationService
+}
+
+func NewEmailVerificationHandler(verificationService service.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		verificationService: verificationService,
+	}
+}
+
+func (h *EmailVerificationHandler) Verify(c echo.Context) error {
+	var req model.VerifyEmailRequest
+	if err := c.Bind(&req); err != nil || req.Token == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if err := h.verificationService.Verify(c.Request().Context(), &req); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidVerificationToken):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid or expired token"})
+		case errors.Is(err, service.ErrEmailTaken):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *EmailVerificationHandler) Resend(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	if err := h.verificationService.Resend(c.Request().Context(), identity); err != nil {
+		if handled, respErr := respondLocked(c, err, "verification mail was sent recently"); handled {
+			return respErr
+		}
+		if errors.Is(err, service.ErrEmailAlreadyVerified) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+func (h *EmailVerificationHandler) RegisterRoutes(e *echo.Echo, authMiddleware echo.MiddlewareFunc) {
+	api := e.Group("/api/v1")
+
+	api.POST("/auth/email/verify", h.Verify)
+	api.POST("/users/me/email/verification", h.Resend, authMiddleware)
+}
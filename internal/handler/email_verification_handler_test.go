@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockEmailVerificationService struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationService) SendVerification(ctx context.Context, user *model.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationService) RequestEmailChange(ctx context.Context, user *model.User, email string) error {
+	args := m.Called(ctx, user, email)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationService) Verify(ctx context.Context, req *model.VerifyEmailRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationService) Resend(ctx context.Context, caller *auth.Identity) error {
+	args := m.Called(ctx, caller)
+	return args.Error(0)
+}
+
+func TestEmailVerificationHandler_Verify(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "verified", err: nil, wantStatus: http.StatusNoContent},
+		{name: "invalid token", err: service.ErrInvalidVerificationToken, wantStatus: http.StatusBadRequest},
+		{name: "email taken", err: service.ErrEmailTaken, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockEmailVerificationService)
+			handler := NewEmailVerificationHandler(mockService)
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/email/verify", strings.NewReader(`{"token":"raw-token"}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			mockService.On("Verify", mock.Anything, &model.VerifyEmailRequest{Token: "raw-token"}).Return(tt.err)
+
+			err := handler.Verify(c)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestEmailVerificationHandler_Resend(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{name: "sent", err: nil, wantStatus: http.StatusAccepted},
+		{name: "already verified", err: service.ErrEmailAlreadyVerified, wantStatus: http.StatusConflict},
+		{name: "throttled", err: &lockout.LockedError{RetryAfter: 42 * time.Second}, wantStatus: http.StatusTooManyRequests, wantRetryAfter: "42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockEmailVerificationService)
+			handler := NewEmailVerificationHandler(mockService)
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/email/verification", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+			withIdentity(c, identity)
+
+			mockService.On("Resend", mock.Anything, identity).Return(tt.err)
+
+			err := handler.Resend(c)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantRetryAfter, rec.Header().Get("Retry-After"))
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package handler
+
+import (
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/labstack/echo/v4"
+)
+
+func currentIdentity(c echo.Context) (*auth.Identity, bool) {
+	return auth.IdentityFromContext(c.Request().Context())
+}
+
+func currentDevice(c echo.Context) (*auth.Device, bool) {
+	return auth.DeviceFromContext(c.Request().Context())
+}
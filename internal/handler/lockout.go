@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/labstack/echo/v4"
+)
+
+// respondLocked は err が試行回数の超過であれば Retry-After ヘッダー付きの 429 を返す
+func respondLocked(c echo.Context, err error, message string) (bool, error) {
+	var lockedErr *lockout.LockedError
+	if !errors.As(err, &lockedErr) {
+		return false, nil
+	}
+
+	retryAfter := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	return true, c.JSON(http.StatusTooManyRequests, map[string]string{"error": message})
+}
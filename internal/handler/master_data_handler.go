@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// MasterDataHandler は学年・所属組織・場所を同じ形のエンドポイントで扱う。各メソッドは種類ごとのハンドラーを返す
+type MasterDataHandler struct {
+	masterDataService service.MasterDataService
+}
+
+func NewMasterDataHandler(masterDataService service.MasterDataService) *MasterDataHandler {
+	return &MasterDataHandler{
+		masterDataService: masterDataService,
+	}
+}
+
+func respondMasterDataError(c echo.Context, err error) error {
+	if handled, respErr := respondValidationError(c, err); handled {
+		return respErr
+	}
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+	case errors.Is(err, service.ErrMasterDataNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+func (h *MasterDataHandler) List(kind model.MasterDataKind) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		includeArchived, _ := strconv.ParseBool(c.QueryParam("include_archived"))
+
+		items, err := h.masterDataService.List(c.Request().Context(), kind, includeArchived)
+		if err != nil {
+			return respondMasterDataError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, items)
+	}
+}
+
+func (h *MasterDataHandler) Get(kind model.MasterDataKind) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		}
+
+		item, err := h.masterDataService.Get(c.Request().Context(), kind, id)
+		if err != nil {
+			return respondMasterDataError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, item)
+	}
+}
+
+func (h *MasterDataHandler) Create(kind model.MasterDataKind) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		identity, ok := currentIdentity(c)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		}
+
+		var req model.MasterDataRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		item, err := h.masterDataService.Create(c.Request().Context(), identity, kind, &req)
+		if err != nil {
+			return respondMasterDataError(c, err)
+		}
+
+		return c.JSON(http.StatusCreated, item)
+	}
+}
+
+func (h *MasterDataHandler) Update(kind model.MasterDataKind) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		identity, ok := currentIdentity(c)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		}
+
+		var req model.MasterDataRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		}
+
+		item, err := h.masterDataService.Update(c.Request().Context(), identity, kind, id, &req)
+		if err != nil {
+			return respondMasterDataError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, item)
+	}
+}
+
+// Delete は削除できた場合は 204 を、参照されていてアーカイブした場合はアーカイブ後の内容を 200 で返す
+func (h *MasterDataHandler) Delete(kind model.MasterDataKind) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		identity, ok := currentIdentity(c)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		}
+
+		archived, err := h.masterDataService.Delete(c.Request().Context(), identity, kind, id)
+		if err != nil {
+			return respondMasterDataError(c, err)
+		}
+		if archived != nil {
+			return c.JSON(http.StatusOK, archived)
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+func (h *MasterDataHandler) Restore(kind model.MasterDataKind) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		identity, ok := currentIdentity(c)
+		if !ok {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		}
+
+		item, err := h.masterDataService.Restore(c.Request().Context(), identity, kind, id)
+		if err != nil {
+			return respondMasterDataError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, item)
+	}
+}
+
+func (h *MasterDataHandler) RegisterRoutes(e *echo.Echo, authMiddleware echo.MiddlewareFunc) {
+	api := e.Group("/api/v1")
+	adminOnly := authmw.RequireRole(model.RoleAdmin)
+
+	for _, kind := range model.MasterDataKinds {
+		path := "/" + string(kind)
+
+		api.GET(path, h.List(kind), authMiddleware)
+		api.GET(path+"/:id", h.Get(kind), authMiddleware)
+		api.POST(path, h.Create(kind), authMiddleware, adminOnly)
+		api.PUT(path+"/:id", h.Update(kind), authMiddleware, adminOnly)
+		api.DELETE(path+"/:id", h.Delete(kind), authMiddleware, adminOnly)
+		api.POST(path+"/:id/restore", h.Restore(kind), authMiddleware, adminOnly)
+	}
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockMasterDataService struct {
+	mock.Mock
+}
+
+func (m *MockMasterDataService) List(ctx context.Context, kind model.MasterDataKind, includeArchived bool) ([]*model.MasterDataItem, error) {
+	args := m.Called(ctx, kind, includeArchived)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.MasterDataItem), args.Error(1)
+}
+
+func (m *MockMasterDataService) Get(ctx context.Context, kind model.MasterDataKind, id int) (*model.MasterDataItem, error) {
+	args := m.Called(ctx, kind, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MasterDataItem), args.Error(1)
+}
+
+func (m *MockMasterDataService) Create(ctx context.Context, caller *auth.Identity, kind model.MasterDataKind, req *model.MasterDataRequest) (*model.MasterDataItem, error) {
+	args := m.Called(ctx, caller, kind, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MasterDataItem), args.Error(1)
+}
+
+func (m *MockMasterDataService) Update(ctx context.Context, caller *auth.Identity, kind model.MasterDataKind, id int, req *model.MasterDataRequest) (*model.MasterDataItem, error) {
+	args := m.Called(ctx, caller, kind, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MasterDataItem), args.Error(1)
+}
+
+func (m *MockMasterDataService) Delete(ctx context.Context, caller *auth.Identity, kind model.MasterDataKind, id int) (*model.MasterDataItem, error) {
+	args := m.Called(ctx, caller, kind, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MasterDataItem), args.Error(1)
+}
+
+func (m *MockMasterDataService) Restore(ctx context.Context, caller *auth.Identity, kind model.MasterDataKind, id int) (*model.MasterDataItem, error) {
+	args := m.Called(ctx, caller, kind, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MasterDataItem), args.Error(1)
+}
+
+func (m *MockMasterDataService) Seed(ctx context.Context, seed model.MasterDataSeed) (*model.MasterDataSeedResult, error) {
+	args := m.Called(ctx, seed)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MasterDataSeedResult), args.Error(1)
+}
+
+func serveMasterDataRoutes(mockService *MockMasterDataService, identity *auth.Identity, method, path, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	authMiddleware := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			withIdentity(c, identity)
+			return next(c)
+		}
+	}
+	NewMasterDataHandler(mockService).RegisterRoutes(e, authMiddleware)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMasterDataHandler_List(t *testing.T) {
+	mockService := new(MockMasterDataService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	items := []*model.MasterDataItem{{ID: 1, Name: "1年", SortOrder: 1}, {ID: 2, Name: "2年", SortOrder: 2}}
+	mockService.On("List", mock.Anything, model.MasterDataGrades, true).Return(items, nil)
+
+	rec := serveMasterDataRoutes(mockService, identity, http.MethodGet, "/api/v1/grades?include_archived=true", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body []model.MasterDataItem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Len(t, body, 2)
+	mockService.AssertExpectations(t)
+}
+
+func TestMasterDataHandler_Create(t *testing.T) {
+	mockService := new(MockMasterDataService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	req := &model.MasterDataRequest{Name: "部室", SortOrder: 1}
+	mockService.On("Create", mock.Anything, identity, model.MasterDataLocations, req).Return(&model.MasterDataItem{ID: 5, Name: "部室", SortOrder: 1}, nil)
+
+	rec := serveMasterDataRoutes(mockService, identity, http.MethodPost, "/api/v1/locations", `{"name":"部室","sort_order":1}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestMasterDataHandler_Create_Duplicate(t *testing.T) {
+	mockService := new(MockMasterDataService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	duplicate := &service.ValidationError{}
+	duplicate.Add("name", "is already used")
+	mockService.On("Create", mock.Anything, identity, model.MasterDataOrganizations, mock.Anything).Return(nil, duplicate)
+
+	rec := serveMasterDataRoutes(mockService, identity, http.MethodPost, "/api/v1/organizations", `{"name":"情報部"}`)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "is already used")
+}
+
+func TestMasterDataHandler_Delete(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	archivedAt := time.Now()
+
+	tests := []struct {
+		name       string
+		archived   *model.MasterDataItem
+		err        error
+		wantStatus int
+	}{
+		{name: "deleted", wantStatus: http.StatusNoContent},
+		{name: "archived", archived: &model.MasterDataItem{ID: 1, Name: "1年", ArchivedAt: &archivedAt}, wantStatus: http.StatusOK},
+		{name: "not found", err: service.ErrMasterDataNotFound, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockMasterDataService)
+			if tt.archived != nil {
+				mockService.On("Delete", mock.Anything, identity, model.MasterDataGrades, 1).Return(tt.archived, nil)
+			} else {
+				mockService.On("Delete", mock.Anything, identity, model.MasterDataGrades, 1).Return(nil, tt.err)
+			}
+
+			rec := serveMasterDataRoutes(mockService, identity, http.MethodDelete, "/api/v1/grades/1", "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestMasterDataHandler_Get_InvalidID(t *testing.T) {
+	mockService := new(MockMasterDataService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+
+	rec := serveMasterDataRoutes(mockService, identity, http.MethodGet, "/api/v1/locations/abc", "")
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMasterDataHandler_WritesRequireAdmin(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: http.MethodPost, path: "/api/v1/grades", body: `{"name":"1年"}`},
+		{method: http.MethodPut, path: "/api/v1/organizations/1", body: `{"name":"情報部"}`},
+		{method: http.MethodDelete, path: "/api/v1/locations/1"},
+		{method: http.MethodPost, path: "/api/v1/locations/1/restore"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			mockService := new(MockMasterDataService)
+			identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+
+			rec := serveMasterDataRoutes(mockService, identity, tt.method, tt.path, tt.body)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Empty(t, mockService.Calls)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type MFAHandler struct {
+	mfaService service.MFAService
+}
+
+func NewMFAHandler(mfaService service.MFAService) *MFAHandler {
+	return &MFAHandler{
+		mfaService: mfaService,
+	}
+}
+
+func (h *MFAHandler) Enroll(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	enrollment, err := h.mfaService.Enroll(c.Request().Context(), identity)
+	if err != nil {
+		return respondMFAError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *MFAHandler) Confirm(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	var req model.MFACodeRequest
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	codes, err := h.mfaService.Confirm(c.Request().Context(), identity, &req)
+	if err != nil {
+		return respondMFAError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, codes)
+}
+
+func (h *MFAHandler) Disable(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	var req model.MFACodeRequest
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if err := h.mfaService.Disable(c.Request().Context(), identity, &req); err != nil {
+		return respondMFAError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *MFAHandler) Verify(c echo.Context) error {
+	var req model.MFAVerifyRequest
+	if err := c.Bind(&req); err != nil || req.MFAToken == "" || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	response, err := h.mfaService.Verify(c.Request().Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMFAToken) || errors.Is(err, service.ErrInvalidMFACode) {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		}
+		return respondMFAError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func respondMFAError(c echo.Context, err error) error {
+	if handled, respErr := respondLocked(c, err, "too many invalid codes"); handled {
+		return respErr
+	}
+
+	switch {
+	case errors.Is(err, service.ErrInvalidMFACode):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrMFAAlreadyEnabled),
+		errors.Is(err, service.ErrMFANotEnabled),
+		errors.Is(err, service.ErrMFAUnavailable):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+func (h *MFAHandler) RegisterRoutes(e *echo.Echo, authMiddleware echo.MiddlewareFunc) {
+	api := e.Group("/api/v1")
+
+	api.POST("/auth/mfa/verify", h.Verify)
+	// 登録は勤怠データを扱う staff と admin に限る。権限が下がった後も無効化はできる
+	api.POST("/users/me/mfa", h.Enroll, authMiddleware, authmw.RequireRole(model.RoleStaff, model.RoleAdmin))
+	api.POST("/users/me/mfa/confirm", h.Confirm, authMiddleware, authmw.RequireRole(model.RoleStaff, model.RoleAdmin))
+	api.POST("/users/me/mfa/disable", h.Disable, authMiddleware)
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockMFAService struct {
+	mock.Mock
+}
+
+func (m *MockMFAService) Enroll(ctx context.Context, caller *auth.Identity) (*model.MFAEnrollment, error) {
+	args := m.Called(ctx, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MFAEnrollment), args.Error(1)
+}
+
+func (m *MockMFAService) Confirm(ctx context.Context, caller *auth.Identity, req *model.MFACodeRequest) (*model.MFARecoveryCodesResponse, error) {
+	args := m.Called(ctx, caller, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MFARecoveryCodesResponse), args.Error(1)
+}
+
+func (m *MockMFAService) Disable(ctx context.Context, caller *auth.Identity, req *model.MFACodeRequest) error {
+	args := m.Called(ctx, caller, req)
+	return args.Error(0)
+}
+
+func (m *MockMFAService) Verify(ctx context.Context, req *model.MFAVerifyRequest) (*model.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.LoginResponse), args.Error(1)
+}
+
+func TestMFAHandler_Verify(t *testing.T) {
+	mockService := new(MockMFAService)
+	handler := NewMFAHandler(mockService)
+
+	e := echo.New()
+	reqBody := `{"mfa_token":"challenge","code":"123456"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/mfa/verify", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	expected := &model.LoginResponse{Token: "access", RefreshToken: "refresh", ExpiresIn: 900}
+	mockService.On("Verify", mock.Anything, &model.MFAVerifyRequest{MFAToken: "challenge", Code: "123456"}).Return(expected, nil)
+
+	err := handler.Verify(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response model.LoginResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "access", response.Token)
+	assert.False(t, response.MFARequired)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestMFAHandler_Verify_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "invalid token", err: service.ErrInvalidMFAToken, wantStatus: http.StatusUnauthorized},
+		{name: "invalid code", err: service.ErrInvalidMFACode, wantStatus: http.StatusUnauthorized},
+		{name: "locked", err: &lockout.LockedError{RetryAfter: 10 * time.Minute}, wantStatus: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockMFAService)
+			handler := NewMFAHandler(mockService)
+
+			e := echo.New()
+			reqBody := `{"mfa_token":"challenge","code":"123456"}`
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/mfa/verify", strings.NewReader(reqBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			mockService.On("Verify", mock.Anything, mock.Anything).Return(nil, tt.err)
+
+			err := handler.Verify(c)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestMFAHandler_Enroll_AlreadyEnabled(t *testing.T) {
+	mockService := new(MockMFAService)
+	handler := NewMFAHandler(mockService)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/mfa", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	withIdentity(c, identity)
+
+	mockService.On("Enroll", mock.Anything, identity).Return(nil, service.ErrMFAAlreadyEnabled)
+
+	err := handler.Enroll(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestMFAHandler_Confirm_InvalidCode(t *testing.T) {
+	mockService := new(MockMFAService)
+	handler := NewMFAHandler(mockService)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/mfa/confirm", strings.NewReader(`{"code":"000000"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	withIdentity(c, identity)
+
+	mockService.On("Confirm", mock.Anything, identity, &model.MFACodeRequest{Code: "000000"}).Return(nil, service.ErrInvalidMFACode)
+
+	err := handler.Confirm(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestMFAHandler_EnrollRequiresStaff(t *testing.T) {
+	mockService := new(MockMFAService)
+	handler := NewMFAHandler(mockService)
+
+	e := echo.New()
+	handler.RegisterRoutes(e, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			withIdentity(c, &auth.Identity{UserID: uuid.New(), Role: model.RoleMember})
+			return next(c)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/mfa", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockService.AssertNotCalled(t, "Enroll", mock.Anything, mock.Anything)
+}
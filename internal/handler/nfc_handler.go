@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type NFCHandler struct {
+	nfcService service.NFCService
+}
+
+func NewNFCHandler(nfcService service.NFCService) *NFCHandler {
+	return &NFCHandler{
+		nfcService: nfcService,
+	}
+}
+
+func respondNFCError(c echo.Context, err error) error {
+	if handled, respErr := respondValidationError(c, err); handled {
+		return respErr
+	}
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+	case errors.Is(err, service.ErrProfileNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "profile not found"})
+	case errors.Is(err, service.ErrEnrollmentNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "enrollment not found"})
+	case errors.Is(err, service.ErrNoCard):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "no card"})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+func (h *NFCHandler) ArmEnrollment(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	var req model.NFCEnrollmentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	enrollment, err := h.nfcService.ArmEnrollment(c.Request().Context(), identity, &req)
+	if err != nil {
+		return respondNFCError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, enrollment)
+}
+
+func (h *NFCHandler) CancelEnrollment(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+
+	if err := h.nfcService.CancelEnrollment(c.Request().Context(), identity, id); err != nil {
+		return respondNFCError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *NFCHandler) UnbindCard(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+
+	if err := h.nfcService.UnbindCard(c.Request().Context(), identity, userID); err != nil {
+		return respondNFCError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *NFCHandler) ReplaceCard(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+
+	var req model.NFCReplaceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	enrollment, err := h.nfcService.ReplaceCard(c.Request().Context(), identity, userID, &req)
+	if err != nil {
+		return respondNFCError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, enrollment)
+}
+
+func (h *NFCHandler) CardHistory(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	// /users/me/nfc-card/history では呼び出し元自身を対象にする
+	userID, err := profileUserID(c, identity)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+
+	events, err := h.nfcService.CardHistory(c.Request().Context(), identity, userID)
+	if err != nil {
+		return respondNFCError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, events)
+}
+
+func (h *NFCHandler) RegisterRoutes(e *echo.Echo, authMiddleware echo.MiddlewareFunc) {
+	api := e.Group("/api/v1")
+	adminOnly := authmw.RequireRole(model.RoleAdmin)
+
+	api.POST("/nfc/enrollments", h.ArmEnrollment, authMiddleware, adminOnly)
+	api.DELETE("/nfc/enrollments/:id", h.CancelEnrollment, authMiddleware, adminOnly)
+	api.GET("/users/me/nfc-card/history", h.CardHistory, authMiddleware)
+	api.GET("/users/:id/nfc-card/history", h.CardHistory, authMiddleware)
+	api.DELETE("/users/:id/nfc-card", h.UnbindCard, authMiddleware, adminOnly)
+	api.POST("/users/:id/nfc-card/replace", h.ReplaceCard, authMiddleware, adminOnly)
+}
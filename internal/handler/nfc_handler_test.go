@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockNFCService struct {
+	mock.Mock
+}
+
+func (m *MockNFCService) CompleteEnrollment(ctx context.Context, locationID int, serial string) (*model.Profile, error) {
+	args := m.Called(ctx, locationID, serial)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Profile), args.Error(1)
+}
+
+func (m *MockNFCService) ArmEnrollment(ctx context.Context, caller *auth.Identity, req *model.NFCEnrollmentRequest) (*model.NFCEnrollment, error) {
+	args := m.Called(ctx, caller, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.NFCEnrollment), args.Error(1)
+}
+
+func (m *MockNFCService) CancelEnrollment(ctx context.Context, caller *auth.Identity, id int64) error {
+	args := m.Called(ctx, caller, id)
+	return args.Error(0)
+}
+
+func (m *MockNFCService) UnbindCard(ctx context.Context, caller *auth.Identity, userID uuid.UUID) error {
+	args := m.Called(ctx, caller, userID)
+	return args.Error(0)
+}
+
+func (m *MockNFCService) ReplaceCard(ctx context.Context, caller *auth.Identity, userID uuid.UUID, req *model.NFCReplaceRequest) (*model.NFCEnrollment, error) {
+	args := m.Called(ctx, caller, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.NFCEnrollment), args.Error(1)
+}
+
+func (m *MockNFCService) CardHistory(ctx context.Context, caller *auth.Identity, userID uuid.UUID) ([]*model.NFCCardEvent, error) {
+	args := m.Called(ctx, caller, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.NFCCardEvent), args.Error(1)
+}
+
+func serveNFCRoutes(mockService *MockNFCService, identity *auth.Identity, method, path, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	authMiddleware := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			withIdentity(c, identity)
+			return next(c)
+		}
+	}
+	NewNFCHandler(mockService).RegisterRoutes(e, authMiddleware)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNFCHandler_ArmEnrollment(t *testing.T) {
+	mockService := new(MockNFCService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	userID := uuid.New()
+	enrollment := &model.NFCEnrollment{ID: 1, UserID: userID, LocationID: 2, ExpiresAt: time.Now().Add(5 * time.Minute)}
+	mockService.On("ArmEnrollment", mock.Anything, identity, &model.NFCEnrollmentRequest{UserID: userID, LocationID: 2}).Return(enrollment, nil)
+
+	rec := serveNFCRoutes(mockService, identity, http.MethodPost, "/api/v1/nfc/enrollments", `{"user_id":"`+userID.String()+`","location_id":2}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var body model.NFCEnrollment
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, userID, body.UserID)
+}
+
+func TestNFCHandler_ArmEnrollment_ProfileNotFound(t *testing.T) {
+	mockService := new(MockNFCService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	mockService.On("ArmEnrollment", mock.Anything, identity, mock.Anything).Return(nil, service.ErrProfileNotFound)
+
+	rec := serveNFCRoutes(mockService, identity, http.MethodPost, "/api/v1/nfc/enrollments", `{"user_id":"`+uuid.NewString()+`","location_id":2}`)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestNFCHandler_CancelEnrollment(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "cancelled", wantStatus: http.StatusNoContent},
+		{name: "not pending", err: service.ErrEnrollmentNotFound, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockNFCService)
+			mockService.On("CancelEnrollment", mock.Anything, identity, int64(7)).Return(tt.err)
+
+			rec := serveNFCRoutes(mockService, identity, http.MethodDelete, "/api/v1/nfc/enrollments/7", "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestNFCHandler_UnbindCard(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	userID := uuid.New()
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "unbound", wantStatus: http.StatusNoContent},
+		{name: "no card", err: service.ErrNoCard, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockNFCService)
+			mockService.On("UnbindCard", mock.Anything, identity, userID).Return(tt.err)
+
+			rec := serveNFCRoutes(mockService, identity, http.MethodDelete, "/api/v1/users/"+userID.String()+"/nfc-card", "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestNFCHandler_ReplaceCard(t *testing.T) {
+	mockService := new(MockNFCService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	userID := uuid.New()
+	mockService.On("ReplaceCard", mock.Anything, identity, userID, &model.NFCReplaceRequest{LocationID: 2}).
+		Return(&model.NFCEnrollment{ID: 3, UserID: userID, LocationID: 2}, nil)
+
+	rec := serveNFCRoutes(mockService, identity, http.MethodPost, "/api/v1/users/"+userID.String()+"/nfc-card/replace", `{"location_id":2}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestNFCHandler_CardHistory_Me(t *testing.T) {
+	mockService := new(MockNFCService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	events := []*model.NFCCardEvent{{ID: 1, UserID: identity.UserID, NFCSerial: "04A3B2C1", Event: model.NFCCardBound}}
+	mockService.On("CardHistory", mock.Anything, identity, identity.UserID).Return(events, nil)
+
+	rec := serveNFCRoutes(mockService, identity, http.MethodGet, "/api/v1/users/me/nfc-card/history", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"event":"bound"`)
+}
+
+func TestNFCHandler_AdminRoutesRequireAdmin(t *testing.T) {
+	userID := uuid.NewString()
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: http.MethodPost, path: "/api/v1/nfc/enrollments", body: `{"location_id":2}`},
+		{method: http.MethodDelete, path: "/api/v1/nfc/enrollments/1"},
+		{method: http.MethodDelete, path: "/api/v1/users/" + userID + "/nfc-card"},
+		{method: http.MethodPost, path: "/api/v1/users/" + userID + "/nfc-card/replace", body: `{"location_id":2}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			mockService := new(MockNFCService)
+			identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+
+			rec := serveNFCRoutes(mockService, identity, tt.method, tt.path, tt.body)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Empty(t, mockService.Calls)
+		})
+	}
+}
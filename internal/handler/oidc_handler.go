@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	oidcStateCookieName = "oidc_state"
+	oidcStateCookiePath = "/api/v1/auth/oidc"
+	oidcStateCookieTTL  = 10 * time.Minute
+)
+
+type OIDCHandler struct {
+	oidcService service.OIDCService
+}
+
+func NewOIDCHandler(oidcService service.OIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+	}
+}
+
+func (h *OIDCHandler) Login(c echo.Context) error {
+	start, err := h.oidcService.BeginLogin(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "failed to start oidc login"})
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    start.StateCookie,
+		Path:     oidcStateCookiePath,
+		MaxAge:   int(oidcStateCookieTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return c.Redirect(http.StatusFound, start.AuthorizationURL)
+}
+
+func (h *OIDCHandler) Callback(c echo.Context) error {
+	var req model.OIDCCallbackRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	// state Cookie は一度きりの利用とする
+	c.SetCookie(&http.Cookie{
+		Name:     oidcStateCookieName,
+		Path:     oidcStateCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if req.Error != "" {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": req.Error})
+	}
+	if req.Code == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing authorization code"})
+	}
+
+	cookie, err := c.Cookie(oidcStateCookieName)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing login state"})
+	}
+	req.StateCookie = cookie.Value
+
+	response, err := h.oidcService.CompleteLogin(c.Request().Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidOIDCState):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid login state"})
+		case errors.Is(err, service.ErrOIDCUserNotLinked):
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "account is not linked"})
+		default:
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "oidc login failed"})
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *OIDCHandler) RegisterRoutes(e *echo.Echo) {
+	api := e.Group("/api/v1")
+
+	api.GET("/auth/oidc/login", h.Login)
+	api.GET("/auth/oidc/callback", h.Callback)
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOIDCService struct {
+	mock.Mock
+}
+
+func (m *MockOIDCService) BeginLogin(ctx context.Context) (*model.OIDCLoginStart, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.OIDCLoginStart), args.Error(1)
+}
+
+func (m *MockOIDCService) CompleteLogin(ctx context.Context, req *model.OIDCCallbackRequest) (*model.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.LoginResponse), args.Error(1)
+}
+
+func (m *MockOIDCService) ValidateAccessToken(ctx context.Context, token string) (*auth.Identity, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.Identity), args.Error(1)
+}
+
+func TestOIDCHandler_Login(t *testing.T) {
+	e := echo.New()
+	mockService := new(MockOIDCService)
+	handler := NewOIDCHandler(mockService)
+
+	mockService.On("BeginLogin", mock.Anything).Return(&model.OIDCLoginStart{
+		AuthorizationURL: "https://zitadel.example.com/oauth/v2/authorize?state=abc",
+		StateCookie:      "signed-state",
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/login", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := handler.Login(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "https://zitadel.example.com/oauth/v2/authorize?state=abc", rec.Header().Get(echo.HeaderLocation))
+
+	cookies := rec.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "oidc_state", cookies[0].Name)
+		assert.Equal(t, "signed-state", cookies[0].Value)
+		assert.True(t, cookies[0].HttpOnly)
+	}
+
+	mockService.AssertExpectations(t)
+}
+
+func TestOIDCHandler_Callback(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		cookie     string
+		serviceErr error
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			query:      "?code=abc&state=xyz",
+			cookie:     "signed-state",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "provider error",
+			query:      "?error=access_denied&state=xyz",
+			cookie:     "signed-state",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "missing code",
+			query:      "?state=xyz",
+			cookie:     "signed-state",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing state cookie",
+			query:      "?code=abc&state=xyz",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid state",
+			query:      "?code=abc&state=xyz",
+			cookie:     "signed-state",
+			serviceErr: service.ErrInvalidOIDCState,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "user not linked",
+			query:      "?code=abc&state=xyz",
+			cookie:     "signed-state",
+			serviceErr: service.ErrOIDCUserNotLinked,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			mockService := new(MockOIDCService)
+			handler := NewOIDCHandler(mockService)
+
+			expectedReq := &model.OIDCCallbackRequest{Code: "abc", State: "xyz", StateCookie: tt.cookie}
+			if tt.serviceErr != nil {
+				mockService.On("CompleteLogin", mock.Anything, expectedReq).Return(nil, tt.serviceErr)
+			} else {
+				mockService.On("CompleteLogin", mock.Anything, expectedReq).Return(&model.LoginResponse{Token: "access"}, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/callback"+tt.query, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "oidc_state", Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := handler.Callback(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
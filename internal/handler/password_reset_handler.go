@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+type PasswordResetHandler struct {
+	resetService service.PasswordResetService
+}
+
+func NewPasswordResetHandler(resetService service.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		resetService: resetService,
+	}
+}
+
+func (h *PasswordResetHandler) Forgot(c echo.Context) error {
+	var req model.ForgotPasswordRequest
+	if err := c.Bind(&req); err != nil || req.Email == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if err := h.resetService.RequestReset(c.Request().Context(), &req, c.RealIP()); err != nil {
+		if handled, respErr := respondLocked(c, err, "password reset was requested too recently"); handled {
+			return respErr
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to request password reset"})
+	}
+
+	// 登録の有無に関わらず同じ応答を返す
+	return c.NoContent(http.StatusAccepted)
+}
+
+func (h *PasswordResetHandler) Reset(c echo.Context) error {
+	var req model.ResetPasswordRequest
+	if err := c.Bind(&req); err != nil || req.Token == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if err := h.resetService.ResetPassword(c.Request().Context(), &req); err != nil {
+		if handled, respErr := respondValidationError(c, err); handled {
+			return respErr
+		}
+		if errors.Is(err, service.ErrInvalidResetToken) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid or expired token"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *PasswordResetHandler) RegisterRoutes(e *echo.Echo) {
+	api := e.Group("/api/v1")
+
+	api.POST("/auth/password/forgot", h.Forgot)
+	api.POST("/auth/password/reset", h.Reset)
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockPasswordResetService struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetService) RequestReset(ctx context.Context, req *model.ForgotPasswordRequest, clientIP string) error {
+	args := m.Called(ctx, req, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetService) ResetPassword(ctx context.Context, req *model.ResetPasswordRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func TestPasswordResetHandler_Forgot(t *testing.T) {
+	mockService := new(MockPasswordResetService)
+	handler := NewPasswordResetHandler(mockService)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/forgot", strings.NewReader(`{"email":"test@example.com"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	mockService.On("RequestReset", mock.Anything, &model.ForgotPasswordRequest{Email: "test@example.com"}, "192.0.2.1").Return(nil)
+
+	err := handler.Forgot(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestPasswordResetHandler_Forgot_Locked(t *testing.T) {
+	mockService := new(MockPasswordResetService)
+	handler := NewPasswordResetHandler(mockService)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/forgot", strings.NewReader(`{"email":"test@example.com"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.RemoteAddr = "192.0.2.10:54321"
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	mockService.On("RequestReset", mock.Anything, &model.ForgotPasswordRequest{Email: "test@example.com"}, "192.0.2.10").
+		Return(&lockout.LockedError{RetryAfter: 30 * time.Second})
+
+	err := handler.Forgot(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestPasswordResetHandler_Forgot_MissingEmail(t *testing.T) {
+	mockService := new(MockPasswordResetService)
+	handler := NewPasswordResetHandler(mockService)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/forgot", strings.NewReader(`{}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := handler.Forgot(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertNotCalled(t, "RequestReset", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPasswordResetHandler_Reset(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		serviceErr error
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			body:       `{"token":"reset-token","new_password":"new-password"}`,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "missing token",
+			body:       `{"new_password":"new-password"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid token",
+			body:       `{"token":"reset-token","new_password":"new-password"}`,
+			serviceErr: service.ErrInvalidResetToken,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "password policy violation",
+			body:       `{"token":"reset-token","new_password":"short"}`,
+			serviceErr: &service.ValidationError{Fields: map[string][]string{"new_password": {"must be at least 8 characters"}}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "internal error",
+			body:       `{"token":"reset-token","new_password":"new-password"}`,
+			serviceErr: fmt.Errorf("db error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockPasswordResetService)
+			handler := NewPasswordResetHandler(mockService)
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/reset", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			mockService.On("ResetPassword", mock.Anything, mock.AnythingOfType("*model.ResetPasswordRequest")).Return(tt.serviceErr)
+
+			err := handler.Reset(c)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
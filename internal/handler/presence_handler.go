@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// presenceHeartbeat はプロキシに接続を切られないよう、通知がない間に送るコメントの間隔
+const presenceHeartbeat = 30 * time.Second
+
+type PresenceHandler struct {
+	presenceService service.PresenceService
+	heartbeat       time.Duration
+}
+
+func NewPresenceHandler(presenceService service.PresenceService) *PresenceHandler {
+	return &PresenceHandler{
+		presenceService: presenceService,
+		heartbeat:       presenceHeartbeat,
+	}
+}
+
+func (h *PresenceHandler) List(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	locationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+
+	presences, err := h.presenceService.List(c.Request().Context(), identity, locationID)
+	if err != nil {
+		return respondAttendanceError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, presences)
+}
+
+// Stream は Server-Sent Events で、最初に入室中のユーザーを snapshot として送り、以降の入退室を join / leave として送る。
+// 接続が閉じられた場合、クライアントは接続し直して snapshot から取り直す
+func (h *PresenceHandler) Stream(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	locationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+
+	ctx := c.Request().Context()
+	// 取得との間の入退室を取りこぼさないよう、先に購読する。重複した通知はクライアントが user_id で無視する
+	events, err := h.presenceService.Subscribe(ctx, identity, locationID)
+	if err != nil {
+		return respondAttendanceError(c, err)
+	}
+	presences, err := h.presenceService.List(ctx, identity, locationID)
+	if err != nil {
+		return respondAttendanceError(c, err)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	// nginx などのプロキシにバッファリングさせない
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	if err := writeServerSentEvent(res, "snapshot", presences); err != nil {
+		return nil
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := writeServerSentEvent(res, string(event.Type), event); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func writeServerSentEvent(res *echo.Response, name string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", name, payload); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
+
+func (h *PresenceHandler) RegisterRoutes(e *echo.Echo, authMiddleware echo.MiddlewareFunc) {
+	api := e.Group("/api/v1")
+	staffOrAdmin := authmw.RequireRole(model.RoleStaff, model.RoleAdmin)
+
+	api.GET("/locations/:id/presence", h.List, authMiddleware, staffOrAdmin)
+	api.GET("/locations/:id/presence/stream", h.Stream, authMiddleware, staffOrAdmin)
+}
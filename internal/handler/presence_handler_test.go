@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPresenceService struct {
+	mock.Mock
+}
+
+func (m *MockPresenceService) List(ctx context.Context, caller *auth.Identity, locationID int) ([]*model.Presence, error) {
+	args := m.Called(ctx, caller, locationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Presence), args.Error(1)
+}
+
+func (m *MockPresenceService) Subscribe(ctx context.Context, caller *auth.Identity, locationID int) (<-chan *model.PresenceEvent, error) {
+	args := m.Called(ctx, caller, locationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(<-chan *model.PresenceEvent), args.Error(1)
+}
+
+func servePresenceRoutes(mockService *MockPresenceService, identity *auth.Identity, path string) *httptest.ResponseRecorder {
+	e := echo.New()
+	authMiddleware := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			withIdentity(c, identity)
+			return next(c)
+		}
+	}
+	NewPresenceHandler(mockService).RegisterRoutes(e, authMiddleware)
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPresenceHandler_List(t *testing.T) {
+	staff := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+
+	tests := []struct {
+		name       string
+		identity   *auth.Identity
+		path       string
+		result     []*model.Presence
+		err        error
+		wantStatus int
+	}{
+		{name: "lists present users", identity: staff, path: "/api/v1/locations/3/presence", result: []*model.Presence{{UserID: uuid.New(), Name: "山田太郎"}}, wantStatus: http.StatusOK},
+		{name: "unknown location", identity: staff, path: "/api/v1/locations/3/presence", err: &service.ValidationError{Fields: map[string][]string{"location_id": {"does not exist"}}}, wantStatus: http.StatusBadRequest},
+		{name: "invalid id", identity: staff, path: "/api/v1/locations/lab/presence", wantStatus: http.StatusBadRequest},
+		{name: "requires staff", identity: &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}, path: "/api/v1/locations/3/presence", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockPresenceService)
+			if tt.result != nil || tt.err != nil {
+				mockService.On("List", mock.Anything, tt.identity, 3).Return(tt.result, tt.err)
+			}
+
+			rec := servePresenceRoutes(mockService, tt.identity, tt.path)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPresenceHandler_Stream(t *testing.T) {
+	staff := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	present := uuid.New()
+	joined := uuid.New()
+
+	events := make(chan *model.PresenceEvent, 1)
+	events <- &model.PresenceEvent{Type: model.PresenceJoin, LocationID: 3, UserID: joined, Name: "鈴木花子"}
+	// 購読が終わると応答を閉じ、クライアントに接続し直させる
+	close(events)
+
+	mockService := new(MockPresenceService)
+	mockService.On("Subscribe", mock.Anything, staff, 3).Return((<-chan *model.PresenceEvent)(events), nil)
+	mockService.On("List", mock.Anything, staff, 3).Return([]*model.Presence{{UserID: present, Name: "山田太郎"}}, nil)
+
+	rec := servePresenceRoutes(mockService, staff, "/api/v1/locations/3/presence/stream")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get(echo.HeaderContentType))
+	body := rec.Body.String()
+	snapshot := strings.Index(body, "event: snapshot\ndata: [{\"user_id\":\""+present.String())
+	join := strings.Index(body, "event: join\ndata: {\"type\":\"join\",\"location_id\":3,\"user_id\":\""+joined.String())
+	assert.GreaterOrEqual(t, snapshot, 0, body)
+	assert.Greater(t, join, snapshot, body)
+	mockService.AssertExpectations(t)
+}
+
+func TestPresenceHandler_Stream_Error(t *testing.T) {
+	staff := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+
+	mockService := new(MockPresenceService)
+	mockService.On("Subscribe", mock.Anything, staff, 3).Return(nil, &service.ValidationError{Fields: map[string][]string{"location_id": {"is archived"}}})
+
+	rec := servePresenceRoutes(mockService, staff, "/api/v1/locations/3/presence/stream")
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, echo.MIMEApplicationJSON, rec.Header().Get(echo.HeaderContentType))
+	mockService.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type ProfileHandler struct {
+	profileService service.ProfileService
+}
+
+func NewProfileHandler(profileService service.ProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+	}
+}
+
+// profileUserID は /users/me/profile では呼び出し元自身を、/users/:id/profile では id を対象にする
+func profileUserID(c echo.Context, identity *auth.Identity) (uuid.UUID, error) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		return identity.UserID, nil
+	}
+	return uuid.Parse(idParam)
+}
+
+func (h *ProfileHandler) GetProfile(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	userID, err := profileUserID(c, identity)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+
+	profile, err := h.profileService.GetProfile(c.Request().Context(), identity, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrForbidden):
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		case errors.Is(err, service.ErrProfileNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "profile not found"})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+
+	return c.JSON(http.StatusOK, profile)
+}
+
+func (h *ProfileHandler) SaveProfile(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	userID, err := profileUserID(c, identity)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+
+	var req model.ProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	profile, created, err := h.profileService.SaveProfile(c.Request().Context(), identity, userID, &req)
+	if err != nil {
+		if handled, respErr := respondValidationError(c, err); handled {
+			return respErr
+		}
+		switch {
+		case errors.Is(err, service.ErrForbidden):
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		case errors.Is(err, service.ErrUserNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+
+	if created {
+		return c.JSON(http.StatusCreated, profile)
+	}
+	return c.JSON(http.StatusOK, profile)
+}
+
+func (h *ProfileHandler) ListProfiles(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	filter := model.ProfileFilter{}
+	for _, param := range []struct {
+		name string
+		dest **int
+	}{
+		{"grade_id", &filter.GradeID},
+		{"org_id", &filter.OrgID},
+	} {
+		raw := c.QueryParam(param.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid " + param.name})
+		}
+		*param.dest = &value
+	}
+
+	filter.Limit, _ = strconv.Atoi(c.QueryParam("limit"))
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+
+	filter.Offset, _ = strconv.Atoi(c.QueryParam("offset"))
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	profiles, err := h.profileService.ListProfiles(c.Request().Context(), identity, filter)
+	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, profiles)
+}
+
+func (h *ProfileHandler) RegisterRoutes(e *echo.Echo, authMiddleware echo.MiddlewareFunc) {
+	api := e.Group("/api/v1")
+
+	api.GET("/profiles", h.ListProfiles, authMiddleware, authmw.RequireRole(model.RoleStaff, model.RoleAdmin))
+	api.GET("/users/me/profile", h.GetProfile, authMiddleware)
+	api.PUT("/users/me/profile", h.SaveProfile, authMiddleware)
+	api.GET("/users/:id/profile", h.GetProfile, authMiddleware)
+	api.PUT("/users/:id/profile", h.SaveProfile, authMiddleware)
+}
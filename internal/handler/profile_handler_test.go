@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockProfileService struct {
+	mock.Mock
+}
+
+func (m *MockProfileService) CreateProfile(ctx context.Context, user *model.User, req *model.ProfileRequest) (*model.Profile, error) {
+	args := m.Called(ctx, user, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Profile), args.Error(1)
+}
+
+func (m *MockProfileService) GetProfile(ctx context.Context, caller *auth.Identity, userID uuid.UUID) (*model.Profile, error) {
+	args := m.Called(ctx, caller, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Profile), args.Error(1)
+}
+
+func (m *MockProfileService) SaveProfile(ctx context.Context, caller *auth.Identity, userID uuid.UUID, req *model.ProfileRequest) (*model.Profile, bool, error) {
+	args := m.Called(ctx, caller, userID, req)
+	if args.Get(0) == nil {
+		return nil, false, args.Error(2)
+	}
+	return args.Get(0).(*model.Profile), args.Bool(1), args.Error(2)
+}
+
+func (m *MockProfileService) ListProfiles(ctx context.Context, caller *auth.Identity, filter model.ProfileFilter) ([]*model.Profile, error) {
+	args := m.Called(ctx, caller, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Profile), args.Error(1)
+}
+
+// serveProfileRoutes はルーティングを含めてリクエストを処理する
+func serveProfileRoutes(mockService *MockProfileService, identity *auth.Identity, method, path, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	authMiddleware := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			withIdentity(c, identity)
+			return next(c)
+		}
+	}
+	NewProfileHandler(mockService).RegisterRoutes(e, authMiddleware)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestProfileHandler_GetProfile(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	otherID := uuid.New()
+
+	tests := []struct {
+		name       string
+		path       string
+		target     uuid.UUID
+		err        error
+		wantStatus int
+	}{
+		{name: "own profile", path: "/api/v1/users/me/profile", target: identity.UserID, wantStatus: http.StatusOK},
+		{name: "other user", path: "/api/v1/users/" + otherID.String() + "/profile", target: otherID, wantStatus: http.StatusOK},
+		{name: "not found", path: "/api/v1/users/me/profile", target: identity.UserID, err: service.ErrProfileNotFound, wantStatus: http.StatusNotFound},
+		{name: "forbidden", path: "/api/v1/users/" + otherID.String() + "/profile", target: otherID, err: service.ErrForbidden, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockProfileService)
+			if tt.err != nil {
+				mockService.On("GetProfile", mock.Anything, identity, tt.target).Return(nil, tt.err)
+			} else {
+				mockService.On("GetProfile", mock.Anything, identity, tt.target).Return(&model.Profile{UserID: tt.target, DisplayID: "test-user"}, nil)
+			}
+
+			rec := serveProfileRoutes(mockService, identity, http.MethodGet, tt.path, "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProfileHandler_GetProfile_InvalidID(t *testing.T) {
+	mockService := new(MockProfileService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+
+	rec := serveProfileRoutes(mockService, identity, http.MethodGet, "/api/v1/users/not-a-uuid/profile", "")
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockService.AssertNotCalled(t, "GetProfile", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProfileHandler_SaveProfile(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	gradeID := 2
+	req := &model.ProfileRequest{DisplayID: "test-user", GradeID: &gradeID}
+
+	tests := []struct {
+		name       string
+		created    bool
+		wantStatus int
+	}{
+		{name: "created", created: true, wantStatus: http.StatusCreated},
+		{name: "replaced", created: false, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockProfileService)
+			profile := &model.Profile{UserID: identity.UserID, DisplayID: "test-user", GradeID: &gradeID}
+			mockService.On("SaveProfile", mock.Anything, identity, identity.UserID, req).Return(profile, tt.created, nil)
+
+			rec := serveProfileRoutes(mockService, identity, http.MethodPut, "/api/v1/users/me/profile", `{"display_id":"test-user","grade_id":2}`)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			var body model.Profile
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, "test-user", body.DisplayID)
+		})
+	}
+}
+
+func TestProfileHandler_SaveProfile_Errors(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	taken := &service.ValidationError{}
+	taken.Add("display_id", "is already taken")
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "display id taken", err: taken, wantStatus: http.StatusBadRequest},
+		{name: "forbidden", err: service.ErrForbidden, wantStatus: http.StatusForbidden},
+		{name: "unknown user", err: service.ErrUserNotFound, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockProfileService)
+			target := uuid.New()
+			mockService.On("SaveProfile", mock.Anything, identity, target, mock.AnythingOfType("*model.ProfileRequest")).Return(nil, false, tt.err)
+
+			rec := serveProfileRoutes(mockService, identity, http.MethodPut, "/api/v1/users/"+target.String()+"/profile", `{"display_id":"taken"}`)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestProfileHandler_ListProfiles(t *testing.T) {
+	mockService := new(MockProfileService)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	gradeID, orgID := 1, 4
+	filter := model.ProfileFilter{GradeID: &gradeID, OrgID: &orgID, Limit: 20, Offset: 0}
+	mockService.On("ListProfiles", mock.Anything, identity, filter).Return([]*model.Profile{{DisplayID: "test-user"}}, nil)
+
+	rec := serveProfileRoutes(mockService, identity, http.MethodGet, "/api/v1/profiles?grade_id=1&org_id=4&limit=20", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var profiles []model.Profile
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &profiles))
+	assert.Len(t, profiles, 1)
+	mockService.AssertExpectations(t)
+}
+
+func TestProfileHandler_ListProfiles_Rejected(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       model.Role
+		path       string
+		wantStatus int
+	}{
+		{name: "member", role: model.RoleMember, path: "/api/v1/profiles", wantStatus: http.StatusForbidden},
+		{name: "invalid grade", role: model.RoleStaff, path: "/api/v1/profiles?grade_id=first", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockProfileService)
+			identity := &auth.Identity{UserID: uuid.New(), Role: tt.role}
+
+			rec := serveProfileRoutes(mockService, identity, http.MethodGet, tt.path, "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			mockService.AssertNotCalled(t, "ListProfiles", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
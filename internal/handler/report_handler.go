@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/xlsx"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	mimeCSV  = "text/csv; charset=UTF-8"
+	mimeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	// utf8BOM は Excel に CSV を UTF-8 として読ませるために先頭に付ける
+	utf8BOM = "\xEF\xBB\xBF"
+	// reportFlushRows はこの行数ごとに書き出した内容をクライアントに送る
+	reportFlushRows = 100
+)
+
+type ReportHandler struct {
+	reportService service.ReportService
+}
+
+func NewReportHandler(reportService service.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// reportWriter は集計の行を形式ごとに書き出す
+type reportWriter interface {
+	WriteRow(row *model.ReportRow) error
+	Flush() error
+	Close() error
+}
+
+// Attendance は入退室の集計を format（json・csv・xlsx）で返す。行は集計した順に書き出し、応答全体をメモリに保持しない
+func (h *ReportHandler) Attendance(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	format := c.QueryParam("format")
+	switch format {
+	case "":
+		format = "json"
+	case "json", "csv", "xlsx":
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid format"})
+	}
+
+	req := &model.ReportRequest{
+		GroupBy:  model.ReportGroupBy(c.QueryParam("group_by")),
+		Period:   model.ReportPeriod(c.QueryParam("period")),
+		From:     c.QueryParam("from"),
+		To:       c.QueryParam("to"),
+		Timezone: c.QueryParam("tz"),
+	}
+	if raw := c.QueryParam("location_id"); raw != "" {
+		locationID, err := strconv.Atoi(raw)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid location_id"})
+		}
+		req.LocationID = &locationID
+	}
+
+	// 条件の誤りをエラーとして返せるよう、最初の行が届くまで応答を書き始めない
+	var writer reportWriter
+	rows := 0
+	start := func() error {
+		if writer != nil {
+			return nil
+		}
+		var err error
+		writer, err = h.startReport(c, format, req)
+		return err
+	}
+
+	err := h.reportService.Attendance(c.Request().Context(), identity, req, func(row *model.ReportRow) error {
+		if err := start(); err != nil {
+			return err
+		}
+		if err := writer.WriteRow(row); err != nil {
+			return err
+		}
+		rows++
+		if rows%reportFlushRows == 0 {
+			return writer.Flush()
+		}
+		return nil
+	})
+	if err != nil && writer == nil {
+		return respondAttendanceError(c, err)
+	}
+	if err != nil {
+		// 書き始めた後はステータスを変えられないため、途中で打ち切る
+		log.Printf("Failed to write attendance report: %v", err)
+		return nil
+	}
+
+	if err := start(); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// startReport は応答のヘッダーを書き、形式に応じた reportWriter を返す
+func (h *ReportHandler) startReport(c echo.Context, format string, req *model.ReportRequest) (reportWriter, error) {
+	res := c.Response()
+	filename := fmt.Sprintf("attendance-%s-%s-%s.%s",
+		strings.ReplaceAll(req.From, "-", ""), strings.ReplaceAll(req.To, "-", ""), groupByOrDefault(req.GroupBy), format)
+
+	switch format {
+	case "csv":
+		res.Header().Set(echo.HeaderContentType, mimeCSV)
+		res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+		res.WriteHeader(http.StatusOK)
+		if _, err := io.WriteString(res, utf8BOM); err != nil {
+			return nil, err
+		}
+		w := &csvReportWriter{res: res, csv: csv.NewWriter(res), groupBy: groupByOrDefault(req.GroupBy)}
+		return w, w.csv.Write(reportHeader(w.groupBy))
+	case "xlsx":
+		res.Header().Set(echo.HeaderContentType, mimeXLSX)
+		res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+		res.WriteHeader(http.StatusOK)
+		sheet, err := xlsx.NewWriter(res, "入退室集計")
+		if err != nil {
+			return nil, err
+		}
+		w := &xlsxReportWriter{res: res, sheet: sheet, groupBy: groupByOrDefault(req.GroupBy)}
+		header := reportHeader(w.groupBy)
+		cells := make([]interface{}, len(header))
+		for i, name := range header {
+			cells[i] = name
+		}
+		return w, sheet.WriteRow(cells...)
+	default:
+		res.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		res.WriteHeader(http.StatusOK)
+		if _, err := io.WriteString(res, "["); err != nil {
+			return nil, err
+		}
+		return &jsonReportWriter{res: res}, nil
+	}
+}
+
+func groupByOrDefault(groupBy model.ReportGroupBy) model.ReportGroupBy {
+	if groupBy == "" {
+		return model.ReportByUser
+	}
+	return groupBy
+}
+
+// reportHeader は CSV と XLSX の見出し。ユーザー単位では人数を省く
+func reportHeader(groupBy model.ReportGroupBy) []string {
+	var header []string
+	switch groupBy {
+	case model.ReportByGrade:
+		header = []string{"学年ID", "学年", "人数"}
+	case model.ReportByOrganization:
+		header = []string{"所属組織ID", "所属組織", "人数"}
+	default:
+		header = []string{"ユーザーID", "表示用ID", "名前"}
+	}
+	return append(header, "開始日", "終了日", "出席日数", "滞在時間（時間）", "最初の入室", "最後の退室")
+}
+
+// reportCells は見出しに対応するセルの値。滞在時間は小数第2位までの時間、時刻は集計のタイムゾーンで表す。
+// 利用者が入力した名前などは数式として解釈されないようにする
+func reportCells(groupBy model.ReportGroupBy, row *model.ReportRow) []interface{} {
+	var cells []interface{}
+	if groupBy == model.ReportByUser {
+		cells = []interface{}{escapeFormula(row.GroupID), escapeFormula(row.DisplayID), escapeFormula(row.GroupName)}
+	} else {
+		cells = []interface{}{escapeFormula(row.GroupID), escapeFormula(row.GroupName), row.Users}
+	}
+	return append(cells,
+		row.PeriodStart,
+		row.PeriodEnd,
+		row.DaysPresent,
+		math.Round(float64(row.TotalSeconds)/36)/100,
+		formatReportTime(row.FirstIn),
+		formatReportTime(row.LastOut),
+	)
+}
+
+// escapeFormula は表計算ソフトが数式として解釈する文字で始まる値の先頭に ' を付ける
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func formatReportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+type jsonReportWriter struct {
+	res  *echo.Response
+	rows int
+}
+
+func (w *jsonReportWriter) WriteRow(row *model.ReportRow) error {
+	payload, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if w.rows > 0 {
+		if _, err := io.WriteString(w.res, ","); err != nil {
+			return err
+		}
+	}
+	w.rows++
+	_, err = w.res.Write(payload)
+	return err
+}
+
+func (w *jsonReportWriter) Flush() error {
+	w.res.Flush()
+	return nil
+}
+
+func (w *jsonReportWriter) Close() error {
+	_, err := io.WriteString(w.res, "]\n")
+	return err
+}
+
+type csvReportWriter struct {
+	res     *echo.Response
+	csv     *csv.Writer
+	groupBy model.ReportGroupBy
+}
+
+func (w *csvReportWriter) WriteRow(row *model.ReportRow) error {
+	cells := reportCells(w.groupBy, row)
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		record[i] = fmt.Sprint(cell)
+	}
+	return w.csv.Write(record)
+}
+
+func (w *csvReportWriter) Flush() error {
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return err
+	}
+	w.res.Flush()
+	return nil
+}
+
+func (w *csvReportWriter) Close() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+type xlsxReportWriter struct {
+	res     *echo.Response
+	sheet   *xlsx.Writer
+	groupBy model.ReportGroupBy
+}
+
+func (w *xlsxReportWriter) WriteRow(row *model.ReportRow) error {
+	return w.sheet.WriteRow(reportCells(w.groupBy, row)...)
+}
+
+func (w *xlsxReportWriter) Flush() error {
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	w.res.Flush()
+	return nil
+}
+
+func (w *xlsxReportWriter) Close() error {
+	return w.sheet.Close()
+}
+
+func (h *ReportHandler) RegisterRoutes(e *echo.Echo, authMiddleware echo.MiddlewareFunc) {
+	api := e.Group("/api/v1")
+	staffOrAdmin := authmw.RequireRole(model.RoleStaff, model.RoleAdmin)
+
+	api.GET("/reports/attendance", h.Attendance, authMiddleware, staffOrAdmin)
+}
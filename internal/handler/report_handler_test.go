@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockReportService struct {
+	mock.Mock
+}
+
+// Attendance は Return に渡した行を順に emit に渡す
+func (m *MockReportService) Attendance(ctx context.Context, caller *auth.Identity, req *model.ReportRequest, emit func(*model.ReportRow) error) error {
+	args := m.Called(ctx, caller, req)
+	if rows, ok := args.Get(0).([]*model.ReportRow); ok {
+		for _, row := range rows {
+			if err := emit(row); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func serveReportRoutes(mockService *MockReportService, identity *auth.Identity, query string) *httptest.ResponseRecorder {
+	e := echo.New()
+	authMiddleware := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			withIdentity(c, identity)
+			return next(c)
+		}
+	}
+	NewReportHandler(mockService).RegisterRoutes(e, authMiddleware)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/attendance?"+query, nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func testReportRows() []*model.ReportRow {
+	firstIn := time.Date(2026, 10, 1, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	return []*model.ReportRow{
+		{
+			GroupID:      uuid.NewString(),
+			GroupName:    "山田, 太郎",
+			DisplayID:    "taro",
+			PeriodStart:  "2026-10-01",
+			PeriodEnd:    "2026-10-01",
+			Users:        1,
+			DaysPresent:  1,
+			TotalSeconds: 5400,
+			FirstIn:      &firstIn,
+		},
+	}
+}
+
+func TestReportHandler_Attendance_JSON(t *testing.T) {
+	staff := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	rows := testReportRows()
+	locationID := 3
+
+	mockService := new(MockReportService)
+	mockService.On("Attendance", mock.Anything, staff, &model.ReportRequest{
+		GroupBy:    model.ReportByUser,
+		Period:     model.ReportWeekly,
+		From:       "2026-10-01",
+		To:         "2026-10-31",
+		Timezone:   "UTC",
+		LocationID: &locationID,
+	}).Return(append(rows, rows[0]), nil)
+
+	rec := serveReportRoutes(mockService, staff, "group_by=user&period=week&from=2026-10-01&to=2026-10-31&tz=UTC&location_id=3")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var got []*model.ReportRow
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	require.Len(t, got, 2)
+	assert.Equal(t, rows[0].GroupID, got[0].GroupID)
+	assert.Equal(t, int64(5400), got[1].TotalSeconds)
+}
+
+func TestReportHandler_Attendance_CSV(t *testing.T) {
+	staff := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	rows := testReportRows()
+
+	mockService := new(MockReportService)
+	mockService.On("Attendance", mock.Anything, staff, mock.Anything).Return(rows, nil)
+
+	rec := serveReportRoutes(mockService, staff, "format=csv&from=2026-10-01&to=2026-10-31")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=UTF-8", rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, `attachment; filename="attendance-20261001-20261031-user.csv"`, rec.Header().Get(echo.HeaderContentDisposition))
+	body := rec.Body.String()
+	assert.True(t, strings.HasPrefix(body, "\xEF\xBB\xBF"), "CSV must start with a UTF-8 BOM for Excel")
+	lines := strings.Split(strings.TrimPrefix(body, "\xEF\xBB\xBF"), "\n")
+	assert.Equal(t, "ユーザーID,表示用ID,名前,開始日,終了日,出席日数,滞在時間（時間）,最初の入室,最後の退室", lines[0])
+	assert.Equal(t, rows[0].GroupID+`,taro,"山田, 太郎",2026-10-01,2026-10-01,1,1.5,2026-10-01 09:00:00,`, lines[1])
+}
+
+func TestReportHandler_Attendance_EscapesFormulas(t *testing.T) {
+	staff := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	rows := testReportRows()
+	rows[0].GroupName = `=HYPERLINK("http://example.com","x")`
+	rows[0].DisplayID = "@taro"
+
+	mockService := new(MockReportService)
+	mockService.On("Attendance", mock.Anything, staff, mock.Anything).Return(rows, nil)
+
+	rec := serveReportRoutes(mockService, staff, "format=csv&from=2026-10-01&to=2026-10-31")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	lines := strings.Split(strings.TrimPrefix(rec.Body.String(), "\xEF\xBB\xBF"), "\n")
+	assert.Equal(t, rows[0].GroupID+`,'@taro,"'=HYPERLINK(""http://example.com"",""x"")",2026-10-01,2026-10-01,1,1.5,2026-10-01 09:00:00,`, lines[1])
+
+	rec = serveReportRoutes(mockService, staff, "format=xlsx&from=2026-10-01&to=2026-10-31")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	require.NoError(t, err)
+	f, err := zr.Open("xl/worksheets/sheet1.xml")
+	require.NoError(t, err)
+	sheet, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Contains(t, string(sheet), "&#39;@taro")
+	assert.NotContains(t, string(sheet), "<t>@taro")
+}
+
+func TestEscapeFormula(t *testing.T) {
+	for _, value := range []string{"=1+1", "+81", "-1", "@SUM(A1)", "\tcmd", "\rcmd"} {
+		assert.Equal(t, "'"+value, escapeFormula(value))
+	}
+	assert.Equal(t, "山田太郎", escapeFormula("山田太郎"))
+	assert.Equal(t, "", escapeFormula(""))
+}
+
+func TestReportHandler_Attendance_CSV_Empty(t *testing.T) {
+	staff := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+
+	mockService := new(MockReportService)
+	mockService.On("Attendance", mock.Anything, staff, mock.Anything).Return(nil, nil)
+
+	rec := serveReportRoutes(mockService, staff, "format=csv&group_by=grade&from=2026-10-01&to=2026-10-31")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	// 行がなくても見出しは返す
+	assert.Equal(t, "\xEF\xBB\xBF学年ID,学年,人数,開始日,終了日,出席日数,滞在時間（時間）,最初の入室,最後の退室\n", rec.Body.String())
+}
+
+func TestReportHandler_Attendance_XLSX(t *testing.T) {
+	staff := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+
+	mockService := new(MockReportService)
+	mockService.On("Attendance", mock.Anything, staff, mock.Anything).Return(testReportRows(), nil)
+
+	rec := serveReportRoutes(mockService, staff, "format=xlsx&from=2026-10-01&to=2026-10-31")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", rec.Header().Get(echo.HeaderContentType))
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	require.NoError(t, err)
+	f, err := zr.Open("xl/worksheets/sheet1.xml")
+	require.NoError(t, err)
+	sheet, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Contains(t, string(sheet), "滞在時間（時間）")
+	assert.Contains(t, string(sheet), "<v>1.5</v>")
+}
+
+func TestReportHandler_Attendance_Errors(t *testing.T) {
+	staff := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+
+	tests := []struct {
+		name       string
+		identity   *auth.Identity
+		query      string
+		err        error
+		wantStatus int
+	}{
+		{name: "invalid range", identity: staff, query: "format=csv", err: &service.ValidationError{Fields: map[string][]string{"from": {"is required"}}}, wantStatus: http.StatusBadRequest},
+		{name: "invalid format", identity: staff, query: "format=pdf&from=2026-10-01&to=2026-10-31", wantStatus: http.StatusBadRequest},
+		{name: "invalid location", identity: staff, query: "location_id=lab&from=2026-10-01&to=2026-10-31", wantStatus: http.StatusBadRequest},
+		{name: "requires staff", identity: &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}, query: "from=2026-10-01&to=2026-10-31", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockReportService)
+			if tt.err != nil {
+				mockService.On("Attendance", mock.Anything, tt.identity, mock.Anything).Return(nil, tt.err)
+			}
+
+			rec := serveReportRoutes(mockService, tt.identity, tt.query)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, echo.MIMEApplicationJSON, rec.Header().Get(echo.HeaderContentType))
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	authmw "github.com/StepByCode/TSUNAGU-Link-back/internal/middleware"
 	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
 	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
 	"github.com/google/uuid"
@@ -28,6 +30,9 @@ func (h *UserHandler) CreateUser(c echo.Context) error {
 
 	user, err := h.userService.CreateUser(c.Request().Context(), &req)
 	if err != nil {
+		if handled, respErr := respondValidationError(c, err); handled {
+			return respErr
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
@@ -35,21 +40,67 @@ func (h *UserHandler) CreateUser(c echo.Context) error {
 }
 
 func (h *UserHandler) GetUser(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
 	idParam := c.Param("id")
 	id, err := uuid.Parse(idParam)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
 	}
 
-	user, err := h.userService.GetUser(c.Request().Context(), id)
+	user, err := h.userService.GetUser(c.Request().Context(), identity, id)
 	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		}
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
 	}
 
 	return c.JSON(http.StatusOK, user)
 }
 
+func (h *UserHandler) GetCurrentUser(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	user, err := h.userService.GetCurrentUser(c.Request().Context(), identity)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) UpdateCurrentUser(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	var req model.UpdateCurrentUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	user, err := h.userService.UpdateCurrentUser(c.Request().Context(), identity, &req)
+	if err != nil {
+		return respondUpdateUserError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
 func (h *UserHandler) UpdateUser(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
 	idParam := c.Param("id")
 	id, err := uuid.Parse(idParam)
 	if err != nil {
@@ -61,22 +112,106 @@ func (h *UserHandler) UpdateUser(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
 	}
 
-	user, err := h.userService.UpdateUser(c.Request().Context(), id, &req)
+	user, err := h.userService.UpdateUser(c.Request().Context(), identity, id, &req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return respondUpdateUserError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// respondUpdateUserError は UpdateUser と UpdateCurrentUser のエラーをステータスコードに変換する
+func respondUpdateUserError(c echo.Context, err error) error {
+	if handled, respErr := respondValidationError(c, err); handled {
+		return respErr
+	}
+	if handled, respErr := respondLocked(c, err, "verification mail was sent recently"); handled {
+		return respErr
+	}
+
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+	case errors.Is(err, service.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+	case errors.Is(err, service.ErrEmailTaken):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update user"})
+	}
+}
+
+func (h *UserHandler) UpdateUserRole(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+	}
+
+	var req model.UpdateUserRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	user, err := h.userService.UpdateUserRole(c.Request().Context(), identity, id, req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrForbidden):
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		case errors.Is(err, service.ErrInvalidRole):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid role"})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
 	}
 
 	return c.JSON(http.StatusOK, user)
 }
 
+func (h *UserHandler) ChangePassword(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	var req model.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if err := h.userService.ChangePassword(c.Request().Context(), identity, &req); err != nil {
+		if handled, respErr := respondValidationError(c, err); handled {
+			return respErr
+		}
+		if errors.Is(err, service.ErrPasswordManagedExternally) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (h *UserHandler) DeleteUser(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
 	idParam := c.Param("id")
 	id, err := uuid.Parse(idParam)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user id"})
 	}
 
-	if err := h.userService.DeleteUser(c.Request().Context(), id); err != nil {
+	if err := h.userService.DeleteUser(c.Request().Context(), identity, id); err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
@@ -84,6 +219,11 @@ func (h *UserHandler) DeleteUser(c echo.Context) error {
 }
 
 func (h *UserHandler) ListUsers(c echo.Context) error {
+	identity, ok := currentIdentity(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
 	limit, _ := strconv.Atoi(c.QueryParam("limit"))
 	if limit <= 0 {
 		limit = 10
@@ -94,35 +234,27 @@ func (h *UserHandler) ListUsers(c echo.Context) error {
 		offset = 0
 	}
 
-	users, err := h.userService.ListUsers(c.Request().Context(), limit, offset)
+	users, err := h.userService.ListUsers(c.Request().Context(), identity, limit, offset)
 	if err != nil {
+		if errors.Is(err, service.ErrForbidden) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
 	return c.JSON(http.StatusOK, users)
 }
 
-func (h *UserHandler) Login(c echo.Context) error {
-	var req model.LoginRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
-	}
-
-	response, err := h.userService.Login(c.Request().Context(), &req)
-	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid credentials"})
-	}
-
-	return c.JSON(http.StatusOK, response)
-}
-
-func (h *UserHandler) RegisterRoutes(e *echo.Echo) {
+func (h *UserHandler) RegisterRoutes(e *echo.Echo, authMiddleware echo.MiddlewareFunc) {
 	api := e.Group("/api/v1")
 
-	api.POST("/auth/login", h.Login)
 	api.POST("/users", h.CreateUser)
-	api.GET("/users/:id", h.GetUser)
-	api.PUT("/users/:id", h.UpdateUser)
-	api.DELETE("/users/:id", h.DeleteUser)
-	api.GET("/users", h.ListUsers)
+	api.GET("/users/me", h.GetCurrentUser, authMiddleware)
+	api.PATCH("/users/me", h.UpdateCurrentUser, authMiddleware)
+	api.PUT("/users/me/password", h.ChangePassword, authMiddleware)
+	api.GET("/users/:id", h.GetUser, authMiddleware)
+	api.PUT("/users/:id", h.UpdateUser, authMiddleware)
+	api.PUT("/users/:id/role", h.UpdateUserRole, authMiddleware, authmw.RequireRole(model.RoleAdmin))
+	api.DELETE("/users/:id", h.DeleteUser, authMiddleware, authmw.RequireRole(model.RoleAdmin))
+	api.GET("/users", h.ListUsers, authMiddleware, authmw.RequireRole(model.RoleStaff, model.RoleAdmin))
 }
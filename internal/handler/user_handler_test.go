@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,7 +11,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
 	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
@@ -30,41 +33,78 @@ func (m *MockUserService) CreateUser(ctx context.Context, req *model.CreateUserR
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserService) GetUser(ctx context.Context, id uuid.UUID) (*model.User, error) {
-	args := m.Called(ctx, id)
+func (m *MockUserService) GetUser(ctx context.Context, caller *auth.Identity, id uuid.UUID) (*model.User, error) {
+	args := m.Called(ctx, caller, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserService) UpdateUser(ctx context.Context, id uuid.UUID, req *model.UpdateUserRequest) (*model.User, error) {
-	args := m.Called(ctx, id, req)
+func (m *MockUserService) GetCurrentUser(ctx context.Context, caller *auth.Identity) (*model.CurrentUser, error) {
+	args := m.Called(ctx, caller)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.CurrentUser), args.Error(1)
+}
+
+func (m *MockUserService) UpdateCurrentUser(ctx context.Context, caller *auth.Identity, req *model.UpdateCurrentUserRequest) (*model.CurrentUser, error) {
+	args := m.Called(ctx, caller, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.CurrentUser), args.Error(1)
+}
+
+func (m *MockUserService) UpdateUser(ctx context.Context, caller *auth.Identity, id uuid.UUID, req *model.UpdateUserRequest) (*model.User, error) {
+	args := m.Called(ctx, caller, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserService) UpdateUserRole(ctx context.Context, caller *auth.Identity, id uuid.UUID, role model.Role) (*model.User, error) {
+	args := m.Called(ctx, caller, id, role)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
-func (m *MockUserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	args := m.Called(ctx, id)
+func (m *MockUserService) DeleteUser(ctx context.Context, caller *auth.Identity, id uuid.UUID) error {
+	args := m.Called(ctx, caller, id)
 	return args.Error(0)
 }
 
-func (m *MockUserService) ListUsers(ctx context.Context, limit, offset int) ([]*model.User, error) {
-	args := m.Called(ctx, limit, offset)
+func (m *MockUserService) ListUsers(ctx context.Context, caller *auth.Identity, limit, offset int) ([]*model.User, error) {
+	args := m.Called(ctx, caller, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*model.User), args.Error(1)
 }
 
-func (m *MockUserService) Login(ctx context.Context, req *model.LoginRequest) (*model.LoginResponse, error) {
-	args := m.Called(ctx, req)
+func (m *MockUserService) ProvisionExternalUser(ctx context.Context, identity *model.ExternalIdentity) (*model.User, error) {
+	args := m.Called(ctx, identity)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*model.LoginResponse), args.Error(1)
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserService) GetExternalUser(ctx context.Context, subject string) (*model.User, error) {
+	args := m.Called(ctx, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserService) ChangePassword(ctx context.Context, caller *auth.Identity, req *model.ChangePasswordRequest) error {
+	args := m.Called(ctx, caller, req)
+	return args.Error(0)
 }
 
 func TestUserHandler_CreateUser(t *testing.T) {
@@ -140,6 +180,30 @@ func TestUserHandler_CreateUser_ServiceError(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestUserHandler_CreateUser_ValidationError(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	e := echo.New()
+	reqBody := `{"email":"test@example.com","name":"Test User","password":"password"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	validationErr := &service.ValidationError{}
+	validationErr.Add("password", "is too common")
+	mockService.On("CreateUser", mock.Anything, mock.AnythingOfType("*model.CreateUserRequest")).Return(nil, validationErr)
+
+	err := handler.CreateUser(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error":"validation failed","fields":{"password":["is too common"]}}`, rec.Body.String())
+
+	mockService.AssertExpectations(t)
+}
+
 func TestUserHandler_GetUser(t *testing.T) {
 	mockService := new(MockUserService)
 	handler := NewUserHandler(mockService)
@@ -149,6 +213,8 @@ func TestUserHandler_GetUser(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+userID.String(), nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
+	identity := &auth.Identity{UserID: userID, Role: model.RoleMember}
+	withIdentity(c, identity)
 	c.SetParamNames("id")
 	c.SetParamValues(userID.String())
 
@@ -160,7 +226,7 @@ func TestUserHandler_GetUser(t *testing.T) {
 		UpdatedAt: time.Now(),
 	}
 
-	mockService.On("GetUser", mock.Anything, userID).Return(expectedUser, nil)
+	mockService.On("GetUser", mock.Anything, identity, userID).Return(expectedUser, nil)
 
 	err := handler.GetUser(c)
 
@@ -184,6 +250,7 @@ func TestUserHandler_GetUser_InvalidID(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/invalid-id", nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
+	withIdentity(c, &auth.Identity{UserID: uuid.New(), Role: model.RoleMember})
 	c.SetParamNames("id")
 	c.SetParamValues("invalid-id")
 
@@ -202,10 +269,12 @@ func TestUserHandler_GetUser_NotFound(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+userID.String(), nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
+	identity := &auth.Identity{UserID: userID, Role: model.RoleMember}
+	withIdentity(c, identity)
 	c.SetParamNames("id")
 	c.SetParamValues(userID.String())
 
-	mockService.On("GetUser", mock.Anything, userID).Return(nil, fmt.Errorf("user not found"))
+	mockService.On("GetUser", mock.Anything, identity, userID).Return(nil, fmt.Errorf("user not found"))
 
 	err := handler.GetUser(c)
 
@@ -226,6 +295,8 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
+	identity := &auth.Identity{UserID: userID, Role: model.RoleMember}
+	withIdentity(c, identity)
 	c.SetParamNames("id")
 	c.SetParamValues(userID.String())
 
@@ -237,7 +308,7 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 		UpdatedAt: time.Now(),
 	}
 
-	mockService.On("UpdateUser", mock.Anything, userID, mock.AnythingOfType("*model.UpdateUserRequest")).Return(updatedUser, nil)
+	mockService.On("UpdateUser", mock.Anything, identity, userID, mock.AnythingOfType("*model.UpdateUserRequest")).Return(updatedUser, nil)
 
 	err := handler.UpdateUser(c)
 
@@ -253,6 +324,50 @@ func TestUserHandler_UpdateUser(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestUserHandler_UpdateUser_Errors(t *testing.T) {
+	invalid := &service.ValidationError{}
+	invalid.Add("name", "is managed by the external identity provider")
+
+	tests := []struct {
+		name       string
+		serviceErr error
+		wantStatus int
+	}{
+		{name: "validation", serviceErr: invalid, wantStatus: http.StatusBadRequest},
+		{name: "forbidden", serviceErr: service.ErrForbidden, wantStatus: http.StatusForbidden},
+		{name: "not found", serviceErr: service.ErrUserNotFound, wantStatus: http.StatusNotFound},
+		{name: "email taken", serviceErr: service.ErrEmailTaken, wantStatus: http.StatusConflict},
+		{name: "internal", serviceErr: errors.New("pq: connection refused"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			handler := NewUserHandler(mockService)
+
+			e := echo.New()
+			userID := uuid.New()
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/users/"+userID.String(), strings.NewReader(`{"name":"Updated Name"}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(userID.String())
+			identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+			withIdentity(c, identity)
+
+			mockService.On("UpdateUser", mock.Anything, identity, userID, mock.AnythingOfType("*model.UpdateUserRequest")).Return(nil, tt.serviceErr)
+
+			err := handler.UpdateUser(c)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			// 内部エラーの詳細は返さない
+			assert.NotContains(t, rec.Body.String(), "pq:")
+		})
+	}
+}
+
 func TestUserHandler_DeleteUser(t *testing.T) {
 	mockService := new(MockUserService)
 	handler := NewUserHandler(mockService)
@@ -262,10 +377,12 @@ func TestUserHandler_DeleteUser(t *testing.T) {
 	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+userID.String(), nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	withIdentity(c, identity)
 	c.SetParamNames("id")
 	c.SetParamValues(userID.String())
 
-	mockService.On("DeleteUser", mock.Anything, userID).Return(nil)
+	mockService.On("DeleteUser", mock.Anything, identity, userID).Return(nil)
 
 	err := handler.DeleteUser(c)
 
@@ -283,6 +400,8 @@ func TestUserHandler_ListUsers(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/users?limit=10&offset=0", nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	withIdentity(c, identity)
 
 	expectedUsers := []*model.User{
 		{
@@ -301,7 +420,7 @@ func TestUserHandler_ListUsers(t *testing.T) {
 		},
 	}
 
-	mockService.On("ListUsers", mock.Anything, 10, 0).Return(expectedUsers, nil)
+	mockService.On("ListUsers", mock.Anything, identity, 10, 0).Return(expectedUsers, nil)
 
 	err := handler.ListUsers(c)
 
@@ -324,8 +443,10 @@ func TestUserHandler_ListUsers_DefaultPagination(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	withIdentity(c, identity)
 
-	mockService.On("ListUsers", mock.Anything, 10, 0).Return([]*model.User{}, nil)
+	mockService.On("ListUsers", mock.Anything, identity, 10, 0).Return([]*model.User{}, nil)
 
 	err := handler.ListUsers(c)
 
@@ -335,61 +456,292 @@ func TestUserHandler_ListUsers_DefaultPagination(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
-func TestUserHandler_Login(t *testing.T) {
+func TestUserHandler_GetUser_Forbidden(t *testing.T) {
 	mockService := new(MockUserService)
 	handler := NewUserHandler(mockService)
 
 	e := echo.New()
-	reqBody := `{"email":"test@example.com","password":"password123"}`
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(reqBody))
-	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	userID := uuid.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+userID.String(), nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(userID.String())
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	withIdentity(c, identity)
 
-	expectedResponse := &model.LoginResponse{
-		Token: "test-token",
-		User: model.User{
-			ID:        uuid.New(),
-			Email:     "test@example.com",
-			Name:      "Test User",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+	mockService.On("GetUser", mock.Anything, identity, userID).Return(nil, service.ErrForbidden)
+
+	err := handler.GetUser(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestUserHandler_UpdateUserRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		serviceErr error
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			body:       `{"role":"staff"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid role",
+			body:       `{"role":"owner"}`,
+			serviceErr: service.ErrInvalidRole,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "forbidden",
+			body:       `{"role":"staff"}`,
+			serviceErr: service.ErrForbidden,
+			wantStatus: http.StatusForbidden,
 		},
 	}
 
-	mockService.On("Login", mock.Anything, mock.AnythingOfType("*model.LoginRequest")).Return(expectedResponse, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			handler := NewUserHandler(mockService)
+
+			e := echo.New()
+			userID := uuid.New()
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/users/"+userID.String()+"/role", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(userID.String())
+			identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+			withIdentity(c, identity)
+
+			if tt.serviceErr != nil {
+				mockService.On("UpdateUserRole", mock.Anything, identity, userID, mock.AnythingOfType("model.Role")).Return(nil, tt.serviceErr)
+			} else {
+				mockService.On("UpdateUserRole", mock.Anything, identity, userID, model.RoleStaff).Return(&model.User{ID: userID, Role: model.RoleStaff}, nil)
+			}
+
+			err := handler.UpdateUserRole(c)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
 
-	err := handler.Login(c)
+func TestUserHandler_RoleProtectedRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		role       model.Role
+		wantStatus int
+	}{
+		{
+			name:       "member cannot list users",
+			method:     http.MethodGet,
+			path:       "/api/v1/users",
+			role:       model.RoleMember,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "staff can list users",
+			method:     http.MethodGet,
+			path:       "/api/v1/users",
+			role:       model.RoleStaff,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "staff cannot delete users",
+			method:     http.MethodDelete,
+			path:       "/api/v1/users/" + uuid.NewString(),
+			role:       model.RoleStaff,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "member cannot change roles",
+			method:     http.MethodPut,
+			path:       "/api/v1/users/" + uuid.NewString() + "/role",
+			role:       model.RoleMember,
+			wantStatus: http.StatusForbidden,
+		},
+	}
 
-	require.NoError(t, err)
-	assert.Equal(t, http.StatusOK, rec.Code)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			mockService.On("ListUsers", mock.Anything, mock.Anything, 10, 0).Return([]*model.User{}, nil)
+
+			e := echo.New()
+			identity := &auth.Identity{UserID: uuid.New(), Role: tt.role}
+			authMiddleware := func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					withIdentity(c, identity)
+					return next(c)
+				}
+			}
+			NewUserHandler(mockService).RegisterRoutes(e, authMiddleware)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
 
-	var response model.LoginResponse
-	err = json.Unmarshal(rec.Body.Bytes(), &response)
-	require.NoError(t, err)
-	assert.Equal(t, expectedResponse.Token, response.Token)
-	assert.Equal(t, expectedResponse.User.Email, response.User.Email)
+func TestUserHandler_ChangePassword(t *testing.T) {
+	validationErr := &service.ValidationError{}
+	validationErr.Add("current_password", "is incorrect")
+
+	tests := []struct {
+		name       string
+		serviceErr error
+		wantStatus int
+	}{
+		{
+			name:       "changed",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "validation error",
+			serviceErr: validationErr,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "external user",
+			serviceErr: service.ErrPasswordManagedExternally,
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			handler := NewUserHandler(mockService)
+
+			e := echo.New()
+			reqBody := `{"current_password":"correct-horse-battery","new_password":"staple-orbit-lantern"}`
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/users/me/password", strings.NewReader(reqBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			identity := &auth.Identity{UserID: uuid.New()}
+			withIdentity(c, identity)
+
+			mockService.On("ChangePassword", mock.Anything, identity, &model.ChangePasswordRequest{
+				CurrentPassword: "correct-horse-battery",
+				NewPassword:     "staple-orbit-lantern",
+			}).Return(tt.serviceErr)
+
+			err := handler.ChangePassword(c)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_GetCurrentUser(t *testing.T) {
+	mockService := new(MockUserService)
+	userID := uuid.New()
+	identity := &auth.Identity{UserID: userID, Role: model.RoleMember}
+
+	e := echo.New()
+	NewUserHandler(mockService).RegisterRoutes(e, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			withIdentity(c, identity)
+			return next(c)
+		}
+	})
+
+	expected := &model.CurrentUser{
+		User:       &model.User{ID: userID, Email: "test@example.com", Name: "Test User", Role: model.RoleMember},
+		MFAEnabled: true,
+		Profile:    &model.Profile{UserID: userID, DisplayID: "taro", Name: "山田太郎"},
+	}
+	mockService.On("GetCurrentUser", mock.Anything, identity).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, userID.String(), body["id"])
+	assert.Equal(t, "test@example.com", body["email"])
+	assert.Equal(t, true, body["mfa_enabled"])
+	profile, ok := body["profile"].(map[string]any)
+	require.True(t, ok, "profile must be included")
+	assert.Equal(t, "taro", profile["display_id"])
+	assert.Equal(t, "山田太郎", profile["name"])
 
 	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "GetUser", mock.Anything, mock.Anything, mock.Anything)
 }
 
-func TestUserHandler_Login_InvalidCredentials(t *testing.T) {
+func TestUserHandler_UpdateCurrentUser(t *testing.T) {
 	mockService := new(MockUserService)
 	handler := NewUserHandler(mockService)
 
 	e := echo.New()
-	reqBody := `{"email":"test@example.com","password":"wrongpassword"}`
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(reqBody))
+	reqBody := `{"name":"New Name","role":"admin"}`
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/me", strings.NewReader(reqBody))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
+	identity := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	withIdentity(c, identity)
 
-	mockService.On("Login", mock.Anything, mock.AnythingOfType("*model.LoginRequest")).Return(nil, fmt.Errorf("invalid credentials"))
+	newName := "New Name"
+	expected := &model.CurrentUser{User: &model.User{ID: identity.UserID, Name: newName, Role: model.RoleMember}}
+	mockService.On("UpdateCurrentUser", mock.Anything, identity, &model.UpdateCurrentUserRequest{Name: &newName}).Return(expected, nil)
 
-	err := handler.Login(c)
+	err := handler.UpdateCurrentUser(c)
 
 	require.NoError(t, err)
-	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "New Name", body["name"])
+	assert.Equal(t, "member", body["role"])
 
 	mockService.AssertExpectations(t)
 }
+
+func TestUserHandler_UpdateCurrentUser_ValidationError(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/me", strings.NewReader(`{"name":""}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	withIdentity(c, &auth.Identity{UserID: uuid.New(), Role: model.RoleMember})
+
+	validationErr := &service.ValidationError{}
+	validationErr.Add("name", "must not be empty")
+	mockService.On("UpdateCurrentUser", mock.Anything, mock.Anything, mock.Anything).Return(nil, validationErr)
+
+	err := handler.UpdateCurrentUser(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error":"validation failed","fields":{"name":["must not be empty"]}}`, rec.Body.String())
+}
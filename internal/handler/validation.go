@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// respondValidationError は err が検証エラーであれば項目ごとのメッセージを 400 で返す
+func respondValidationError(c echo.Context, err error) (bool, error) {
+	var validationErr *service.ValidationError
+	if !errors.As(err, &validationErr) {
+		return false, nil
+	}
+
+	return true, c.JSON(http.StatusBadRequest, map[string]interface{}{
+		"error":  "validation failed",
+		"fields": validationErr.Fields,
+	})
+}
@@ -0,0 +1,170 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Store は失敗回数とロック期限を保持する。複数レプリカで共有する場合は PostgreSQL の実装を使う
+type Store interface {
+	// LockedUntil は key のロック期限を返す。記録がなければゼロ値を返す
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+	// RecordFailure は失敗回数を1増やした値を返す。最後の失敗が since より前であれば1から数え直す
+	RecordFailure(ctx context.Context, key string, now, since time.Time) (int, error)
+	// Lock はロック期限を until まで延ばす。既により後の期限であれば変更しない
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+	// Release は RecordFailure で数えた失敗を1つ取り消す
+	Release(ctx context.Context, key string) error
+	// Purge は最後の失敗とロック期限がどちらも before より前の記録を削除する
+	Purge(ctx context.Context, before time.Time) error
+}
+
+// Policy は失敗回数に応じた待ち時間を決める
+type Policy struct {
+	// MaxFailures 回失敗すると Lockout の間ロックする
+	MaxFailures int
+	Lockout     time.Duration
+	// BaseDelay は1回目の失敗後の待ち時間で、以降の失敗ごとに倍になる。0 なら待ち時間を設けない
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// Delay は failures 回目の失敗の後、次の試行を受け付けるまでの時間を返す
+func (p Policy) Delay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	if p.MaxFailures > 0 && failures >= p.MaxFailures {
+		return p.Lockout
+	}
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < failures && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// LockedError は試行がロック中のため拒否されたことを表す
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// Limiter は1種類のキー（アカウントや接続元IPなど）について試行を制限する
+type Limiter struct {
+	store  Store
+	scope  string
+	policy Policy
+	now    func() time.Time
+}
+
+func NewLimiter(store Store, scope string, policy Policy) *Limiter {
+	return &Limiter{
+		store:  store,
+		scope:  scope,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+func (l *Limiter) key(id string) string {
+	return l.scope + ":" + id
+}
+
+// Check は id がロック中であれば *LockedError を返す
+func (l *Limiter) Check(ctx context.Context, id string) error {
+	until, err := l.store.LockedUntil(ctx, l.key(id))
+	if err != nil {
+		return fmt.Errorf("failed to check lockout: %w", err)
+	}
+
+	if remaining := until.Sub(l.now()); remaining > 0 {
+		return &LockedError{RetryAfter: remaining}
+	}
+	return nil
+}
+
+// RecordFailure は失敗を記録し、回数に応じて id をロックする
+func (l *Limiter) RecordFailure(ctx context.Context, id string) error {
+	now := l.now()
+	// 最後の失敗からロック期間が過ぎていれば数え直す
+	failures, err := l.store.RecordFailure(ctx, l.key(id), now, now.Add(-l.policy.Lockout))
+	if err != nil {
+		return fmt.Errorf("failed to record failed attempt: %w", err)
+	}
+
+	if delay := l.policy.Delay(failures); delay > 0 {
+		if err := l.store.Lock(ctx, l.key(id), now.Add(delay)); err != nil {
+			return fmt.Errorf("failed to lock: %w", err)
+		}
+	}
+	return nil
+}
+
+// Reservation は結果が出る前に失敗として数えた試行を表す。結果に応じて Fail か Release を呼ぶ
+type Reservation struct {
+	limiter  *Limiter
+	id       string
+	failures int
+}
+
+// Reserve は試行を先に失敗として数える。同時に届いた試行も含めて上限を超える場合は
+// 数えた分を取り消して *LockedError を返すため、並行した試行でも上限を超えて検証しない
+func (l *Limiter) Reserve(ctx context.Context, id string) (*Reservation, error) {
+	now := l.now()
+	failures, err := l.store.RecordFailure(ctx, l.key(id), now, now.Add(-l.policy.Lockout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve attempt: %w", err)
+	}
+
+	if l.policy.MaxFailures > 0 && failures > l.policy.MaxFailures {
+		if err := l.store.Release(ctx, l.key(id)); err != nil {
+			return nil, fmt.Errorf("failed to release attempt: %w", err)
+		}
+		return nil, &LockedError{RetryAfter: l.policy.Lockout}
+	}
+	return &Reservation{limiter: l, id: id, failures: failures}, nil
+}
+
+// Fail は試行の失敗を確定し、回数に応じて id をロックする
+func (r *Reservation) Fail(ctx context.Context) error {
+	if delay := r.limiter.policy.Delay(r.failures); delay > 0 {
+		if err := r.limiter.store.Lock(ctx, r.limiter.key(r.id), r.limiter.now().Add(delay)); err != nil {
+			return fmt.Errorf("failed to lock: %w", err)
+		}
+	}
+	return nil
+}
+
+// Release は成功した試行を失敗の数から取り除く
+func (r *Reservation) Release(ctx context.Context) error {
+	if err := r.limiter.store.Release(ctx, r.limiter.key(r.id)); err != nil {
+		return fmt.Errorf("failed to release attempt: %w", err)
+	}
+	return nil
+}
+
+// Reset は id の失敗回数とロックを消去する
+func (l *Limiter) Reset(ctx context.Context, id string) error {
+	if err := l.store.Reset(ctx, l.key(id)); err != nil {
+		return fmt.Errorf("failed to reset lockout: %w", err)
+	}
+	return nil
+}
+
+// Purge は期限切れの記録を削除する
+func (l *Limiter) Purge(ctx context.Context) error {
+	return l.store.Purge(ctx, l.now().Add(-l.policy.Lockout))
+}
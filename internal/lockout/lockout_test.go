@@ -0,0 +1,143 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Delay(t *testing.T) {
+	policy := Policy{
+		MaxFailures: 5,
+		Lockout:     15 * time.Minute,
+		BaseDelay:   time.Second,
+		MaxDelay:    5 * time.Second,
+	}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{5, 15 * time.Minute},
+		{100, 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, policy.Delay(tt.failures), "failures=%d", tt.failures)
+	}
+}
+
+func TestPolicy_Delay_WithoutBackoff(t *testing.T) {
+	policy := Policy{MaxFailures: 3, Lockout: time.Minute}
+
+	assert.Equal(t, time.Duration(0), policy.Delay(2))
+	assert.Equal(t, time.Minute, policy.Delay(3))
+}
+
+func newTestLimiter(now *time.Time) *Limiter {
+	limiter := NewLimiter(NewMemoryStore(), "account", Policy{
+		MaxFailures: 3,
+		Lockout:     10 * time.Minute,
+		BaseDelay:   time.Second,
+		MaxDelay:    time.Minute,
+	})
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	require.NoError(t, limiter.Check(ctx, "a@example.com"))
+
+	require.NoError(t, limiter.RecordFailure(ctx, "a@example.com"))
+	var lockedErr *LockedError
+	require.ErrorAs(t, limiter.Check(ctx, "a@example.com"), &lockedErr)
+	assert.Equal(t, time.Second, lockedErr.RetryAfter)
+	assert.NoError(t, limiter.Check(ctx, "b@example.com"))
+
+	now = now.Add(time.Second)
+	require.NoError(t, limiter.Check(ctx, "a@example.com"))
+
+	require.NoError(t, limiter.RecordFailure(ctx, "a@example.com"))
+	require.NoError(t, limiter.RecordFailure(ctx, "a@example.com"))
+	require.ErrorAs(t, limiter.Check(ctx, "a@example.com"), &lockedErr)
+	assert.Equal(t, 10*time.Minute, lockedErr.RetryAfter)
+
+	require.NoError(t, limiter.Reset(ctx, "a@example.com"))
+	assert.NoError(t, limiter.Check(ctx, "a@example.com"))
+}
+
+func TestLimiter_FailuresExpire(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	require.NoError(t, limiter.RecordFailure(ctx, "a@example.com"))
+	require.NoError(t, limiter.RecordFailure(ctx, "a@example.com"))
+
+	// ロック期間より前の失敗は数えない
+	now = now.Add(11 * time.Minute)
+	require.NoError(t, limiter.RecordFailure(ctx, "a@example.com"))
+
+	var lockedErr *LockedError
+	require.ErrorAs(t, limiter.Check(ctx, "a@example.com"), &lockedErr)
+	assert.Equal(t, time.Second, lockedErr.RetryAfter)
+}
+
+func TestLimiter_Reserve(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	// 結果が出る前の試行も上限まで数え、それを超える試行は照合させない
+	var reservations []*Reservation
+	for i := 0; i < 3; i++ {
+		reservation, err := limiter.Reserve(ctx, "a@example.com")
+		require.NoError(t, err)
+		reservations = append(reservations, reservation)
+	}
+	_, err := limiter.Reserve(ctx, "a@example.com")
+	var lockedErr *LockedError
+	require.ErrorAs(t, err, &lockedErr)
+	assert.Equal(t, 10*time.Minute, lockedErr.RetryAfter)
+
+	// 成功した試行を取り消すと、次の試行を受け付ける
+	require.NoError(t, reservations[0].Release(ctx))
+	reservation, err := limiter.Reserve(ctx, "a@example.com")
+	require.NoError(t, err)
+
+	require.NoError(t, reservation.Fail(ctx))
+	require.ErrorAs(t, limiter.Check(ctx, "a@example.com"), &lockedErr)
+	assert.Equal(t, 10*time.Minute, lockedErr.RetryAfter)
+}
+
+func TestMemoryStore_Purge(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	_, err := store.RecordFailure(ctx, "stale", now.Add(-time.Hour), now.Add(-2*time.Hour))
+	require.NoError(t, err)
+	_, err = store.RecordFailure(ctx, "locked", now.Add(-time.Hour), now.Add(-2*time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, store.Lock(ctx, "locked", now.Add(time.Hour)))
+	_, err = store.RecordFailure(ctx, "recent", now, now.Add(-time.Hour))
+	require.NoError(t, err)
+
+	require.NoError(t, store.Purge(ctx, now.Add(-time.Minute)))
+
+	assert.NotContains(t, store.entries, "stale")
+	assert.Contains(t, store.entries, "locked")
+	assert.Contains(t, store.entries, "recent")
+}
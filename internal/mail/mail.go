@@ -0,0 +1,78 @@
+// Package mail はメール送信を抽象化する。本番向けの送信手段は Sender を実装して差し替える
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// LogSender はメールを送信せずにログへ出力する。ローカル開発用
+type LogSender struct {
+	logger *log.Logger
+}
+
+func NewLogSender(logger *log.Logger) *LogSender {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogSender{logger: logger}
+}
+
+func (s *LogSender) Send(_ context.Context, msg *Message) error {
+	s.logger.Printf("mail: from=%s to=%s subject=%q\n%s", msg.From, msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileSender はメールを 1 通ずつ .eml ファイルとして dir に書き出す。ローカル開発・結合テスト用
+type FileSender struct {
+	dir string
+	seq atomic.Uint64
+}
+
+func NewFileSender(dir string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &FileSender{dir: dir}, nil
+}
+
+func (s *FileSender) Send(_ context.Context, msg *Message) error {
+	now := time.Now()
+	name := fmt.Sprintf("%s-%06d.eml", now.Format("20060102T150405.000000000"), s.seq.Add(1))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(msg.From))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(msg.Body)
+
+	if err := os.WriteFile(filepath.Join(s.dir, name), []byte(b.String()), 0o600); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+
+	return nil
+}
+
+// headerValue はヘッダーインジェクションを防ぐため改行を取り除く
+func headerValue(v string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+}
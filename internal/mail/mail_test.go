@@ -0,0 +1,55 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogSender_Send(t *testing.T) {
+	var buf bytes.Buffer
+	sender := NewLogSender(log.New(&buf, "", 0))
+
+	err := sender.Send(context.Background(), &Message{
+		From:    "noreply@example.com",
+		To:      "test@example.com",
+		Subject: "Hello",
+		Body:    "body text",
+	})
+
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "to=test@example.com")
+	assert.Contains(t, buf.String(), "body text")
+}
+
+func TestFileSender_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	sender, err := NewFileSender(dir)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		err := sender.Send(context.Background(), &Message{
+			From:    "noreply@example.com",
+			To:      "test@example.com",
+			Subject: "Hello",
+			Body:    "body text",
+		})
+		require.NoError(t, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	content, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "To: test@example.com\r\n")
+	assert.Contains(t, string(content), "Subject: Hello\r\n")
+	assert.Contains(t, string(content), "\r\n\r\nbody text")
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+// ContextKeyIdentity は echo.Context に認証済みの呼び出し元を格納するキー
+const ContextKeyIdentity = "identity"
+
+// JWTAuth は Authorization: Bearer ヘッダーのトークンを検証し、
+// 呼び出し元の情報をリクエストのコンテキストに格納する
+func JWTAuth(validator auth.TokenValidator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, ok := bearerToken(c.Request().Header.Get(echo.HeaderAuthorization))
+			if !ok {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="api"`)
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "missing bearer token"})
+			}
+
+			req := c.Request()
+			identity, err := validator.ValidateAccessToken(req.Context(), token)
+			if errors.Is(err, auth.ErrUnavailable) {
+				return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "identity provider is unavailable"})
+			}
+			if err != nil {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="api", error="invalid_token"`)
+				switch {
+				case errors.Is(err, auth.ErrExpiredToken):
+					return c.JSON(http.StatusUnauthorized, map[string]string{"error": "token expired"})
+				case errors.Is(err, auth.ErrRevokedToken):
+					return c.JSON(http.StatusUnauthorized, map[string]string{"error": "token revoked"})
+				case errors.Is(err, auth.ErrInvalidToken):
+					return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+				default:
+					return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to validate token"})
+				}
+			}
+
+			c.SetRequest(req.WithContext(auth.WithIdentity(req.Context(), identity)))
+			c.Set(ContextKeyIdentity, identity)
+
+			return next(c)
+		}
+	}
+}
+
+// RequireRole は呼び出し元が roles のいずれかを持つ場合のみ次のハンドラーを実行する。
+// JWTAuth の後に適用すること
+func RequireRole(roles ...model.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			identity, ok := auth.IdentityFromContext(c.Request().Context())
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			}
+
+			if !identity.HasRole(roles...) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthenticatedEcho(validator auth.TokenValidator) *echo.Echo {
+	e := echo.New()
+	e.GET("/protected", func(c echo.Context) error {
+		identity, ok := auth.IdentityFromContext(c.Request().Context())
+		if !ok {
+			return c.NoContent(http.StatusInternalServerError)
+		}
+		return c.JSON(http.StatusOK, map[string]string{
+			"user_id": identity.UserID.String(),
+			"email":   identity.Email,
+		})
+	}, JWTAuth(validator))
+	return e
+}
+
+func TestJWTAuth_ValidToken(t *testing.T) {
+	manager := auth.NewTokenManager("test-secret", time.Hour)
+	user := &model.User{ID: uuid.New(), Email: "test@example.com"}
+	token, err := manager.Generate(user)
+	require.NoError(t, err)
+
+	e := newAuthenticatedEcho(manager)
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), user.ID.String())
+	assert.Contains(t, rec.Body.String(), user.Email)
+}
+
+func TestJWTAuth_MissingToken(t *testing.T) {
+	e := newAuthenticatedEcho(auth.NewTokenManager("test-secret", time.Hour))
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get(echo.HeaderWWWAuthenticate))
+}
+
+func TestJWTAuth_InvalidScheme(t *testing.T) {
+	e := newAuthenticatedEcho(auth.NewTokenManager("test-secret", time.Hour))
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Basic dXNlcjpwYXNz")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestJWTAuth_ForgedToken(t *testing.T) {
+	user := &model.User{ID: uuid.New(), Email: "test@example.com"}
+	token, err := auth.NewTokenManager("attacker-secret", time.Hour).Generate(user)
+	require.NoError(t, err)
+
+	e := newAuthenticatedEcho(auth.NewTokenManager("test-secret", time.Hour))
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "invalid token")
+}
+
+func TestJWTAuth_ExpiredToken(t *testing.T) {
+	user := &model.User{ID: uuid.New(), Email: "test@example.com"}
+	token, err := auth.NewTokenManager("test-secret", -time.Minute).Generate(user)
+	require.NoError(t, err)
+
+	e := newAuthenticatedEcho(auth.NewTokenManager("test-secret", time.Hour))
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "token expired")
+}
+
+type stubValidator struct {
+	identity *auth.Identity
+	err      error
+}
+
+func (s stubValidator) ValidateAccessToken(_ context.Context, _ string) (*auth.Identity, error) {
+	return s.identity, s.err
+}
+
+func TestJWTAuth_SetsEchoContextIdentity(t *testing.T) {
+	identity := &auth.Identity{UserID: uuid.New(), Email: "test@example.com"}
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer anything")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	handler := JWTAuth(stubValidator{identity: identity})(func(c echo.Context) error {
+		assert.Equal(t, identity, c.Get(ContextKeyIdentity))
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	require.NoError(t, handler(c))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestJWTAuth_RevokedToken(t *testing.T) {
+	e := echo.New()
+	e.GET("/protected", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, JWTAuth(stubValidator{err: auth.ErrRevokedToken}))
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer revoked")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "token revoked")
+}
+
+func TestJWTAuth_ValidatorUnavailable(t *testing.T) {
+	e := echo.New()
+	e.GET("/protected", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, JWTAuth(stubValidator{err: fmt.Errorf("%w: introspection failed", auth.ErrUnavailable)}))
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer opaque-access-token")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "introspection")
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		identity   *auth.Identity
+		wantStatus int
+	}{
+		{
+			name:       "allowed role",
+			identity:   &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "insufficient role",
+			identity:   &auth.Identity{UserID: uuid.New(), Role: model.RoleMember},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "unauthenticated",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.identity != nil {
+				req = req.WithContext(auth.WithIdentity(req.Context(), tt.identity))
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			handler := RequireRole(model.RoleStaff, model.RoleAdmin)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			err := handler(c)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
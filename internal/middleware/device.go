@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/signature"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// HeaderDeviceKey は端末の API キーを送るヘッダー。利用者の JWT とは別に扱う
+	HeaderDeviceKey = "X-Device-Key"
+	// ContextKeyDevice は echo.Context に認証済みの端末を格納するキー
+	ContextKeyDevice = "device"
+
+	// maxDeviceBodyBytes は端末から受け付ける本文の上限。一括送信の上限（500件）のタップが十分に収まる大きさにする
+	maxDeviceBodyBytes = 1 << 20
+)
+
+// DeviceAuth は X-Device-Key ヘッダーの API キーを検証し、端末の情報をリクエストのコンテキストに格納する
+func DeviceAuth(authenticator auth.DeviceAuthenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := strings.TrimSpace(c.Request().Header.Get(HeaderDeviceKey))
+			if key == "" {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "missing device key"})
+			}
+
+			req := c.Request()
+			device, err := authenticator.AuthenticateDevice(req.Context(), key)
+			if err != nil {
+				switch {
+				case errors.Is(err, auth.ErrInvalidDeviceKey):
+					return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid device key"})
+				case errors.Is(err, auth.ErrRevokedDevice):
+					return c.JSON(http.StatusUnauthorized, map[string]string{"error": "device revoked"})
+				default:
+					return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to authenticate device"})
+				}
+			}
+
+			c.SetRequest(req.WithContext(auth.WithDevice(req.Context(), device)))
+			c.Set(ContextKeyDevice, device)
+
+			return next(c)
+		}
+	}
+}
+
+// DeviceSignature は端末からのリクエストの署名と nonce を検証する。DeviceAuth の後に適用すること
+func DeviceSignature(verifier auth.DeviceRequestVerifier) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			device, ok := auth.DeviceFromContext(req.Context())
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(c.Response(), req.Body, maxDeviceBodyBytes))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "request body too large"})
+				}
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+			}
+			// ハンドラーが本文を読めるよう元に戻す
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			signed := &signature.Request{
+				Method:    req.Method,
+				Path:      req.URL.RequestURI(),
+				Timestamp: req.Header.Get(signature.HeaderTimestamp),
+				Nonce:     req.Header.Get(signature.HeaderNonce),
+				Body:      body,
+			}
+			err = verifier.VerifyDeviceRequest(req.Context(), device, signed, req.Header.Get(signature.HeaderSignature))
+			if err != nil {
+				switch {
+				case errors.Is(err, auth.ErrInvalidSignature):
+					return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid signature"})
+				case errors.Is(err, auth.ErrStaleSignature):
+					return c.JSON(http.StatusUnauthorized, map[string]string{"error": "stale signature"})
+				case errors.Is(err, auth.ErrReplayedRequest):
+					return c.JSON(http.StatusUnauthorized, map[string]string{"error": "replayed request"})
+				case errors.Is(err, auth.ErrInvalidDeviceKey), errors.Is(err, auth.ErrRevokedDevice):
+					return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+				default:
+					return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to verify signature"})
+				}
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// Chain は middlewares を先頭から順に適用するミドルウェアにまとめる
+func Chain(middlewares ...echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// DeviceOr は X-Device-Key ヘッダーがあれば device を、なければ fallback を順に適用する。
+// 端末と利用者のどちらからも呼び出せるエンドポイントに使う
+func DeviceOr(device echo.MiddlewareFunc, fallback ...echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withDevice := device(next)
+		withFallback := Chain(fallback...)(next)
+
+		return func(c echo.Context) error {
+			if c.Request().Header.Get(HeaderDeviceKey) != "" {
+				return withDevice(c)
+			}
+			return withFallback(c)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AttendanceType string
+
+const (
+	AttendanceCheckIn  AttendanceType = "check-in"
+	AttendanceCheckOut AttendanceType = "check-out"
+)
+
+// AttendanceLog は入退室の記録。場所ごとに最後の記録が check-in であれば入室中とみなす
+type AttendanceLog struct {
+	ID         int64          `json:"id" db:"id"`
+	UserID     uuid.UUID      `json:"user_id" db:"user_id"`
+	LocationID int            `json:"location_id" db:"location_id"`
+	Type       AttendanceType `json:"type" db:"type"`
+	RecordedAt time.Time      `json:"recorded_at" db:"recorded_at"`
+	// Auto は退室し忘れた入室を締め時刻に自動で閉じた記録であることを表す
+	Auto bool `json:"auto" db:"auto"`
+	// Manual は承認された修正申請で追加した記録であることを表す
+	Manual bool `json:"manual" db:"manual"`
+}
+
+// AttendanceRequest は入室・退室の記録に使う。UserID を省略した場合は呼び出し元自身を記録する
+type AttendanceRequest struct {
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+	LocationID int        `json:"location_id" validate:"required"`
+}
+
+// AttendanceFilter は入退室ログ・セッション一覧の絞り込み条件。From は含み、To は含まない。
+// セッションは期間と重なるものを返す
+type AttendanceFilter struct {
+	UserID     *uuid.UUID
+	LocationID *int
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// TapRequest はカードリーダーからのタップ。NFCSerial は区切り文字と大文字小文字を無視して照合する。
+// 端末からのタップでは LocationID を省略すると端末の場所を使う
+type TapRequest struct {
+	NFCSerial  string `json:"nfc_serial" validate:"required"`
+	LocationID int    `json:"location_id"`
+}
+
+// TapResult はキオスクに表示するタップの結果。CheckedIn はタップ後に入室中であるかを表す。
+// Enrolled はタップでカードを登録したことを表し、その場合は入退室を記録しないため Log は nil になる
+type TapResult struct {
+	UserID    uuid.UUID      `json:"user_id"`
+	DisplayID string         `json:"display_id"`
+	Name      string         `json:"name"`
+	CheckedIn bool           `json:"checked_in"`
+	Enrolled  bool           `json:"enrolled"`
+	Log       *AttendanceLog `json:"log,omitempty"`
+}
+
+type AttendanceSessionIssue string
+
+const (
+	// SessionOpen は退室がまだ記録されていない入室中のセッション
+	SessionOpen AttendanceSessionIssue = "open"
+	// SessionMissingCheckOut は退室を記録しないまま次の入室が記録されたセッション
+	SessionMissingCheckOut AttendanceSessionIssue = "missing_check_out"
+	// SessionMissingCheckIn は対応する入室がない退室
+	SessionMissingCheckIn AttendanceSessionIssue = "missing_check_in"
+)
+
+// AttendanceSession は入室とそれに続く退室の組。揃っていない場合は Incomplete とし、Issue に理由を入れる。
+// DurationSeconds は入室と退室が揃っている場合のみ設定する
+type AttendanceSession struct {
+	UserID          uuid.UUID  `json:"user_id"`
+	LocationID      int        `json:"location_id"`
+	CheckInLogID    *int64     `json:"check_in_log_id"`
+	CheckedInAt     *time.Time `json:"checked_in_at"`
+	CheckOutLogID   *int64     `json:"check_out_log_id"`
+	CheckedOutAt    *time.Time `json:"checked_out_at"`
+	DurationSeconds *int64     `json:"duration_seconds"`
+	// AutoCheckedOut は退室が締め時刻に自動で記録されたことを表す
+	AutoCheckedOut bool                   `json:"auto_checked_out"`
+	Incomplete     bool                   `json:"incomplete"`
+	Issue          AttendanceSessionIssue `json:"issue,omitempty"`
+}
+
+type RejectedTapReason string
+
+const (
+	// RejectedTapUnknownCard はどのプロフィールにも登録されていないカード
+	RejectedTapUnknownCard RejectedTapReason = "unknown_card"
+	// RejectedTapInactiveUser は削除済みのユーザーに登録されたままのカード
+	RejectedTapInactiveUser RejectedTapReason = "inactive_user"
+)
+
+// RejectedTap は受け付けなかったタップの記録。未登録のカードを後から登録するために使う
+type RejectedTap struct {
+	ID         int64             `json:"id" db:"id"`
+	NFCSerial  string            `json:"nfc_serial" db:"nfc_serial"`
+	LocationID int               `json:"location_id" db:"location_id"`
+	Reason     RejectedTapReason `json:"reason" db:"reason"`
+	TappedAt   time.Time         `json:"tapped_at" db:"tapped_at"`
+}
+
+// BatchTap は端末がオフラインの間に保存したタップ。ClientTapID は端末が生成する一意な ID で、再送の重複を取り除くために使う
+type BatchTap struct {
+	ClientTapID string    `json:"client_tap_id" validate:"required"`
+	NFCSerial   string    `json:"nfc_serial" validate:"required"`
+	TappedAt    time.Time `json:"tapped_at" validate:"required"`
+}
+
+// TapBatchRequest は端末に保存されていたタップをまとめて送る。場所は端末に登録された場所を使う
+type TapBatchRequest struct {
+	Taps []BatchTap `json:"taps" validate:"required"`
+}
+
+type BatchTapStatus string
+
+const (
+	// BatchTapRecorded は入退室を記録したタップ
+	BatchTapRecorded BatchTapStatus = "recorded"
+	// BatchTapRejected は未登録のカードなどで受け付けなかったタップ。受け付けなかったタップとして記録する
+	BatchTapRejected BatchTapStatus = "rejected"
+	// BatchTapDuplicate は以前の送信で処理済みのタップ
+	BatchTapDuplicate BatchTapStatus = "duplicate"
+	// BatchTapInvalid は内容が不正なため処理しなかったタップ
+	BatchTapInvalid BatchTapStatus = "invalid"
+)
+
+// BatchTapResult はタップごとの処理結果。端末はいずれの結果でもタップを送信待ちから取り除いてよい
+type BatchTapResult struct {
+	ClientTapID string         `json:"client_tap_id"`
+	Status      BatchTapStatus `json:"status"`
+	Error       string         `json:"error,omitempty"`
+	Result      *TapResult     `json:"result,omitempty"`
+}
+
+type TapBatchResult struct {
+	Results []*BatchTapResult `json:"results"`
+}
+
+// DeviceTap は端末から受け付けたタップの記録。同じ端末の同じ ClientTapID を二度処理しないために残す
+type DeviceTap struct {
+	DeviceID        uuid.UUID      `db:"device_id"`
+	ClientTapID     string         `db:"client_tap_id"`
+	TappedAt        time.Time      `db:"tapped_at"`
+	Status          BatchTapStatus `db:"status"`
+	AttendanceLogID *int64         `db:"attendance_log_id"`
+	CreatedAt       time.Time      `db:"created_at"`
+}
+
+// AutoCheckoutResult は自動退室で追加した退室の記録
+type AutoCheckoutResult struct {
+	Closed int              `json:"closed"`
+	Logs   []*AttendanceLog `json:"logs"`
+}
+
+// Presence は場所に入室中のユーザー。プロフィールがない場合 DisplayID と Name は空になる
+type Presence struct {
+	UserID       uuid.UUID `json:"user_id"`
+	DisplayID    string    `json:"display_id"`
+	Name         string    `json:"name"`
+	CheckInLogID int64     `json:"check_in_log_id"`
+	CheckedInAt  time.Time `json:"checked_in_at"`
+}
+
+type PresenceEventType string
+
+const (
+	PresenceJoin  PresenceEventType = "join"
+	PresenceLeave PresenceEventType = "leave"
+)
+
+// PresenceEvent は入退室の記録による在室状況の変化。DisplayID と Name は購読者に届ける際に設定する
+type PresenceEvent struct {
+	Type       PresenceEventType `json:"type"`
+	LocationID int               `json:"location_id"`
+	UserID     uuid.UUID         `json:"user_id"`
+	DisplayID  string            `json:"display_id"`
+	Name       string            `json:"name"`
+	LogID      int64             `json:"log_id"`
+	RecordedAt time.Time         `json:"recorded_at"`
+	Auto       bool              `json:"auto"`
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CorrectionStatus string
+
+const (
+	CorrectionPending  CorrectionStatus = "pending"
+	CorrectionApproved CorrectionStatus = "approved"
+	CorrectionRejected CorrectionStatus = "rejected"
+)
+
+// AttendanceCorrection はカードの忘れやリーダーの故障で記録されなかった入退室の修正申請。
+// 承認すると AttendanceLogID に Manual の記録を追加し、既存の記録は変更しない
+type AttendanceCorrection struct {
+	ID          int64            `json:"id" db:"id"`
+	UserID      uuid.UUID        `json:"user_id" db:"user_id"`
+	LocationID  int              `json:"location_id" db:"location_id"`
+	Type        AttendanceType   `json:"type" db:"type"`
+	RecordedAt  time.Time        `json:"recorded_at" db:"recorded_at"`
+	Reason      string           `json:"reason" db:"reason"`
+	Status      CorrectionStatus `json:"status" db:"status"`
+	RequestedBy *uuid.UUID       `json:"requested_by" db:"requested_by"`
+	RequestedAt time.Time        `json:"requested_at" db:"requested_at"`
+	// ReviewedBy と ReviewedAt は承認または却下した場合のみ設定する
+	ReviewedBy      *uuid.UUID `json:"reviewed_by" db:"reviewed_by"`
+	ReviewedAt      *time.Time `json:"reviewed_at" db:"reviewed_at"`
+	ReviewComment   string     `json:"review_comment" db:"review_comment"`
+	AttendanceLogID *int64     `json:"attendance_log_id" db:"attendance_log_id"`
+}
+
+// CorrectionRequest は修正申請の内容。UserID を省略した場合は呼び出し元自身の記録を申請する
+type CorrectionRequest struct {
+	UserID     *uuid.UUID     `json:"user_id,omitempty"`
+	LocationID int            `json:"location_id" validate:"required"`
+	Type       AttendanceType `json:"type" validate:"required"`
+	RecordedAt time.Time      `json:"recorded_at" validate:"required"`
+	Reason     string         `json:"reason" validate:"required"`
+}
+
+// CorrectionReviewRequest は承認・却下に添えるコメント
+type CorrectionReviewRequest struct {
+	Comment string `json:"comment"`
+}
+
+// CorrectionFilter は修正申請一覧の絞り込み条件
+type CorrectionFilter struct {
+	UserID *uuid.UUID
+	Status *CorrectionStatus
+	Limit  int
+	Offset int
+}
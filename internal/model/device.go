@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Device は場所に設置された NFC リーダーなどの端末。API キーはハッシュのみを保存し、KeyPrefix で見分ける
+type Device struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	Name         string    `json:"name" db:"name"`
+	LocationID   int       `json:"location_id" db:"location_id"`
+	KeyPrefix    string    `json:"key_prefix" db:"key_prefix"`
+	KeyHash      string    `json:"-" db:"key_hash"`
+	KeyRotatedAt time.Time `json:"key_rotated_at" db:"key_rotated_at"`
+	// SigningSecret は暗号化したリクエスト署名の鍵。キーを再発行する前に登録した端末では nil
+	SigningSecret *string    `json:"-" db:"signing_secret"`
+	LastSeenAt    *time.Time `json:"last_seen_at" db:"last_seen_at"`
+	RevokedAt     *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedBy     *uuid.UUID `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (d *Device) Revoked() bool {
+	return d.RevokedAt != nil
+}
+
+type DeviceRequest struct {
+	Name       string `json:"name" validate:"required"`
+	LocationID int    `json:"location_id" validate:"required"`
+}
+
+// DeviceCredentials は登録時とキーの再発行時にだけ返す。APIKey と SigningSecret は再表示できない
+type DeviceCredentials struct {
+	*Device
+	APIKey        string `json:"api_key"`
+	SigningSecret string `json:"signing_secret"`
+}
@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// MasterDataKind はマスターデータの種類。API のパスとテーブル名に対応する
+type MasterDataKind string
+
+const (
+	MasterDataGrades        MasterDataKind = "grades"
+	MasterDataOrganizations MasterDataKind = "organizations"
+	MasterDataLocations     MasterDataKind = "locations"
+)
+
+// MasterDataKinds は全ての種類をシードファイルの適用順に並べたもの
+var MasterDataKinds = []MasterDataKind{MasterDataGrades, MasterDataOrganizations, MasterDataLocations}
+
+func (k MasterDataKind) Valid() bool {
+	switch k {
+	case MasterDataGrades, MasterDataOrganizations, MasterDataLocations:
+		return true
+	}
+	return false
+}
+
+// MasterDataItem は学年・所属組織・場所の共通の形。Name は学年では label 列に保存する
+type MasterDataItem struct {
+	ID         int        `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	SortOrder  int        `json:"sort_order" db:"sort_order"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty" db:"archived_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Archived はプロフィールや入退室ログから参照されているため削除せずにアーカイブしたかを返す
+func (i *MasterDataItem) Archived() bool {
+	return i.ArchivedAt != nil
+}
+
+type MasterDataRequest struct {
+	Name      string `json:"name" validate:"required"`
+	SortOrder int    `json:"sort_order"`
+}
+
+// MasterDataSeed はシードファイルの内容。種類ごとに名前で既存の行と突き合わせる
+type MasterDataSeed map[MasterDataKind][]MasterDataRequest
+
+// MasterDataSeedResult はシードの適用結果の件数
+type MasterDataSeedResult struct {
+	Created   int
+	Updated   int
+	Unchanged int
+}
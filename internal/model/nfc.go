@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NFCEnrollment はカード登録の待機。有効期限内に場所でタップされたカードを UserID のプロフィールに登録する
+type NFCEnrollment struct {
+	ID          int64      `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	LocationID  int        `json:"location_id" db:"location_id"`
+	ArmedBy     *uuid.UUID `json:"armed_by" db:"armed_by"`
+	NFCSerial   *string    `json:"nfc_serial,omitempty" db:"nfc_serial"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+type NFCEnrollmentRequest struct {
+	UserID     uuid.UUID `json:"user_id" validate:"required"`
+	LocationID int       `json:"location_id" validate:"required"`
+}
+
+// NFCReplaceRequest は紛失したカードを無効にし、新しいカードを登録する場所を指定する
+type NFCReplaceRequest struct {
+	LocationID int `json:"location_id" validate:"required"`
+}
+
+type NFCCardEventType string
+
+const (
+	NFCCardBound   NFCCardEventType = "bound"
+	NFCCardUnbound NFCCardEventType = "unbound"
+	// NFCCardLost は紛失の届け出により無効にしたカード
+	NFCCardLost NFCCardEventType = "lost"
+	// NFCCardReplaced は新しいカードの登録により置き換えられたカード
+	NFCCardReplaced NFCCardEventType = "replaced"
+)
+
+// NFCCardEvent はカードの登録・解除の履歴。ActorID は操作した管理者で、削除されると nil になる
+type NFCCardEvent struct {
+	ID        int64            `json:"id" db:"id"`
+	UserID    uuid.UUID        `json:"user_id" db:"user_id"`
+	NFCSerial string           `json:"nfc_serial" db:"nfc_serial"`
+	Event     NFCCardEventType `json:"event" db:"event"`
+	ActorID   *uuid.UUID       `json:"actor_id" db:"actor_id"`
+	CreatedAt time.Time        `json:"created_at" db:"created_at"`
+}
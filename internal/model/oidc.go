@@ -0,0 +1,22 @@
+package model
+
+type OIDCLoginStart struct {
+	AuthorizationURL string `json:"authorization_url"`
+	StateCookie      string `json:"-"`
+}
+
+type OIDCCallbackRequest struct {
+	Code             string `query:"code"`
+	State            string `query:"state"`
+	Error            string `query:"error"`
+	ErrorDescription string `query:"error_description"`
+	StateCookie      string `json:"-"`
+}
+
+// ExternalIdentity は外部 IdP が発行したトークンから得た検証済みの利用者情報
+type ExternalIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Profile はユーザーの表示用 ID・学年・所属。DisplayID は他のユーザーと重複できない
+type Profile struct {
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	DisplayID string    `json:"display_id" db:"display_id"`
+	Name      string    `json:"name" db:"name"`
+	NFCSerial *string   `json:"-" db:"nfc_serial"`
+	GradeID   *int      `json:"grade_id" db:"grade_id"`
+	OrgID     *int      `json:"org_id" db:"org_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ProfileRequest はプロフィールの作成・置き換えに使う。Name を省略した場合はユーザーの名前を使う
+type ProfileRequest struct {
+	DisplayID string `json:"display_id" validate:"required"`
+	Name      string `json:"name,omitempty"`
+	GradeID   *int   `json:"grade_id,omitempty"`
+	OrgID     *int   `json:"org_id,omitempty"`
+}
+
+// ProfileFilter はプロフィール一覧の絞り込み条件
+type ProfileFilter struct {
+	GradeID *int
+	OrgID   *int
+	Limit   int
+	Offset  int
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReportGroupBy は集計の単位
+type ReportGroupBy string
+
+const (
+	ReportByUser         ReportGroupBy = "user"
+	ReportByGrade        ReportGroupBy = "grade"
+	ReportByOrganization ReportGroupBy = "organization"
+)
+
+// ReportPeriod は集計する期間の区切り。週は月曜日から始める
+type ReportPeriod string
+
+const (
+	ReportDaily   ReportPeriod = "day"
+	ReportWeekly  ReportPeriod = "week"
+	ReportMonthly ReportPeriod = "month"
+)
+
+// ReportRequest は入退室の集計条件。From と To は Timezone での日付（YYYY-MM-DD）で、どちらの日も含む
+type ReportRequest struct {
+	GroupBy    ReportGroupBy
+	Period     ReportPeriod
+	From       string
+	To         string
+	Timezone   string
+	LocationID *int
+}
+
+// ReportFilter はリポジトリに渡す集計の条件。From は含み、To は含まない
+type ReportFilter struct {
+	GroupBy    ReportGroupBy
+	From       time.Time
+	To         time.Time
+	LocationID *int
+}
+
+// ReportSession は集計に使う入室と退室が揃ったセッション。GroupID と GroupName は集計の単位のもので、
+// 学年・所属組織が設定されていない場合は空になる
+type ReportSession struct {
+	GroupID      string
+	GroupName    string
+	DisplayID    string
+	UserID       uuid.UUID
+	CheckedInAt  time.Time
+	CheckedOutAt time.Time
+}
+
+// ReportRow はグループと期間ごとの集計。DaysPresent はユーザーと日付の組の数で、ユーザー単位では出席した日数になる。
+// FirstIn と LastOut はその期間に記録された最初の入室と最後の退室で、日をまたいだ滞在だけの期間では nil になる
+type ReportRow struct {
+	GroupID      string     `json:"group_id"`
+	GroupName    string     `json:"group_name"`
+	DisplayID    string     `json:"display_id,omitempty"`
+	PeriodStart  string     `json:"period_start"`
+	PeriodEnd    string     `json:"period_end"`
+	Users        int        `json:"users"`
+	DaysPresent  int        `json:"days_present"`
+	TotalSeconds int64      `json:"total_seconds"`
+	FirstIn      *time.Time `json:"first_in"`
+	LastOut      *time.Time `json:"last_out"`
+}
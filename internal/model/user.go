@@ -6,20 +6,59 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	AuthSourceLocal   = "local"
+	AuthSourceZitadel = "zitadel"
+)
+
+// Role はユーザーの権限。member は自分自身の参照・更新のみ、staff は全ユーザーの参照、admin は全ユーザーの管理ができる
+type Role string
+
+const (
+	RoleMember Role = "member"
+	RoleStaff  Role = "staff"
+	RoleAdmin  Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleMember, RoleStaff, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 type User struct {
-	ID        uuid.UUID  `json:"id" db:"id"`
-	Email     string     `json:"email" db:"email"`
-	Name      string     `json:"name" db:"name"`
-	Password  string     `json:"-" db:"password"`
-	CreatedAt time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	ID              uuid.UUID  `json:"id" db:"id"`
+	Email           string     `json:"email" db:"email"`
+	Name            string     `json:"name" db:"name"`
+	Password        string     `json:"-" db:"password"`
+	AuthSource      string     `json:"auth_source" db:"auth_source"`
+	Role            Role       `json:"role" db:"role"`
+	ZitadelID       *string    `json:"-" db:"zitadel_id"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at" db:"email_verified_at"`
+	PendingEmail    *string    `json:"pending_email,omitempty" db:"pending_email"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// EmailVerified は現在のメールアドレスの所有確認が済んでいるかを返す
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// HasLocalPassword は外部 IdP で管理されるユーザーのようにローカルパスワードを持たない場合に false を返す
+func (u *User) HasLocalPassword() bool {
+	return u.Password != ""
 }
 
 type CreateUserRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Name     string `json:"name" validate:"required"`
 	Password string `json:"password" validate:"required,min=8"`
+	// Profile を指定するとユーザーと同時にプロフィールを作成する
+	Profile *ProfileRequest `json:"profile,omitempty"`
 }
 
 type UpdateUserRequest struct {
@@ -27,12 +66,24 @@ type UpdateUserRequest struct {
 	Email *string `json:"email,omitempty" validate:"omitempty,email"`
 }
 
-type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+type UpdateUserRoleRequest struct {
+	Role Role `json:"role" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
 }
 
-type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+// CurrentUser は GET /users/me で返す、ログイン中のユーザー本人の情報
+type CurrentUser struct {
+	*User
+	MFAEnabled bool     `json:"mfa_enabled"`
+	Profile    *Profile `json:"profile,omitempty"`
+}
+
+// UpdateCurrentUserRequest は本人が変更できる項目だけを持つ。権限やパスワードは別のエンドポイントで変更する
+type UpdateCurrentUserRequest struct {
+	Name  *string `json:"name,omitempty"`
+	Email *string `json:"email,omitempty" validate:"omitempty,email"`
 }
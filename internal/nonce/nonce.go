@@ -0,0 +1,52 @@
+// Package nonce は使用済みの nonce を期限まで記録し、同じリクエストの再送を検出する
+package nonce
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store は使用済みの nonce を保持する。複数レプリカで共有する場合は PostgreSQL の実装を使う
+type Store interface {
+	// Remember は key を expiresAt まで記録する。期限内の同じ key が記録済みであれば false を返す
+	Remember(ctx context.Context, key string, expiresAt, now time.Time) (bool, error)
+	// Purge は before より前に期限が切れた記録を削除する
+	Purge(ctx context.Context, before time.Time) error
+}
+
+// MemoryStore はプロセス内に記録を保持する。レプリカ間では共有されない
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryStore) Remember(ctx context.Context, key string, expiresAt, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.entries[key]; ok && existing.After(now) {
+		return false, nil
+	}
+
+	s.entries[key] = expiresAt
+	return true, nil
+}
+
+func (s *MemoryStore) Purge(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, expiresAt := range s.entries {
+		if expiresAt.Before(before) {
+			delete(s.entries, key)
+		}
+	}
+	return nil
+}
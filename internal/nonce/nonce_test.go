@@ -0,0 +1,46 @@
+package nonce
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Remember(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	ok, err := store.Remember(ctx, "device:abc", now.Add(5*time.Minute), now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = store.Remember(ctx, "device:abc", now.Add(6*time.Minute), now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, ok, "reused nonce must be rejected until it expires")
+
+	ok, err = store.Remember(ctx, "other:abc", now.Add(5*time.Minute), now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = store.Remember(ctx, "device:abc", now.Add(11*time.Minute), now.Add(6*time.Minute))
+	require.NoError(t, err)
+	assert.True(t, ok, "expired nonce can be remembered again")
+}
+
+func TestMemoryStore_Purge(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	_, _ = store.Remember(ctx, "old", now.Add(-time.Minute), now.Add(-10*time.Minute))
+	_, _ = store.Remember(ctx, "fresh", now.Add(time.Minute), now)
+
+	require.NoError(t, store.Purge(ctx, now))
+
+	assert.NotContains(t, store.entries, "old")
+	assert.Contains(t, store.entries, "fresh")
+}
@@ -0,0 +1,307 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("oidc: invalid token")
+	ErrInactive     = errors.New("oidc: token is not active")
+	// ErrUnavailable はプロバイダーに接続できないか、異常な応答が返ったことを表す
+	ErrUnavailable = errors.New("oidc: provider is unavailable")
+)
+
+// opaque トークンとしてイントロスペクションに回すトークンの長さの範囲
+const (
+	minOpaqueTokenLength = 16
+	maxOpaqueTokenLength = 4096
+)
+
+var DefaultScopes = []string{"openid", "profile", "email"}
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	HTTPClient   *http.Client
+}
+
+// Claims は IdP が発行したトークンから取り出した検証済みの利用者情報
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	TokenID       string
+	IssuedAt      time.Time
+	ExpiresAt     time.Time
+}
+
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	IntrospectionEndpoint string `json:"introspection_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client は Zitadel などの OpenID Connect プロバイダーとの通信を行う。
+// ディスカバリー文書は初回利用時に取得してキャッシュする
+type Client struct {
+	cfg        Config
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	discovery *discoveryDocument
+	keys      *keySet
+}
+
+func NewClient(cfg Config) *Client {
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = DefaultScopes
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Client{
+		cfg:        cfg,
+		httpClient: httpClient,
+	}
+}
+
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	doc, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.cfg.ClientID},
+		"redirect_uri":          {c.cfg.RedirectURL},
+		"scope":                 {strings.Join(c.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return doc.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+func (c *Client) Exchange(ctx context.Context, code, codeVerifier string) (*Tokens, error) {
+	doc, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+		"client_id":     {c.cfg.ClientID},
+	}
+
+	var tokens Tokens
+	if err := c.postForm(ctx, doc.TokenEndpoint, form, &tokens); err != nil {
+		return nil, fmt.Errorf("oidc: token exchange failed: %w", err)
+	}
+
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("oidc: token response has no id_token")
+	}
+
+	return &tokens, nil
+}
+
+// VerifyAccessToken は JWT 形式のアクセストークンを JWKS で検証し、opaque トークンに見えるものだけを
+// イントロスペクションで検証する。どちらにも見えないトークンは IdP に問い合わせずに拒否する
+func (c *Client) VerifyAccessToken(ctx context.Context, raw string) (*Claims, error) {
+	if strings.Count(raw, ".") == 2 {
+		claims, err := c.parseJWT(ctx, raw)
+		if err != nil {
+			return nil, err
+		}
+		// ID トークンも同じ発行者と aud で署名されるため、ID トークンにだけ含まれるクレームで見分ける
+		if claims.Nonce != "" || claims.AccessTokenHash != "" {
+			return nil, fmt.Errorf("%w: id token is not an access token", ErrInvalidToken)
+		}
+		return claims.toClaims(), nil
+	}
+
+	if !looksOpaque(raw) {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+	return c.Introspect(ctx, raw)
+}
+
+func (c *Client) VerifyIDToken(ctx context.Context, raw, nonce string) (*Claims, error) {
+	claims, err := c.parseJWT(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	if nonce != "" && claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	}
+	return claims.toClaims(), nil
+}
+
+// looksOpaque は raw が RFC 6750 の b64token の文字だけからなり、ピリオドを含まないかを判定する
+func looksOpaque(raw string) bool {
+	if len(raw) < minOpaqueTokenLength || len(raw) > maxOpaqueTokenLength {
+		return false
+	}
+	for _, r := range raw {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case strings.ContainsRune("-_~+/=", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type introspectionResponse struct {
+	Active        bool   `json:"active"`
+	Subject       string `json:"sub"`
+	ClientID      string `json:"client_id"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	TokenID       string `json:"jti"`
+	IssuedAt      int64  `json:"iat"`
+	ExpiresAt     int64  `json:"exp"`
+	Issuer        string `json:"iss"`
+}
+
+func (c *Client) Introspect(ctx context.Context, token string) (*Claims, error) {
+	doc, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if doc.IntrospectionEndpoint == "" {
+		return nil, fmt.Errorf("%w: provider does not support introspection", ErrInvalidToken)
+	}
+
+	var resp introspectionResponse
+	if err := c.postForm(ctx, doc.IntrospectionEndpoint, url.Values{"token": {token}}, &resp); err != nil {
+		return nil, fmt.Errorf("%w: introspection failed: %w", ErrUnavailable, err)
+	}
+
+	if !resp.Active {
+		return nil, ErrInactive
+	}
+	if resp.Issuer != "" && resp.Issuer != doc.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if resp.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	}
+
+	claims := &Claims{
+		Subject:       resp.Subject,
+		Email:         resp.Email,
+		EmailVerified: resp.EmailVerified,
+		Name:          resp.Name,
+		TokenID:       resp.TokenID,
+	}
+	if resp.IssuedAt > 0 {
+		claims.IssuedAt = time.Unix(resp.IssuedAt, 0)
+	}
+	if resp.ExpiresAt > 0 {
+		claims.ExpiresAt = time.Unix(resp.ExpiresAt, 0)
+	}
+
+	return claims, nil
+}
+
+func (c *Client) discover(ctx context.Context) (*discoveryDocument, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc discoveryDocument
+	if err := c.do(req, &doc); err != nil {
+		return nil, fmt.Errorf("%w: discovery failed: %w", ErrUnavailable, err)
+	}
+
+	if strings.TrimSuffix(doc.Issuer, "/") != c.cfg.Issuer {
+		return nil, fmt.Errorf("oidc: issuer mismatch: expected %q, got %q", c.cfg.Issuer, doc.Issuer)
+	}
+
+	c.discovery = &doc
+	c.keys = newKeySet(c.httpClient, doc.JWKSURI)
+
+	return c.discovery, nil
+}
+
+func (c *Client) postForm(ctx context.Context, endpoint string, form url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+
+	return c.do(req, out)
+}
+
+func (c *Client) do(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.Unmarshal(body, out)
+}
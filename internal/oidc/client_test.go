@@ -0,0 +1,224 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T) (*Client, *oidctest.Server) {
+	t.Helper()
+
+	provider := oidctest.NewServer("test-client", "test-secret")
+	t.Cleanup(provider.Close)
+
+	client := NewClient(Config{
+		Issuer:       provider.URL,
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		RedirectURL:  "http://localhost:8080/api/v1/auth/oidc/callback",
+	})
+
+	return client, provider
+}
+
+func TestClient_AuthCodeURL(t *testing.T) {
+	client, provider := newTestClient(t)
+
+	authURL, err := client.AuthCodeURL(context.Background(), "state-value", "nonce-value", "challenge-value")
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, provider.URL+"/oauth/v2/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+
+	query := parsed.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "test-client", query.Get("client_id"))
+	assert.Equal(t, "state-value", query.Get("state"))
+	assert.Equal(t, "nonce-value", query.Get("nonce"))
+	assert.Equal(t, "challenge-value", query.Get("code_challenge"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, "openid profile email", query.Get("scope"))
+}
+
+func TestClient_ExchangeAndVerifyIDToken(t *testing.T) {
+	client, provider := newTestClient(t)
+	ctx := context.Background()
+
+	verifier, err := RandomString()
+	require.NoError(t, err)
+
+	claims := provider.Claims("zitadel-user-1", "test@example.com", "Test User")
+	claims["nonce"] = "expected-nonce"
+	code := provider.IssueCode(S256Challenge(verifier), claims)
+
+	tokens, err := client.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+
+	verified, err := client.VerifyIDToken(ctx, tokens.IDToken, "expected-nonce")
+	require.NoError(t, err)
+	assert.Equal(t, "zitadel-user-1", verified.Subject)
+	assert.Equal(t, "test@example.com", verified.Email)
+	assert.True(t, verified.EmailVerified)
+	assert.Equal(t, "Test User", verified.Name)
+}
+
+func TestClient_Exchange_WrongVerifier(t *testing.T) {
+	client, provider := newTestClient(t)
+
+	code := provider.IssueCode(S256Challenge("right-verifier"), provider.Claims("sub", "a@example.com", "A"))
+
+	tokens, err := client.Exchange(context.Background(), code, "wrong-verifier")
+
+	assert.Error(t, err)
+	assert.Nil(t, tokens)
+}
+
+func TestClient_VerifyIDToken_NonceMismatch(t *testing.T) {
+	client, provider := newTestClient(t)
+
+	claims := provider.Claims("sub", "a@example.com", "A")
+	claims["nonce"] = "other-nonce"
+
+	verified, err := client.VerifyIDToken(context.Background(), provider.SignToken(claims), "expected-nonce")
+
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Nil(t, verified)
+}
+
+func TestClient_VerifyAccessToken_JWT(t *testing.T) {
+	client, provider := newTestClient(t)
+
+	verified, err := client.VerifyAccessToken(context.Background(), provider.SignToken(provider.Claims("sub-1", "a@example.com", "A")))
+
+	require.NoError(t, err)
+	assert.Equal(t, "sub-1", verified.Subject)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), verified.ExpiresAt, 5*time.Second)
+}
+
+func TestClient_VerifyAccessToken_RejectsInvalidTokens(t *testing.T) {
+	client, provider := newTestClient(t)
+
+	tests := []struct {
+		name   string
+		mutate func(claims map[string]interface{})
+	}{
+		{
+			name:   "wrong issuer",
+			mutate: func(claims map[string]interface{}) { claims["iss"] = "https://evil.example.com" },
+		},
+		{
+			name:   "wrong audience",
+			mutate: func(claims map[string]interface{}) { claims["aud"] = []string{"other-client"} },
+		},
+		{
+			name:   "expired",
+			mutate: func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := provider.Claims("sub-1", "a@example.com", "A")
+			tt.mutate(claims)
+
+			verified, err := client.VerifyAccessToken(context.Background(), provider.SignToken(claims))
+
+			assert.ErrorIs(t, err, ErrInvalidToken)
+			assert.Nil(t, verified)
+		})
+	}
+}
+
+func TestClient_VerifyAccessToken_ForeignSigningKey(t *testing.T) {
+	client, _ := newTestClient(t)
+	other := oidctest.NewServer("test-client", "test-secret")
+	defer other.Close()
+
+	claims := other.Claims("sub-1", "a@example.com", "A")
+	verified, err := client.VerifyAccessToken(context.Background(), other.SignToken(claims))
+
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Nil(t, verified)
+}
+
+func TestClient_VerifyAccessToken_Opaque(t *testing.T) {
+	client, provider := newTestClient(t)
+	ctx := context.Background()
+
+	provider.AddOpaqueToken("opaque-access-token", provider.Claims("sub-2", "b@example.com", "B"))
+
+	verified, err := client.VerifyAccessToken(ctx, "opaque-access-token")
+	require.NoError(t, err)
+	assert.Equal(t, "sub-2", verified.Subject)
+	assert.Equal(t, "b@example.com", verified.Email)
+
+	verified, err = client.VerifyAccessToken(ctx, "unknown-opaque-token")
+	assert.ErrorIs(t, err, ErrInactive)
+	assert.Nil(t, verified)
+}
+
+func TestClient_VerifyAccessToken_RejectsIDToken(t *testing.T) {
+	client, provider := newTestClient(t)
+
+	for _, claim := range []string{"nonce", "at_hash"} {
+		t.Run(claim, func(t *testing.T) {
+			claims := provider.Claims("sub-1", "a@example.com", "A")
+			claims[claim] = "id-token-value"
+
+			verified, err := client.VerifyAccessToken(context.Background(), provider.SignToken(claims))
+
+			assert.ErrorIs(t, err, ErrInvalidToken)
+			assert.Nil(t, verified)
+		})
+	}
+}
+
+func TestClient_VerifyAccessToken_MalformedTokenIsNotIntrospected(t *testing.T) {
+	client, provider := newTestClient(t)
+
+	// イントロスペクションに回れば active と応答されるトークンでも、opaque に見えなければ拒否する
+	for _, token := range []string{"short-token", "header.payload", "a.b.c.d", "opaque token with spaces", strings.Repeat("x", 5000)} {
+		provider.AddOpaqueToken(token, provider.Claims("sub-2", "b@example.com", "B"))
+
+		verified, err := client.VerifyAccessToken(context.Background(), token)
+
+		assert.ErrorIs(t, err, ErrInvalidToken, token)
+		assert.Nil(t, verified)
+	}
+}
+
+func TestClient_VerifyAccessToken_ProviderUnavailable(t *testing.T) {
+	client, provider := newTestClient(t)
+	ctx := context.Background()
+
+	_, err := client.AuthCodeURL(ctx, "state", "nonce", "challenge")
+	require.NoError(t, err)
+	provider.Close()
+
+	verified, err := client.VerifyAccessToken(ctx, "opaque-access-token")
+
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.NotErrorIs(t, err, ErrInvalidToken)
+	assert.Nil(t, verified)
+
+	verified, err = client.VerifyAccessToken(ctx, provider.SignToken(provider.Claims("sub-1", "a@example.com", "A")))
+
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Nil(t, verified)
+}
+
+func TestS256Challenge(t *testing.T) {
+	// RFC 7636 Appendix B のテストベクタ
+	assert.Equal(t,
+		"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		S256Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"),
+	)
+}
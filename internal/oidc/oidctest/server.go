@@ -0,0 +1,204 @@
+// Package oidctest はテスト用に OpenID Connect プロバイダーを模倣する httptest サーバーを提供する
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const KeyID = "oidctest-key"
+
+type codeGrant struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+// Server はディスカバリー・JWKS・トークン・イントロスペクションの各エンドポイントを提供する
+type Server struct {
+	*httptest.Server
+
+	ClientID     string
+	ClientSecret string
+
+	key *rsa.PrivateKey
+
+	mu           sync.Mutex
+	codes        map[string]codeGrant
+	opaqueTokens map[string]map[string]interface{}
+}
+
+func NewServer(clientID, clientSecret string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		codes:        make(map[string]codeGrant),
+		opaqueTokens: make(map[string]map[string]interface{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.handleDiscovery)
+	mux.HandleFunc("/oauth/v2/keys", s.handleKeys)
+	mux.HandleFunc("/oauth/v2/token", s.handleToken)
+	mux.HandleFunc("/oauth/v2/introspect", s.handleIntrospect)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Claims は sub・email・name と、iss・aud・iat・exp を埋めたクレームを返す
+func (s *Server) Claims(subject, email, name string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            s.URL,
+		"aud":            []string{s.ClientID},
+		"sub":            subject,
+		"email":          email,
+		"email_verified": true,
+		"name":           name,
+		"jti":            subject + "-" + now.Format(time.RFC3339Nano),
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+}
+
+func (s *Server) SignToken(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = KeyID
+
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+// IssueCode は認可エンドポイントでのログイン完了を模倣し、トークンエンドポイントで交換できる認可コードを返す
+func (s *Server) IssueCode(codeChallenge string, claims jwt.MapClaims) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	code := base64.RawURLEncoding.EncodeToString(b)
+	s.codes[code] = codeGrant{challenge: codeChallenge, claims: claims}
+	return code
+}
+
+// AddOpaqueToken はイントロスペクションで active と応答する opaque トークンを登録する
+func (s *Server) AddOpaqueToken(token string, claims jwt.MapClaims) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.opaqueTokens[token] = claims
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 s.URL,
+		"authorization_endpoint": s.URL + "/oauth/v2/authorize",
+		"token_endpoint":         s.URL + "/oauth/v2/token",
+		"introspection_endpoint": s.URL + "/oauth/v2/introspect",
+		"userinfo_endpoint":      s.URL + "/oidc/v1/userinfo",
+		"jwks_uri":               s.URL + "/oauth/v2/keys",
+	})
+}
+
+func (s *Server) handleKeys(w http.ResponseWriter, _ *http.Request) {
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": KeyID,
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if !s.authenticateClient(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	if r.PostFormValue("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	s.mu.Lock()
+	grant, ok := s.codes[r.PostFormValue("code")]
+	delete(s.codes, r.PostFormValue("code"))
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": s.SignToken(grant.claims),
+		"token_type":   "Bearer",
+		"id_token":     s.SignToken(grant.claims),
+		"expires_in":   3600,
+	})
+}
+
+func (s *Server) handleIntrospect(w http.ResponseWriter, r *http.Request) {
+	if !s.authenticateClient(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mu.Lock()
+	claims, ok := s.opaqueTokens[r.PostFormValue("token")]
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusOK, map[string]bool{"active": false})
+		return
+	}
+
+	response := map[string]interface{}{"active": true}
+	for k, v := range claims {
+		response[k] = v
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) authenticateClient(r *http.Request) bool {
+	clientID, clientSecret, ok := r.BasicAuth()
+	return ok && clientID == s.ClientID && clientSecret == s.ClientSecret
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,83 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	strict := Policy{
+		MinLength:     10,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		RejectCommon:  true,
+	}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		password string
+		want     []string
+	}{
+		{
+			name:     "default policy accepts long uncommon password",
+			policy:   DefaultPolicy(),
+			password: "correct horse battery",
+		},
+		{
+			name:     "too short",
+			policy:   DefaultPolicy(),
+			password: "x7#kq",
+			want:     []string{"must be at least 8 characters"},
+		},
+		{
+			name:     "multibyte characters count as one",
+			policy:   DefaultPolicy(),
+			password: "つながるりんくです",
+		},
+		{
+			name:     "too long for bcrypt",
+			policy:   DefaultPolicy(),
+			password: strings.Repeat("a", MaxLength+1),
+			want:     []string{"must be at most 72 bytes"},
+		},
+		{
+			name:     "common password is case insensitive",
+			policy:   DefaultPolicy(),
+			password: "PassWord123",
+			want:     []string{"is too common"},
+		},
+		{
+			name:     "strict policy reports every missing class",
+			policy:   strict,
+			password: "abcdefghijk",
+			want: []string{
+				"must contain an uppercase letter",
+				"must contain a digit",
+				"must contain a symbol",
+			},
+		},
+		{
+			name:     "strict policy accepts compliant password",
+			policy:   strict,
+			password: "Tsunagu-Link-2026",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.policy.Validate(tt.password))
+		})
+	}
+}
+
+func TestIsCommon(t *testing.T) {
+	assert.True(t, IsCommon("123456"))
+	assert.True(t, IsCommon("QWERTY"))
+	assert.False(t, IsCommon("# よく使われる・漏洩済みのパスワード（小文字で比較する）"))
+	assert.False(t, IsCommon("v3ry-uncommon-passphrase"))
+}
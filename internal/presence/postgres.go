@@ -0,0 +1,87 @@
+package presence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/lib/pq"
+)
+
+// Channel は通知に使う PostgreSQL の LISTEN / NOTIFY のチャネル
+const Channel = "presence_events"
+
+// PostgresBus は PostgreSQL の NOTIFY で全てのレプリカに通知し、LISTEN で受け取った通知をプロセス内の購読者に届ける
+type PostgresBus struct {
+	db       *sql.DB
+	listener *pq.Listener
+	local    *MemoryBus
+}
+
+// NewPostgresBus は dsn への LISTEN 用の接続を開く。通知を届けるには Run を実行する
+func NewPostgresBus(db *sql.DB, dsn string) (*PostgresBus, error) {
+	listener := pq.NewListener(dsn, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Presence listener error: %v", err)
+		}
+	})
+	if err := listener.Listen(Channel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", Channel, err)
+	}
+
+	return &PostgresBus{
+		db:       db,
+		listener: listener,
+		local:    NewMemoryBus(),
+	}, nil
+}
+
+func (b *PostgresBus) Publish(ctx context.Context, event *model.PresenceEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, Channel, string(payload))
+	return err
+}
+
+func (b *PostgresBus) Subscribe(locationID int) (<-chan *model.PresenceEvent, func()) {
+	return b.local.Subscribe(locationID)
+}
+
+// Run は受け取った通知をプロセス内の購読者に届ける。ctx が終了するまで戻らない
+func (b *PostgresBus) Run(ctx context.Context) {
+	defer b.listener.Close()
+
+	ticker := time.NewTicker(90 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.local.CloseAll()
+			return
+		case n := <-b.listener.Notify:
+			if n == nil {
+				// 再接続するまでの通知を取りこぼした可能性があるため、購読者に在室状況を取り直させる
+				b.local.CloseAll()
+				continue
+			}
+			var event model.PresenceEvent
+			if err := json.Unmarshal([]byte(n.Extra), &event); err != nil {
+				log.Printf("Failed to decode presence event: %v", err)
+				continue
+			}
+			b.local.Publish(ctx, &event)
+		case <-ticker.C:
+			// 切断に気付けるよう、通知がない間も接続を確認する
+			go b.listener.Ping()
+		}
+	}
+}
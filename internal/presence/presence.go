@@ -0,0 +1,95 @@
+// Package presence は入退室の記録による在室状況の変化を購読者に届ける
+package presence
+
+import (
+	"context"
+	"sync"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+)
+
+// subscriberBuffer は購読者ごとに溜めておける通知の数。溢れた購読者は切断する
+const subscriberBuffer = 64
+
+// Publisher は入退室の記録を通知する。記録のトランザクションをコミットした後に呼び出す
+type Publisher interface {
+	Publish(ctx context.Context, event *model.PresenceEvent) error
+}
+
+// Bus は通知を場所ごとの購読者に届ける
+type Bus interface {
+	Publisher
+	// Subscribe は locationID の場所の通知を受け取るチャネルと、購読を終える関数を返す。
+	// 受信が追いつかない場合や通知を取りこぼした可能性がある場合はチャネルを閉じるため、
+	// 購読者は在室状況を取り直してから購読し直す
+	Subscribe(locationID int) (<-chan *model.PresenceEvent, func())
+}
+
+// MemoryBus はプロセス内の購読者に通知を届ける。レプリカ間では共有されない
+type MemoryBus struct {
+	mu          sync.Mutex
+	subscribers map[int]map[chan *model.PresenceEvent]struct{}
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		subscribers: make(map[int]map[chan *model.PresenceEvent]struct{}),
+	}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, event *model.PresenceEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[event.LocationID] {
+		select {
+		case ch <- event:
+		default:
+			b.remove(event.LocationID, ch)
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(locationID int) (<-chan *model.PresenceEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan *model.PresenceEvent, subscriberBuffer)
+	if b.subscribers[locationID] == nil {
+		b.subscribers[locationID] = make(map[chan *model.PresenceEvent]struct{})
+	}
+	b.subscribers[locationID][ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(locationID, ch)
+	}
+}
+
+// CloseAll は全ての購読者のチャネルを閉じる
+func (b *MemoryBus) CloseAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for locationID, channels := range b.subscribers {
+		for ch := range channels {
+			close(ch)
+		}
+		delete(b.subscribers, locationID)
+	}
+}
+
+// remove は購読者を取り除いてチャネルを閉じる。既に取り除かれていれば何もしない
+func (b *MemoryBus) remove(locationID int, ch chan *model.PresenceEvent) {
+	channels := b.subscribers[locationID]
+	if _, ok := channels[ch]; !ok {
+		return
+	}
+	delete(channels, ch)
+	close(ch)
+	if len(channels) == 0 {
+		delete(b.subscribers, locationID)
+	}
+}
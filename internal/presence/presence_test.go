@@ -0,0 +1,65 @@
+package presence
+
+import (
+	"context"
+	"testing"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBus_PublishToLocation(t *testing.T) {
+	bus := NewMemoryBus()
+	lab, unsubscribeLab := bus.Subscribe(1)
+	defer unsubscribeLab()
+	other, unsubscribeOther := bus.Subscribe(2)
+	defer unsubscribeOther()
+
+	event := &model.PresenceEvent{Type: model.PresenceJoin, LocationID: 1, UserID: uuid.New()}
+	require.NoError(t, bus.Publish(context.Background(), event))
+
+	assert.Same(t, event, <-lab)
+	assert.Empty(t, other)
+}
+
+func TestMemoryBus_Unsubscribe(t *testing.T) {
+	bus := NewMemoryBus()
+	ch, unsubscribe := bus.Subscribe(1)
+
+	unsubscribe()
+	// 二度呼び出してもよい
+	unsubscribe()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+	require.NoError(t, bus.Publish(context.Background(), &model.PresenceEvent{LocationID: 1}))
+}
+
+func TestMemoryBus_DropsSlowSubscriber(t *testing.T) {
+	bus := NewMemoryBus()
+	ch, unsubscribe := bus.Subscribe(1)
+	defer unsubscribe()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		require.NoError(t, bus.Publish(context.Background(), &model.PresenceEvent{LocationID: 1}))
+	}
+
+	received := 0
+	for range ch {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+}
+
+func TestMemoryBus_CloseAll(t *testing.T) {
+	bus := NewMemoryBus()
+	ch, unsubscribe := bus.Subscribe(1)
+
+	bus.CloseAll()
+	unsubscribe()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+}
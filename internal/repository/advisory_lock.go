@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// AdvisoryLocker は PostgreSQL のアドバイザリーロックで、複数のレプリカのうち一つだけが処理を実行するようにする
+type AdvisoryLocker interface {
+	// TryLock はロックを取得できれば true を返す。トランザクション内で使用し、コミットまたはロールバックで解放される
+	TryLock(ctx context.Context, key int64) (bool, error)
+}
+
+type advisoryLocker struct {
+	db *sql.DB
+}
+
+func NewAdvisoryLocker(db *sql.DB) AdvisoryLocker {
+	return &advisoryLocker{db: db}
+}
+
+func (l *advisoryLocker) TryLock(ctx context.Context, key int64) (bool, error) {
+	var locked bool
+	err := conn(ctx, l.db).QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, key).Scan(&locked)
+	return locked, err
+}
@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrAttendanceLogNotFound = errors.New("attendance log not found")
+	// ErrDeviceTapExists は同じ端末の同じ client_tap_id が記録済みの場合に返す
+	ErrDeviceTapExists = errors.New("device tap already recorded")
+)
+
+type AttendanceRepository interface {
+	Create(ctx context.Context, log *model.AttendanceLog) error
+	// Latest はユーザーのその場所での最新の記録を返す。記録がなければ ErrAttendanceLogNotFound を返す
+	Latest(ctx context.Context, userID uuid.UUID, locationID int) (*model.AttendanceLog, error)
+	// LatestAt は at 以前で最新の記録を返す。記録がなければ ErrAttendanceLogNotFound を返す
+	LatestAt(ctx context.Context, userID uuid.UUID, locationID int, at time.Time) (*model.AttendanceLog, error)
+	// List は新しい順に返す
+	List(ctx context.Context, filter model.AttendanceFilter) ([]*model.AttendanceLog, error)
+	// ListOpen は削除されていないユーザーの入室中の記録のうち before より前に入室したものをユーザー順に返す
+	ListOpen(ctx context.Context, before time.Time) ([]*model.AttendanceLog, error)
+	// ListPresent は削除されていないユーザーのうちその場所に入室中のユーザーを入室の古い順に返す
+	ListPresent(ctx context.Context, locationID int) ([]*model.Presence, error)
+	// ListSessions は入室と直後の退室を組にしたセッションを開始の新しい順に返す
+	ListSessions(ctx context.Context, filter model.AttendanceFilter) ([]*model.AttendanceSession, error)
+	CreateRejectedTap(ctx context.Context, tap *model.RejectedTap) error
+	// ListRejectedTaps は新しい順に返す
+	ListRejectedTaps(ctx context.Context, limit, offset int) ([]*model.RejectedTap, error)
+	// ListDeviceTaps は端末から受け付けたタップのうち clientTapIDs に含まれるものを返す
+	ListDeviceTaps(ctx context.Context, deviceID uuid.UUID, clientTapIDs []string) ([]*model.DeviceTap, error)
+	// CreateDeviceTap は同じ端末の同じ client_tap_id が記録済みであれば ErrDeviceTapExists を返す
+	CreateDeviceTap(ctx context.Context, tap *model.DeviceTap) error
+}
+
+const attendanceColumns = `id, user_id, location_id, type, recorded_at, auto, manual`
+
+func scanAttendanceLog(row rowScanner) (*model.AttendanceLog, error) {
+	log := &model.AttendanceLog{}
+	err := row.Scan(
+		&log.ID,
+		&log.UserID,
+		&log.LocationID,
+		&log.Type,
+		&log.RecordedAt,
+		&log.Auto,
+		&log.Manual,
+	)
+	if err != nil {
+		return nil, err
+	}
+	log.RecordedAt = serverLocal(log.RecordedAt)
+	return log, nil
+}
+
+type attendanceRepository struct {
+	db *sql.DB
+}
+
+func NewAttendanceRepository(db *sql.DB) AttendanceRepository {
+	return &attendanceRepository{db: db}
+}
+
+func (r *attendanceRepository) Create(ctx context.Context, log *model.AttendanceLog) error {
+	query := `
+		INSERT INTO attendance_logs (user_id, location_id, type, recorded_at, auto, manual)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	return conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		log.UserID,
+		log.LocationID,
+		log.Type,
+		toServerZone(log.RecordedAt),
+		log.Auto,
+		log.Manual,
+	).Scan(&log.ID)
+}
+
+func (r *attendanceRepository) Latest(ctx context.Context, userID uuid.UUID, locationID int) (*model.AttendanceLog, error) {
+	query := `
+		SELECT ` + attendanceColumns + `
+		FROM attendance_logs
+		WHERE user_id = $1 AND location_id = $2
+		ORDER BY recorded_at DESC, id DESC
+		LIMIT 1
+	`
+
+	log, err := scanAttendanceLog(conn(ctx, r.db).QueryRowContext(ctx, query, userID, locationID))
+	if err == sql.ErrNoRows {
+		return nil, ErrAttendanceLogNotFound
+	}
+
+	return log, err
+}
+
+func (r *attendanceRepository) LatestAt(ctx context.Context, userID uuid.UUID, locationID int, at time.Time) (*model.AttendanceLog, error) {
+	query := `
+		SELECT ` + attendanceColumns + `
+		FROM attendance_logs
+		WHERE user_id = $1 AND location_id = $2 AND recorded_at <= $3
+		ORDER BY recorded_at DESC, id DESC
+		LIMIT 1
+	`
+
+	log, err := scanAttendanceLog(conn(ctx, r.db).QueryRowContext(ctx, query, userID, locationID, toServerZone(at)))
+	if err == sql.ErrNoRows {
+		return nil, ErrAttendanceLogNotFound
+	}
+
+	return log, err
+}
+
+func (r *attendanceRepository) List(ctx context.Context, filter model.AttendanceFilter) ([]*model.AttendanceLog, error) {
+	query := `
+		SELECT ` + attendanceColumns + `
+		FROM attendance_logs
+		WHERE ($1::uuid IS NULL OR user_id = $1)
+			AND ($2::integer IS NULL OR location_id = $2)
+			AND ($3::timestamp IS NULL OR recorded_at >= $3)
+			AND ($4::timestamp IS NULL OR recorded_at < $4)
+		ORDER BY recorded_at DESC, id DESC
+		LIMIT $5 OFFSET $6
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(
+		ctx,
+		query,
+		filter.UserID,
+		filter.LocationID,
+		toServerZonePtr(filter.From),
+		toServerZonePtr(filter.To),
+		filter.Limit,
+		filter.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []*model.AttendanceLog
+	for rows.Next() {
+		log, err := scanAttendanceLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+
+	return logs, rows.Err()
+}
+
+func (r *attendanceRepository) ListOpen(ctx context.Context, before time.Time) ([]*model.AttendanceLog, error) {
+	query := `
+		SELECT ` + attendanceColumns + `
+		FROM (
+			SELECT DISTINCT ON (a.user_id, a.location_id) a.*
+			FROM attendance_logs a
+			JOIN users u ON u.id = a.user_id AND u.deleted_at IS NULL
+			ORDER BY a.user_id, a.location_id, a.recorded_at DESC, a.id DESC
+		) latest
+		WHERE type = 'check-in' AND recorded_at < $1
+		ORDER BY user_id, location_id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, toServerZone(before))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []*model.AttendanceLog
+	for rows.Next() {
+		log, err := scanAttendanceLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+
+	return logs, rows.Err()
+}
+
+func (r *attendanceRepository) ListPresent(ctx context.Context, locationID int) ([]*model.Presence, error) {
+	query := `
+		SELECT latest.user_id, COALESCE(p.display_id, ''), COALESCE(p.name, ''), latest.id, latest.recorded_at
+		FROM (
+			SELECT DISTINCT ON (a.user_id) a.id, a.user_id, a.type, a.recorded_at
+			FROM attendance_logs a
+			JOIN users u ON u.id = a.user_id AND u.deleted_at IS NULL
+			WHERE a.location_id = $1
+			ORDER BY a.user_id, a.recorded_at DESC, a.id DESC
+		) latest
+		LEFT JOIN profiles p ON p.user_id = latest.user_id
+		WHERE latest.type = 'check-in'
+		ORDER BY latest.recorded_at, latest.id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, locationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	presences := []*model.Presence{}
+	for rows.Next() {
+		presence := &model.Presence{}
+		if err := rows.Scan(&presence.UserID, &presence.DisplayID, &presence.Name, &presence.CheckInLogID, &presence.CheckedInAt); err != nil {
+			return nil, err
+		}
+		presence.CheckedInAt = serverLocal(presence.CheckedInAt)
+		presences = append(presences, presence)
+	}
+
+	return presences, rows.Err()
+}
+
+func (r *attendanceRepository) ListSessions(ctx context.Context, filter model.AttendanceFilter) ([]*model.AttendanceSession, error) {
+	// 組み合わせはユーザーと場所ごとの全ての記録から決め、期間の絞り込みは組にした後で行う
+	query := `
+		WITH events AS (
+			SELECT id, user_id, location_id, type, recorded_at,
+				LAG(type) OVER w AS prev_type,
+				LEAD(type) OVER w AS next_type,
+				LEAD(id) OVER w AS next_id,
+				LEAD(recorded_at) OVER w AS next_recorded_at,
+				LEAD(auto) OVER w AS next_auto
+			FROM attendance_logs
+			WHERE ($1::uuid IS NULL OR user_id = $1)
+				AND ($2::integer IS NULL OR location_id = $2)
+			WINDOW w AS (PARTITION BY user_id, location_id ORDER BY recorded_at, id)
+		),
+		sessions AS (
+			SELECT user_id, location_id,
+				id AS check_in_id,
+				recorded_at AS checked_in_at,
+				CASE WHEN next_type = 'check-out' THEN next_id END AS check_out_id,
+				CASE WHEN next_type = 'check-out' THEN next_recorded_at END AS checked_out_at,
+				next_type = 'check-out' AND next_auto AS auto_checked_out,
+				CASE
+					WHEN next_type IS NULL THEN 'open'
+					WHEN next_type = 'check-in' THEN 'missing_check_out'
+				END AS issue
+			FROM events
+			WHERE type = 'check-in'
+			UNION ALL
+			SELECT user_id, location_id, NULL, NULL, id, recorded_at, auto, 'missing_check_in'
+			FROM events
+			WHERE type = 'check-out' AND (prev_type IS NULL OR prev_type = 'check-out')
+		)
+		SELECT user_id, location_id, check_in_id, checked_in_at, check_out_id, checked_out_at,
+			EXTRACT(EPOCH FROM checked_out_at - checked_in_at)::bigint AS duration_seconds,
+			COALESCE(auto_checked_out, false) AS auto_checked_out,
+			COALESCE(issue, '') AS issue
+		FROM sessions
+		WHERE ($3::timestamp IS NULL
+				OR CASE
+					WHEN issue = 'open' THEN 'infinity'::timestamp
+					ELSE COALESCE(checked_out_at, checked_in_at)
+				END >= $3)
+			AND ($4::timestamp IS NULL OR COALESCE(checked_in_at, checked_out_at) < $4)
+		ORDER BY COALESCE(checked_in_at, checked_out_at) DESC, COALESCE(check_in_id, check_out_id) DESC
+		LIMIT $5 OFFSET $6
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(
+		ctx,
+		query,
+		filter.UserID,
+		filter.LocationID,
+		toServerZonePtr(filter.From),
+		toServerZonePtr(filter.To),
+		filter.Limit,
+		filter.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*model.AttendanceSession
+	for rows.Next() {
+		session := &model.AttendanceSession{}
+		err := rows.Scan(
+			&session.UserID,
+			&session.LocationID,
+			&session.CheckInLogID,
+			&session.CheckedInAt,
+			&session.CheckOutLogID,
+			&session.CheckedOutAt,
+			&session.DurationSeconds,
+			&session.AutoCheckedOut,
+			&session.Issue,
+		)
+		if err != nil {
+			return nil, err
+		}
+		session.CheckedInAt = serverLocalPtr(session.CheckedInAt)
+		session.CheckedOutAt = serverLocalPtr(session.CheckedOutAt)
+		session.Incomplete = session.Issue != ""
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *attendanceRepository) CreateRejectedTap(ctx context.Context, tap *model.RejectedTap) error {
+	query := `
+		INSERT INTO rejected_taps (nfc_serial, location_id, reason, tapped_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	return conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		tap.NFCSerial,
+		tap.LocationID,
+		tap.Reason,
+		toServerZone(tap.TappedAt),
+	).Scan(&tap.ID)
+}
+
+func (r *attendanceRepository) ListRejectedTaps(ctx context.Context, limit, offset int) ([]*model.RejectedTap, error) {
+	query := `
+		SELECT id, nfc_serial, location_id, reason, tapped_at
+		FROM rejected_taps
+		ORDER BY tapped_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var taps []*model.RejectedTap
+	for rows.Next() {
+		tap := &model.RejectedTap{}
+		if err := rows.Scan(&tap.ID, &tap.NFCSerial, &tap.LocationID, &tap.Reason, &tap.TappedAt); err != nil {
+			return nil, err
+		}
+		tap.TappedAt = serverLocal(tap.TappedAt)
+		taps = append(taps, tap)
+	}
+
+	return taps, rows.Err()
+}
+
+func (r *attendanceRepository) ListDeviceTaps(ctx context.Context, deviceID uuid.UUID, clientTapIDs []string) ([]*model.DeviceTap, error) {
+	query := `
+		SELECT device_id, client_tap_id, tapped_at, status, attendance_log_id, created_at
+		FROM device_taps
+		WHERE device_id = $1 AND client_tap_id = ANY($2)
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, deviceID, pq.Array(clientTapIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var taps []*model.DeviceTap
+	for rows.Next() {
+		tap := &model.DeviceTap{}
+		if err := rows.Scan(&tap.DeviceID, &tap.ClientTapID, &tap.TappedAt, &tap.Status, &tap.AttendanceLogID, &tap.CreatedAt); err != nil {
+			return nil, err
+		}
+		tap.TappedAt = serverLocal(tap.TappedAt)
+		taps = append(taps, tap)
+	}
+
+	return taps, rows.Err()
+}
+
+func (r *attendanceRepository) CreateDeviceTap(ctx context.Context, tap *model.DeviceTap) error {
+	query := `
+		INSERT INTO device_taps (device_id, client_tap_id, tapped_at, status, attendance_log_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		tap.DeviceID,
+		tap.ClientTapID,
+		toServerZone(tap.TappedAt),
+		tap.Status,
+		tap.AttendanceLogID,
+	).Scan(&tap.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrDeviceTapExists
+	}
+
+	return err
+}
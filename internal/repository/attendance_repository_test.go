@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRow は lib/pq が返す値をそのまま Scan の引数に書き込む
+type fakeRow []interface{}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(r[i]))
+	}
+	return nil
+}
+
+func TestScanAttendanceLog_ReturnsServerLocalTime(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	local := time.Local
+	time.Local = tokyo
+	t.Cleanup(func() { time.Local = local })
+
+	// timestamp 列の値は保存したときの壁時計の時刻のまま UTC として返る
+	stored := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	row := fakeRow{int64(1), uuid.New(), 3, model.AttendanceCheckIn, stored, false, false}
+
+	log, err := scanAttendanceLog(row)
+
+	require.NoError(t, err)
+	assert.True(t, log.RecordedAt.Equal(time.Date(2026, 10, 1, 9, 0, 0, 0, tokyo)), "got %v", log.RecordedAt)
+	assert.Equal(t, tokyo, log.RecordedAt.Location())
+}
+
+func TestToServerZonePtr(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	local := time.Local
+	time.Local = tokyo
+	t.Cleanup(func() { time.Local = local })
+
+	// 2026-10-01T00:00:00Z は東京の 09:00 として条件に使う
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	got := toServerZonePtr(&from)
+
+	require.NotNil(t, got)
+	assert.Equal(t, time.Date(2026, 10, 1, 9, 0, 0, 0, tokyo).String(), got.String())
+	assert.Nil(t, toServerZonePtr(nil))
+}
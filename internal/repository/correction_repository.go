@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+)
+
+var ErrCorrectionNotFound = errors.New("attendance correction not found")
+
+type CorrectionRepository interface {
+	Create(ctx context.Context, correction *model.AttendanceCorrection) error
+	GetByID(ctx context.Context, id int64) (*model.AttendanceCorrection, error)
+	// GetByIDForUpdate は行ロックを取得して検索する。トランザクション内で使用すること
+	GetByIDForUpdate(ctx context.Context, id int64) (*model.AttendanceCorrection, error)
+	// List は申請の新しい順に返す
+	List(ctx context.Context, filter model.CorrectionFilter) ([]*model.AttendanceCorrection, error)
+	// Review は審査の結果（状態・審査者・審査日時・コメント・追加した記録）を保存する
+	Review(ctx context.Context, correction *model.AttendanceCorrection) error
+}
+
+const correctionColumns = `id, user_id, location_id, type, recorded_at, reason, status, requested_by, requested_at, reviewed_by, reviewed_at, review_comment, attendance_log_id`
+
+func scanCorrection(row rowScanner) (*model.AttendanceCorrection, error) {
+	correction := &model.AttendanceCorrection{}
+	err := row.Scan(
+		&correction.ID,
+		&correction.UserID,
+		&correction.LocationID,
+		&correction.Type,
+		&correction.RecordedAt,
+		&correction.Reason,
+		&correction.Status,
+		&correction.RequestedBy,
+		&correction.RequestedAt,
+		&correction.ReviewedBy,
+		&correction.ReviewedAt,
+		&correction.ReviewComment,
+		&correction.AttendanceLogID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	correction.RecordedAt = serverLocal(correction.RecordedAt)
+	correction.RequestedAt = serverLocal(correction.RequestedAt)
+	correction.ReviewedAt = serverLocalPtr(correction.ReviewedAt)
+	return correction, nil
+}
+
+type correctionRepository struct {
+	db *sql.DB
+}
+
+func NewCorrectionRepository(db *sql.DB) CorrectionRepository {
+	return &correctionRepository{db: db}
+}
+
+func (r *correctionRepository) Create(ctx context.Context, correction *model.AttendanceCorrection) error {
+	query := `
+		INSERT INTO attendance_corrections (user_id, location_id, type, recorded_at, reason, status, requested_by, requested_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	return conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		correction.UserID,
+		correction.LocationID,
+		correction.Type,
+		toServerZone(correction.RecordedAt),
+		correction.Reason,
+		correction.Status,
+		correction.RequestedBy,
+		toServerZone(correction.RequestedAt),
+	).Scan(&correction.ID)
+}
+
+func (r *correctionRepository) GetByID(ctx context.Context, id int64) (*model.AttendanceCorrection, error) {
+	query := `
+		SELECT ` + correctionColumns + `
+		FROM attendance_corrections
+		WHERE id = $1
+	`
+
+	correction, err := scanCorrection(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrCorrectionNotFound
+	}
+
+	return correction, err
+}
+
+func (r *correctionRepository) GetByIDForUpdate(ctx context.Context, id int64) (*model.AttendanceCorrection, error) {
+	query := `
+		SELECT ` + correctionColumns + `
+		FROM attendance_corrections
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	correction, err := scanCorrection(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrCorrectionNotFound
+	}
+
+	return correction, err
+}
+
+func (r *correctionRepository) List(ctx context.Context, filter model.CorrectionFilter) ([]*model.AttendanceCorrection, error) {
+	query := `
+		SELECT ` + correctionColumns + `
+		FROM attendance_corrections
+		WHERE ($1::uuid IS NULL OR user_id = $1)
+			AND ($2::varchar IS NULL OR status = $2)
+		ORDER BY requested_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, filter.UserID, filter.Status, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	corrections := []*model.AttendanceCorrection{}
+	for rows.Next() {
+		correction, err := scanCorrection(rows)
+		if err != nil {
+			return nil, err
+		}
+		corrections = append(corrections, correction)
+	}
+
+	return corrections, rows.Err()
+}
+
+func (r *correctionRepository) Review(ctx context.Context, correction *model.AttendanceCorrection) error {
+	query := `
+		UPDATE attendance_corrections
+		SET status = $2, reviewed_by = $3, reviewed_at = $4, review_comment = $5, attendance_log_id = $6
+		WHERE id = $1
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		correction.ID,
+		correction.Status,
+		correction.ReviewedBy,
+		toServerZonePtr(correction.ReviewedAt),
+		correction.ReviewComment,
+		correction.AttendanceLogID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrCorrectionNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanCorrection_ReturnsServerLocalTime(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	local := time.Local
+	time.Local = tokyo
+	t.Cleanup(func() { time.Local = local })
+
+	recordedAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	requestedAt := time.Date(2026, 10, 1, 18, 0, 0, 0, time.UTC)
+	requestedBy := uuid.New()
+	row := fakeRow{
+		int64(7), uuid.New(), 3, model.AttendanceCheckIn, recordedAt, "カードを忘れた", model.CorrectionPending,
+		&requestedBy, requestedAt, (*uuid.UUID)(nil), (*time.Time)(nil), "", (*int64)(nil),
+	}
+
+	correction, err := scanCorrection(row)
+
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 1, 9, 0, 0, 0, tokyo).String(), correction.RecordedAt.String())
+	assert.Equal(t, time.Date(2026, 10, 1, 18, 0, 0, 0, tokyo).String(), correction.RequestedAt.String())
+	assert.Nil(t, correction.ReviewedAt)
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DeviceNonceRepository は端末のリクエスト署名で使用済みの nonce を保持し、nonce.Store として使う
+type DeviceNonceRepository interface {
+	Remember(ctx context.Context, key string, expiresAt, now time.Time) (bool, error)
+	Purge(ctx context.Context, before time.Time) error
+}
+
+type deviceNonceRepository struct {
+	db *sql.DB
+}
+
+func NewDeviceNonceRepository(db *sql.DB) DeviceNonceRepository {
+	return &deviceNonceRepository{db: db}
+}
+
+func (r *deviceNonceRepository) Remember(ctx context.Context, key string, expiresAt, now time.Time) (bool, error) {
+	// 同時に届いた同じ nonce の一方だけが行を追加・更新できるよう、1文で判定する
+	query := `
+		INSERT INTO device_nonces (key, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET expires_at = EXCLUDED.expires_at
+		WHERE device_nonces.expires_at <= $3
+		RETURNING key
+	`
+
+	var stored string
+	err := r.db.QueryRowContext(ctx, query, key, expiresAt, now).Scan(&stored)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *deviceNonceRepository) Purge(ctx context.Context, before time.Time) error {
+	query := `DELETE FROM device_nonces WHERE expires_at < $1`
+
+	_, err := r.db.ExecContext(ctx, query, before)
+	return err
+}
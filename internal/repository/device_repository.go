@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/google/uuid"
+)
+
+var ErrDeviceNotFound = errors.New("device not found")
+
+type DeviceRepository interface {
+	Create(ctx context.Context, device *model.Device) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Device, error)
+	GetByKeyHash(ctx context.Context, keyHash string) (*model.Device, error)
+	// List は locationID を指定するとその場所の端末のみを返す
+	List(ctx context.Context, locationID *int) ([]*model.Device, error)
+	Update(ctx context.Context, device *model.Device) error
+	// SetKey は API キーと暗号化した署名鍵を置き換える
+	SetKey(ctx context.Context, id uuid.UUID, keyPrefix, keyHash, signingSecret string, rotatedAt time.Time) error
+	Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error
+	TouchLastSeen(ctx context.Context, id uuid.UUID, seenAt time.Time) error
+}
+
+const deviceColumns = `id, name, location_id, key_prefix, key_hash, key_rotated_at, signing_secret, last_seen_at, revoked_at, created_by, created_at, updated_at`
+
+func scanDevice(row rowScanner) (*model.Device, error) {
+	device := &model.Device{}
+	err := row.Scan(
+		&device.ID,
+		&device.Name,
+		&device.LocationID,
+		&device.KeyPrefix,
+		&device.KeyHash,
+		&device.KeyRotatedAt,
+		&device.SigningSecret,
+		&device.LastSeenAt,
+		&device.RevokedAt,
+		&device.CreatedBy,
+		&device.CreatedAt,
+		&device.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	device.KeyRotatedAt = serverLocal(device.KeyRotatedAt)
+	device.LastSeenAt = serverLocalPtr(device.LastSeenAt)
+	device.RevokedAt = serverLocalPtr(device.RevokedAt)
+	device.CreatedAt = serverLocal(device.CreatedAt)
+	device.UpdatedAt = serverLocal(device.UpdatedAt)
+	return device, nil
+}
+
+type deviceRepository struct {
+	db *sql.DB
+}
+
+func NewDeviceRepository(db *sql.DB) DeviceRepository {
+	return &deviceRepository{db: db}
+}
+
+func (r *deviceRepository) Create(ctx context.Context, device *model.Device) error {
+	query := `
+		INSERT INTO devices (name, location_id, key_prefix, key_hash, key_rotated_at, signing_secret, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+
+	return conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		device.Name,
+		device.LocationID,
+		device.KeyPrefix,
+		device.KeyHash,
+		toServerZone(device.KeyRotatedAt),
+		device.SigningSecret,
+		device.CreatedBy,
+		toServerZone(device.CreatedAt),
+		toServerZone(device.UpdatedAt),
+	).Scan(&device.ID)
+}
+
+func (r *deviceRepository) get(ctx context.Context, where string, arg any) (*model.Device, error) {
+	query := `SELECT ` + deviceColumns + ` FROM devices WHERE ` + where
+
+	device, err := scanDevice(conn(ctx, r.db).QueryRowContext(ctx, query, arg))
+	if err == sql.ErrNoRows {
+		return nil, ErrDeviceNotFound
+	}
+
+	return device, err
+}
+
+func (r *deviceRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Device, error) {
+	return r.get(ctx, "id = $1", id)
+}
+
+func (r *deviceRepository) GetByKeyHash(ctx context.Context, keyHash string) (*model.Device, error) {
+	return r.get(ctx, "key_hash = $1", keyHash)
+}
+
+func (r *deviceRepository) List(ctx context.Context, locationID *int) ([]*model.Device, error) {
+	query := `
+		SELECT ` + deviceColumns + `
+		FROM devices
+		WHERE $1::integer IS NULL OR location_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, locationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []*model.Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, rows.Err()
+}
+
+func (r *deviceRepository) Update(ctx context.Context, device *model.Device) error {
+	query := `
+		UPDATE devices
+		SET name = $1, location_id = $2, updated_at = $3
+		WHERE id = $4
+	`
+
+	return expectDevice(conn(ctx, r.db).ExecContext(ctx, query, device.Name, device.LocationID, toServerZone(device.UpdatedAt), device.ID))
+}
+
+func (r *deviceRepository) SetKey(ctx context.Context, id uuid.UUID, keyPrefix, keyHash, signingSecret string, rotatedAt time.Time) error {
+	query := `
+		UPDATE devices
+		SET key_prefix = $1, key_hash = $2, signing_secret = $3, key_rotated_at = $4, updated_at = $4
+		WHERE id = $5
+	`
+
+	return expectDevice(conn(ctx, r.db).ExecContext(ctx, query, keyPrefix, keyHash, signingSecret, toServerZone(rotatedAt), id))
+}
+
+func (r *deviceRepository) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	query := `
+		UPDATE devices
+		SET revoked_at = $1, updated_at = $1
+		WHERE id = $2 AND revoked_at IS NULL
+	`
+
+	return expectDevice(conn(ctx, r.db).ExecContext(ctx, query, toServerZone(revokedAt), id))
+}
+
+func (r *deviceRepository) TouchLastSeen(ctx context.Context, id uuid.UUID, seenAt time.Time) error {
+	query := `UPDATE devices SET last_seen_at = $1 WHERE id = $2`
+
+	return expectDevice(conn(ctx, r.db).ExecContext(ctx, query, toServerZone(seenAt), id))
+}
+
+// expectDevice は更新対象の端末がなかった場合に ErrDeviceNotFound を返す
+func expectDevice(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrDeviceNotFound
+	}
+
+	return nil
+}
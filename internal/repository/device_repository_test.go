@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanDevice_ReturnsServerLocalTime(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	local := time.Local
+	time.Local = tokyo
+	t.Cleanup(func() { time.Local = local })
+
+	// timestamp 列の値は保存したときの壁時計の時刻のまま UTC として返る
+	stored := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	lastSeen := stored.Add(time.Minute)
+	secret := "encrypted-secret"
+	createdBy := uuid.New()
+	var revokedAt *time.Time
+	row := fakeRow{uuid.New(), "Entrance", 3, "tsd_abcd", "hash", stored, &secret, &lastSeen, revokedAt, &createdBy, stored, stored}
+
+	device, err := scanDevice(row)
+
+	require.NoError(t, err)
+	assert.True(t, device.KeyRotatedAt.Equal(time.Date(2026, 10, 1, 9, 0, 0, 0, tokyo)), "got %v", device.KeyRotatedAt)
+	require.NotNil(t, device.LastSeenAt)
+	assert.True(t, device.LastSeenAt.Equal(time.Date(2026, 10, 1, 9, 1, 0, 0, tokyo)), "got %v", device.LastSeenAt)
+	assert.Equal(t, tokyo, device.LastSeenAt.Location())
+	assert.Nil(t, device.RevokedAt)
+	assert.Equal(t, tokyo, device.CreatedAt.Location())
+}
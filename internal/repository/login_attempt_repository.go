@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// LoginAttemptRepository はログイン失敗の記録を保持し、lockout.Store として使う
+type LoginAttemptRepository interface {
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+	RecordFailure(ctx context.Context, key string, now, since time.Time) (int, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+	Release(ctx context.Context, key string) error
+	Purge(ctx context.Context, before time.Time) error
+}
+
+type loginAttemptRepository struct {
+	db *sql.DB
+}
+
+func NewLoginAttemptRepository(db *sql.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+func (r *loginAttemptRepository) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	query := `SELECT locked_until FROM login_attempts WHERE key = $1`
+
+	var lockedUntil sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, key).Scan(&lockedUntil)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !lockedUntil.Valid {
+		return time.Time{}, nil
+	}
+
+	return serverLocal(lockedUntil.Time), nil
+}
+
+func (r *loginAttemptRepository) RecordFailure(ctx context.Context, key string, now, since time.Time) (int, error) {
+	// 同時に失敗した場合でも取りこぼさないよう、1文で加算する
+	query := `
+		INSERT INTO login_attempts (key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.last_failure_at < $3 THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures
+	`
+
+	var failures int
+	err := r.db.QueryRowContext(ctx, query, key, toServerZone(now), toServerZone(since)).Scan(&failures)
+	return failures, err
+}
+
+func (r *loginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	query := `
+		UPDATE login_attempts
+		SET locked_until = GREATEST(COALESCE(locked_until, $2), $2)
+		WHERE key = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, key, toServerZone(until))
+	return err
+}
+
+func (r *loginAttemptRepository) Reset(ctx context.Context, key string) error {
+	query := `DELETE FROM login_attempts WHERE key = $1`
+
+	_, err := r.db.ExecContext(ctx, query, key)
+	return err
+}
+
+func (r *loginAttemptRepository) Release(ctx context.Context, key string) error {
+	query := `
+		UPDATE login_attempts
+		SET failures = GREATEST(failures - 1, 0)
+		WHERE key = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, key)
+	return err
+}
+
+func (r *loginAttemptRepository) Purge(ctx context.Context, before time.Time) error {
+	query := `
+		DELETE FROM login_attempts
+		WHERE last_failure_at < $1
+		  AND (locked_until IS NULL OR locked_until < $1)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, toServerZone(before))
+	return err
+}
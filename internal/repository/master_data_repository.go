@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrMasterDataNotFound = errors.New("master data not found")
+	// ErrMasterDataConflict は同じ種類の中で名前が重複した場合に返す
+	ErrMasterDataConflict = errors.New("master data with the same name already exists")
+)
+
+type MasterDataRepository interface {
+	// List は sort_order と id の順に返す。includeArchived が false の場合はアーカイブ済みの行を除く
+	List(ctx context.Context, kind model.MasterDataKind, includeArchived bool) ([]*model.MasterDataItem, error)
+	GetByID(ctx context.Context, kind model.MasterDataKind, id int) (*model.MasterDataItem, error)
+	Create(ctx context.Context, kind model.MasterDataKind, item *model.MasterDataItem) error
+	Update(ctx context.Context, kind model.MasterDataKind, item *model.MasterDataItem) error
+	// Delete は行を削除する。他のテーブルから参照されていて削除できない場合はアーカイブし、true を返す。
+	// 参照の確認に失敗した DELETE を使うため、トランザクションの外で呼ぶこと
+	Delete(ctx context.Context, kind model.MasterDataKind, id int) (bool, error)
+	SetArchived(ctx context.Context, kind model.MasterDataKind, id int, archived bool) error
+	// Upsert は名前が一致する行がなければ作成し、あれば sort_order を更新する。
+	// 作成した場合は created、並び順が変わった場合は updated を true で返す。アーカイブの状態は変更しない
+	Upsert(ctx context.Context, kind model.MasterDataKind, name string, sortOrder int) (created, updated bool, err error)
+}
+
+// masterDataTable は種類ごとのテーブル名と名前の列名を返す
+func masterDataTable(kind model.MasterDataKind) (table, nameColumn string, err error) {
+	switch kind {
+	case model.MasterDataGrades:
+		return "grades", "label", nil
+	case model.MasterDataOrganizations:
+		return "organizations", "name", nil
+	case model.MasterDataLocations:
+		return "locations", "name", nil
+	}
+	return "", "", fmt.Errorf("unknown master data kind %q", kind)
+}
+
+func masterDataColumns(nameColumn string) string {
+	return `id, ` + nameColumn + `, sort_order, archived_at, created_at, updated_at`
+}
+
+func scanMasterDataItem(row rowScanner) (*model.MasterDataItem, error) {
+	item := &model.MasterDataItem{}
+	err := row.Scan(
+		&item.ID,
+		&item.Name,
+		&item.SortOrder,
+		&item.ArchivedAt,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+type masterDataRepository struct {
+	db *sql.DB
+}
+
+func NewMasterDataRepository(db *sql.DB) MasterDataRepository {
+	return &masterDataRepository{db: db}
+}
+
+func (r *masterDataRepository) List(ctx context.Context, kind model.MasterDataKind, includeArchived bool) ([]*model.MasterDataItem, error) {
+	table, nameColumn, err := masterDataTable(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + masterDataColumns(nameColumn) + `
+		FROM ` + table + `
+		WHERE $1 OR archived_at IS NULL
+		ORDER BY sort_order, id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*model.MasterDataItem{}
+	for rows.Next() {
+		item, err := scanMasterDataItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *masterDataRepository) GetByID(ctx context.Context, kind model.MasterDataKind, id int) (*model.MasterDataItem, error) {
+	table, nameColumn, err := masterDataTable(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + masterDataColumns(nameColumn) + `
+		FROM ` + table + `
+		WHERE id = $1
+	`
+
+	item, err := scanMasterDataItem(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrMasterDataNotFound
+	}
+
+	return item, err
+}
+
+func (r *masterDataRepository) Create(ctx context.Context, kind model.MasterDataKind, item *model.MasterDataItem) error {
+	table, nameColumn, err := masterDataTable(kind)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO ` + table + ` (` + nameColumn + `, sort_order)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`
+
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, item.Name, item.SortOrder).
+		Scan(&item.ID, &item.CreatedAt, &item.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrMasterDataConflict
+	}
+
+	return err
+}
+
+func (r *masterDataRepository) Update(ctx context.Context, kind model.MasterDataKind, item *model.MasterDataItem) error {
+	table, nameColumn, err := masterDataTable(kind)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE ` + table + `
+		SET ` + nameColumn + ` = $1, sort_order = $2, updated_at = NOW()
+		WHERE id = $3
+		RETURNING updated_at
+	`
+
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, item.Name, item.SortOrder, item.ID).Scan(&item.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrMasterDataNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrMasterDataConflict
+	}
+
+	return err
+}
+
+func (r *masterDataRepository) Delete(ctx context.Context, kind model.MasterDataKind, id int) (bool, error) {
+	table, _, err := masterDataTable(kind)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM `+table+` WHERE id = $1`, id)
+	if isForeignKeyViolation(err) {
+		if err := r.SetArchived(ctx, kind, id, true); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if rows == 0 {
+		return false, ErrMasterDataNotFound
+	}
+
+	return false, nil
+}
+
+func (r *masterDataRepository) SetArchived(ctx context.Context, kind model.MasterDataKind, id int, archived bool) error {
+	table, _, err := masterDataTable(kind)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE ` + table + `
+		SET archived_at = CASE WHEN $1 THEN COALESCE(archived_at, NOW()) END, updated_at = NOW()
+		WHERE id = $2
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, archived, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrMasterDataNotFound
+	}
+
+	return nil
+}
+
+func (r *masterDataRepository) Upsert(ctx context.Context, kind model.MasterDataKind, name string, sortOrder int) (bool, bool, error) {
+	table, nameColumn, err := masterDataTable(kind)
+	if err != nil {
+		return false, false, err
+	}
+
+	// 並び順が同じ場合は更新せず行を返さないため、変更がなかったことを sql.ErrNoRows で判定する
+	query := `
+		INSERT INTO ` + table + ` AS t (` + nameColumn + `, sort_order)
+		VALUES ($1, $2)
+		ON CONFLICT (` + nameColumn + `) DO UPDATE
+		SET sort_order = EXCLUDED.sort_order, updated_at = NOW()
+		WHERE t.sort_order <> EXCLUDED.sort_order
+		RETURNING (xmax = 0) AS inserted
+	`
+
+	var inserted bool
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, name, sortOrder).Scan(&inserted)
+	if err == sql.ErrNoRows {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	return inserted, !inserted, nil
+}
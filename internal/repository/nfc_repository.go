@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrEnrollmentNotFound = errors.New("nfc enrollment not found")
+	// ErrNFCSerialTaken はカードが他のユーザーのプロフィールに登録されている場合に返す
+	ErrNFCSerialTaken = errors.New("nfc serial is already enrolled")
+)
+
+type NFCRepository interface {
+	CreateEnrollment(ctx context.Context, enrollment *model.NFCEnrollment) error
+	// GetActiveEnrollmentForUpdate は場所で待機中かつ有効期限内の登録を行ロックを取得して返す。トランザクション内で使用すること
+	GetActiveEnrollmentForUpdate(ctx context.Context, locationID int, now time.Time) (*model.NFCEnrollment, error)
+	CompleteEnrollment(ctx context.Context, id int64, serial string, completedAt time.Time) error
+	CancelEnrollment(ctx context.Context, id int64, cancelledAt time.Time) error
+	// CancelPendingEnrollments は場所で待機中の登録を期限切れのものも含めて取り消す
+	CancelPendingEnrollments(ctx context.Context, locationID int, cancelledAt time.Time) error
+	// SetCardSerial はプロフィールのカードを設定する。nil で解除する
+	SetCardSerial(ctx context.Context, userID uuid.UUID, serial *string) error
+	CreateCardEvent(ctx context.Context, event *model.NFCCardEvent) error
+	// ListCardEvents は新しい順に返す
+	ListCardEvents(ctx context.Context, userID uuid.UUID) ([]*model.NFCCardEvent, error)
+}
+
+const enrollmentColumns = `id, user_id, location_id, armed_by, nfc_serial, expires_at, completed_at, cancelled_at, created_at`
+
+func scanEnrollment(row rowScanner) (*model.NFCEnrollment, error) {
+	enrollment := &model.NFCEnrollment{}
+	err := row.Scan(
+		&enrollment.ID,
+		&enrollment.UserID,
+		&enrollment.LocationID,
+		&enrollment.ArmedBy,
+		&enrollment.NFCSerial,
+		&enrollment.ExpiresAt,
+		&enrollment.CompletedAt,
+		&enrollment.CancelledAt,
+		&enrollment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+type nfcRepository struct {
+	db *sql.DB
+}
+
+func NewNFCRepository(db *sql.DB) NFCRepository {
+	return &nfcRepository{db: db}
+}
+
+func (r *nfcRepository) CreateEnrollment(ctx context.Context, enrollment *model.NFCEnrollment) error {
+	query := `
+		INSERT INTO nfc_enrollments (user_id, location_id, armed_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	return conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		enrollment.UserID,
+		enrollment.LocationID,
+		enrollment.ArmedBy,
+		enrollment.ExpiresAt,
+		enrollment.CreatedAt,
+	).Scan(&enrollment.ID)
+}
+
+func (r *nfcRepository) GetActiveEnrollmentForUpdate(ctx context.Context, locationID int, now time.Time) (*model.NFCEnrollment, error) {
+	query := `
+		SELECT ` + enrollmentColumns + `
+		FROM nfc_enrollments
+		WHERE location_id = $1 AND completed_at IS NULL AND cancelled_at IS NULL AND expires_at > $2
+		FOR UPDATE
+	`
+
+	enrollment, err := scanEnrollment(conn(ctx, r.db).QueryRowContext(ctx, query, locationID, now))
+	if err == sql.ErrNoRows {
+		return nil, ErrEnrollmentNotFound
+	}
+
+	return enrollment, err
+}
+
+func (r *nfcRepository) CompleteEnrollment(ctx context.Context, id int64, serial string, completedAt time.Time) error {
+	query := `
+		UPDATE nfc_enrollments
+		SET nfc_serial = $1, completed_at = $2
+		WHERE id = $3 AND completed_at IS NULL AND cancelled_at IS NULL
+	`
+
+	return expectAffected(conn(ctx, r.db).ExecContext(ctx, query, serial, completedAt, id))
+}
+
+func (r *nfcRepository) CancelEnrollment(ctx context.Context, id int64, cancelledAt time.Time) error {
+	query := `
+		UPDATE nfc_enrollments
+		SET cancelled_at = $1
+		WHERE id = $2 AND completed_at IS NULL AND cancelled_at IS NULL
+	`
+
+	return expectAffected(conn(ctx, r.db).ExecContext(ctx, query, cancelledAt, id))
+}
+
+// expectAffected は更新対象の登録がなかった場合に ErrEnrollmentNotFound を返す
+func expectAffected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrEnrollmentNotFound
+	}
+
+	return nil
+}
+
+func (r *nfcRepository) CancelPendingEnrollments(ctx context.Context, locationID int, cancelledAt time.Time) error {
+	query := `
+		UPDATE nfc_enrollments
+		SET cancelled_at = $1
+		WHERE location_id = $2 AND completed_at IS NULL AND cancelled_at IS NULL
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, cancelledAt, locationID)
+	return err
+}
+
+func (r *nfcRepository) SetCardSerial(ctx context.Context, userID uuid.UUID, serial *string) error {
+	query := `
+		UPDATE profiles
+		SET nfc_serial = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $2
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, serial, userID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == "profiles_nfc_serial_key" {
+		return ErrNFCSerialTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrProfileNotFound
+	}
+
+	return nil
+}
+
+func (r *nfcRepository) CreateCardEvent(ctx context.Context, event *model.NFCCardEvent) error {
+	query := `
+		INSERT INTO nfc_card_events (user_id, nfc_serial, event, actor_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	return conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		event.UserID,
+		event.NFCSerial,
+		event.Event,
+		event.ActorID,
+		event.CreatedAt,
+	).Scan(&event.ID)
+}
+
+func (r *nfcRepository) ListCardEvents(ctx context.Context, userID uuid.UUID) ([]*model.NFCCardEvent, error) {
+	query := `
+		SELECT id, user_id, nfc_serial, event, actor_id, created_at
+		FROM nfc_card_events
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*model.NFCCardEvent
+	for rows.Next() {
+		event := &model.NFCCardEvent{}
+		if err := rows.Scan(&event.ID, &event.UserID, &event.NFCSerial, &event.Event, &event.ActorID, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/google/uuid"
+)
+
+type PasswordResetTokenRepository interface {
+	Create(ctx context.Context, token *model.PasswordResetToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error)
+	MarkUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	InvalidateForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type passwordResetTokenRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordResetTokenRepository(db *sql.DB) PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{db: db}
+}
+
+func (r *passwordResetTokenRepository) Create(ctx context.Context, token *model.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		token.ID,
+		token.UserID,
+		token.TokenHash,
+		toServerZone(token.ExpiresAt),
+		toServerZone(token.CreatedAt),
+	).Scan(&token.CreatedAt)
+	if err != nil {
+		return err
+	}
+	token.CreatedAt = serverLocal(token.CreatedAt)
+	return nil
+}
+
+func (r *passwordResetTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, used_at, created_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1
+	`
+
+	token := &model.PasswordResetToken{}
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("password reset token not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token.ExpiresAt = serverLocal(token.ExpiresAt)
+	token.UsedAt = serverLocalPtr(token.UsedAt)
+	token.CreatedAt = serverLocal(token.CreatedAt)
+	return token, nil
+}
+
+// MarkUsed は未使用かつ有効期限内のトークンを使用済みにする。条件を満たさない場合は false を返す
+func (r *passwordResetTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `
+		UPDATE password_reset_tokens
+		SET used_at = NOW()
+		WHERE id = $1 AND used_at IS NULL AND expires_at > $2
+	`
+
+	// 有効期限は発行時と同じくサーバーの時計で判定する
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, toServerZone(time.Now()))
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// InvalidateForUser はユーザーの未使用のトークンを全て使用済みにする
+func (r *passwordResetTokenRepository) InvalidateForUser(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE password_reset_tokens
+		SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, userID)
+	return err
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	// ErrDisplayIDTaken は display_id が他のユーザーのプロフィールと重複した場合に返す
+	ErrDisplayIDTaken       = errors.New("display id is already taken")
+	ErrGradeNotFound        = errors.New("grade not found")
+	ErrOrganizationNotFound = errors.New("organization not found")
+)
+
+type ProfileRepository interface {
+	Create(ctx context.Context, profile *model.Profile) error
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*model.Profile, error)
+	GetByDisplayID(ctx context.Context, displayID string) (*model.Profile, error)
+	GetByNFCSerial(ctx context.Context, serial string) (*model.Profile, error)
+	Update(ctx context.Context, profile *model.Profile) error
+	// List は削除されていないユーザーのプロフィールを display_id 順に返す
+	List(ctx context.Context, filter model.ProfileFilter) ([]*model.Profile, error)
+}
+
+const profileColumns = `p.user_id, p.display_id, p.name, p.nfc_serial, p.grade_id, p.org_id, p.created_at, p.updated_at`
+
+func scanProfile(row rowScanner) (*model.Profile, error) {
+	profile := &model.Profile{}
+	err := row.Scan(
+		&profile.UserID,
+		&profile.DisplayID,
+		&profile.Name,
+		&profile.NFCSerial,
+		&profile.GradeID,
+		&profile.OrgID,
+		&profile.CreatedAt,
+		&profile.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// profileWriteError は一意制約と外部キー制約の違反を呼び出し元が扱えるエラーに変換する
+func profileWriteError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Constraint {
+	case "profiles_display_id_key":
+		return ErrDisplayIDTaken
+	case "profiles_grade_id_fkey":
+		return ErrGradeNotFound
+	case "profiles_org_id_fkey":
+		return ErrOrganizationNotFound
+	}
+	return err
+}
+
+type profileRepository struct {
+	db *sql.DB
+}
+
+func NewProfileRepository(db *sql.DB) ProfileRepository {
+	return &profileRepository{db: db}
+}
+
+func (r *profileRepository) Create(ctx context.Context, profile *model.Profile) error {
+	query := `
+		INSERT INTO profiles (user_id, display_id, name, grade_id, org_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at, updated_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		profile.UserID,
+		profile.DisplayID,
+		profile.Name,
+		profile.GradeID,
+		profile.OrgID,
+		profile.CreatedAt,
+		profile.UpdatedAt,
+	).Scan(&profile.CreatedAt, &profile.UpdatedAt)
+
+	return profileWriteError(err)
+}
+
+func (r *profileRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.Profile, error) {
+	query := `
+		SELECT ` + profileColumns + `
+		FROM profiles p
+		WHERE p.user_id = $1
+	`
+
+	profile, err := scanProfile(conn(ctx, r.db).QueryRowContext(ctx, query, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrProfileNotFound
+	}
+
+	return profile, err
+}
+
+func (r *profileRepository) GetByDisplayID(ctx context.Context, displayID string) (*model.Profile, error) {
+	query := `
+		SELECT ` + profileColumns + `
+		FROM profiles p
+		WHERE p.display_id = $1
+	`
+
+	profile, err := scanProfile(conn(ctx, r.db).QueryRowContext(ctx, query, displayID))
+	if err == sql.ErrNoRows {
+		return nil, ErrProfileNotFound
+	}
+
+	return profile, err
+}
+
+func (r *profileRepository) GetByNFCSerial(ctx context.Context, serial string) (*model.Profile, error) {
+	query := `
+		SELECT ` + profileColumns + `
+		FROM profiles p
+		WHERE p.nfc_serial = $1
+	`
+
+	profile, err := scanProfile(conn(ctx, r.db).QueryRowContext(ctx, query, serial))
+	if err == sql.ErrNoRows {
+		return nil, ErrProfileNotFound
+	}
+
+	return profile, err
+}
+
+func (r *profileRepository) Update(ctx context.Context, profile *model.Profile) error {
+	query := `
+		UPDATE profiles
+		SET display_id = $1, name = $2, grade_id = $3, org_id = $4, updated_at = $5
+		WHERE user_id = $6
+		RETURNING updated_at
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		profile.DisplayID,
+		profile.Name,
+		profile.GradeID,
+		profile.OrgID,
+		profile.UpdatedAt,
+		profile.UserID,
+	).Scan(&profile.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrProfileNotFound
+	}
+
+	return profileWriteError(err)
+}
+
+func (r *profileRepository) List(ctx context.Context, filter model.ProfileFilter) ([]*model.Profile, error) {
+	query := `
+		SELECT ` + profileColumns + `
+		FROM profiles p
+		JOIN users u ON u.id = p.user_id
+		WHERE u.deleted_at IS NULL
+			AND ($1::integer IS NULL OR p.grade_id = $1)
+			AND ($2::integer IS NULL OR p.org_id = $2)
+		ORDER BY p.display_id
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, filter.GradeID, filter.OrgID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profiles []*model.Profile
+	for rows.Next() {
+		profile, err := scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, rows.Err()
+}
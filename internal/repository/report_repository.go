@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+)
+
+type ReportRepository interface {
+	// EachSession は期間と重なる入室と退室が揃ったセッションを、集計の単位ごとにまとめて入室の古い順に fn に渡す。
+	// 行を保持せずに一件ずつ渡すため、fn がエラーを返すとその時点で終了する
+	EachSession(ctx context.Context, filter model.ReportFilter, fn func(*model.ReportSession) error) error
+}
+
+type reportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+// reportGroupColumns は集計の単位の ID・名前と並び順の式を返す
+func reportGroupColumns(groupBy model.ReportGroupBy) (id, name, order string, err error) {
+	switch groupBy {
+	case model.ReportByUser:
+		return `e.user_id::text`, `COALESCE(p.name, '')`, `COALESCE(p.display_id, ''), e.user_id`, nil
+	case model.ReportByGrade:
+		return `COALESCE(p.grade_id::text, '')`, `COALESCE(g.label, '')`, `g.sort_order NULLS LAST, p.grade_id NULLS LAST`, nil
+	case model.ReportByOrganization:
+		return `COALESCE(p.org_id::text, '')`, `COALESCE(o.name, '')`, `o.sort_order NULLS LAST, p.org_id NULLS LAST`, nil
+	}
+	return "", "", "", fmt.Errorf("unknown report group %q", groupBy)
+}
+
+func (r *reportRepository) EachSession(ctx context.Context, filter model.ReportFilter, fn func(*model.ReportSession) error) error {
+	groupID, groupName, groupOrder, err := reportGroupColumns(filter.GroupBy)
+	if err != nil {
+		return err
+	}
+
+	// 入室と直後の退室の組み合わせは ListSessions と同じく全ての記録から決める
+	query := `
+		WITH events AS (
+			SELECT id, user_id, type, recorded_at,
+				LEAD(type) OVER w AS next_type,
+				LEAD(recorded_at) OVER w AS next_recorded_at
+			FROM attendance_logs
+			WHERE ($1::integer IS NULL OR location_id = $1)
+			WINDOW w AS (PARTITION BY user_id, location_id ORDER BY recorded_at, id)
+		)
+		SELECT ` + groupID + `, ` + groupName + `, COALESCE(p.display_id, ''), e.user_id, e.recorded_at, e.next_recorded_at
+		FROM events e
+		LEFT JOIN profiles p ON p.user_id = e.user_id
+		LEFT JOIN grades g ON g.id = p.grade_id
+		LEFT JOIN organizations o ON o.id = p.org_id
+		WHERE e.type = 'check-in' AND e.next_type = 'check-out'
+			AND e.next_recorded_at > $2 AND e.recorded_at < $3
+		ORDER BY ` + groupOrder + `, e.recorded_at, e.id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, filter.LocationID, toServerZone(filter.From), toServerZone(filter.To))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		session := &model.ReportSession{}
+		err := rows.Scan(
+			&session.GroupID,
+			&session.GroupName,
+			&session.DisplayID,
+			&session.UserID,
+			&session.CheckedInAt,
+			&session.CheckedOutAt,
+		)
+		if err != nil {
+			return err
+		}
+		session.CheckedInAt = serverLocal(session.CheckedInAt)
+		session.CheckedOutAt = serverLocal(session.CheckedOutAt)
+		if err := fn(session); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,33 @@
+package repository
+
+import "time"
+
+// タイムゾーンなしの timestamp 列には、時刻をサーバーのタイムゾーンで保存する。
+// lib/pq は書き込む時刻のオフセットを捨て、読み出した時刻を UTC として返すため、
+// 書き込みと読み出しの両方でサーバーのタイムゾーンに揃える
+
+// serverLocal はタイムゾーンなしで保存した時刻を、保存したときのサーバーのタイムゾーンの時刻に戻す
+func serverLocal(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+}
+
+func serverLocalPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := serverLocal(*t)
+	return &local
+}
+
+// toServerZone は書き込みや条件に使う時刻をサーバーのタイムゾーンに変換する
+func toServerZone(t time.Time) time.Time {
+	return t.In(time.Local)
+}
+
+func toServerZonePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := toServerZone(*t)
+	return &local
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TokenRevocationRepository interface {
+	RevokeToken(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) (time.Time, error)
+	GetUserRevokedAt(ctx context.Context, userID uuid.UUID) (*time.Time, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+type tokenRevocationRepository struct {
+	db *sql.DB
+}
+
+func NewTokenRevocationRepository(db *sql.DB) TokenRevocationRepository {
+	return &tokenRevocationRepository{db: db}
+}
+
+func (r *tokenRevocationRepository) RevokeToken(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query, jti, userID, toServerZone(expiresAt))
+	return err
+}
+
+func (r *tokenRevocationRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+
+	var revoked bool
+	err := r.db.QueryRowContext(ctx, query, jti).Scan(&revoked)
+	return revoked, err
+}
+
+func (r *tokenRevocationRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	query := `
+		INSERT INTO user_session_revocations (user_id, revoked_at)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET revoked_at = EXCLUDED.revoked_at
+		RETURNING revoked_at
+	`
+
+	// 失効時刻はトークンの発行時刻（iat）と比べるため、データベースではなくサーバーの時計で決める
+	var revokedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, userID, toServerZone(time.Now())).Scan(&revokedAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return serverLocal(revokedAt), nil
+}
+
+func (r *tokenRevocationRepository) GetUserRevokedAt(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	query := `SELECT revoked_at FROM user_session_revocations WHERE user_id = $1`
+
+	var revokedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	revokedAt = serverLocal(revokedAt)
+	return &revokedAt, nil
+}
+
+func (r *tokenRevocationRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM revoked_tokens WHERE expires_at < $1`
+
+	_, err := r.db.ExecContext(ctx, query, toServerZone(time.Now()))
+	return err
+}
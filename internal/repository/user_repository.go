@@ -3,21 +3,77 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrUserConflict はメールアドレスや zitadel_id が他のユーザーと重複した場合に返す
+	ErrUserConflict = errors.New("user already exists")
+	// ErrUserNotFound は削除されていないユーザーが見つからない場合に返す
+	ErrUserNotFound = errors.New("user not found")
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.User, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByZitadelID(ctx context.Context, zitadelID string) (*model.User, error)
+	GetByZitadelIDForUpdate(ctx context.Context, zitadelID string) (*model.User, error)
 	Update(ctx context.Context, user *model.User) error
+	UpdateRole(ctx context.Context, id uuid.UUID, role model.Role) error
+	UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword string) error
+	LinkZitadelID(ctx context.Context, id uuid.UUID, zitadelID string) error
+	// SetPendingEmail は確認待ちのメールアドレスを設定する。nil で取り消す
+	SetPendingEmail(ctx context.Context, id uuid.UUID, email *string) error
+	// MarkEmailVerified は現在のメールアドレスが email と一致する場合だけ確認済みにし、true を返す
+	MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) (bool, error)
+	// ApplyPendingEmail は確認待ちのメールアドレスが email と一致する場合だけ email に反映して確認済みにし、true を返す
+	ApplyPendingEmail(ctx context.Context, id uuid.UUID, email string) (bool, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, limit, offset int) ([]*model.User, error)
 }
 
+const userColumns = `id, email, name, password, auth_source, role, zitadel_id, email_verified_at, pending_email, created_at, updated_at, deleted_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner) (*model.User, error) {
+	user := &model.User{}
+	var password sql.NullString
+	err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.Name,
+		&password,
+		&user.AuthSource,
+		&user.Role,
+		&user.ZitadelID,
+		&user.EmailVerifiedAt,
+		&user.PendingEmail,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = password.String
+	return user, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 type userRepository struct {
 	db *sql.DB
 }
@@ -28,43 +84,69 @@ func NewUserRepository(db *sql.DB) UserRepository {
 
 func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 	query := `
-		INSERT INTO users (id, email, name, password, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO users (id, email, name, password, auth_source, role, zitadel_id, email_verified_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, created_at, updated_at
 	`
 
-	return r.db.QueryRowContext(
+	if user.AuthSource == "" {
+		user.AuthSource = model.AuthSourceLocal
+	}
+	if user.Role == "" {
+		user.Role = model.RoleMember
+	}
+
+	// 外部 IdP で管理するユーザーはローカルパスワードを持たない
+	password := sql.NullString{String: user.Password, Valid: user.Password != ""}
+
+	err := conn(ctx, r.db).QueryRowContext(
 		ctx,
 		query,
 		user.ID,
 		user.Email,
 		user.Name,
-		user.Password,
+		password,
+		user.AuthSource,
+		user.Role,
+		user.ZitadelID,
+		user.EmailVerifiedAt,
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrUserConflict
+	}
+
+	return err
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
 	query := `
-		SELECT id, email, name, password, created_at, updated_at, deleted_at
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	user := &model.User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Name,
-		&user.Password,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-		&user.DeletedAt,
-	)
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+
+	return user, err
+}
+
+// GetByIDForUpdate は行ロックを取得して検索する。トランザクション内で使用すること
+func (r *userRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`
 
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	return user, err
@@ -72,24 +154,46 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	query := `
-		SELECT id, email, name, password, created_at, updated_at, deleted_at
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE email = $1 AND deleted_at IS NULL
 	`
 
-	user := &model.User{}
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Name,
-		&user.Password,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-		&user.DeletedAt,
-	)
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, email))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+
+	return user, err
+}
+
+func (r *userRepository) GetByZitadelID(ctx context.Context, zitadelID string) (*model.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE zitadel_id = $1 AND deleted_at IS NULL
+	`
+
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, zitadelID))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+
+	return user, err
+}
+
+// GetByZitadelIDForUpdate は行ロックを取得して検索する。トランザクション内で使用すること
+func (r *userRepository) GetByZitadelIDForUpdate(ctx context.Context, zitadelID string) (*model.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE zitadel_id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`
 
+	user, err := scanUser(conn(ctx, r.db).QueryRowContext(ctx, query, zitadelID))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	return user, err
@@ -103,7 +207,7 @@ func (r *userRepository) Update(ctx context.Context, user *model.User) error {
 		RETURNING updated_at
 	`
 
-	return r.db.QueryRowContext(
+	err := conn(ctx, r.db).QueryRowContext(
 		ctx,
 		query,
 		user.Email,
@@ -111,6 +215,150 @@ func (r *userRepository) Update(ctx context.Context, user *model.User) error {
 		user.UpdatedAt,
 		user.ID,
 	).Scan(&user.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrUserConflict
+	}
+
+	return err
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id uuid.UUID, role model.Role) error {
+	query := `
+		UPDATE users
+		SET role = $1, updated_at = NOW()
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, role, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword string) error {
+	query := `
+		UPDATE users
+		SET password = $1, updated_at = NOW()
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, hashedPassword, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *userRepository) LinkZitadelID(ctx context.Context, id uuid.UUID, zitadelID string) error {
+	query := `
+		UPDATE users
+		SET zitadel_id = $1, updated_at = NOW()
+		WHERE id = $2 AND zitadel_id IS NULL AND deleted_at IS NULL
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, zitadelID, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("user not found or already linked")
+	}
+
+	return nil
+}
+
+func (r *userRepository) SetPendingEmail(ctx context.Context, id uuid.UUID, email *string) error {
+	query := `
+		UPDATE users
+		SET pending_email = $1, updated_at = NOW()
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, email, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) (bool, error) {
+	query := `
+		UPDATE users
+		SET email_verified_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND email = $2 AND deleted_at IS NULL
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, email)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (r *userRepository) ApplyPendingEmail(ctx context.Context, id uuid.UUID, email string) (bool, error) {
+	query := `
+		UPDATE users
+		SET email = pending_email, pending_email = NULL, email_verified_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND pending_email = $2 AND deleted_at IS NULL
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, email)
+	if isUniqueViolation(err) {
+		return false, ErrUserConflict
+	}
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
 }
 
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
@@ -120,7 +368,7 @@ func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -131,7 +379,7 @@ func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("user not found")
+		return ErrUserNotFound
 	}
 
 	return nil
@@ -139,14 +387,14 @@ func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*model.User, error) {
 	query := `
-		SELECT id, email, name, password, created_at, updated_at, deleted_at
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -154,16 +402,7 @@ func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*model.
 
 	var users []*model.User
 	for rows.Next() {
-		user := &model.User{}
-		err := rows.Scan(
-			&user.ID,
-			&user.Email,
-			&user.Name,
-			&user.Password,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-			&user.DeletedAt,
-		)
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,45 @@
+package secretbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBox_SealOpen(t *testing.T) {
+	box, err := New("test-key")
+	require.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "JBSWY3DPEHPK3PXP")
+
+	opened, err := box.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", string(opened))
+
+	// 同じ平文でも毎回異なる暗号文になる
+	again, err := box.Seal([]byte("JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+}
+
+func TestBox_Open_Rejected(t *testing.T) {
+	box, err := New("test-key")
+	require.NoError(t, err)
+	other, err := New("other-key")
+	require.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = other.Open(sealed)
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	_, err = box.Open("not base64!")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	_, err = box.Open(sealed[:len(sealed)-2])
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+}
@@ -0,0 +1,115 @@
+// Package seed はマスターデータのシードファイルを読み込む。YAML と CSV に対応する
+package seed
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported seed file format")
+
+// yamlItem は YAML の各要素。sort_order を省略した場合は 0 になる
+type yamlItem struct {
+	Name      string `yaml:"name"`
+	SortOrder int    `yaml:"sort_order"`
+}
+
+// Load は拡張子から形式を判断してシードファイルを読み込む
+func Load(path string) (model.MasterDataSeed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	case ".csv":
+		return ParseCSV(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, filepath.Ext(path))
+	}
+}
+
+// ParseYAML は種類をキー、{name, sort_order} の配列を値とする YAML を読み込む
+func ParseYAML(data []byte) (model.MasterDataSeed, error) {
+	var raw map[string][]yamlItem
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse seed yaml: %w", err)
+	}
+
+	seed := model.MasterDataSeed{}
+	for kind, items := range raw {
+		requests := make([]model.MasterDataRequest, 0, len(items))
+		for _, item := range items {
+			requests = append(requests, model.MasterDataRequest{Name: item.Name, SortOrder: item.SortOrder})
+		}
+		seed[model.MasterDataKind(kind)] = requests
+	}
+
+	return seed, nil
+}
+
+// ParseCSV は kind,name,sort_order のヘッダーを持つ CSV を読み込む。sort_order は空でもよい
+func ParseCSV(r io.Reader) (model.MasterDataSeed, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"kind", "name"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("seed csv is missing %q column", required)
+		}
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	seed := model.MasterDataSeed{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read seed csv: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		sortOrder := 0
+		if value := field(record, "sort_order"); value != "" {
+			sortOrder, err = strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sort_order on line %d: %w", line, err)
+			}
+		}
+
+		kind := model.MasterDataKind(field(record, "kind"))
+		seed[kind] = append(seed[kind], model.MasterDataRequest{Name: field(record, "name"), SortOrder: sortOrder})
+	}
+
+	return seed, nil
+}
@@ -0,0 +1,83 @@
+package seed
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseYAML(t *testing.T) {
+	seed, err := ParseYAML([]byte(`
+grades:
+  - name: 1年
+    sort_order: 1
+  - name: 2年
+    sort_order: 2
+locations:
+  - name: 部室
+`))
+
+	require.NoError(t, err)
+	assert.Equal(t, model.MasterDataSeed{
+		model.MasterDataGrades:    {{Name: "1年", SortOrder: 1}, {Name: "2年", SortOrder: 2}},
+		model.MasterDataLocations: {{Name: "部室"}},
+	}, seed)
+}
+
+func TestParseYAML_Invalid(t *testing.T) {
+	_, err := ParseYAML([]byte("grades: [name: 1年"))
+
+	assert.Error(t, err)
+}
+
+func TestParseCSV(t *testing.T) {
+	seed, err := ParseCSV(strings.NewReader("kind,name,sort_order\ngrades,1年,1\norganizations, 情報部,\nlocations,部室,3\n"))
+
+	require.NoError(t, err)
+	assert.Equal(t, model.MasterDataSeed{
+		model.MasterDataGrades:        {{Name: "1年", SortOrder: 1}},
+		model.MasterDataOrganizations: {{Name: "情報部"}},
+		model.MasterDataLocations:     {{Name: "部室", SortOrder: 3}},
+	}, seed)
+}
+
+func TestParseCSV_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "missing column", input: "kind,sort_order\ngrades,1\n", wantErr: `missing "name" column`},
+		{name: "invalid sort order", input: "kind,name,sort_order\ngrades,1年,first\n", wantErr: "invalid sort_order on line 2"},
+		{name: "empty", input: "", wantErr: "header"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCSV(strings.NewReader(tt.input))
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "master.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte("kind,name\nlocations,部室\n"), 0o600))
+	txtPath := filepath.Join(dir, "master.txt")
+	require.NoError(t, os.WriteFile(txtPath, []byte("locations"), 0o600))
+
+	seed, err := Load(csvPath)
+	require.NoError(t, err)
+	assert.Equal(t, model.MasterDataSeed{model.MasterDataLocations: {{Name: "部室"}}}, seed)
+
+	_, err = Load(txtPath)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -0,0 +1,607 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/presence"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrAlreadyCheckedIn は同じ場所で退室せずに再度入室しようとした場合に返す
+	ErrAlreadyCheckedIn = errors.New("already checked in at this location")
+	// ErrNotCheckedIn は入室していない場所から退室しようとした場合に返す
+	ErrNotCheckedIn = errors.New("not checked in at this location")
+	// ErrUnknownCard はタップされたカードが有効なユーザーに登録されていない場合に返す
+	ErrUnknownCard = errors.New("unknown card")
+	// ErrTapBatchConflict は同じタップを含む送信を別のリクエストが処理している場合に返す。端末は再送すればよい
+	ErrTapBatchConflict = errors.New("taps are being processed by another request")
+)
+
+const (
+	// maxTapBatchSize は一度に送れるタップの上限
+	maxTapBatchSize = 500
+	// tapClockSkew は端末の時計が進んでいる場合に許容する未来の時刻の幅
+	tapClockSkew = time.Minute
+)
+
+type AttendanceService interface {
+	CheckIn(ctx context.Context, caller *auth.Identity, req *model.AttendanceRequest) (*model.AttendanceLog, error)
+	CheckOut(ctx context.Context, caller *auth.Identity, req *model.AttendanceRequest) (*model.AttendanceLog, error)
+	// Tap はカードの持ち主がその場所に入室中であれば退室を、そうでなければ入室を記録する。
+	// 場所がカードの登録を待機している場合は入退室を記録せずにカードを登録する。
+	// 登録されていないカードは受け付けなかったタップとして記録し、ErrUnknownCard を返す
+	Tap(ctx context.Context, caller *auth.Identity, req *model.TapRequest) (*model.TapResult, error)
+	// DeviceTap は端末からのタップを Tap と同じように記録する。場所は端末に登録された場所に限る
+	DeviceTap(ctx context.Context, device *auth.Device, req *model.TapRequest) (*model.TapResult, error)
+	// DeviceTapBatch は端末がオフラインの間に保存したタップを一つのトランザクションで時刻順に記録する。
+	// 入退室はタップした時点の状態から決め、処理済みの client_tap_id は重複として記録しない。
+	// カードの登録待機は対象にしない
+	DeviceTapBatch(ctx context.Context, device *auth.Device, req *model.TapBatchRequest) (*model.TapBatchResult, error)
+	// ListLogs は member には自分の記録のみ、staff と admin には全ユーザーの記録を返す
+	ListLogs(ctx context.Context, caller *auth.Identity, filter model.AttendanceFilter) ([]*model.AttendanceLog, error)
+	// ListSessions は入室と退室を組にしたセッションを返す。ListLogs と同じく member には自分のセッションのみを返す
+	ListSessions(ctx context.Context, caller *auth.Identity, filter model.AttendanceFilter) ([]*model.AttendanceSession, error)
+	ListRejectedTaps(ctx context.Context, caller *auth.Identity, limit, offset int) ([]*model.RejectedTap, error)
+}
+
+type attendanceService struct {
+	attendanceRepo repository.AttendanceRepository
+	profileRepo    repository.ProfileRepository
+	userRepo       repository.UserRepository
+	masterRepo     repository.MasterDataRepository
+	enroller       CardEnroller
+	tx             repository.Transactor
+	publisher      presence.Publisher
+}
+
+func NewAttendanceService(
+	attendanceRepo repository.AttendanceRepository,
+	profileRepo repository.ProfileRepository,
+	userRepo repository.UserRepository,
+	masterRepo repository.MasterDataRepository,
+	enroller CardEnroller,
+	tx repository.Transactor,
+	publisher presence.Publisher,
+) AttendanceService {
+	return &attendanceService{
+		attendanceRepo: attendanceRepo,
+		profileRepo:    profileRepo,
+		userRepo:       userRepo,
+		masterRepo:     masterRepo,
+		enroller:       enroller,
+		tx:             tx,
+		publisher:      publisher,
+	}
+}
+
+// normalizeNFCSerial はリーダーごとに異なる区切り文字と大文字小文字を揃える
+func normalizeNFCSerial(serial string) string {
+	return strings.ToUpper(strings.NewReplacer(":", "", "-", "", " ", "").Replace(strings.TrimSpace(serial)))
+}
+
+func (s *attendanceService) CheckIn(ctx context.Context, caller *auth.Identity, req *model.AttendanceRequest) (*model.AttendanceLog, error) {
+	return s.record(ctx, caller, req, model.AttendanceCheckIn)
+}
+
+func (s *attendanceService) CheckOut(ctx context.Context, caller *auth.Identity, req *model.AttendanceRequest) (*model.AttendanceLog, error) {
+	return s.record(ctx, caller, req, model.AttendanceCheckOut)
+}
+
+// record は staff と admin が任意のユーザーを記録できることを表す。
+// member はカードのタップか修正申請で記録し、場所にいなくても記録できる直接の入退室は使えない
+func (s *attendanceService) record(ctx context.Context, caller *auth.Identity, req *model.AttendanceRequest, typ model.AttendanceType) (*model.AttendanceLog, error) {
+	if !caller.HasRole(model.RoleStaff, model.RoleAdmin) {
+		return nil, ErrForbidden
+	}
+	userID := caller.UserID
+	if req.UserID != nil {
+		userID = *req.UserID
+	}
+
+	if err := checkLocation(ctx, s.masterRepo, req.LocationID); err != nil {
+		return nil, err
+	}
+
+	return s.append(ctx, userID, req.LocationID, func(checkedIn bool) (model.AttendanceType, error) {
+		switch {
+		case typ == model.AttendanceCheckIn && checkedIn:
+			return "", ErrAlreadyCheckedIn
+		case typ == model.AttendanceCheckOut && !checkedIn:
+			return "", ErrNotCheckedIn
+		}
+		return typ, nil
+	})
+}
+
+func (s *attendanceService) Tap(ctx context.Context, caller *auth.Identity, req *model.TapRequest) (*model.TapResult, error) {
+	if !caller.HasRole(model.RoleStaff, model.RoleAdmin) {
+		return nil, ErrForbidden
+	}
+
+	return s.tap(ctx, req)
+}
+
+func (s *attendanceService) DeviceTap(ctx context.Context, device *auth.Device, req *model.TapRequest) (*model.TapResult, error) {
+	if req.LocationID == 0 {
+		req.LocationID = device.LocationID
+	}
+	if req.LocationID != device.LocationID {
+		validationErr := &ValidationError{}
+		validationErr.Add("location_id", "does not match the device location")
+		return nil, validationErr
+	}
+
+	return s.tap(ctx, req)
+}
+
+func (s *attendanceService) tap(ctx context.Context, req *model.TapRequest) (*model.TapResult, error) {
+	serial := normalizeNFCSerial(req.NFCSerial)
+	validationErr := &ValidationError{}
+	switch {
+	case serial == "":
+		validationErr.Add("nfc_serial", "is required")
+	case len(serial) > 64:
+		validationErr.Add("nfc_serial", "must be at most 64 characters")
+	}
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := checkLocation(ctx, s.masterRepo, req.LocationID); err != nil {
+		return nil, err
+	}
+
+	enrolled, err := s.enroller.CompleteEnrollment(ctx, req.LocationID, serial)
+	if err != nil {
+		return nil, err
+	}
+	if enrolled != nil {
+		checkedIn, err := s.checkedIn(ctx, enrolled.UserID, req.LocationID)
+		if err != nil {
+			return nil, err
+		}
+		return &model.TapResult{
+			UserID:    enrolled.UserID,
+			DisplayID: enrolled.DisplayID,
+			Name:      enrolled.Name,
+			CheckedIn: checkedIn,
+			Enrolled:  true,
+		}, nil
+	}
+
+	profile, err := s.profileRepo.GetByNFCSerial(ctx, serial)
+	if errors.Is(err, repository.ErrProfileNotFound) {
+		return nil, s.reject(ctx, serial, req.LocationID, model.RejectedTapUnknownCard, time.Now())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	log, err := s.append(ctx, profile.UserID, req.LocationID, func(checkedIn bool) (model.AttendanceType, error) {
+		if checkedIn {
+			return model.AttendanceCheckOut, nil
+		}
+		return model.AttendanceCheckIn, nil
+	})
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, s.reject(ctx, serial, req.LocationID, model.RejectedTapInactiveUser, time.Now())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TapResult{
+		UserID:    profile.UserID,
+		DisplayID: profile.DisplayID,
+		Name:      profile.Name,
+		CheckedIn: log.Type == model.AttendanceCheckIn,
+		Log:       log,
+	}, nil
+}
+
+// reject は受け付けなかったタップを記録し、呼び出し元に返す ErrUnknownCard を返す
+func (s *attendanceService) reject(ctx context.Context, serial string, locationID int, reason model.RejectedTapReason, tappedAt time.Time) error {
+	tap := &model.RejectedTap{
+		NFCSerial:  serial,
+		LocationID: locationID,
+		Reason:     reason,
+		TappedAt:   tappedAt,
+	}
+	if err := s.attendanceRepo.CreateRejectedTap(ctx, tap); err != nil {
+		return fmt.Errorf("failed to record rejected tap: %w", err)
+	}
+	return ErrUnknownCard
+}
+
+// append はユーザーのその場所での状態を decide に渡し、返された種類の記録を追加する
+func (s *attendanceService) append(
+	ctx context.Context,
+	userID uuid.UUID,
+	locationID int,
+	decide func(checkedIn bool) (model.AttendanceType, error),
+) (*model.AttendanceLog, error) {
+	log := &model.AttendanceLog{
+		UserID:     userID,
+		LocationID: locationID,
+		RecordedAt: time.Now(),
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// 同じユーザーの記録を直列化し、同時に届いた入室が両方とも受け付けられないようにする
+		if err := lockUser(ctx, s.userRepo, userID); err != nil {
+			return err
+		}
+
+		checkedIn, err := s.checkedIn(ctx, userID, locationID)
+		if err != nil {
+			return err
+		}
+
+		log.Type, err = decide(checkedIn)
+		if err != nil {
+			return err
+		}
+
+		return s.attendanceRepo.Create(ctx, log)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publishPresence(ctx, s.publisher, log)
+	return log, nil
+}
+
+// checkedIn はその場所での最新の記録が入室であるかを返す
+func (s *attendanceService) checkedIn(ctx context.Context, userID uuid.UUID, locationID int) (bool, error) {
+	latest, err := s.attendanceRepo.Latest(ctx, userID, locationID)
+	if errors.Is(err, repository.ErrAttendanceLogNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load attendance: %w", err)
+	}
+	return latest.Type == model.AttendanceCheckIn, nil
+}
+
+// pendingTap は一括送信のうち処理するタップ
+type pendingTap struct {
+	result   *model.BatchTapResult
+	serial   string
+	tappedAt time.Time
+	profile  *model.Profile
+}
+
+func (s *attendanceService) DeviceTapBatch(ctx context.Context, device *auth.Device, req *model.TapBatchRequest) (*model.TapBatchResult, error) {
+	validationErr := &ValidationError{}
+	switch {
+	case len(req.Taps) == 0:
+		validationErr.Add("taps", "is required")
+	case len(req.Taps) > maxTapBatchSize:
+		validationErr.Add("taps", fmt.Sprintf("must contain at most %d taps", maxTapBatchSize))
+	}
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := checkLocation(ctx, s.masterRepo, device.LocationID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	results := make([]*model.BatchTapResult, len(req.Taps))
+	var pending []*pendingTap
+	seen := make(map[string]bool, len(req.Taps))
+	for i, tap := range req.Taps {
+		results[i] = &model.BatchTapResult{ClientTapID: tap.ClientTapID}
+		serial := normalizeNFCSerial(tap.NFCSerial)
+		if msg := validateBatchTap(tap, serial, now); msg != "" {
+			results[i].Status = model.BatchTapInvalid
+			results[i].Error = msg
+			continue
+		}
+		if seen[tap.ClientTapID] {
+			results[i].Status = model.BatchTapDuplicate
+			continue
+		}
+		seen[tap.ClientTapID] = true
+		pending = append(pending, &pendingTap{
+			result:   results[i],
+			serial:   serial,
+			tappedAt: tap.TappedAt,
+		})
+	}
+	// 同じ時刻のタップは送られた順に処理する
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].tappedAt.Before(pending[j].tappedAt)
+	})
+
+	var changed []*model.AttendanceLog
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		remaining, err := s.skipProcessedTaps(ctx, device.DeviceID, pending)
+		if err != nil {
+			return err
+		}
+
+		active, err := s.lockTapUsers(ctx, remaining)
+		if err != nil {
+			return err
+		}
+		for _, tap := range remaining {
+			if err := s.replayTap(ctx, device, tap, active); err != nil {
+				return err
+			}
+		}
+
+		changed, err = s.latestReplayed(ctx, device.LocationID, remaining)
+		return err
+	})
+	if errors.Is(err, repository.ErrDeviceTapExists) {
+		return nil, ErrTapBatchConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	publishPresence(ctx, s.publisher, changed...)
+	return &model.TapBatchResult{Results: results}, nil
+}
+
+func validateBatchTap(tap model.BatchTap, serial string, now time.Time) string {
+	switch {
+	case tap.ClientTapID == "":
+		return "client_tap_id is required"
+	case len(tap.ClientTapID) > 128:
+		return "client_tap_id must be at most 128 characters"
+	case serial == "":
+		return "nfc_serial is required"
+	case len(serial) > 64:
+		return "nfc_serial must be at most 64 characters"
+	case tap.TappedAt.IsZero():
+		return "tapped_at is required"
+	case tap.TappedAt.After(now.Add(tapClockSkew)):
+		return "tapped_at must not be in the future"
+	}
+	return ""
+}
+
+// skipProcessedTaps は以前の送信で処理済みのタップを重複とし、残りのタップのカードの持ち主を調べる
+func (s *attendanceService) skipProcessedTaps(ctx context.Context, deviceID uuid.UUID, pending []*pendingTap) ([]*pendingTap, error) {
+	ids := make([]string, len(pending))
+	for i, tap := range pending {
+		ids[i] = tap.result.ClientTapID
+	}
+	processed, err := s.attendanceRepo.ListDeviceTaps(ctx, deviceID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load processed taps: %w", err)
+	}
+	done := make(map[string]bool, len(processed))
+	for _, tap := range processed {
+		done[tap.ClientTapID] = true
+	}
+
+	var remaining []*pendingTap
+	for _, tap := range pending {
+		if done[tap.result.ClientTapID] {
+			tap.result.Status = model.BatchTapDuplicate
+			continue
+		}
+
+		profile, err := s.profileRepo.GetByNFCSerial(ctx, tap.serial)
+		if err != nil && !errors.Is(err, repository.ErrProfileNotFound) {
+			return nil, fmt.Errorf("failed to load profile: %w", err)
+		}
+		tap.profile = profile
+		remaining = append(remaining, tap)
+	}
+
+	return remaining, nil
+}
+
+// lockTapUsers はカードの持ち主の行を ID 順にロックし、有効なユーザーを返す。
+// 順序を揃えることで、同じユーザーを含む一括送信が同時に届いてもデッドロックしない
+func (s *attendanceService) lockTapUsers(ctx context.Context, pending []*pendingTap) (map[uuid.UUID]bool, error) {
+	var userIDs []uuid.UUID
+	active := make(map[uuid.UUID]bool)
+	for _, tap := range pending {
+		if tap.profile == nil {
+			continue
+		}
+		if _, ok := active[tap.profile.UserID]; !ok {
+			active[tap.profile.UserID] = false
+			userIDs = append(userIDs, tap.profile.UserID)
+		}
+	}
+	sort.Slice(userIDs, func(i, j int) bool {
+		return userIDs[i].String() < userIDs[j].String()
+	})
+
+	for _, userID := range userIDs {
+		err := lockUser(ctx, s.userRepo, userID)
+		if errors.Is(err, ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		active[userID] = true
+	}
+
+	return active, nil
+}
+
+// replayTap はタップした時点の状態から入退室を決めて記録し、処理済みのタップとして残す
+func (s *attendanceService) replayTap(ctx context.Context, device *auth.Device, tap *pendingTap, active map[uuid.UUID]bool) error {
+	record := &model.DeviceTap{
+		DeviceID:    device.DeviceID,
+		ClientTapID: tap.result.ClientTapID,
+		TappedAt:    tap.tappedAt,
+	}
+
+	var reason model.RejectedTapReason
+	switch {
+	case tap.profile == nil:
+		reason = model.RejectedTapUnknownCard
+	case !active[tap.profile.UserID]:
+		reason = model.RejectedTapInactiveUser
+	}
+
+	if reason != "" {
+		// reject は記録に成功すると ErrUnknownCard を返す
+		if err := s.reject(ctx, tap.serial, device.LocationID, reason, tap.tappedAt); !errors.Is(err, ErrUnknownCard) {
+			return err
+		}
+		tap.result.Status = model.BatchTapRejected
+		tap.result.Error = ErrUnknownCard.Error()
+		record.Status = model.BatchTapRejected
+	} else {
+		checkedIn, err := s.checkedInAt(ctx, tap.profile.UserID, device.LocationID, tap.tappedAt)
+		if err != nil {
+			return err
+		}
+		log := &model.AttendanceLog{
+			UserID:     tap.profile.UserID,
+			LocationID: device.LocationID,
+			Type:       model.AttendanceCheckIn,
+			RecordedAt: tap.tappedAt,
+		}
+		if checkedIn {
+			log.Type = model.AttendanceCheckOut
+		}
+		if err := s.attendanceRepo.Create(ctx, log); err != nil {
+			return fmt.Errorf("failed to record attendance: %w", err)
+		}
+
+		tap.result.Status = model.BatchTapRecorded
+		tap.result.Result = &model.TapResult{
+			UserID:    tap.profile.UserID,
+			DisplayID: tap.profile.DisplayID,
+			Name:      tap.profile.Name,
+			CheckedIn: log.Type == model.AttendanceCheckIn,
+			Log:       log,
+		}
+		record.Status = model.BatchTapRecorded
+		record.AttendanceLogID = &log.ID
+	}
+
+	return s.attendanceRepo.CreateDeviceTap(ctx, record)
+}
+
+// latestReplayed はユーザーごとに最後に再生した記録のうち、その場所での最新の記録になったものを返す。
+// 後から記録済みの入退室より前の時刻で再生した記録は在室状況を変えないため含めない
+func (s *attendanceService) latestReplayed(ctx context.Context, locationID int, replayed []*pendingTap) ([]*model.AttendanceLog, error) {
+	var userIDs []uuid.UUID
+	last := make(map[uuid.UUID]*model.AttendanceLog)
+	for _, tap := range replayed {
+		if tap.result.Status != model.BatchTapRecorded {
+			continue
+		}
+		log := tap.result.Result.Log
+		if _, ok := last[log.UserID]; !ok {
+			userIDs = append(userIDs, log.UserID)
+		}
+		last[log.UserID] = log
+	}
+
+	var changed []*model.AttendanceLog
+	for _, userID := range userIDs {
+		latest, err := s.attendanceRepo.Latest(ctx, userID, locationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load attendance: %w", err)
+		}
+		if latest.ID == last[userID].ID {
+			changed = append(changed, last[userID])
+		}
+	}
+
+	return changed, nil
+}
+
+// checkedInAt は at 以前の最新の記録が入室であるかを返す
+func (s *attendanceService) checkedInAt(ctx context.Context, userID uuid.UUID, locationID int, at time.Time) (bool, error) {
+	latest, err := s.attendanceRepo.LatestAt(ctx, userID, locationID, at)
+	if errors.Is(err, repository.ErrAttendanceLogNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load attendance: %w", err)
+	}
+	return latest.Type == model.AttendanceCheckIn, nil
+}
+
+// checkLocation はアーカイブされていない場所であることを確認する
+func checkLocation(ctx context.Context, masterRepo repository.MasterDataRepository, locationID int) error {
+	validationErr := &ValidationError{}
+	if locationID <= 0 {
+		validationErr.Add("location_id", "is required")
+		return validationErr
+	}
+
+	location, err := masterRepo.GetByID(ctx, model.MasterDataLocations, locationID)
+	switch {
+	case errors.Is(err, repository.ErrMasterDataNotFound):
+		validationErr.Add("location_id", "does not exist")
+	case err != nil:
+		return fmt.Errorf("failed to load location: %w", err)
+	case location.Archived():
+		validationErr.Add("location_id", "is archived")
+	}
+
+	return validationErr.Err()
+}
+
+// lockUser はユーザーの行ロックを取得する。トランザクション内で使用すること
+func lockUser(ctx context.Context, userRepo repository.UserRepository, userID uuid.UUID) error {
+	_, err := userRepo.GetByIDForUpdate(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+	return nil
+}
+
+// scopeAttendanceFilter は staff と admin 以外の絞り込みを呼び出し元自身に限る
+func scopeAttendanceFilter(caller *auth.Identity, filter *model.AttendanceFilter) error {
+	if !caller.HasRole(model.RoleStaff, model.RoleAdmin) {
+		if filter.UserID != nil && *filter.UserID != caller.UserID {
+			return ErrForbidden
+		}
+		filter.UserID = &caller.UserID
+	}
+	return nil
+}
+
+func (s *attendanceService) ListLogs(ctx context.Context, caller *auth.Identity, filter model.AttendanceFilter) ([]*model.AttendanceLog, error) {
+	if err := scopeAttendanceFilter(caller, &filter); err != nil {
+		return nil, err
+	}
+
+	return s.attendanceRepo.List(ctx, filter)
+}
+
+func (s *attendanceService) ListSessions(ctx context.Context, caller *auth.Identity, filter model.AttendanceFilter) ([]*model.AttendanceSession, error) {
+	if err := scopeAttendanceFilter(caller, &filter); err != nil {
+		return nil, err
+	}
+
+	return s.attendanceRepo.ListSessions(ctx, filter)
+}
+
+func (s *attendanceService) ListRejectedTaps(ctx context.Context, caller *auth.Identity, limit, offset int) ([]*model.RejectedTap, error) {
+	if !caller.HasRole(model.RoleStaff, model.RoleAdmin) {
+		return nil, ErrForbidden
+	}
+
+	return s.attendanceRepo.ListRejectedTaps(ctx, limit, offset)
+}
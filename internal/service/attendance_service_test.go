@@ -0,0 +1,728 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/presence"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAttendanceRepository struct {
+	mock.Mock
+}
+
+func (m *MockAttendanceRepository) Create(ctx context.Context, log *model.AttendanceLog) error {
+	args := m.Called(ctx, log)
+	return args.Error(0)
+}
+
+func (m *MockAttendanceRepository) Latest(ctx context.Context, userID uuid.UUID, locationID int) (*model.AttendanceLog, error) {
+	args := m.Called(ctx, userID, locationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AttendanceLog), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) LatestAt(ctx context.Context, userID uuid.UUID, locationID int, at time.Time) (*model.AttendanceLog, error) {
+	args := m.Called(ctx, userID, locationID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AttendanceLog), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) List(ctx context.Context, filter model.AttendanceFilter) ([]*model.AttendanceLog, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.AttendanceLog), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) ListOpen(ctx context.Context, before time.Time) ([]*model.AttendanceLog, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.AttendanceLog), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) ListPresent(ctx context.Context, locationID int) ([]*model.Presence, error) {
+	args := m.Called(ctx, locationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Presence), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) ListSessions(ctx context.Context, filter model.AttendanceFilter) ([]*model.AttendanceSession, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.AttendanceSession), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) CreateRejectedTap(ctx context.Context, tap *model.RejectedTap) error {
+	args := m.Called(ctx, tap)
+	return args.Error(0)
+}
+
+func (m *MockAttendanceRepository) ListRejectedTaps(ctx context.Context, limit, offset int) ([]*model.RejectedTap, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.RejectedTap), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) ListDeviceTaps(ctx context.Context, deviceID uuid.UUID, clientTapIDs []string) ([]*model.DeviceTap, error) {
+	args := m.Called(ctx, deviceID, clientTapIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.DeviceTap), args.Error(1)
+}
+
+func (m *MockAttendanceRepository) CreateDeviceTap(ctx context.Context, tap *model.DeviceTap) error {
+	args := m.Called(ctx, tap)
+	return args.Error(0)
+}
+
+type MockCardEnroller struct {
+	mock.Mock
+}
+
+func (m *MockCardEnroller) CompleteEnrollment(ctx context.Context, locationID int, serial string) (*model.Profile, error) {
+	args := m.Called(ctx, locationID, serial)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Profile), args.Error(1)
+}
+
+type attendanceTestDeps struct {
+	attendanceRepo *MockAttendanceRepository
+	profileRepo    *MockProfileRepository
+	userRepo       *MockUserRepository
+	masterRepo     *MockMasterDataRepository
+	enroller       *MockCardEnroller
+	tx             *fakeTransactor
+	bus            *presence.MemoryBus
+}
+
+func newTestAttendanceService() (AttendanceService, *attendanceTestDeps) {
+	deps := &attendanceTestDeps{
+		attendanceRepo: new(MockAttendanceRepository),
+		profileRepo:    new(MockProfileRepository),
+		userRepo:       new(MockUserRepository),
+		masterRepo:     new(MockMasterDataRepository),
+		enroller:       new(MockCardEnroller),
+		tx:             &fakeTransactor{},
+		bus:            presence.NewMemoryBus(),
+	}
+	// 登録を待機している場所はないものとして扱う。登録のテストでは個別に上書きする
+	deps.enroller.On("CompleteEnrollment", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	return NewAttendanceService(deps.attendanceRepo, deps.profileRepo, deps.userRepo, deps.masterRepo, deps.enroller, deps.tx, deps.bus), deps
+}
+
+const testLocationID = 3
+
+func (d *attendanceTestDeps) expectRecord(ctx context.Context, userID uuid.UUID, latest *model.AttendanceLog) {
+	d.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID, Name: "部室"}, nil)
+	d.userRepo.On("GetByIDForUpdate", ctx, userID).Return(&model.User{ID: userID}, nil)
+	if latest == nil {
+		d.attendanceRepo.On("Latest", ctx, userID, testLocationID).Return(nil, repository.ErrAttendanceLogNotFound)
+	} else {
+		d.attendanceRepo.On("Latest", ctx, userID, testLocationID).Return(latest, nil)
+	}
+}
+
+func TestAttendanceService_CheckIn(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	ctx := context.Background()
+	caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	deps.expectRecord(ctx, caller.UserID, &model.AttendanceLog{Type: model.AttendanceCheckOut})
+	deps.attendanceRepo.On("Create", ctx, mock.AnythingOfType("*model.AttendanceLog")).Return(nil)
+	events, unsubscribe := deps.bus.Subscribe(testLocationID)
+	defer unsubscribe()
+
+	log, err := svc.CheckIn(ctx, caller, &model.AttendanceRequest{LocationID: testLocationID})
+
+	require.NoError(t, err)
+	assert.Equal(t, caller.UserID, log.UserID)
+	assert.Equal(t, testLocationID, log.LocationID)
+	assert.Equal(t, model.AttendanceCheckIn, log.Type)
+	assert.WithinDuration(t, time.Now(), log.RecordedAt, time.Second)
+	assert.Equal(t, 1, deps.tx.calls)
+	require.Len(t, events, 1)
+	event := <-events
+	assert.Equal(t, model.PresenceJoin, event.Type)
+	assert.Equal(t, caller.UserID, event.UserID)
+}
+
+func TestAttendanceService_CheckIn_AlreadyCheckedIn(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	ctx := context.Background()
+	caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	deps.expectRecord(ctx, caller.UserID, &model.AttendanceLog{Type: model.AttendanceCheckIn})
+
+	log, err := svc.CheckIn(ctx, caller, &model.AttendanceRequest{LocationID: testLocationID})
+
+	require.ErrorIs(t, err, ErrAlreadyCheckedIn)
+	assert.Nil(t, log)
+	deps.attendanceRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAttendanceService_CheckOut(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	ctx := context.Background()
+	caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	userID := uuid.New()
+	deps.expectRecord(ctx, userID, &model.AttendanceLog{Type: model.AttendanceCheckIn})
+	deps.attendanceRepo.On("Create", ctx, mock.AnythingOfType("*model.AttendanceLog")).Return(nil)
+
+	log, err := svc.CheckOut(ctx, caller, &model.AttendanceRequest{UserID: &userID, LocationID: testLocationID})
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, log.UserID)
+	assert.Equal(t, model.AttendanceCheckOut, log.Type)
+}
+
+func TestAttendanceService_CheckOut_NotCheckedIn(t *testing.T) {
+	tests := []struct {
+		name   string
+		latest *model.AttendanceLog
+	}{
+		{name: "no logs"},
+		{name: "already checked out", latest: &model.AttendanceLog{Type: model.AttendanceCheckOut}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deps := newTestAttendanceService()
+
+			ctx := context.Background()
+			caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+			deps.expectRecord(ctx, caller.UserID, tt.latest)
+
+			log, err := svc.CheckOut(ctx, caller, &model.AttendanceRequest{LocationID: testLocationID})
+
+			require.ErrorIs(t, err, ErrNotCheckedIn)
+			assert.Nil(t, log)
+			deps.attendanceRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAttendanceService_CheckIn_Rejected(t *testing.T) {
+	archivedAt := time.Now()
+
+	tests := []struct {
+		name     string
+		req      *model.AttendanceRequest
+		location *model.MasterDataItem
+		wantErr  error
+		message  string
+	}{
+		{
+			name:    "missing location",
+			req:     &model.AttendanceRequest{},
+			message: "is required",
+		},
+		{
+			name:    "unknown location",
+			req:     &model.AttendanceRequest{LocationID: testLocationID},
+			message: "does not exist",
+		},
+		{
+			name:     "archived location",
+			req:      &model.AttendanceRequest{LocationID: testLocationID},
+			location: &model.MasterDataItem{ID: testLocationID, ArchivedAt: &archivedAt},
+			message:  "is archived",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deps := newTestAttendanceService()
+
+			ctx := context.Background()
+			caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+			if tt.location != nil {
+				deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(tt.location, nil)
+			} else {
+				deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(nil, repository.ErrMasterDataNotFound).Maybe()
+			}
+
+			log, err := svc.CheckIn(ctx, caller, tt.req)
+
+			assert.Nil(t, log)
+			assert.Equal(t, 0, deps.tx.calls)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, []string{tt.message}, validationErr.Fields["location_id"])
+		})
+	}
+}
+
+func TestAttendanceService_Record_MemberForbidden(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	ctx := context.Background()
+	caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	other := uuid.New()
+
+	// 自分自身の記録でも、場所にいることを確かめられない直接の入退室は受け付けない
+	for _, req := range []*model.AttendanceRequest{
+		{LocationID: testLocationID},
+		{UserID: &other, LocationID: testLocationID},
+	} {
+		log, err := svc.CheckIn(ctx, caller, req)
+		require.ErrorIs(t, err, ErrForbidden)
+		assert.Nil(t, log)
+
+		log, err = svc.CheckOut(ctx, caller, req)
+		require.ErrorIs(t, err, ErrForbidden)
+		assert.Nil(t, log)
+	}
+	assert.Equal(t, 0, deps.tx.calls)
+	deps.masterRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAttendanceService_CheckIn_UserNotFound(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	ctx := context.Background()
+	caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	userID := uuid.New()
+	deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID}, nil)
+	deps.userRepo.On("GetByIDForUpdate", ctx, userID).Return(nil, repository.ErrUserNotFound)
+
+	log, err := svc.CheckIn(ctx, caller, &model.AttendanceRequest{UserID: &userID, LocationID: testLocationID})
+
+	require.ErrorIs(t, err, ErrUserNotFound)
+	assert.Nil(t, log)
+}
+
+func TestAttendanceService_CheckIn_LockFailure(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	ctx := context.Background()
+	caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	userID := uuid.New()
+	deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID}, nil)
+	deps.userRepo.On("GetByIDForUpdate", ctx, userID).Return(nil, assert.AnError)
+
+	log, err := svc.CheckIn(ctx, caller, &model.AttendanceRequest{UserID: &userID, LocationID: testLocationID})
+
+	require.ErrorIs(t, err, assert.AnError)
+	assert.NotErrorIs(t, err, ErrUserNotFound)
+	assert.Nil(t, log)
+}
+
+func TestAttendanceService_Tap(t *testing.T) {
+	tests := []struct {
+		name          string
+		latest        *model.AttendanceLog
+		wantType      model.AttendanceType
+		wantCheckedIn bool
+	}{
+		{name: "first tap checks in", wantType: model.AttendanceCheckIn, wantCheckedIn: true},
+		{name: "tap while checked in checks out", latest: &model.AttendanceLog{Type: model.AttendanceCheckIn}, wantType: model.AttendanceCheckOut},
+		{name: "tap after check-out checks in", latest: &model.AttendanceLog{Type: model.AttendanceCheckOut}, wantType: model.AttendanceCheckIn, wantCheckedIn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deps := newTestAttendanceService()
+
+			ctx := context.Background()
+			kiosk := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+			profile := &model.Profile{UserID: uuid.New(), DisplayID: "taro", Name: "山田太郎"}
+			deps.expectRecord(ctx, profile.UserID, tt.latest)
+			deps.profileRepo.On("GetByNFCSerial", ctx, "04A3B2C1").Return(profile, nil)
+			deps.attendanceRepo.On("Create", ctx, mock.AnythingOfType("*model.AttendanceLog")).Return(nil)
+
+			result, err := svc.Tap(ctx, kiosk, &model.TapRequest{NFCSerial: " 04:a3:b2:c1 ", LocationID: testLocationID})
+
+			require.NoError(t, err)
+			assert.Equal(t, profile.UserID, result.UserID)
+			assert.Equal(t, "山田太郎", result.Name)
+			assert.Equal(t, tt.wantCheckedIn, result.CheckedIn)
+			assert.Equal(t, tt.wantType, result.Log.Type)
+		})
+	}
+}
+
+func TestAttendanceService_Tap_Enrolls(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	ctx := context.Background()
+	kiosk := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	profile := &model.Profile{UserID: uuid.New(), DisplayID: "taro", Name: "山田太郎"}
+	deps.enroller.ExpectedCalls = nil
+	deps.enroller.On("CompleteEnrollment", ctx, testLocationID, "04A3B2C1").Return(profile, nil)
+	deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID}, nil)
+	deps.attendanceRepo.On("Latest", ctx, profile.UserID, testLocationID).Return(nil, repository.ErrAttendanceLogNotFound)
+
+	result, err := svc.Tap(ctx, kiosk, &model.TapRequest{NFCSerial: "04a3b2c1", LocationID: testLocationID})
+
+	require.NoError(t, err)
+	assert.True(t, result.Enrolled)
+	assert.False(t, result.CheckedIn)
+	assert.Nil(t, result.Log)
+	assert.Equal(t, "山田太郎", result.Name)
+	deps.profileRepo.AssertNotCalled(t, "GetByNFCSerial", mock.Anything, mock.Anything)
+	deps.attendanceRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAttendanceService_Tap_Rejected(t *testing.T) {
+	tests := []struct {
+		name       string
+		profileErr error
+		userErr    error
+		wantReason model.RejectedTapReason
+	}{
+		{name: "unknown card", profileErr: repository.ErrProfileNotFound, wantReason: model.RejectedTapUnknownCard},
+		{name: "deleted user", userErr: repository.ErrUserNotFound, wantReason: model.RejectedTapInactiveUser},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deps := newTestAttendanceService()
+
+			ctx := context.Background()
+			kiosk := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+			userID := uuid.New()
+			deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID}, nil)
+			if tt.profileErr != nil {
+				deps.profileRepo.On("GetByNFCSerial", ctx, "04A3B2C1").Return(nil, tt.profileErr)
+			} else {
+				deps.profileRepo.On("GetByNFCSerial", ctx, "04A3B2C1").Return(&model.Profile{UserID: userID}, nil)
+				deps.userRepo.On("GetByIDForUpdate", ctx, userID).Return(nil, tt.userErr)
+			}
+			deps.attendanceRepo.On("CreateRejectedTap", ctx, mock.MatchedBy(func(tap *model.RejectedTap) bool {
+				return tap.NFCSerial == "04A3B2C1" && tap.LocationID == testLocationID && tap.Reason == tt.wantReason
+			})).Return(nil)
+
+			result, err := svc.Tap(ctx, kiosk, &model.TapRequest{NFCSerial: "04a3b2c1", LocationID: testLocationID})
+
+			require.ErrorIs(t, err, ErrUnknownCard)
+			assert.Nil(t, result)
+			deps.attendanceRepo.AssertExpectations(t)
+			deps.attendanceRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAttendanceService_Tap_Invalid(t *testing.T) {
+	t.Run("member cannot tap", func(t *testing.T) {
+		svc, _ := newTestAttendanceService()
+
+		caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+		result, err := svc.Tap(context.Background(), caller, &model.TapRequest{NFCSerial: "04A3B2C1", LocationID: testLocationID})
+
+		require.ErrorIs(t, err, ErrForbidden)
+		assert.Nil(t, result)
+	})
+
+	t.Run("missing serial", func(t *testing.T) {
+		svc, deps := newTestAttendanceService()
+
+		caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+		result, err := svc.Tap(context.Background(), caller, &model.TapRequest{NFCSerial: " : ", LocationID: testLocationID})
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Nil(t, result)
+		assert.Equal(t, []string{"is required"}, validationErr.Fields["nfc_serial"])
+		deps.attendanceRepo.AssertNotCalled(t, "CreateRejectedTap", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAttendanceService_DeviceTap(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	ctx := context.Background()
+	device := &auth.Device{DeviceID: uuid.New(), LocationID: testLocationID}
+	profile := &model.Profile{UserID: uuid.New(), DisplayID: "taro", Name: "山田太郎"}
+	deps.expectRecord(ctx, profile.UserID, nil)
+	deps.profileRepo.On("GetByNFCSerial", ctx, "04A3B2C1").Return(profile, nil)
+	deps.attendanceRepo.On("Create", ctx, mock.AnythingOfType("*model.AttendanceLog")).Return(nil)
+
+	// 場所を省略すると端末の場所で記録する
+	result, err := svc.DeviceTap(ctx, device, &model.TapRequest{NFCSerial: "04A3B2C1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, testLocationID, result.Log.LocationID)
+	assert.True(t, result.CheckedIn)
+}
+
+func TestAttendanceService_DeviceTap_OtherLocation(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	device := &auth.Device{DeviceID: uuid.New(), LocationID: testLocationID}
+	result, err := svc.DeviceTap(context.Background(), device, &model.TapRequest{NFCSerial: "04A3B2C1", LocationID: testLocationID + 1})
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Nil(t, result)
+	assert.Equal(t, []string{"does not match the device location"}, validationErr.Fields["location_id"])
+	deps.enroller.AssertNotCalled(t, "CompleteEnrollment", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// sameTime は時刻が同じであればタイムゾーンが異なっても一致とみなす
+func sameTime(want time.Time) interface{} {
+	return mock.MatchedBy(func(got time.Time) bool { return got.Equal(want) })
+}
+
+func TestAttendanceService_DeviceTapBatch(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	ctx := context.Background()
+	device := &auth.Device{DeviceID: uuid.New(), LocationID: testLocationID}
+	profile := &model.Profile{UserID: uuid.New(), DisplayID: "taro", Name: "山田太郎"}
+	morning := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	evening := morning.Add(2 * time.Hour)
+
+	deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID}, nil)
+	deps.attendanceRepo.On("ListDeviceTaps", ctx, device.DeviceID, []string{"tap-2", "tap-3", "tap-0", "tap-1"}).
+		Return([]*model.DeviceTap{{DeviceID: device.DeviceID, ClientTapID: "tap-0", Status: model.BatchTapRecorded}}, nil)
+	deps.profileRepo.On("GetByNFCSerial", ctx, "04A3B2C1").Return(profile, nil)
+	deps.profileRepo.On("GetByNFCSerial", ctx, "FFFF").Return(nil, repository.ErrProfileNotFound)
+	deps.userRepo.On("GetByIDForUpdate", ctx, profile.UserID).Return(&model.User{ID: profile.UserID}, nil).Once()
+	// 送られた順ではなく時刻順に再生し、タップした時点の状態から入退室を決める
+	deps.attendanceRepo.On("LatestAt", ctx, profile.UserID, testLocationID, sameTime(morning)).Return(nil, repository.ErrAttendanceLogNotFound).Once()
+	deps.attendanceRepo.On("LatestAt", ctx, profile.UserID, testLocationID, sameTime(evening)).Return(&model.AttendanceLog{Type: model.AttendanceCheckIn}, nil).Once()
+	var created []*model.AttendanceLog
+	deps.attendanceRepo.On("Create", ctx, mock.AnythingOfType("*model.AttendanceLog")).Run(func(args mock.Arguments) {
+		log := args.Get(1).(*model.AttendanceLog)
+		log.ID = int64(len(created) + 1)
+		created = append(created, log)
+	}).Return(nil)
+	deps.attendanceRepo.On("CreateRejectedTap", ctx, mock.MatchedBy(func(tap *model.RejectedTap) bool {
+		return tap.NFCSerial == "FFFF" && tap.Reason == model.RejectedTapUnknownCard && tap.TappedAt.Equal(morning)
+	})).Return(nil).Once()
+	deps.attendanceRepo.On("CreateDeviceTap", ctx, mock.AnythingOfType("*model.DeviceTap")).Return(nil)
+	deps.attendanceRepo.On("Latest", ctx, profile.UserID, testLocationID).Return(&model.AttendanceLog{ID: 2}, nil).Once()
+	events, unsubscribe := deps.bus.Subscribe(testLocationID)
+	defer unsubscribe()
+
+	result, err := svc.DeviceTapBatch(ctx, device, &model.TapBatchRequest{Taps: []model.BatchTap{
+		{ClientTapID: "tap-1", NFCSerial: "04:a3:b2:c1", TappedAt: evening},
+		{ClientTapID: "tap-2", NFCSerial: "04a3b2c1", TappedAt: morning},
+		{ClientTapID: "tap-3", NFCSerial: "ffff", TappedAt: morning},
+		{ClientTapID: "tap-2", NFCSerial: "04a3b2c1", TappedAt: morning},
+		{ClientTapID: "tap-0", NFCSerial: "04a3b2c1", TappedAt: morning},
+		{ClientTapID: "tap-4", NFCSerial: "", TappedAt: morning},
+		{ClientTapID: "tap-5", NFCSerial: "04a3b2c1", TappedAt: time.Now().Add(time.Hour)},
+	}})
+
+	require.NoError(t, err)
+	require.Len(t, result.Results, 7)
+	// 結果は送られた順に返す
+	statuses := make([]model.BatchTapStatus, len(result.Results))
+	for i, r := range result.Results {
+		statuses[i] = r.Status
+	}
+	assert.Equal(t, []model.BatchTapStatus{
+		model.BatchTapRecorded,
+		model.BatchTapRecorded,
+		model.BatchTapRejected,
+		model.BatchTapDuplicate,
+		model.BatchTapDuplicate,
+		model.BatchTapInvalid,
+		model.BatchTapInvalid,
+	}, statuses)
+	assert.Equal(t, model.AttendanceCheckOut, result.Results[0].Result.Log.Type)
+	assert.Equal(t, model.AttendanceCheckIn, result.Results[1].Result.Log.Type)
+	assert.Equal(t, "nfc_serial is required", result.Results[5].Error)
+	assert.Equal(t, "tapped_at must not be in the future", result.Results[6].Error)
+
+	require.Len(t, created, 2)
+	assert.True(t, created[0].RecordedAt.Equal(morning))
+	assert.True(t, created[1].RecordedAt.Equal(evening))
+	deps.attendanceRepo.AssertNumberOfCalls(t, "CreateDeviceTap", 3)
+	deps.attendanceRepo.AssertCalled(t, "CreateDeviceTap", ctx, mock.MatchedBy(func(tap *model.DeviceTap) bool {
+		return tap.ClientTapID == "tap-2" && tap.AttendanceLogID != nil && *tap.AttendanceLogID == 1
+	}))
+	assert.Equal(t, 1, deps.tx.calls)
+	// 在室状況はユーザーごとの最後の記録でのみ通知する
+	require.Len(t, events, 1)
+	event := <-events
+	assert.Equal(t, model.PresenceLeave, event.Type)
+	assert.Equal(t, int64(2), event.LogID)
+}
+
+func TestAttendanceService_DeviceTapBatch_PastTapNotPublished(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	ctx := context.Background()
+	device := &auth.Device{DeviceID: uuid.New(), LocationID: testLocationID}
+	profile := &model.Profile{UserID: uuid.New(), DisplayID: "taro", Name: "山田太郎"}
+	tappedAt := time.Now().Add(-3 * time.Hour)
+
+	deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID}, nil)
+	deps.attendanceRepo.On("ListDeviceTaps", ctx, device.DeviceID, []string{"tap-1"}).Return(nil, nil)
+	deps.profileRepo.On("GetByNFCSerial", ctx, "04A3B2C1").Return(profile, nil)
+	deps.userRepo.On("GetByIDForUpdate", ctx, profile.UserID).Return(&model.User{ID: profile.UserID}, nil)
+	deps.attendanceRepo.On("LatestAt", ctx, profile.UserID, testLocationID, sameTime(tappedAt)).Return(nil, repository.ErrAttendanceLogNotFound)
+	deps.attendanceRepo.On("Create", ctx, mock.AnythingOfType("*model.AttendanceLog")).Run(func(args mock.Arguments) {
+		args.Get(1).(*model.AttendanceLog).ID = 10
+	}).Return(nil)
+	deps.attendanceRepo.On("CreateDeviceTap", ctx, mock.AnythingOfType("*model.DeviceTap")).Return(nil)
+	// オフラインの間に別の端末で新しい記録が追加されている
+	deps.attendanceRepo.On("Latest", ctx, profile.UserID, testLocationID).Return(&model.AttendanceLog{ID: 11}, nil)
+	events, unsubscribe := deps.bus.Subscribe(testLocationID)
+	defer unsubscribe()
+
+	_, err := svc.DeviceTapBatch(ctx, device, &model.TapBatchRequest{Taps: []model.BatchTap{
+		{ClientTapID: "tap-1", NFCSerial: "04a3b2c1", TappedAt: tappedAt},
+	}})
+
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestAttendanceService_DeviceTapBatch_LockFailure(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	ctx := context.Background()
+	device := &auth.Device{DeviceID: uuid.New(), LocationID: testLocationID}
+	profile := &model.Profile{UserID: uuid.New()}
+
+	deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID}, nil)
+	deps.attendanceRepo.On("ListDeviceTaps", ctx, device.DeviceID, []string{"tap-1"}).Return(nil, nil)
+	deps.profileRepo.On("GetByNFCSerial", ctx, "04A3B2C1").Return(profile, nil)
+	deps.userRepo.On("GetByIDForUpdate", ctx, profile.UserID).Return(nil, assert.AnError)
+
+	result, err := svc.DeviceTapBatch(ctx, device, &model.TapBatchRequest{Taps: []model.BatchTap{
+		{ClientTapID: "tap-1", NFCSerial: "04a3b2c1", TappedAt: time.Now().Add(-time.Hour)},
+	}})
+
+	// 削除済みのユーザーとして受け付けずに、一括送信全体を失敗させる
+	require.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, result)
+	deps.attendanceRepo.AssertNotCalled(t, "CreateRejectedTap", mock.Anything, mock.Anything)
+	deps.attendanceRepo.AssertNotCalled(t, "CreateDeviceTap", mock.Anything, mock.Anything)
+}
+
+func TestAttendanceService_DeviceTapBatch_Conflict(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	ctx := context.Background()
+	device := &auth.Device{DeviceID: uuid.New(), LocationID: testLocationID}
+	deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID}, nil)
+	deps.attendanceRepo.On("ListDeviceTaps", ctx, device.DeviceID, []string{"tap-1"}).Return(nil, nil)
+	deps.profileRepo.On("GetByNFCSerial", ctx, "FFFF").Return(nil, repository.ErrProfileNotFound)
+	deps.attendanceRepo.On("CreateRejectedTap", ctx, mock.AnythingOfType("*model.RejectedTap")).Return(nil)
+	deps.attendanceRepo.On("CreateDeviceTap", ctx, mock.AnythingOfType("*model.DeviceTap")).Return(repository.ErrDeviceTapExists)
+
+	result, err := svc.DeviceTapBatch(ctx, device, &model.TapBatchRequest{Taps: []model.BatchTap{
+		{ClientTapID: "tap-1", NFCSerial: "FFFF", TappedAt: time.Now()},
+	}})
+
+	require.ErrorIs(t, err, ErrTapBatchConflict)
+	assert.Nil(t, result)
+}
+
+func TestAttendanceService_DeviceTapBatch_Invalid(t *testing.T) {
+	svc, deps := newTestAttendanceService()
+
+	device := &auth.Device{DeviceID: uuid.New(), LocationID: testLocationID}
+	result, err := svc.DeviceTapBatch(context.Background(), device, &model.TapBatchRequest{})
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Nil(t, result)
+	assert.Equal(t, []string{"is required"}, validationErr.Fields["taps"])
+	assert.Equal(t, 0, deps.tx.calls)
+}
+
+func TestAttendanceService_ListLogs(t *testing.T) {
+	t.Run("member is limited to own logs", func(t *testing.T) {
+		svc, deps := newTestAttendanceService()
+
+		ctx := context.Background()
+		caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+		expected := []*model.AttendanceLog{{ID: 1, UserID: caller.UserID}}
+		deps.attendanceRepo.On("List", ctx, model.AttendanceFilter{UserID: &caller.UserID, Limit: 10}).Return(expected, nil)
+
+		logs, err := svc.ListLogs(ctx, caller, model.AttendanceFilter{Limit: 10})
+
+		require.NoError(t, err)
+		assert.Equal(t, expected, logs)
+	})
+
+	t.Run("member cannot list other users", func(t *testing.T) {
+		svc, deps := newTestAttendanceService()
+
+		other := uuid.New()
+		caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+
+		logs, err := svc.ListLogs(context.Background(), caller, model.AttendanceFilter{UserID: &other})
+
+		require.ErrorIs(t, err, ErrForbidden)
+		assert.Nil(t, logs)
+		deps.attendanceRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+
+	t.Run("staff lists every user", func(t *testing.T) {
+		svc, deps := newTestAttendanceService()
+
+		ctx := context.Background()
+		caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+		deps.attendanceRepo.On("List", ctx, model.AttendanceFilter{Limit: 10}).Return([]*model.AttendanceLog{}, nil)
+
+		_, err := svc.ListLogs(ctx, caller, model.AttendanceFilter{Limit: 10})
+
+		require.NoError(t, err)
+		deps.attendanceRepo.AssertExpectations(t)
+	})
+}
+
+func TestAttendanceService_ListSessions(t *testing.T) {
+	t.Run("member is limited to own sessions", func(t *testing.T) {
+		svc, deps := newTestAttendanceService()
+
+		ctx := context.Background()
+		caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+		expected := []*model.AttendanceSession{{UserID: caller.UserID, Incomplete: true, Issue: model.SessionOpen}}
+		deps.attendanceRepo.On("ListSessions", ctx, model.AttendanceFilter{UserID: &caller.UserID, Limit: 10}).Return(expected, nil)
+
+		sessions, err := svc.ListSessions(ctx, caller, model.AttendanceFilter{Limit: 10})
+
+		require.NoError(t, err)
+		assert.Equal(t, expected, sessions)
+	})
+
+	t.Run("member cannot list other users", func(t *testing.T) {
+		svc, deps := newTestAttendanceService()
+
+		other := uuid.New()
+		caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+
+		sessions, err := svc.ListSessions(context.Background(), caller, model.AttendanceFilter{UserID: &other})
+
+		require.ErrorIs(t, err, ErrForbidden)
+		assert.Nil(t, sessions)
+		deps.attendanceRepo.AssertNotCalled(t, "ListSessions", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,429 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+type AuthService interface {
+	Login(ctx context.Context, req *model.LoginRequest, clientIP string) (*model.LoginResponse, error)
+	Refresh(ctx context.Context, req *model.RefreshTokenRequest) (*model.LoginResponse, error)
+	Logout(ctx context.Context, identity *auth.Identity, req *model.LogoutRequest) error
+	RevokeAllSessions(ctx context.Context, userID uuid.UUID) error
+	ValidateAccessToken(ctx context.Context, token string) (*auth.Identity, error)
+	IssueSession(ctx context.Context, user *model.User) (*model.LoginResponse, error)
+	CheckRevocation(ctx context.Context, identity *auth.Identity) error
+	UnlockAccount(ctx context.Context, userID uuid.UUID) error
+}
+
+// LoginLockouts はログインの失敗をアカウント（メールアドレス）と接続元IPごとに数え、試行を制限する
+type LoginLockouts struct {
+	Account *lockout.Limiter
+	IP      *lockout.Limiter
+}
+
+type authService struct {
+	userRepo       repository.UserRepository
+	refreshRepo    repository.RefreshTokenRepository
+	revocationRepo repository.TokenRevocationRepository
+	mfaRepo        repository.MFARepository
+	tokens         *auth.TokenManager
+	refreshExpiry  time.Duration
+	revocations    *revocationCache
+	lockouts       LoginLockouts
+}
+
+func NewAuthService(
+	userRepo repository.UserRepository,
+	refreshRepo repository.RefreshTokenRepository,
+	revocationRepo repository.TokenRevocationRepository,
+	mfaRepo repository.MFARepository,
+	tokens *auth.TokenManager,
+	refreshExpiry time.Duration,
+	revocationCacheTTL time.Duration,
+	lockouts LoginLockouts,
+) AuthService {
+	return &authService{
+		userRepo:       userRepo,
+		refreshRepo:    refreshRepo,
+		revocationRepo: revocationRepo,
+		mfaRepo:        mfaRepo,
+		tokens:         tokens,
+		refreshExpiry:  refreshExpiry,
+		revocations:    newRevocationCache(revocationCacheTTL),
+		lockouts:       lockouts,
+	}
+}
+
+func (s *authService) Login(ctx context.Context, req *model.LoginRequest, clientIP string) (*model.LoginResponse, error) {
+	account := normalizeEmail(req.Email)
+	if err := s.checkLockouts(ctx, account, clientIP); err != nil {
+		return nil, err
+	}
+	attempt, err := s.reserveLoginAttempt(ctx, account, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.authenticate(ctx, req)
+	if err != nil {
+		if failErr := attempt.fail(ctx); failErr != nil {
+			return nil, failErr
+		}
+		return nil, err
+	}
+
+	// 接続元IPの失敗回数は、別アカウントでの成功で消せないようこの試行の分だけ取り消す
+	if attempt.account != nil {
+		if err := s.lockouts.Account.Reset(ctx, account); err != nil {
+			log.Printf("Failed to reset login failures: %v", err)
+		}
+	}
+	if attempt.ip != nil {
+		if err := attempt.ip.Release(ctx); err != nil {
+			log.Printf("Failed to release login attempt: %v", err)
+		}
+	}
+	if err := s.lockouts.Account.Purge(ctx); err != nil {
+		log.Printf("Failed to purge stale login attempts: %v", err)
+	}
+
+	mfa, err := s.mfaRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load mfa settings: %w", err)
+	}
+	if mfa.Enabled() {
+		return s.issueMFAChallenge(user)
+	}
+
+	return s.IssueSession(ctx, user)
+}
+
+// issueMFAChallenge はトークンの代わりに、POST /auth/mfa/verify でコードと交換するチャレンジトークンを返す
+func (s *authService) issueMFAChallenge(user *model.User) (*model.LoginResponse, error) {
+	challenge, err := s.tokens.GenerateMFAChallenge(user.ID, mfaChallengeTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate mfa challenge: %w", err)
+	}
+
+	return &model.LoginResponse{
+		ExpiresIn:   int(mfaChallengeTTL.Seconds()),
+		MFARequired: true,
+		MFAToken:    challenge,
+	}, nil
+}
+
+func (s *authService) authenticate(ctx context.Context, req *model.LoginRequest) (*model.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if !user.HasLocalPassword() {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// checkLockouts はアカウントか接続元IPがロック中であれば、長い方の待ち時間で *lockout.LockedError を返す
+func (s *authService) checkLockouts(ctx context.Context, account, clientIP string) error {
+	var locked *lockout.LockedError
+	for _, check := range []struct {
+		limiter *lockout.Limiter
+		id      string
+	}{
+		{s.lockouts.Account, account},
+		{s.lockouts.IP, clientIP},
+	} {
+		if check.id == "" {
+			continue
+		}
+
+		err := check.limiter.Check(ctx, check.id)
+		var lockedErr *lockout.LockedError
+		switch {
+		case errors.As(err, &lockedErr):
+			if locked == nil || lockedErr.RetryAfter > locked.RetryAfter {
+				locked = lockedErr
+			}
+		case err != nil:
+			return err
+		}
+	}
+
+	if locked != nil {
+		return locked
+	}
+	return nil
+}
+
+// loginAttempt は照合の前に失敗として数えたログインの試行
+type loginAttempt struct {
+	account *lockout.Reservation
+	ip      *lockout.Reservation
+}
+
+// reserveLoginAttempt はアカウントと接続元IPの試行を照合の前に失敗として数える。
+// 同時に届いた試行でも失敗回数の上限を超えて照合しないよう、上限を超える場合は *lockout.LockedError を返す
+func (s *authService) reserveLoginAttempt(ctx context.Context, account, clientIP string) (*loginAttempt, error) {
+	attempt := &loginAttempt{}
+	if account != "" {
+		reservation, err := s.lockouts.Account.Reserve(ctx, account)
+		if err != nil {
+			return nil, err
+		}
+		attempt.account = reservation
+	}
+	if clientIP != "" {
+		reservation, err := s.lockouts.IP.Reserve(ctx, clientIP)
+		if err != nil {
+			if attempt.account != nil {
+				if releaseErr := attempt.account.Release(ctx); releaseErr != nil {
+					log.Printf("Failed to release login attempt: %v", releaseErr)
+				}
+			}
+			return nil, err
+		}
+		attempt.ip = reservation
+	}
+	return attempt, nil
+}
+
+// fail は試行の失敗を確定し、回数に応じてアカウントと接続元IPをロックする
+func (a *loginAttempt) fail(ctx context.Context) error {
+	for _, reservation := range []*lockout.Reservation{a.account, a.ip} {
+		if reservation == nil {
+			continue
+		}
+		if err := reservation.Fail(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnlockAccount はユーザーのログイン失敗回数とロックを消去する
+func (s *authService) UnlockAccount(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+
+	return s.lockouts.Account.Reset(ctx, normalizeEmail(user.Email))
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// IssueSession は新しいトークン系列を開始し、アクセストークンとリフレッシュトークンを発行する
+func (s *authService) IssueSession(ctx context.Context, user *model.User) (*model.LoginResponse, error) {
+	return s.issueTokens(ctx, user, uuid.New())
+}
+
+func (s *authService) Refresh(ctx context.Context, req *model.RefreshTokenRequest) (*model.LoginResponse, error) {
+	stored, err := s.refreshRepo.GetByHash(ctx, hashToken(req.RefreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// 使用済みトークンの再提示は漏洩とみなし、同じ系列のトークンを全て失効させる
+	if stored.UsedAt != nil {
+		return nil, s.revokeFamily(ctx, stored.FamilyID)
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	marked, err := s.refreshRepo.MarkUsed(ctx, stored.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if !marked {
+		return nil, s.revokeFamily(ctx, stored.FamilyID)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		if err := s.refreshRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, fmt.Errorf("failed to revoke refresh token family: %w", err)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return s.issueTokens(ctx, user, stored.FamilyID)
+}
+
+func (s *authService) Logout(ctx context.Context, identity *auth.Identity, req *model.LogoutRequest) error {
+	if err := s.revocationRepo.RevokeToken(ctx, identity.TokenID, identity.UserID, identity.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	s.revocations.setToken(identity.TokenID, true, identity.ExpiresAt)
+
+	if req != nil && req.RefreshToken != "" {
+		stored, err := s.refreshRepo.GetByHash(ctx, hashToken(req.RefreshToken))
+		if err == nil && stored.UserID == identity.UserID {
+			if err := s.refreshRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+				return fmt.Errorf("failed to revoke refresh token family: %w", err)
+			}
+		}
+	}
+
+	if err := s.revocationRepo.DeleteExpired(ctx); err != nil {
+		log.Printf("Failed to purge expired token revocations: %v", err)
+	}
+
+	return nil
+}
+
+func (s *authService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	revokedAt, err := s.revocationRepo.RevokeAllForUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	s.revocations.setUser(userID, &revokedAt)
+
+	if err := s.refreshRepo.RevokeAllForUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (s *authService) ValidateAccessToken(ctx context.Context, token string) (*auth.Identity, error) {
+	identity, err := s.tokens.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.CheckRevocation(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+func (s *authService) CheckRevocation(ctx context.Context, identity *auth.Identity) error {
+	revoked, err := s.isRevoked(ctx, identity)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return auth.ErrRevokedToken
+	}
+	return nil
+}
+
+func (s *authService) isRevoked(ctx context.Context, identity *auth.Identity) (bool, error) {
+	revokedAt, found := s.revocations.user(identity.UserID)
+	if !found {
+		var err error
+		revokedAt, err = s.revocationRepo.GetUserRevokedAt(ctx, identity.UserID)
+		if err != nil {
+			return false, fmt.Errorf("failed to check session revocation: %w", err)
+		}
+		s.revocations.setUser(identity.UserID, revokedAt)
+	}
+
+	// iat は秒精度のため、失効と同じ秒に発行されたトークンも失効扱いにする
+	if revokedAt != nil && !identity.IssuedAt.After(*revokedAt) {
+		return true, nil
+	}
+
+	revoked, found := s.revocations.token(identity.TokenID)
+	if !found {
+		var err error
+		revoked, err = s.revocationRepo.IsTokenRevoked(ctx, identity.TokenID)
+		if err != nil {
+			return false, fmt.Errorf("failed to check token revocation: %w", err)
+		}
+		s.revocations.setToken(identity.TokenID, revoked, identity.ExpiresAt)
+	}
+
+	return revoked, nil
+}
+
+func (s *authService) revokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	if err := s.refreshRepo.RevokeFamily(ctx, familyID); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return ErrRefreshTokenReused
+}
+
+func (s *authService) issueTokens(ctx context.Context, user *model.User, familyID uuid.UUID) (*model.LoginResponse, error) {
+	accessToken, err := s.tokens.Generate(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	refreshToken, err := generateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.refreshRepo.Create(ctx, &model.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(s.refreshExpiry),
+		CreatedAt: now,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return &model.LoginResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.tokens.Expiry().Seconds()),
+		User:         user,
+	}, nil
+}
+
+func generateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
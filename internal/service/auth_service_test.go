@@ -0,0 +1,639 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+type MockTokenRevocationRepository struct {
+	mock.Mock
+}
+
+func (m *MockTokenRevocationRepository) RevokeToken(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, userID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenRevocationRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenRevocationRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockTokenRevocationRepository) GetUserRevokedAt(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockTokenRevocationRepository) DeleteExpired(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func newTestTokenManager() *auth.TokenManager {
+	return auth.NewTokenManager("test-secret", 15*time.Minute)
+}
+
+func newTestAuthService(userRepo *MockUserRepository, refreshRepo *MockRefreshTokenRepository) *authService {
+	return newTestAuthServiceWithRevocations(userRepo, refreshRepo, new(MockTokenRevocationRepository))
+}
+
+func newTestAuthServiceWithRevocations(userRepo *MockUserRepository, refreshRepo *MockRefreshTokenRepository, revocationRepo *MockTokenRevocationRepository) *authService {
+	mfaRepo := new(MockMFARepository)
+	mfaRepo.On("GetByUserID", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	return NewAuthService(userRepo, refreshRepo, revocationRepo, mfaRepo, newTestTokenManager(), 24*time.Hour, time.Minute, newTestLoginLockouts()).(*authService)
+}
+
+func newTestLoginLockouts() LoginLockouts {
+	store := lockout.NewMemoryStore()
+	return LoginLockouts{
+		Account: lockout.NewLimiter(store, "account", lockout.Policy{MaxFailures: 3, Lockout: 15 * time.Minute}),
+		IP:      lockout.NewLimiter(store, "ip", lockout.Policy{MaxFailures: 5, Lockout: 15 * time.Minute}),
+	}
+}
+
+func TestAuthService_Login_Success(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	password := "password123"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	user := &model.User{
+		ID:        uuid.New(),
+		Email:     "test@example.com",
+		Name:      "Test User",
+		Password:  string(hashedPassword),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	req := &model.LoginRequest{
+		Email:    "test@example.com",
+		Password: password,
+	}
+
+	mockUserRepo.On("GetByEmail", ctx, req.Email).Return(user, nil)
+	mockRefreshRepo.On("Create", ctx, mock.MatchedBy(func(token *model.RefreshToken) bool {
+		return token.UserID == user.ID && token.TokenHash != "" && token.ExpiresAt.After(time.Now())
+	})).Return(nil)
+
+	response, err := service.Login(ctx, req, "192.0.2.1")
+
+	require.NoError(t, err)
+	assert.NotNil(t, response)
+	assert.NotEmpty(t, response.Token)
+	assert.NotEmpty(t, response.RefreshToken)
+	assert.Equal(t, 900, response.ExpiresIn)
+	assert.Equal(t, user.ID, response.User.ID)
+	assert.Equal(t, user.Email, response.User.Email)
+
+	stored := mockRefreshRepo.Calls[0].Arguments.Get(1).(*model.RefreshToken)
+	assert.Equal(t, hashToken(response.RefreshToken), stored.TokenHash)
+	assert.NotEqual(t, response.RefreshToken, stored.TokenHash)
+
+	mockUserRepo.AssertExpectations(t)
+	mockRefreshRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Login_InvalidEmail(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	req := &model.LoginRequest{
+		Email:    "nonexistent@example.com",
+		Password: "password123",
+	}
+
+	mockUserRepo.On("GetByEmail", ctx, req.Email).Return(nil, fmt.Errorf("user not found"))
+
+	response, err := service.Login(ctx, req, "192.0.2.1")
+
+	require.Error(t, err)
+	assert.Nil(t, response)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Login_ExternallyManagedUser(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	zitadelID := "zitadel-user"
+	user := &model.User{
+		ID:         uuid.New(),
+		Email:      "test@example.com",
+		Name:       "Test User",
+		AuthSource: model.AuthSourceZitadel,
+		ZitadelID:  &zitadelID,
+	}
+
+	req := &model.LoginRequest{
+		Email:    "test@example.com",
+		Password: "",
+	}
+
+	mockUserRepo.On("GetByEmail", ctx, req.Email).Return(user, nil)
+
+	response, err := service.Login(ctx, req, "192.0.2.1")
+
+	assert.Nil(t, response)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	mockRefreshRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_Login_InvalidPassword(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.DefaultCost)
+
+	user := &model.User{
+		ID:        uuid.New(),
+		Email:     "test@example.com",
+		Name:      "Test User",
+		Password:  string(hashedPassword),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	req := &model.LoginRequest{
+		Email:    "test@example.com",
+		Password: "wrongpassword",
+	}
+
+	mockUserRepo.On("GetByEmail", ctx, req.Email).Return(user, nil)
+
+	response, err := service.Login(ctx, req, "192.0.2.1")
+
+	require.Error(t, err)
+	assert.Nil(t, response)
+	assert.Contains(t, err.Error(), "invalid credentials")
+
+	mockUserRepo.AssertExpectations(t)
+	mockRefreshRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_Refresh_RotatesToken(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Name: "Test User"}
+	stored := &model.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  uuid.New(),
+		TokenHash: hashToken("old-token"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	mockRefreshRepo.On("GetByHash", ctx, hashToken("old-token")).Return(stored, nil)
+	mockRefreshRepo.On("MarkUsed", ctx, stored.ID).Return(true, nil)
+	mockUserRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRefreshRepo.On("Create", ctx, mock.MatchedBy(func(token *model.RefreshToken) bool {
+		return token.FamilyID == stored.FamilyID && token.UserID == user.ID
+	})).Return(nil)
+
+	response, err := service.Refresh(ctx, &model.RefreshTokenRequest{RefreshToken: "old-token"})
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, response.Token)
+	assert.NotEmpty(t, response.RefreshToken)
+	assert.NotEqual(t, "old-token", response.RefreshToken)
+
+	mockUserRepo.AssertExpectations(t)
+	mockRefreshRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Refresh_ReuseRevokesFamily(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	usedAt := time.Now().Add(-time.Minute)
+	stored := &model.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		FamilyID:  uuid.New(),
+		ExpiresAt: time.Now().Add(time.Hour),
+		UsedAt:    &usedAt,
+	}
+
+	mockRefreshRepo.On("GetByHash", ctx, hashToken("replayed-token")).Return(stored, nil)
+	mockRefreshRepo.On("RevokeFamily", ctx, stored.FamilyID).Return(nil)
+
+	response, err := service.Refresh(ctx, &model.RefreshTokenRequest{RefreshToken: "replayed-token"})
+
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	assert.Nil(t, response)
+
+	mockRefreshRepo.AssertExpectations(t)
+	mockRefreshRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_Refresh_ConcurrentUseRevokesFamily(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	stored := &model.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		FamilyID:  uuid.New(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	mockRefreshRepo.On("GetByHash", ctx, hashToken("raced-token")).Return(stored, nil)
+	mockRefreshRepo.On("MarkUsed", ctx, stored.ID).Return(false, nil)
+	mockRefreshRepo.On("RevokeFamily", ctx, stored.FamilyID).Return(nil)
+
+	response, err := service.Refresh(ctx, &model.RefreshTokenRequest{RefreshToken: "raced-token"})
+
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	assert.Nil(t, response)
+
+	mockRefreshRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Refresh_Expired(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	stored := &model.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		FamilyID:  uuid.New(),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}
+
+	mockRefreshRepo.On("GetByHash", ctx, hashToken("expired-token")).Return(stored, nil)
+
+	response, err := service.Refresh(ctx, &model.RefreshTokenRequest{RefreshToken: "expired-token"})
+
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	assert.Nil(t, response)
+
+	mockRefreshRepo.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_Refresh_UnknownToken(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	mockRefreshRepo.On("GetByHash", ctx, hashToken("unknown")).Return(nil, fmt.Errorf("refresh token not found"))
+
+	response, err := service.Refresh(ctx, &model.RefreshTokenRequest{RefreshToken: "unknown"})
+
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	assert.Nil(t, response)
+}
+
+func TestAuthService_Logout_RevokesAccessTokenAndRefreshFamily(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	mockRevocationRepo := new(MockTokenRevocationRepository)
+	service := newTestAuthServiceWithRevocations(mockUserRepo, mockRefreshRepo, mockRevocationRepo)
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com"}
+	token, err := service.tokens.Generate(user)
+	require.NoError(t, err)
+	identity, err := service.tokens.Parse(token)
+	require.NoError(t, err)
+
+	stored := &model.RefreshToken{ID: uuid.New(), UserID: user.ID, FamilyID: uuid.New()}
+
+	mockRevocationRepo.On("RevokeToken", ctx, identity.TokenID, user.ID, identity.ExpiresAt).Return(nil)
+	mockRevocationRepo.On("DeleteExpired", ctx).Return(nil)
+	mockRefreshRepo.On("GetByHash", ctx, hashToken("refresh-token")).Return(stored, nil)
+	mockRefreshRepo.On("RevokeFamily", ctx, stored.FamilyID).Return(nil)
+	mockRevocationRepo.On("GetUserRevokedAt", ctx, user.ID).Return(nil, nil)
+
+	err = service.Logout(ctx, identity, &model.LogoutRequest{RefreshToken: "refresh-token"})
+	require.NoError(t, err)
+
+	validated, err := service.ValidateAccessToken(ctx, token)
+
+	assert.ErrorIs(t, err, auth.ErrRevokedToken)
+	assert.Nil(t, validated)
+
+	mockRevocationRepo.AssertExpectations(t)
+	mockRefreshRepo.AssertExpectations(t)
+	mockRevocationRepo.AssertNotCalled(t, "IsTokenRevoked", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_Logout_IgnoresOtherUsersRefreshToken(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	mockRevocationRepo := new(MockTokenRevocationRepository)
+	service := newTestAuthServiceWithRevocations(mockUserRepo, mockRefreshRepo, mockRevocationRepo)
+
+	ctx := context.Background()
+	identity := &auth.Identity{UserID: uuid.New(), TokenID: uuid.NewString(), ExpiresAt: time.Now().Add(time.Minute)}
+	stored := &model.RefreshToken{ID: uuid.New(), UserID: uuid.New(), FamilyID: uuid.New()}
+
+	mockRevocationRepo.On("RevokeToken", ctx, identity.TokenID, identity.UserID, identity.ExpiresAt).Return(nil)
+	mockRevocationRepo.On("DeleteExpired", ctx).Return(nil)
+	mockRefreshRepo.On("GetByHash", ctx, hashToken("someone-elses-token")).Return(stored, nil)
+
+	err := service.Logout(ctx, identity, &model.LogoutRequest{RefreshToken: "someone-elses-token"})
+
+	require.NoError(t, err)
+	mockRefreshRepo.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_ValidateAccessToken_Valid(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	mockRevocationRepo := new(MockTokenRevocationRepository)
+	service := newTestAuthServiceWithRevocations(mockUserRepo, mockRefreshRepo, mockRevocationRepo)
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com"}
+	token, err := service.tokens.Generate(user)
+	require.NoError(t, err)
+
+	mockRevocationRepo.On("GetUserRevokedAt", ctx, user.ID).Return(nil, nil).Once()
+	mockRevocationRepo.On("IsTokenRevoked", ctx, mock.AnythingOfType("string")).Return(false, nil).Once()
+
+	identity, err := service.ValidateAccessToken(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, identity.UserID)
+
+	// 2回目はキャッシュから判定される
+	_, err = service.ValidateAccessToken(ctx, token)
+	require.NoError(t, err)
+
+	mockRevocationRepo.AssertExpectations(t)
+}
+
+func TestAuthService_RevokeAllSessions_RejectsEarlierTokens(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	mockRevocationRepo := new(MockTokenRevocationRepository)
+	service := newTestAuthServiceWithRevocations(mockUserRepo, mockRefreshRepo, mockRevocationRepo)
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com"}
+	token, err := service.tokens.Generate(user)
+	require.NoError(t, err)
+
+	mockRevocationRepo.On("RevokeAllForUser", ctx, user.ID).Return(time.Now(), nil)
+	mockRefreshRepo.On("RevokeAllForUser", ctx, user.ID).Return(nil)
+
+	err = service.RevokeAllSessions(ctx, user.ID)
+	require.NoError(t, err)
+
+	identity, err := service.ValidateAccessToken(ctx, token)
+
+	assert.ErrorIs(t, err, auth.ErrRevokedToken)
+	assert.Nil(t, identity)
+
+	mockRevocationRepo.AssertExpectations(t)
+	mockRefreshRepo.AssertExpectations(t)
+}
+
+func TestAuthService_ValidateAccessToken_RevokedOnAnotherReplica(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	mockRevocationRepo := new(MockTokenRevocationRepository)
+	service := newTestAuthServiceWithRevocations(mockUserRepo, mockRefreshRepo, mockRevocationRepo)
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com"}
+	token, err := service.tokens.Generate(user)
+	require.NoError(t, err)
+
+	revokedAt := time.Now().Add(time.Second)
+	mockRevocationRepo.On("GetUserRevokedAt", ctx, user.ID).Return(&revokedAt, nil)
+
+	identity, err := service.ValidateAccessToken(ctx, token)
+
+	assert.ErrorIs(t, err, auth.ErrRevokedToken)
+	assert.Nil(t, identity)
+}
+
+func TestAuthService_Login_LocksAccountAfterRepeatedFailures(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.MinCost)
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Password: string(hashedPassword)}
+	mockUserRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+
+	for i := 0; i < 3; i++ {
+		_, err := service.Login(ctx, &model.LoginRequest{Email: user.Email, Password: "wrongpassword"}, "192.0.2.1")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	// ロック中は正しいパスワードでも拒否し、別の IP からでも同じ
+	_, err := service.Login(ctx, &model.LoginRequest{Email: "Test@Example.com", Password: "correctpassword"}, "198.51.100.1")
+
+	var lockedErr *lockout.LockedError
+	require.ErrorAs(t, err, &lockedErr)
+	assert.InDelta(t, (15 * time.Minute).Seconds(), lockedErr.RetryAfter.Seconds(), 5)
+	mockUserRepo.AssertNumberOfCalls(t, "GetByEmail", 3)
+	mockRefreshRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_Login_ConcurrentAttemptsRespectFailureCap(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.MinCost)
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Password: string(hashedPassword)}
+	release := make(chan struct{})
+	mockUserRepo.On("GetByEmail", ctx, user.Email).Run(func(mock.Arguments) { <-release }).Return(user, nil)
+
+	// 照合が終わる前に届いた試行も数え、上限の3回を超える分は照合せずに拒否する
+	const attempts = 10
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		go func() {
+			_, err := service.Login(ctx, &model.LoginRequest{Email: user.Email, Password: "wrongpassword"}, "")
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < attempts-3; i++ {
+		select {
+		case err := <-errs:
+			var lockedErr *lockout.LockedError
+			require.ErrorAs(t, err, &lockedErr)
+		case <-time.After(5 * time.Second):
+			t.Fatal("attempts over the failure cap were not rejected")
+		}
+	}
+	close(release)
+	for i := 0; i < 3; i++ {
+		require.ErrorIs(t, <-errs, ErrInvalidCredentials)
+	}
+	mockUserRepo.AssertNumberOfCalls(t, "GetByEmail", 3)
+}
+
+func TestAuthService_Login_SuccessResetsAccountFailures(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.MinCost)
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Password: string(hashedPassword)}
+	mockUserRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	mockRefreshRepo.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+
+	for _, pw := range []string{"wrongpassword", "wrongpassword", "correctpassword", "wrongpassword", "wrongpassword"} {
+		service.Login(ctx, &model.LoginRequest{Email: user.Email, Password: pw}, "")
+	}
+
+	_, err := service.Login(ctx, &model.LoginRequest{Email: user.Email, Password: "correctpassword"}, "")
+
+	require.NoError(t, err)
+}
+
+func TestAuthService_Login_LocksClientIP(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	mockUserRepo.On("GetByEmail", ctx, mock.AnythingOfType("string")).Return(nil, fmt.Errorf("user not found"))
+
+	// アカウントを変えながら試行しても IP 単位でロックされる
+	for i := 0; i < 5; i++ {
+		_, err := service.Login(ctx, &model.LoginRequest{Email: fmt.Sprintf("user%d@example.com", i), Password: "guess"}, "192.0.2.1")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	_, err := service.Login(ctx, &model.LoginRequest{Email: "another@example.com", Password: "guess"}, "192.0.2.1")
+	var lockedErr *lockout.LockedError
+	require.ErrorAs(t, err, &lockedErr)
+
+	_, err = service.Login(ctx, &model.LoginRequest{Email: "another@example.com", Password: "guess"}, "198.51.100.1")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuthService_UnlockAccount(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	service := newTestAuthService(mockUserRepo, mockRefreshRepo)
+
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.MinCost)
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Password: string(hashedPassword)}
+	mockUserRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	mockUserRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRefreshRepo.On("Create", ctx, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+
+	for i := 0; i < 3; i++ {
+		service.Login(ctx, &model.LoginRequest{Email: user.Email, Password: "wrongpassword"}, "")
+	}
+
+	require.NoError(t, service.UnlockAccount(ctx, user.ID))
+
+	_, err := service.Login(ctx, &model.LoginRequest{Email: user.Email, Password: "correctpassword"}, "")
+	require.NoError(t, err)
+}
+
+func TestAuthService_Login_MFAEnabledReturnsChallenge(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockRefreshRepo := new(MockRefreshTokenRepository)
+	mockMFARepo := new(MockMFARepository)
+	tokens := newTestTokenManager()
+	service := NewAuthService(mockUserRepo, mockRefreshRepo, new(MockTokenRevocationRepository), mockMFARepo, tokens, 24*time.Hour, time.Minute, newTestLoginLockouts())
+
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.MinCost)
+	user := &model.User{ID: uuid.New(), Email: "admin@example.com", Password: string(hashedPassword), Role: model.RoleAdmin}
+	enabledAt := time.Now()
+
+	mockUserRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	mockMFARepo.On("GetByUserID", ctx, user.ID).Return(&model.UserMFA{UserID: user.ID, EnabledAt: &enabledAt}, nil)
+
+	response, err := service.Login(ctx, &model.LoginRequest{Email: user.Email, Password: "correctpassword"}, "192.0.2.1")
+
+	require.NoError(t, err)
+	assert.True(t, response.MFARequired)
+	assert.Empty(t, response.Token)
+	assert.Empty(t, response.RefreshToken)
+	assert.Nil(t, response.User)
+
+	userID, err := tokens.ParseMFAChallenge(response.MFAToken)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, userID)
+
+	_, err = tokens.Parse(response.MFAToken)
+	assert.Error(t, err, "challenge token must not be accepted as an access token")
+	mockRefreshRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/presence"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+)
+
+// ErrAutoCheckoutRunning は他のレプリカまたはリクエストが自動退室を実行中の場合に返す
+var ErrAutoCheckoutRunning = errors.New("auto checkout is already running")
+
+// autoCheckoutLockKey は自動退室のアドバイザリーロックのキー
+const autoCheckoutLockKey int64 = 0x54534e47_00000001
+
+type AutoCheckoutService interface {
+	// Run は締め時刻を過ぎても入室中の記録に、入室後最初の締め時刻で自動の退室を追加する。
+	// 同じ締め時刻について何度実行しても結果は変わらない
+	Run(ctx context.Context) (*model.AutoCheckoutResult, error)
+	// Trigger は admin が Run を手動で実行する
+	Trigger(ctx context.Context, caller *auth.Identity) (*model.AutoCheckoutResult, error)
+	// Start は起動時と毎日の締め時刻に Run を実行する。ctx が終了するまで戻らない
+	Start(ctx context.Context)
+}
+
+type AutoCheckoutConfig struct {
+	// Hour と Minute は Location での締め時刻
+	Hour     int
+	Minute   int
+	Location *time.Location
+}
+
+type autoCheckoutService struct {
+	attendanceRepo repository.AttendanceRepository
+	userRepo       repository.UserRepository
+	locker         repository.AdvisoryLocker
+	tx             repository.Transactor
+	publisher      presence.Publisher
+	cfg            AutoCheckoutConfig
+	now            func() time.Time
+}
+
+func NewAutoCheckoutService(
+	attendanceRepo repository.AttendanceRepository,
+	userRepo repository.UserRepository,
+	locker repository.AdvisoryLocker,
+	tx repository.Transactor,
+	publisher presence.Publisher,
+	cfg AutoCheckoutConfig,
+) AutoCheckoutService {
+	return &autoCheckoutService{
+		attendanceRepo: attendanceRepo,
+		userRepo:       userRepo,
+		locker:         locker,
+		tx:             tx,
+		publisher:      publisher,
+		cfg:            cfg,
+		now:            time.Now,
+	}
+}
+
+// cutoffOn は t の日付から days 日後の締め時刻を返す
+func (s *autoCheckoutService) cutoffOn(t time.Time, days int) time.Time {
+	t = t.In(s.cfg.Location)
+	return time.Date(t.Year(), t.Month(), t.Day()+days, s.cfg.Hour, s.cfg.Minute, 0, 0, s.cfg.Location)
+}
+
+// nextCutoff は t より後で最初の締め時刻を返す
+func (s *autoCheckoutService) nextCutoff(t time.Time) time.Time {
+	if cutoff := s.cutoffOn(t, 0); cutoff.After(t) {
+		return cutoff
+	}
+	return s.cutoffOn(t, 1)
+}
+
+// lastCutoff は t 以前で最後の締め時刻を返す
+func (s *autoCheckoutService) lastCutoff(t time.Time) time.Time {
+	if cutoff := s.cutoffOn(t, 0); !cutoff.After(t) {
+		return cutoff
+	}
+	return s.cutoffOn(t, -1)
+}
+
+func (s *autoCheckoutService) Run(ctx context.Context) (*model.AutoCheckoutResult, error) {
+	cutoff := s.lastCutoff(s.now())
+	result := &model.AutoCheckoutResult{Logs: []*model.AttendanceLog{}}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		locked, err := s.locker.TryLock(ctx, autoCheckoutLockKey)
+		if err != nil {
+			return fmt.Errorf("failed to acquire auto checkout lock: %w", err)
+		}
+		if !locked {
+			return ErrAutoCheckoutRunning
+		}
+
+		open, err := s.attendanceRepo.ListOpen(ctx, cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to list open check-ins: %w", err)
+		}
+
+		for _, checkIn := range open {
+			checkOut, err := s.close(ctx, checkIn)
+			if err != nil {
+				return err
+			}
+			if checkOut != nil {
+				result.Logs = append(result.Logs, checkOut)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publishPresence(ctx, s.publisher, result.Logs...)
+	result.Closed = len(result.Logs)
+	return result, nil
+}
+
+// close は入室後最初の締め時刻で退室を記録する。ロックを待つ間に退室が記録された場合は nil を返す
+func (s *autoCheckoutService) close(ctx context.Context, checkIn *model.AttendanceLog) (*model.AttendanceLog, error) {
+	// 同時に届いたタップと二重に退室しないよう、ユーザーの記録を直列化する。
+	// 一覧の取得後に削除されたユーザーは対象にしない
+	err := lockUser(ctx, s.userRepo, checkIn.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := s.attendanceRepo.Latest(ctx, checkIn.UserID, checkIn.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attendance: %w", err)
+	}
+	if latest.ID != checkIn.ID {
+		return nil, nil
+	}
+
+	checkOut := &model.AttendanceLog{
+		UserID:     checkIn.UserID,
+		LocationID: checkIn.LocationID,
+		Type:       model.AttendanceCheckOut,
+		RecordedAt: s.nextCutoff(checkIn.RecordedAt),
+		Auto:       true,
+	}
+	if err := s.attendanceRepo.Create(ctx, checkOut); err != nil {
+		return nil, fmt.Errorf("failed to record auto checkout: %w", err)
+	}
+
+	return checkOut, nil
+}
+
+func (s *autoCheckoutService) Trigger(ctx context.Context, caller *auth.Identity) (*model.AutoCheckoutResult, error) {
+	if !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	return s.Run(ctx)
+}
+
+func (s *autoCheckoutService) Start(ctx context.Context) {
+	for {
+		result, err := s.Run(ctx)
+		switch {
+		case errors.Is(err, ErrAutoCheckoutRunning):
+			log.Printf("Auto checkout skipped: running on another replica")
+		case err != nil:
+			log.Printf("Auto checkout failed: %v", err)
+		case result.Closed > 0:
+			log.Printf("Auto checkout closed %d open check-ins", result.Closed)
+		}
+
+		now := s.now()
+		timer := time.NewTimer(s.nextCutoff(now).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
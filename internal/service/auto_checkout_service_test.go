@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/presence"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAdvisoryLocker struct {
+	mock.Mock
+}
+
+func (m *MockAdvisoryLocker) TryLock(ctx context.Context, key int64) (bool, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Error(1)
+}
+
+var testJST = time.FixedZone("JST", 9*60*60)
+
+func newTestAutoCheckoutService(now time.Time) (*autoCheckoutService, *attendanceTestDeps, *MockAdvisoryLocker) {
+	deps := &attendanceTestDeps{
+		attendanceRepo: new(MockAttendanceRepository),
+		userRepo:       new(MockUserRepository),
+		tx:             &fakeTransactor{},
+		bus:            presence.NewMemoryBus(),
+	}
+	locker := new(MockAdvisoryLocker)
+	svc := NewAutoCheckoutService(deps.attendanceRepo, deps.userRepo, locker, deps.tx, deps.bus, AutoCheckoutConfig{
+		Hour:     23,
+		Minute:   0,
+		Location: testJST,
+	}).(*autoCheckoutService)
+	svc.now = func() time.Time { return now }
+	return svc, deps, locker
+}
+
+func TestAutoCheckoutService_Cutoffs(t *testing.T) {
+	svc, _, _ := newTestAutoCheckoutService(time.Now())
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		wantNext time.Time
+		wantLast time.Time
+	}{
+		{
+			name:     "before cutoff",
+			at:       time.Date(2026, 10, 19, 10, 0, 0, 0, testJST),
+			wantNext: time.Date(2026, 10, 19, 23, 0, 0, 0, testJST),
+			wantLast: time.Date(2026, 10, 18, 23, 0, 0, 0, testJST),
+		},
+		{
+			name:     "at cutoff",
+			at:       time.Date(2026, 10, 19, 23, 0, 0, 0, testJST),
+			wantNext: time.Date(2026, 10, 20, 23, 0, 0, 0, testJST),
+			wantLast: time.Date(2026, 10, 19, 23, 0, 0, 0, testJST),
+		},
+		{
+			// UTC では前日でも締め時刻のタイムゾーンの日付で決める
+			name:     "date differs from UTC",
+			at:       time.Date(2026, 10, 19, 16, 30, 0, 0, time.UTC),
+			wantNext: time.Date(2026, 10, 20, 23, 0, 0, 0, testJST),
+			wantLast: time.Date(2026, 10, 19, 23, 0, 0, 0, testJST),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.wantNext.Equal(svc.nextCutoff(tt.at)), "next: %v", svc.nextCutoff(tt.at))
+			assert.True(t, tt.wantLast.Equal(svc.lastCutoff(tt.at)), "last: %v", svc.lastCutoff(tt.at))
+		})
+	}
+}
+
+func TestAutoCheckoutService_Run(t *testing.T) {
+	now := time.Date(2026, 10, 20, 1, 0, 0, 0, testJST)
+	svc, deps, locker := newTestAutoCheckoutService(now)
+
+	ctx := context.Background()
+	forgotten := &model.AttendanceLog{ID: 1, UserID: uuid.New(), LocationID: testLocationID, Type: model.AttendanceCheckIn, RecordedAt: time.Date(2026, 10, 19, 10, 0, 0, 0, testJST)}
+	lateNight := &model.AttendanceLog{ID: 2, UserID: uuid.New(), LocationID: testLocationID, Type: model.AttendanceCheckIn, RecordedAt: time.Date(2026, 10, 18, 23, 30, 0, 0, testJST)}
+	tappedOut := &model.AttendanceLog{ID: 3, UserID: uuid.New(), LocationID: testLocationID, Type: model.AttendanceCheckIn, RecordedAt: time.Date(2026, 10, 19, 9, 0, 0, 0, testJST)}
+
+	locker.On("TryLock", ctx, autoCheckoutLockKey).Return(true, nil)
+	deps.attendanceRepo.On("ListOpen", ctx, sameTime(time.Date(2026, 10, 19, 23, 0, 0, 0, testJST))).
+		Return([]*model.AttendanceLog{forgotten, lateNight, tappedOut}, nil)
+	for _, checkIn := range []*model.AttendanceLog{forgotten, lateNight, tappedOut} {
+		deps.userRepo.On("GetByIDForUpdate", ctx, checkIn.UserID).Return(&model.User{ID: checkIn.UserID}, nil)
+	}
+	deps.attendanceRepo.On("Latest", ctx, forgotten.UserID, testLocationID).Return(forgotten, nil)
+	deps.attendanceRepo.On("Latest", ctx, lateNight.UserID, testLocationID).Return(lateNight, nil)
+	// ロックを待つ間に退室がタップされた
+	deps.attendanceRepo.On("Latest", ctx, tappedOut.UserID, testLocationID).
+		Return(&model.AttendanceLog{ID: 4, UserID: tappedOut.UserID, Type: model.AttendanceCheckOut}, nil)
+	deps.attendanceRepo.On("Create", ctx, mock.AnythingOfType("*model.AttendanceLog")).Return(nil)
+	events, unsubscribe := deps.bus.Subscribe(testLocationID)
+	defer unsubscribe()
+
+	result, err := svc.Run(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Closed)
+	require.Len(t, result.Logs, 2)
+	for i, checkIn := range []*model.AttendanceLog{forgotten, lateNight} {
+		checkOut := result.Logs[i]
+		assert.Equal(t, checkIn.UserID, checkOut.UserID)
+		assert.Equal(t, model.AttendanceCheckOut, checkOut.Type)
+		assert.True(t, checkOut.Auto)
+		// 入室後最初の締め時刻で退室する
+		assert.True(t, time.Date(2026, 10, 19, 23, 0, 0, 0, testJST).Equal(checkOut.RecordedAt), "recorded at %v", checkOut.RecordedAt)
+	}
+	deps.attendanceRepo.AssertNumberOfCalls(t, "Create", 2)
+	require.Len(t, events, 2)
+	for range result.Logs {
+		event := <-events
+		assert.Equal(t, model.PresenceLeave, event.Type)
+		assert.True(t, event.Auto)
+	}
+}
+
+func TestAutoCheckoutService_Run_LockFailure(t *testing.T) {
+	now := time.Date(2026, 10, 20, 1, 0, 0, 0, testJST)
+	svc, deps, locker := newTestAutoCheckoutService(now)
+
+	ctx := context.Background()
+	checkIn := &model.AttendanceLog{ID: 1, UserID: uuid.New(), LocationID: testLocationID, Type: model.AttendanceCheckIn, RecordedAt: time.Date(2026, 10, 19, 10, 0, 0, 0, testJST)}
+
+	locker.On("TryLock", ctx, autoCheckoutLockKey).Return(true, nil)
+	deps.attendanceRepo.On("ListOpen", ctx, mock.Anything).Return([]*model.AttendanceLog{checkIn}, nil)
+	deps.userRepo.On("GetByIDForUpdate", ctx, checkIn.UserID).Return(nil, assert.AnError)
+
+	result, err := svc.Run(ctx)
+
+	require.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, result)
+	deps.attendanceRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAutoCheckoutService_Run_Locked(t *testing.T) {
+	svc, deps, locker := newTestAutoCheckoutService(time.Now())
+
+	ctx := context.Background()
+	locker.On("TryLock", ctx, autoCheckoutLockKey).Return(false, nil)
+
+	result, err := svc.Run(ctx)
+
+	require.ErrorIs(t, err, ErrAutoCheckoutRunning)
+	assert.Nil(t, result)
+	deps.attendanceRepo.AssertNotCalled(t, "ListOpen", mock.Anything, mock.Anything)
+}
+
+func TestAutoCheckoutService_Trigger_RequiresAdmin(t *testing.T) {
+	svc, _, locker := newTestAutoCheckoutService(time.Now())
+
+	staff := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+	result, err := svc.Trigger(context.Background(), staff)
+
+	require.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, result)
+	locker.AssertNotCalled(t, "TryLock", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/presence"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+)
+
+var (
+	ErrCorrectionNotFound = errors.New("attendance correction not found")
+	// ErrCorrectionReviewed は承認または却下済みの申請を審査しようとした場合に返す
+	ErrCorrectionReviewed = errors.New("attendance correction has already been reviewed")
+	// ErrSelfReview は自分が申請した修正を審査しようとした場合に返す
+	ErrSelfReview = errors.New("cannot review own attendance correction")
+)
+
+const (
+	maxCorrectionReasonLength  = 1000
+	maxCorrectionCommentLength = 1000
+)
+
+type CorrectionService interface {
+	// Submit は入退室の修正を申請する。member は自分の記録のみ、staff と admin は任意のユーザーの記録を申請できる
+	Submit(ctx context.Context, caller *auth.Identity, req *model.CorrectionRequest) (*model.AttendanceCorrection, error)
+	// List は member には自分の申請のみ、staff と admin には全ユーザーの申請を返す
+	List(ctx context.Context, caller *auth.Identity, filter model.CorrectionFilter) ([]*model.AttendanceCorrection, error)
+	Get(ctx context.Context, caller *auth.Identity, id int64) (*model.AttendanceCorrection, error)
+	// Approve は申請を承認し、申請された入退室を manual の記録として追加する。既存の記録は変更しない。
+	// 申請した時刻の状態と矛盾する場合（入室中の入室・入室していない退室）は承認しない。
+	// staff と admin のみ呼び出せ、自分の申請は審査できない
+	Approve(ctx context.Context, caller *auth.Identity, id int64, req *model.CorrectionReviewRequest) (*model.AttendanceCorrection, error)
+	// Reject は申請を却下する。Approve と同じく staff と admin のみ呼び出せる
+	Reject(ctx context.Context, caller *auth.Identity, id int64, req *model.CorrectionReviewRequest) (*model.AttendanceCorrection, error)
+}
+
+type correctionService struct {
+	correctionRepo repository.CorrectionRepository
+	attendanceRepo repository.AttendanceRepository
+	userRepo       repository.UserRepository
+	masterRepo     repository.MasterDataRepository
+	tx             repository.Transactor
+	publisher      presence.Publisher
+}
+
+func NewCorrectionService(
+	correctionRepo repository.CorrectionRepository,
+	attendanceRepo repository.AttendanceRepository,
+	userRepo repository.UserRepository,
+	masterRepo repository.MasterDataRepository,
+	tx repository.Transactor,
+	publisher presence.Publisher,
+) CorrectionService {
+	return &correctionService{
+		correctionRepo: correctionRepo,
+		attendanceRepo: attendanceRepo,
+		userRepo:       userRepo,
+		masterRepo:     masterRepo,
+		tx:             tx,
+		publisher:      publisher,
+	}
+}
+
+func (s *correctionService) Submit(ctx context.Context, caller *auth.Identity, req *model.CorrectionRequest) (*model.AttendanceCorrection, error) {
+	userID := caller.UserID
+	if req.UserID != nil {
+		userID = *req.UserID
+	}
+	if !canRead(caller, userID) {
+		return nil, ErrForbidden
+	}
+
+	now := time.Now()
+	reason := strings.TrimSpace(req.Reason)
+	validationErr := &ValidationError{}
+	switch req.Type {
+	case model.AttendanceCheckIn, model.AttendanceCheckOut:
+	case "":
+		validationErr.Add("type", "is required")
+	default:
+		validationErr.Add("type", "must be one of check-in, check-out")
+	}
+	switch {
+	case req.RecordedAt.IsZero():
+		validationErr.Add("recorded_at", "is required")
+	case req.RecordedAt.After(now):
+		validationErr.Add("recorded_at", "must not be in the future")
+	}
+	switch {
+	case reason == "":
+		validationErr.Add("reason", "is required")
+	case len([]rune(reason)) > maxCorrectionReasonLength:
+		validationErr.Add("reason", fmt.Sprintf("must be at most %d characters", maxCorrectionReasonLength))
+	}
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := checkLocation(ctx, s.masterRepo, req.LocationID); err != nil {
+		return nil, err
+	}
+	_, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	correction := &model.AttendanceCorrection{
+		UserID:      userID,
+		LocationID:  req.LocationID,
+		Type:        req.Type,
+		RecordedAt:  req.RecordedAt,
+		Reason:      reason,
+		Status:      model.CorrectionPending,
+		RequestedBy: &caller.UserID,
+		RequestedAt: now,
+	}
+	if err := s.correctionRepo.Create(ctx, correction); err != nil {
+		return nil, fmt.Errorf("failed to create attendance correction: %w", err)
+	}
+
+	return correction, nil
+}
+
+func (s *correctionService) List(ctx context.Context, caller *auth.Identity, filter model.CorrectionFilter) ([]*model.AttendanceCorrection, error) {
+	if !caller.HasRole(model.RoleStaff, model.RoleAdmin) {
+		if filter.UserID != nil && *filter.UserID != caller.UserID {
+			return nil, ErrForbidden
+		}
+		filter.UserID = &caller.UserID
+	}
+
+	if filter.Status != nil {
+		switch *filter.Status {
+		case model.CorrectionPending, model.CorrectionApproved, model.CorrectionRejected:
+		default:
+			validationErr := &ValidationError{}
+			validationErr.Add("status", "must be one of pending, approved, rejected")
+			return nil, validationErr
+		}
+	}
+
+	return s.correctionRepo.List(ctx, filter)
+}
+
+func (s *correctionService) Get(ctx context.Context, caller *auth.Identity, id int64) (*model.AttendanceCorrection, error) {
+	correction, err := s.correctionRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrCorrectionNotFound) {
+		return nil, ErrCorrectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !canRead(caller, correction.UserID) {
+		return nil, ErrForbidden
+	}
+
+	return correction, nil
+}
+
+func (s *correctionService) Approve(ctx context.Context, caller *auth.Identity, id int64, req *model.CorrectionReviewRequest) (*model.AttendanceCorrection, error) {
+	var changed *model.AttendanceLog
+	correction, err := s.review(ctx, caller, id, req, func(ctx context.Context, correction *model.AttendanceCorrection) error {
+		// 打刻と同じく同じユーザーの記録を直列化する
+		if err := lockUser(ctx, s.userRepo, correction.UserID); err != nil {
+			return err
+		}
+
+		checkedIn, err := s.checkedInAt(ctx, correction)
+		if err != nil {
+			return err
+		}
+		switch {
+		case correction.Type == model.AttendanceCheckIn && checkedIn:
+			return ErrAlreadyCheckedIn
+		case correction.Type == model.AttendanceCheckOut && !checkedIn:
+			return ErrNotCheckedIn
+		}
+
+		log := &model.AttendanceLog{
+			UserID:     correction.UserID,
+			LocationID: correction.LocationID,
+			Type:       correction.Type,
+			RecordedAt: correction.RecordedAt,
+			Manual:     true,
+		}
+		if err := s.attendanceRepo.Create(ctx, log); err != nil {
+			return fmt.Errorf("failed to record attendance: %w", err)
+		}
+		correction.Status = model.CorrectionApproved
+		correction.AttendanceLogID = &log.ID
+
+		// 後から記録済みの入退室より前の時刻の修正は在室状況を変えない
+		latest, err := s.attendanceRepo.Latest(ctx, correction.UserID, correction.LocationID)
+		if err != nil {
+			return fmt.Errorf("failed to load attendance: %w", err)
+		}
+		if latest.ID == log.ID {
+			changed = log
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if changed != nil {
+		publishPresence(ctx, s.publisher, changed)
+	}
+	return correction, nil
+}
+
+func (s *correctionService) Reject(ctx context.Context, caller *auth.Identity, id int64, req *model.CorrectionReviewRequest) (*model.AttendanceCorrection, error) {
+	return s.review(ctx, caller, id, req, func(ctx context.Context, correction *model.AttendanceCorrection) error {
+		correction.Status = model.CorrectionRejected
+		return nil
+	})
+}
+
+// review は審査中の申請にロックをかけて decide に渡し、審査者と審査日時を記録する
+func (s *correctionService) review(
+	ctx context.Context,
+	caller *auth.Identity,
+	id int64,
+	req *model.CorrectionReviewRequest,
+	decide func(ctx context.Context, correction *model.AttendanceCorrection) error,
+) (*model.AttendanceCorrection, error) {
+	if !caller.HasRole(model.RoleStaff, model.RoleAdmin) {
+		return nil, ErrForbidden
+	}
+
+	comment := strings.TrimSpace(req.Comment)
+	if len([]rune(comment)) > maxCorrectionCommentLength {
+		validationErr := &ValidationError{}
+		validationErr.Add("comment", fmt.Sprintf("must be at most %d characters", maxCorrectionCommentLength))
+		return nil, validationErr
+	}
+
+	var correction *model.AttendanceCorrection
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		correction, err = s.correctionRepo.GetByIDForUpdate(ctx, id)
+		if errors.Is(err, repository.ErrCorrectionNotFound) {
+			return ErrCorrectionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if correction.Status != model.CorrectionPending {
+			return ErrCorrectionReviewed
+		}
+		if correction.RequestedBy != nil && *correction.RequestedBy == caller.UserID {
+			return ErrSelfReview
+		}
+
+		if err := decide(ctx, correction); err != nil {
+			return err
+		}
+
+		reviewedAt := time.Now()
+		correction.ReviewedBy = &caller.UserID
+		correction.ReviewedAt = &reviewedAt
+		correction.ReviewComment = comment
+		return s.correctionRepo.Review(ctx, correction)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return correction, nil
+}
+
+// checkedInAt は申請された時刻の時点でその場所に入室中であったかを返す
+func (s *correctionService) checkedInAt(ctx context.Context, correction *model.AttendanceCorrection) (bool, error) {
+	latest, err := s.attendanceRepo.LatestAt(ctx, correction.UserID, correction.LocationID, correction.RecordedAt)
+	if errors.Is(err, repository.ErrAttendanceLogNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load attendance: %w", err)
+	}
+	return latest.Type == model.AttendanceCheckIn, nil
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/presence"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockCorrectionRepository struct {
+	mock.Mock
+}
+
+func (m *MockCorrectionRepository) Create(ctx context.Context, correction *model.AttendanceCorrection) error {
+	args := m.Called(ctx, correction)
+	return args.Error(0)
+}
+
+func (m *MockCorrectionRepository) GetByID(ctx context.Context, id int64) (*model.AttendanceCorrection, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AttendanceCorrection), args.Error(1)
+}
+
+func (m *MockCorrectionRepository) GetByIDForUpdate(ctx context.Context, id int64) (*model.AttendanceCorrection, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AttendanceCorrection), args.Error(1)
+}
+
+func (m *MockCorrectionRepository) List(ctx context.Context, filter model.CorrectionFilter) ([]*model.AttendanceCorrection, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.AttendanceCorrection), args.Error(1)
+}
+
+func (m *MockCorrectionRepository) Review(ctx context.Context, correction *model.AttendanceCorrection) error {
+	args := m.Called(ctx, correction)
+	return args.Error(0)
+}
+
+type correctionTestDeps struct {
+	correctionRepo *MockCorrectionRepository
+	attendanceRepo *MockAttendanceRepository
+	userRepo       *MockUserRepository
+	masterRepo     *MockMasterDataRepository
+	tx             *fakeTransactor
+	bus            *presence.MemoryBus
+}
+
+func newTestCorrectionService() (CorrectionService, *correctionTestDeps) {
+	deps := &correctionTestDeps{
+		correctionRepo: new(MockCorrectionRepository),
+		attendanceRepo: new(MockAttendanceRepository),
+		userRepo:       new(MockUserRepository),
+		masterRepo:     new(MockMasterDataRepository),
+		tx:             &fakeTransactor{},
+		bus:            presence.NewMemoryBus(),
+	}
+	return NewCorrectionService(deps.correctionRepo, deps.attendanceRepo, deps.userRepo, deps.masterRepo, deps.tx, deps.bus), deps
+}
+
+// pendingCorrection は member が自分の記録の修正を申請した審査待ちの申請
+func pendingCorrection(member uuid.UUID, typ model.AttendanceType, recordedAt time.Time) *model.AttendanceCorrection {
+	return &model.AttendanceCorrection{
+		ID:          7,
+		UserID:      member,
+		LocationID:  testLocationID,
+		Type:        typ,
+		RecordedAt:  recordedAt,
+		Reason:      "カードを忘れた",
+		Status:      model.CorrectionPending,
+		RequestedBy: &member,
+	}
+}
+
+func TestCorrectionService_Submit(t *testing.T) {
+	t.Run("member requests own correction", func(t *testing.T) {
+		svc, deps := newTestCorrectionService()
+
+		ctx := context.Background()
+		caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+		recordedAt := time.Now().Add(-2 * time.Hour).In(testJST)
+		deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID, Name: "部室"}, nil)
+		deps.userRepo.On("GetByID", ctx, caller.UserID).Return(&model.User{ID: caller.UserID}, nil)
+		deps.correctionRepo.On("Create", ctx, mock.AnythingOfType("*model.AttendanceCorrection")).Return(nil)
+
+		correction, err := svc.Submit(ctx, caller, &model.CorrectionRequest{
+			LocationID: testLocationID,
+			Type:       model.AttendanceCheckIn,
+			RecordedAt: recordedAt,
+			Reason:     "  カードを忘れた  ",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, caller.UserID, correction.UserID)
+		assert.Equal(t, model.CorrectionPending, correction.Status)
+		assert.Equal(t, "カードを忘れた", correction.Reason)
+		assert.Equal(t, &caller.UserID, correction.RequestedBy)
+		assert.True(t, recordedAt.Equal(correction.RecordedAt))
+		assert.Nil(t, correction.ReviewedBy)
+		deps.correctionRepo.AssertExpectations(t)
+	})
+
+	t.Run("member cannot request for another user", func(t *testing.T) {
+		svc, deps := newTestCorrectionService()
+
+		other := uuid.New()
+		_, err := svc.Submit(context.Background(), &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}, &model.CorrectionRequest{
+			UserID:     &other,
+			LocationID: testLocationID,
+			Type:       model.AttendanceCheckIn,
+			RecordedAt: time.Now().Add(-time.Hour),
+			Reason:     "カードを忘れた",
+		})
+
+		assert.ErrorIs(t, err, ErrForbidden)
+		deps.correctionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("validates the request", func(t *testing.T) {
+		svc, _ := newTestCorrectionService()
+
+		_, err := svc.Submit(context.Background(), &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}, &model.CorrectionRequest{
+			LocationID: testLocationID,
+			Type:       "break",
+			RecordedAt: time.Now().Add(time.Hour),
+			Reason:     " ",
+		})
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, map[string][]string{
+			"type":        {"must be one of check-in, check-out"},
+			"recorded_at": {"must not be in the future"},
+			"reason":      {"is required"},
+		}, validationErr.Fields)
+	})
+}
+
+func TestCorrectionService_List(t *testing.T) {
+	t.Run("member only sees own corrections", func(t *testing.T) {
+		svc, deps := newTestCorrectionService()
+
+		ctx := context.Background()
+		caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+		deps.correctionRepo.On("List", ctx, model.CorrectionFilter{UserID: &caller.UserID, Limit: 10}).Return([]*model.AttendanceCorrection{}, nil)
+
+		_, err := svc.List(ctx, caller, model.CorrectionFilter{Limit: 10})
+
+		require.NoError(t, err)
+		deps.correctionRepo.AssertExpectations(t)
+	})
+
+	t.Run("member cannot list another user", func(t *testing.T) {
+		svc, _ := newTestCorrectionService()
+
+		other := uuid.New()
+		_, err := svc.List(context.Background(), &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}, model.CorrectionFilter{UserID: &other})
+
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("rejects unknown status", func(t *testing.T) {
+		svc, _ := newTestCorrectionService()
+
+		status := model.CorrectionStatus("done")
+		_, err := svc.List(context.Background(), testStaff, model.CorrectionFilter{Status: &status})
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Contains(t, validationErr.Fields, "status")
+	})
+}
+
+func TestCorrectionService_Get(t *testing.T) {
+	svc, deps := newTestCorrectionService()
+
+	ctx := context.Background()
+	member := uuid.New()
+	deps.correctionRepo.On("GetByID", ctx, int64(7)).Return(pendingCorrection(member, model.AttendanceCheckIn, time.Now()), nil)
+	deps.correctionRepo.On("GetByID", ctx, int64(8)).Return(nil, repository.ErrCorrectionNotFound)
+
+	_, err := svc.Get(ctx, &auth.Identity{UserID: member, Role: model.RoleMember}, 7)
+	require.NoError(t, err)
+
+	_, err = svc.Get(ctx, &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}, 7)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = svc.Get(ctx, testStaff, 8)
+	assert.ErrorIs(t, err, ErrCorrectionNotFound)
+}
+
+func TestCorrectionService_Approve(t *testing.T) {
+	t.Run("adds a manual log and records the reviewer", func(t *testing.T) {
+		svc, deps := newTestCorrectionService()
+
+		ctx := context.Background()
+		member := uuid.New()
+		recordedAt := time.Now().Add(-3 * time.Hour)
+		correction := pendingCorrection(member, model.AttendanceCheckIn, recordedAt)
+		deps.correctionRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(correction, nil)
+		deps.userRepo.On("GetByIDForUpdate", ctx, member).Return(&model.User{ID: member}, nil)
+		deps.attendanceRepo.On("LatestAt", ctx, member, testLocationID, recordedAt).Return(&model.AttendanceLog{ID: 1, Type: model.AttendanceCheckOut}, nil)
+		deps.attendanceRepo.On("Create", ctx, mock.MatchedBy(func(log *model.AttendanceLog) bool {
+			return log.Manual && !log.Auto && log.Type == model.AttendanceCheckIn && log.RecordedAt.Equal(recordedAt)
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*model.AttendanceLog).ID = 42
+		}).Return(nil)
+		deps.attendanceRepo.On("Latest", ctx, member, testLocationID).Return(&model.AttendanceLog{ID: 42, Type: model.AttendanceCheckIn}, nil)
+		deps.correctionRepo.On("Review", ctx, correction).Return(nil)
+		events, unsubscribe := deps.bus.Subscribe(testLocationID)
+		defer unsubscribe()
+
+		approved, err := svc.Approve(ctx, testStaff, 7, &model.CorrectionReviewRequest{Comment: " 確認済み "})
+
+		require.NoError(t, err)
+		assert.Equal(t, model.CorrectionApproved, approved.Status)
+		assert.Equal(t, &testStaff.UserID, approved.ReviewedBy)
+		require.NotNil(t, approved.ReviewedAt)
+		assert.Equal(t, "確認済み", approved.ReviewComment)
+		require.NotNil(t, approved.AttendanceLogID)
+		assert.Equal(t, int64(42), *approved.AttendanceLogID)
+		assert.Equal(t, 1, deps.tx.calls)
+		require.Len(t, events, 1)
+		assert.Equal(t, model.PresenceJoin, (<-events).Type)
+		deps.correctionRepo.AssertExpectations(t)
+	})
+
+	t.Run("does not publish a correction before later logs", func(t *testing.T) {
+		svc, deps := newTestCorrectionService()
+
+		ctx := context.Background()
+		member := uuid.New()
+		recordedAt := time.Now().Add(-3 * time.Hour)
+		correction := pendingCorrection(member, model.AttendanceCheckOut, recordedAt)
+		deps.correctionRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(correction, nil)
+		deps.userRepo.On("GetByIDForUpdate", ctx, member).Return(&model.User{ID: member}, nil)
+		deps.attendanceRepo.On("LatestAt", ctx, member, testLocationID, recordedAt).Return(&model.AttendanceLog{ID: 1, Type: model.AttendanceCheckIn}, nil)
+		deps.attendanceRepo.On("Create", ctx, mock.AnythingOfType("*model.AttendanceLog")).Run(func(args mock.Arguments) {
+			args.Get(1).(*model.AttendanceLog).ID = 42
+		}).Return(nil)
+		deps.attendanceRepo.On("Latest", ctx, member, testLocationID).Return(&model.AttendanceLog{ID: 50, Type: model.AttendanceCheckIn}, nil)
+		deps.correctionRepo.On("Review", ctx, correction).Return(nil)
+		events, unsubscribe := deps.bus.Subscribe(testLocationID)
+		defer unsubscribe()
+
+		_, err := svc.Approve(ctx, testStaff, 7, &model.CorrectionReviewRequest{})
+
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("conflicts with the state at that time", func(t *testing.T) {
+		svc, deps := newTestCorrectionService()
+
+		ctx := context.Background()
+		member := uuid.New()
+		recordedAt := time.Now().Add(-3 * time.Hour)
+		deps.correctionRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(pendingCorrection(member, model.AttendanceCheckOut, recordedAt), nil)
+		deps.userRepo.On("GetByIDForUpdate", ctx, member).Return(&model.User{ID: member}, nil)
+		deps.attendanceRepo.On("LatestAt", ctx, member, testLocationID, recordedAt).Return(nil, repository.ErrAttendanceLogNotFound)
+
+		_, err := svc.Approve(ctx, testStaff, 7, &model.CorrectionReviewRequest{})
+
+		assert.ErrorIs(t, err, ErrNotCheckedIn)
+		deps.attendanceRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		deps.correctionRepo.AssertNotCalled(t, "Review", mock.Anything, mock.Anything)
+	})
+
+	t.Run("fails when the user cannot be locked", func(t *testing.T) {
+		svc, deps := newTestCorrectionService()
+
+		ctx := context.Background()
+		member := uuid.New()
+		recordedAt := time.Now().Add(-3 * time.Hour)
+		deps.correctionRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(pendingCorrection(member, model.AttendanceCheckIn, recordedAt), nil)
+		deps.userRepo.On("GetByIDForUpdate", ctx, member).Return(nil, assert.AnError)
+
+		_, err := svc.Approve(ctx, testStaff, 7, &model.CorrectionReviewRequest{})
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NotErrorIs(t, err, ErrUserNotFound)
+		deps.attendanceRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		deps.correctionRepo.AssertNotCalled(t, "Review", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCorrectionService_Review_Errors(t *testing.T) {
+	member := uuid.New()
+
+	tests := []struct {
+		name       string
+		caller     *auth.Identity
+		correction *model.AttendanceCorrection
+		err        error
+		wantErr    error
+	}{
+		{name: "requires staff", caller: &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}, wantErr: ErrForbidden},
+		{name: "not found", caller: testStaff, err: repository.ErrCorrectionNotFound, wantErr: ErrCorrectionNotFound},
+		{
+			name:   "already reviewed",
+			caller: testStaff,
+			correction: func() *model.AttendanceCorrection {
+				c := pendingCorrection(member, model.AttendanceCheckIn, time.Now())
+				c.Status = model.CorrectionRejected
+				return c
+			}(),
+			wantErr: ErrCorrectionReviewed,
+		},
+		{name: "own request", caller: &auth.Identity{UserID: member, Role: model.RoleStaff}, correction: pendingCorrection(member, model.AttendanceCheckIn, time.Now()), wantErr: ErrSelfReview},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deps := newTestCorrectionService()
+
+			ctx := context.Background()
+			if tt.correction != nil || tt.err != nil {
+				deps.correctionRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(tt.correction, tt.err)
+			}
+
+			_, err := svc.Approve(ctx, tt.caller, 7, &model.CorrectionReviewRequest{})
+			assert.ErrorIs(t, err, tt.wantErr)
+			_, err = svc.Reject(ctx, tt.caller, 7, &model.CorrectionReviewRequest{})
+			assert.ErrorIs(t, err, tt.wantErr)
+			deps.correctionRepo.AssertNotCalled(t, "Review", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCorrectionService_Reject(t *testing.T) {
+	svc, deps := newTestCorrectionService()
+
+	ctx := context.Background()
+	correction := pendingCorrection(uuid.New(), model.AttendanceCheckIn, time.Now().Add(-time.Hour))
+	deps.correctionRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(correction, nil)
+	deps.correctionRepo.On("Review", ctx, correction).Return(nil)
+
+	rejected, err := svc.Reject(ctx, testAdmin, 7, &model.CorrectionReviewRequest{Comment: "記録が残っています"})
+
+	require.NoError(t, err)
+	assert.Equal(t, model.CorrectionRejected, rejected.Status)
+	assert.Equal(t, &testAdmin.UserID, rejected.ReviewedBy)
+	assert.Equal(t, "記録が残っています", rejected.ReviewComment)
+	assert.Nil(t, rejected.AttendanceLogID)
+	deps.attendanceRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/nonce"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/secretbox"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/signature"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDeviceNotFound = errors.New("device not found")
+	ErrDeviceRevoked  = errors.New("device is revoked")
+)
+
+const (
+	// deviceKeyPrefix は API キーを JWT やリフレッシュトークンと見分けるための接頭辞
+	deviceKeyPrefix = "tld_"
+	// deviceKeyDisplayLength は一覧でキーを見分けるために保存する先頭の文字数
+	deviceKeyDisplayLength = 12
+	// deviceLastSeenInterval はリクエストごとに書き込まないよう、最終アクセス日時を更新する間隔
+	deviceLastSeenInterval = time.Minute
+)
+
+type DeviceService interface {
+	auth.DeviceAuthenticator
+	auth.DeviceRequestVerifier
+	RegisterDevice(ctx context.Context, caller *auth.Identity, req *model.DeviceRequest) (*model.DeviceCredentials, error)
+	ListDevices(ctx context.Context, caller *auth.Identity, locationID *int) ([]*model.Device, error)
+	GetDevice(ctx context.Context, caller *auth.Identity, id uuid.UUID) (*model.Device, error)
+	UpdateDevice(ctx context.Context, caller *auth.Identity, id uuid.UUID, req *model.DeviceRequest) (*model.Device, error)
+	// RotateKey は新しい API キーと署名鍵を発行する。古いキーは直ちに使えなくなる
+	RotateKey(ctx context.Context, caller *auth.Identity, id uuid.UUID) (*model.DeviceCredentials, error)
+	// RevokeDevice は端末を失効させる。失効した端末は元に戻せない
+	RevokeDevice(ctx context.Context, caller *auth.Identity, id uuid.UUID) (*model.Device, error)
+	// CurrentDevice は認証済みの端末自身の登録内容を返す
+	CurrentDevice(ctx context.Context, device *auth.Device) (*model.Device, error)
+}
+
+type DeviceConfig struct {
+	// Secrets は署名鍵をデータベースに保存する前に暗号化する
+	Secrets *secretbox.Box
+	// SignatureTolerance は署名の時刻とサーバーの時刻のずれの許容範囲
+	SignatureTolerance time.Duration
+	// Nonces は使用済みの nonce を記録する
+	Nonces nonce.Store
+}
+
+type deviceService struct {
+	deviceRepo repository.DeviceRepository
+	masterRepo repository.MasterDataRepository
+	cfg        DeviceConfig
+}
+
+func NewDeviceService(deviceRepo repository.DeviceRepository, masterRepo repository.MasterDataRepository, cfg DeviceConfig) DeviceService {
+	return &deviceService{
+		deviceRepo: deviceRepo,
+		masterRepo: masterRepo,
+		cfg:        cfg,
+	}
+}
+
+// deviceSecrets は発行した API キーと署名鍵、およびそれぞれの保存する形
+type deviceSecrets struct {
+	apiKey        string
+	keyPrefix     string
+	keyHash       string
+	signingSecret string
+	sealedSecret  string
+}
+
+func (s *deviceService) generateSecrets() (*deviceSecrets, error) {
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate device key: %w", err)
+	}
+	key := deviceKeyPrefix + token
+
+	signingSecret, err := signature.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.cfg.Secrets.Seal([]byte(signingSecret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt signing secret: %w", err)
+	}
+
+	return &deviceSecrets{
+		apiKey:        key,
+		keyPrefix:     key[:deviceKeyDisplayLength],
+		keyHash:       hashToken(key),
+		signingSecret: signingSecret,
+		sealedSecret:  sealed,
+	}, nil
+}
+
+func validateDeviceName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	validationErr := &ValidationError{}
+	switch {
+	case name == "":
+		validationErr.Add("name", "is required")
+	case len([]rune(name)) > 255:
+		validationErr.Add("name", "must be at most 255 characters")
+	}
+
+	return name, validationErr.Err()
+}
+
+func (s *deviceService) RegisterDevice(ctx context.Context, caller *auth.Identity, req *model.DeviceRequest) (*model.DeviceCredentials, error) {
+	if !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	name, err := validateDeviceName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLocation(ctx, s.masterRepo, req.LocationID); err != nil {
+		return nil, err
+	}
+
+	secrets, err := s.generateSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	device := &model.Device{
+		Name:          name,
+		LocationID:    req.LocationID,
+		KeyPrefix:     secrets.keyPrefix,
+		KeyHash:       secrets.keyHash,
+		KeyRotatedAt:  now,
+		SigningSecret: &secrets.sealedSecret,
+		CreatedBy:     &caller.UserID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.deviceRepo.Create(ctx, device); err != nil {
+		return nil, fmt.Errorf("failed to create device: %w", err)
+	}
+
+	return &model.DeviceCredentials{Device: device, APIKey: secrets.apiKey, SigningSecret: secrets.signingSecret}, nil
+}
+
+func (s *deviceService) ListDevices(ctx context.Context, caller *auth.Identity, locationID *int) ([]*model.Device, error) {
+	if !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	return s.deviceRepo.List(ctx, locationID)
+}
+
+func (s *deviceService) GetDevice(ctx context.Context, caller *auth.Identity, id uuid.UUID) (*model.Device, error) {
+	if !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	return s.getDevice(ctx, id)
+}
+
+func (s *deviceService) CurrentDevice(ctx context.Context, device *auth.Device) (*model.Device, error) {
+	return s.getDevice(ctx, device.DeviceID)
+}
+
+func (s *deviceService) getDevice(ctx context.Context, id uuid.UUID) (*model.Device, error) {
+	device, err := s.deviceRepo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrDeviceNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+
+	return device, err
+}
+
+func (s *deviceService) UpdateDevice(ctx context.Context, caller *auth.Identity, id uuid.UUID, req *model.DeviceRequest) (*model.Device, error) {
+	if !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	device, err := s.getDevice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := validateDeviceName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	// 場所を変えない場合はアーカイブ済みの場所のままでも名前を変更できる
+	if req.LocationID != device.LocationID {
+		if err := checkLocation(ctx, s.masterRepo, req.LocationID); err != nil {
+			return nil, err
+		}
+	}
+
+	device.Name = name
+	device.LocationID = req.LocationID
+	device.UpdatedAt = time.Now()
+	if err := s.deviceRepo.Update(ctx, device); err != nil {
+		return nil, fmt.Errorf("failed to update device: %w", err)
+	}
+
+	return device, nil
+}
+
+func (s *deviceService) RotateKey(ctx context.Context, caller *auth.Identity, id uuid.UUID) (*model.DeviceCredentials, error) {
+	if !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	device, err := s.getDevice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if device.Revoked() {
+		return nil, ErrDeviceRevoked
+	}
+
+	secrets, err := s.generateSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.deviceRepo.SetKey(ctx, id, secrets.keyPrefix, secrets.keyHash, secrets.sealedSecret, now); err != nil {
+		return nil, fmt.Errorf("failed to rotate device key: %w", err)
+	}
+	device.KeyPrefix = secrets.keyPrefix
+	device.KeyHash = secrets.keyHash
+	device.SigningSecret = &secrets.sealedSecret
+	device.KeyRotatedAt = now
+	device.UpdatedAt = now
+
+	return &model.DeviceCredentials{Device: device, APIKey: secrets.apiKey, SigningSecret: secrets.signingSecret}, nil
+}
+
+func (s *deviceService) RevokeDevice(ctx context.Context, caller *auth.Identity, id uuid.UUID) (*model.Device, error) {
+	if !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	device, err := s.getDevice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if device.Revoked() {
+		return device, nil
+	}
+
+	now := time.Now()
+	// 同時に失効された場合も失効済みとして扱う
+	if err := s.deviceRepo.Revoke(ctx, id, now); err != nil && !errors.Is(err, repository.ErrDeviceNotFound) {
+		return nil, fmt.Errorf("failed to revoke device: %w", err)
+	}
+
+	return s.getDevice(ctx, id)
+}
+
+func (s *deviceService) AuthenticateDevice(ctx context.Context, apiKey string) (*auth.Device, error) {
+	if !strings.HasPrefix(apiKey, deviceKeyPrefix) {
+		return nil, auth.ErrInvalidDeviceKey
+	}
+
+	device, err := s.deviceRepo.GetByKeyHash(ctx, hashToken(apiKey))
+	if errors.Is(err, repository.ErrDeviceNotFound) {
+		return nil, auth.ErrInvalidDeviceKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load device: %w", err)
+	}
+	if device.Revoked() {
+		return nil, auth.ErrRevokedDevice
+	}
+
+	now := time.Now()
+	if device.LastSeenAt == nil || now.Sub(*device.LastSeenAt) >= deviceLastSeenInterval {
+		if err := s.deviceRepo.TouchLastSeen(ctx, device.ID, now); err != nil {
+			return nil, fmt.Errorf("failed to record device access: %w", err)
+		}
+	}
+
+	return &auth.Device{DeviceID: device.ID, LocationID: device.LocationID}, nil
+}
+
+func (s *deviceService) VerifyDeviceRequest(ctx context.Context, device *auth.Device, req *signature.Request, sig string) error {
+	if sig == "" || req.Nonce == "" || len(req.Nonce) > signature.MaxNonceLength {
+		return auth.ErrInvalidSignature
+	}
+
+	signedAt, err := req.Time()
+	if err != nil {
+		return auth.ErrInvalidSignature
+	}
+	now := time.Now()
+	if skew := now.Sub(signedAt); skew > s.cfg.SignatureTolerance || skew < -s.cfg.SignatureTolerance {
+		return auth.ErrStaleSignature
+	}
+
+	stored, err := s.deviceRepo.GetByID(ctx, device.DeviceID)
+	if errors.Is(err, repository.ErrDeviceNotFound) {
+		return auth.ErrInvalidDeviceKey
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load device: %w", err)
+	}
+	if stored.Revoked() {
+		return auth.ErrRevokedDevice
+	}
+	// 署名鍵はキーの再発行で発行される
+	if stored.SigningSecret == nil {
+		return auth.ErrInvalidSignature
+	}
+
+	secret, err := s.cfg.Secrets.Open(*stored.SigningSecret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt signing secret: %w", err)
+	}
+	if !req.Verify(string(secret), sig) {
+		return auth.ErrInvalidSignature
+	}
+
+	// 署名を確認してから記録し、偽の署名で nonce を使用済みにされないようにする。
+	// 時刻が許容範囲に収まる間は再送を拒否する必要があるため、その間は記録を残す
+	expiresAt := signedAt.Add(s.cfg.SignatureTolerance + time.Second)
+	fresh, err := s.cfg.Nonces.Remember(ctx, device.DeviceID.String()+":"+req.Nonce, expiresAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to record nonce: %w", err)
+	}
+	if !fresh {
+		return auth.ErrReplayedRequest
+	}
+
+	if err := s.cfg.Nonces.Purge(ctx, now); err != nil {
+		log.Printf("Failed to purge expired device nonces: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/nonce"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/secretbox"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/signature"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockDeviceRepository struct {
+	mock.Mock
+}
+
+func (m *MockDeviceRepository) Create(ctx context.Context, device *model.Device) error {
+	args := m.Called(ctx, device)
+	return args.Error(0)
+}
+
+func (m *MockDeviceRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Device, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Device), args.Error(1)
+}
+
+func (m *MockDeviceRepository) GetByKeyHash(ctx context.Context, keyHash string) (*model.Device, error) {
+	args := m.Called(ctx, keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Device), args.Error(1)
+}
+
+func (m *MockDeviceRepository) List(ctx context.Context, locationID *int) ([]*model.Device, error) {
+	args := m.Called(ctx, locationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Device), args.Error(1)
+}
+
+func (m *MockDeviceRepository) Update(ctx context.Context, device *model.Device) error {
+	args := m.Called(ctx, device)
+	return args.Error(0)
+}
+
+func (m *MockDeviceRepository) SetKey(ctx context.Context, id uuid.UUID, keyPrefix, keyHash, signingSecret string, rotatedAt time.Time) error {
+	args := m.Called(ctx, id, keyPrefix, keyHash, signingSecret, rotatedAt)
+	return args.Error(0)
+}
+
+func (m *MockDeviceRepository) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	args := m.Called(ctx, id, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockDeviceRepository) TouchLastSeen(ctx context.Context, id uuid.UUID, seenAt time.Time) error {
+	args := m.Called(ctx, id, seenAt)
+	return args.Error(0)
+}
+
+var testDeviceSecrets = mustSecretbox("test-device-key")
+
+func mustSecretbox(key string) *secretbox.Box {
+	box, err := secretbox.New(key)
+	if err != nil {
+		panic(err)
+	}
+	return box
+}
+
+func newTestDeviceService() (DeviceService, *MockDeviceRepository, *MockMasterDataRepository) {
+	deviceRepo := new(MockDeviceRepository)
+	masterRepo := new(MockMasterDataRepository)
+	svc := NewDeviceService(deviceRepo, masterRepo, DeviceConfig{
+		Secrets:            testDeviceSecrets,
+		SignatureTolerance: 5 * time.Minute,
+		Nonces:             nonce.NewMemoryStore(),
+	})
+	return svc, deviceRepo, masterRepo
+}
+
+func TestDeviceService_RegisterDevice(t *testing.T) {
+	svc, deviceRepo, masterRepo := newTestDeviceService()
+
+	ctx := context.Background()
+	masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID, Name: "部室"}, nil)
+	deviceRepo.On("Create", ctx, mock.AnythingOfType("*model.Device")).Return(nil)
+
+	credentials, err := svc.RegisterDevice(ctx, testAdmin, &model.DeviceRequest{Name: " 入口リーダー ", LocationID: testLocationID})
+
+	require.NoError(t, err)
+	assert.Equal(t, "入口リーダー", credentials.Name)
+	assert.True(t, strings.HasPrefix(credentials.APIKey, deviceKeyPrefix))
+	assert.Equal(t, credentials.APIKey[:deviceKeyDisplayLength], credentials.KeyPrefix)
+	// 平文のキーは保存しない
+	assert.Equal(t, hashToken(credentials.APIKey), credentials.KeyHash)
+	assert.Equal(t, &testAdmin.UserID, credentials.CreatedBy)
+	// 署名鍵は暗号化して保存する
+	require.NotEmpty(t, credentials.SigningSecret)
+	require.NotNil(t, credentials.Device.SigningSecret)
+	opened, err := testDeviceSecrets.Open(*credentials.Device.SigningSecret)
+	require.NoError(t, err)
+	assert.Equal(t, credentials.SigningSecret, string(opened))
+}
+
+func TestDeviceService_RegisterDevice_Rejected(t *testing.T) {
+	t.Run("not admin", func(t *testing.T) {
+		svc, deviceRepo, _ := newTestDeviceService()
+
+		staff := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+		credentials, err := svc.RegisterDevice(context.Background(), staff, &model.DeviceRequest{Name: "入口", LocationID: testLocationID})
+
+		require.ErrorIs(t, err, ErrForbidden)
+		assert.Nil(t, credentials)
+		deviceRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("archived location", func(t *testing.T) {
+		svc, _, masterRepo := newTestDeviceService()
+
+		ctx := context.Background()
+		archivedAt := time.Now()
+		masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID, ArchivedAt: &archivedAt}, nil)
+
+		credentials, err := svc.RegisterDevice(ctx, testAdmin, &model.DeviceRequest{Name: "入口", LocationID: testLocationID})
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Nil(t, credentials)
+		assert.Equal(t, []string{"is archived"}, validationErr.Fields["location_id"])
+	})
+}
+
+func TestDeviceService_RotateKey(t *testing.T) {
+	svc, deviceRepo, _ := newTestDeviceService()
+
+	ctx := context.Background()
+	device := &model.Device{ID: uuid.New(), Name: "入口", LocationID: testLocationID, KeyPrefix: "tld_oldoldol", KeyHash: "old"}
+	deviceRepo.On("GetByID", ctx, device.ID).Return(device, nil)
+	deviceRepo.On("SetKey", ctx, device.ID, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	credentials, err := svc.RotateKey(ctx, testAdmin, device.ID)
+
+	require.NoError(t, err)
+	assert.NotEqual(t, "old", credentials.KeyHash)
+	assert.Equal(t, hashToken(credentials.APIKey), credentials.KeyHash)
+	assert.NotEmpty(t, credentials.SigningSecret)
+	deviceRepo.AssertCalled(t, "SetKey", ctx, device.ID, credentials.KeyPrefix, credentials.KeyHash, *credentials.Device.SigningSecret, credentials.KeyRotatedAt)
+}
+
+func TestDeviceService_RotateKey_Revoked(t *testing.T) {
+	svc, deviceRepo, _ := newTestDeviceService()
+
+	ctx := context.Background()
+	revokedAt := time.Now()
+	device := &model.Device{ID: uuid.New(), RevokedAt: &revokedAt}
+	deviceRepo.On("GetByID", ctx, device.ID).Return(device, nil)
+
+	credentials, err := svc.RotateKey(ctx, testAdmin, device.ID)
+
+	require.ErrorIs(t, err, ErrDeviceRevoked)
+	assert.Nil(t, credentials)
+	deviceRepo.AssertNotCalled(t, "SetKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeviceService_RevokeDevice(t *testing.T) {
+	svc, deviceRepo, _ := newTestDeviceService()
+
+	ctx := context.Background()
+	id := uuid.New()
+	revokedAt := time.Now()
+	deviceRepo.On("GetByID", ctx, id).Return(&model.Device{ID: id}, nil).Once()
+	deviceRepo.On("Revoke", ctx, id, mock.AnythingOfType("time.Time")).Return(nil)
+	deviceRepo.On("GetByID", ctx, id).Return(&model.Device{ID: id, RevokedAt: &revokedAt}, nil).Once()
+
+	device, err := svc.RevokeDevice(ctx, testAdmin, id)
+
+	require.NoError(t, err)
+	assert.True(t, device.Revoked())
+	deviceRepo.AssertExpectations(t)
+}
+
+func TestDeviceService_UpdateDevice_NotFound(t *testing.T) {
+	svc, deviceRepo, _ := newTestDeviceService()
+
+	ctx := context.Background()
+	id := uuid.New()
+	deviceRepo.On("GetByID", ctx, id).Return(nil, repository.ErrDeviceNotFound)
+
+	device, err := svc.UpdateDevice(ctx, testAdmin, id, &model.DeviceRequest{Name: "入口", LocationID: testLocationID})
+
+	require.ErrorIs(t, err, ErrDeviceNotFound)
+	assert.Nil(t, device)
+}
+
+func TestDeviceService_AuthenticateDevice(t *testing.T) {
+	ctx := context.Background()
+	key := deviceKeyPrefix + "secret"
+	recently := time.Now().Add(-10 * time.Second)
+	longAgo := time.Now().Add(-time.Hour)
+	revokedAt := time.Now()
+
+	tests := []struct {
+		name      string
+		key       string
+		device    *model.Device
+		lookupErr error
+		wantErr   error
+		wantTouch bool
+	}{
+		{name: "first access", key: key, device: &model.Device{}, wantTouch: true},
+		{name: "seen long ago", key: key, device: &model.Device{LastSeenAt: &longAgo}, wantTouch: true},
+		{name: "seen recently", key: key, device: &model.Device{LastSeenAt: &recently}},
+		{name: "unknown key", key: key, lookupErr: repository.ErrDeviceNotFound, wantErr: auth.ErrInvalidDeviceKey},
+		{name: "revoked", key: key, device: &model.Device{RevokedAt: &revokedAt}, wantErr: auth.ErrRevokedDevice},
+		{name: "not a device key", key: "eyJhbGciOi", wantErr: auth.ErrInvalidDeviceKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deviceRepo, _ := newTestDeviceService()
+
+			if tt.device != nil {
+				tt.device.ID = uuid.New()
+				tt.device.LocationID = testLocationID
+				deviceRepo.On("GetByKeyHash", ctx, hashToken(tt.key)).Return(tt.device, nil)
+			} else {
+				deviceRepo.On("GetByKeyHash", ctx, hashToken(tt.key)).Return(nil, tt.lookupErr).Maybe()
+			}
+			deviceRepo.On("TouchLastSeen", ctx, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil).Maybe()
+
+			device, err := svc.AuthenticateDevice(ctx, tt.key)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, device)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &auth.Device{DeviceID: tt.device.ID, LocationID: testLocationID}, device)
+			if tt.wantTouch {
+				deviceRepo.AssertCalled(t, "TouchLastSeen", ctx, tt.device.ID, mock.AnythingOfType("time.Time"))
+			} else {
+				deviceRepo.AssertNotCalled(t, "TouchLastSeen", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestDeviceService_VerifyDeviceRequest(t *testing.T) {
+	ctx := context.Background()
+	secret := "device-signing-secret"
+	sealed, err := testDeviceSecrets.Seal([]byte(secret))
+	require.NoError(t, err)
+
+	now := time.Now()
+	newRequest := func(signedAt time.Time) *signature.Request {
+		return &signature.Request{
+			Method:    "POST",
+			Path:      "/api/v1/attendance/taps",
+			Timestamp: strconv.FormatInt(signedAt.Unix(), 10),
+			Nonce:     uuid.NewString(),
+			Body:      []byte(`{"card_uid":"04A1B2C3"}`),
+		}
+	}
+
+	tests := []struct {
+		name          string
+		req           *signature.Request
+		sign          func(req *signature.Request) string
+		signingSecret *string
+		wantErr       error
+	}{
+		{
+			name:          "valid",
+			req:           newRequest(now),
+			sign:          func(req *signature.Request) string { return req.Sign(secret) },
+			signingSecret: &sealed,
+		},
+		{
+			name:          "stale",
+			req:           newRequest(now.Add(-10 * time.Minute)),
+			sign:          func(req *signature.Request) string { return req.Sign(secret) },
+			signingSecret: &sealed,
+			wantErr:       auth.ErrStaleSignature,
+		},
+		{
+			name:          "signed in the future",
+			req:           newRequest(now.Add(10 * time.Minute)),
+			sign:          func(req *signature.Request) string { return req.Sign(secret) },
+			signingSecret: &sealed,
+			wantErr:       auth.ErrStaleSignature,
+		},
+		{
+			name:          "wrong secret",
+			req:           newRequest(now),
+			sign:          func(req *signature.Request) string { return req.Sign("other-secret") },
+			signingSecret: &sealed,
+			wantErr:       auth.ErrInvalidSignature,
+		},
+		{
+			name:          "missing signature",
+			req:           newRequest(now),
+			sign:          func(req *signature.Request) string { return "" },
+			signingSecret: &sealed,
+			wantErr:       auth.ErrInvalidSignature,
+		},
+		{
+			name:    "no signing secret",
+			req:     newRequest(now),
+			sign:    func(req *signature.Request) string { return req.Sign(secret) },
+			wantErr: auth.ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deviceRepo, _ := newTestDeviceService()
+
+			device := &auth.Device{DeviceID: uuid.New(), LocationID: testLocationID}
+			deviceRepo.On("GetByID", ctx, device.DeviceID).Return(&model.Device{ID: device.DeviceID, SigningSecret: tt.signingSecret}, nil).Maybe()
+
+			err := svc.VerifyDeviceRequest(ctx, device, tt.req, tt.sign(tt.req))
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestDeviceService_VerifyDeviceRequest_Replayed(t *testing.T) {
+	svc, deviceRepo, _ := newTestDeviceService()
+
+	ctx := context.Background()
+	secret := "device-signing-secret"
+	sealed, err := testDeviceSecrets.Seal([]byte(secret))
+	require.NoError(t, err)
+	device := &auth.Device{DeviceID: uuid.New(), LocationID: testLocationID}
+	deviceRepo.On("GetByID", ctx, device.DeviceID).Return(&model.Device{ID: device.DeviceID, SigningSecret: &sealed}, nil)
+
+	req := &signature.Request{
+		Method:    "POST",
+		Path:      "/api/v1/attendance/taps",
+		Timestamp: strconv.FormatInt(time.Now().Unix(), 10),
+		Nonce:     "nonce-1",
+	}
+	forged := *req
+	forged.Body = []byte("forged")
+
+	// 署名が一致しないリクエストでは nonce を使用済みにしない
+	require.ErrorIs(t, svc.VerifyDeviceRequest(ctx, device, &forged, req.Sign(secret)), auth.ErrInvalidSignature)
+	require.NoError(t, svc.VerifyDeviceRequest(ctx, device, req, req.Sign(secret)))
+	require.ErrorIs(t, svc.VerifyDeviceRequest(ctx, device, req, req.Sign(secret)), auth.ErrReplayedRequest)
+
+	// nonce は端末ごとに記録する
+	other := &auth.Device{DeviceID: uuid.New(), LocationID: testLocationID}
+	deviceRepo.On("GetByID", ctx, other.DeviceID).Return(&model.Device{ID: other.DeviceID, SigningSecret: &sealed}, nil)
+	require.NoError(t, svc.VerifyDeviceRequest(ctx, other, req, req.Sign(secret)))
+}
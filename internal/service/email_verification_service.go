@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	netmail "net/mail"
+	"net/url"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/mail"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	ErrEmailAlreadyVerified     = errors.New("email is already verified")
+	ErrEmailTaken               = errors.New("email is already in use by another user")
+)
+
+type EmailVerificationService interface {
+	EmailVerifier
+	Verify(ctx context.Context, req *model.VerifyEmailRequest) error
+	Resend(ctx context.Context, caller *auth.Identity) error
+}
+
+type EmailVerificationConfig struct {
+	// VerifyURL はメールに記載する確認画面の URL。token クエリパラメーターを付与する
+	VerifyURL string
+	Expiry    time.Duration
+	MailFrom  string
+	// Sends はユーザーごとに確認メールの送信間隔と回数を制限する
+	Sends *lockout.Limiter
+}
+
+type emailVerificationService struct {
+	userRepo  repository.UserRepository
+	tokenRepo repository.EmailVerificationTokenRepository
+	tx        repository.Transactor
+	mailer    mail.Sender
+	cfg       EmailVerificationConfig
+}
+
+func NewEmailVerificationService(
+	userRepo repository.UserRepository,
+	tokenRepo repository.EmailVerificationTokenRepository,
+	tx repository.Transactor,
+	mailer mail.Sender,
+	cfg EmailVerificationConfig,
+) EmailVerificationService {
+	return &emailVerificationService{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		tx:        tx,
+		mailer:    mailer,
+		cfg:       cfg,
+	}
+}
+
+// SendVerification は現在のメールアドレスに確認メールを送る
+func (s *emailVerificationService) SendVerification(ctx context.Context, user *model.User) error {
+	if err := s.cfg.Sends.Check(ctx, user.ID.String()); err != nil {
+		return err
+	}
+
+	return s.send(ctx, user, user.Email)
+}
+
+// RequestEmailChange は email を確認待ちのアドレスとして保存し、そのアドレスに確認メールを送る。
+// 確認が済むまで現在のメールアドレスでログインできる
+func (s *emailVerificationService) RequestEmailChange(ctx context.Context, user *model.User, email string) error {
+	if address, err := netmail.ParseAddress(email); err != nil || address.Address != email {
+		validationErr := &ValidationError{}
+		validationErr.Add("email", "is not a valid email address")
+		return validationErr
+	}
+
+	// 他のユーザーが使っているアドレスは、確認メールを送る前に拒否する
+	other, err := s.userRepo.GetByEmail(ctx, email)
+	switch {
+	case errors.Is(err, repository.ErrUserNotFound):
+	case err != nil:
+		return fmt.Errorf("failed to look up email: %w", err)
+	case other.ID != user.ID:
+		return ErrEmailTaken
+	}
+
+	if err := s.cfg.Sends.Check(ctx, user.ID.String()); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.SetPendingEmail(ctx, user.ID, &email); err != nil {
+		return fmt.Errorf("failed to save pending email: %w", err)
+	}
+	user.PendingEmail = &email
+
+	return s.send(ctx, user, email)
+}
+
+// Resend は確認待ちのメールアドレスがあればそのアドレスに、なければ未確認の現在のアドレスに確認メールを送り直す
+func (s *emailVerificationService) Resend(ctx context.Context, caller *auth.Identity) error {
+	user, err := s.userRepo.GetByID(ctx, caller.UserID)
+	if err != nil {
+		return err
+	}
+
+	email := user.Email
+	if user.PendingEmail != nil {
+		email = *user.PendingEmail
+	} else if user.EmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+
+	if err := s.cfg.Sends.Check(ctx, user.ID.String()); err != nil {
+		return err
+	}
+
+	return s.send(ctx, user, email)
+}
+
+// send は email 宛ての確認トークンを発行してメールで送る。以前に発行した未使用のトークンは使えなくする
+func (s *emailVerificationService) send(ctx context.Context, user *model.User, email string) error {
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	verificationToken := &model.EmailVerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Email:     email,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(s.cfg.Expiry),
+		CreatedAt: now,
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.tokenRepo.InvalidateForUser(ctx, user.ID); err != nil {
+			return err
+		}
+		return s.tokenRepo.Create(ctx, verificationToken)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store email verification token: %w", err)
+	}
+
+	// 送信回数は失敗として記録し、Sends のポリシーで次に送れるまでの時間を決める
+	if err := s.cfg.Sends.RecordFailure(ctx, user.ID.String()); err != nil {
+		log.Printf("Failed to record verification mail for user %s: %v", user.ID, err)
+	}
+
+	msg := &mail.Message{
+		From:    s.cfg.MailFrom,
+		To:      email,
+		Subject: "メールアドレス確認のご案内",
+		Body: fmt.Sprintf(
+			"%s 様\n\n以下のリンクからメールアドレスを確認してください。リンクの有効期限は%d時間です。\n\n%s\n\nこのメールに心当たりがない場合は破棄してください。\n",
+			user.Name, int(s.cfg.Expiry.Hours()), s.verifyLink(token),
+		),
+	}
+
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("Failed to send verification mail to user %s: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+func (s *emailVerificationService) verifyLink(token string) string {
+	u, err := url.Parse(s.cfg.VerifyURL)
+	if err != nil {
+		return s.cfg.VerifyURL + "?token=" + url.QueryEscape(token)
+	}
+
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// Verify はトークンを消費してメールアドレスを確認済みにする。確認待ちのアドレス宛てのトークンであればメールアドレスを変更する
+func (s *emailVerificationService) Verify(ctx context.Context, req *model.VerifyEmailRequest) error {
+	token, err := s.tokenRepo.GetByHash(ctx, hashToken(req.Token))
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	if token.UsedAt != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// 有効期限は使用済みにする更新と同時に判定する
+		marked, err := s.tokenRepo.MarkUsed(ctx, token.ID)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			return ErrInvalidVerificationToken
+		}
+
+		applied, err := s.userRepo.ApplyPendingEmail(ctx, token.UserID, token.Email)
+		if errors.Is(err, repository.ErrUserConflict) {
+			return ErrEmailTaken
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply pending email: %w", err)
+		}
+		if applied {
+			return nil
+		}
+
+		// 確認待ちのアドレスでなければ、現在のアドレス宛てのトークンとして扱う
+		verified, err := s.userRepo.MarkEmailVerified(ctx, token.UserID, token.Email)
+		if err != nil {
+			return fmt.Errorf("failed to mark email verified: %w", err)
+		}
+		if !verified {
+			// 発行後にメールアドレスが変わった場合
+			return ErrInvalidVerificationToken
+		}
+		return nil
+	})
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockEmailVerifier struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerifier) SendVerification(ctx context.Context, user *model.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerifier) RequestEmailChange(ctx context.Context, user *model.User, email string) error {
+	args := m.Called(ctx, user, email)
+	return args.Error(0)
+}
+
+type MockEmailVerificationTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationTokenRepository) Create(ctx context.Context, token *model.EmailVerificationToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.EmailVerificationToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.EmailVerificationToken), args.Error(1)
+}
+
+func (m *MockEmailVerificationTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEmailVerificationTokenRepository) InvalidateForUser(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+type emailVerificationTestDeps struct {
+	userRepo  *MockUserRepository
+	tokenRepo *MockEmailVerificationTokenRepository
+	mailer    *recordingSender
+}
+
+func newTestEmailVerificationService() (EmailVerificationService, *emailVerificationTestDeps) {
+	deps := &emailVerificationTestDeps{
+		userRepo:  new(MockUserRepository),
+		tokenRepo: new(MockEmailVerificationTokenRepository),
+		mailer:    &recordingSender{},
+	}
+	svc := NewEmailVerificationService(deps.userRepo, deps.tokenRepo, &fakeTransactor{}, deps.mailer, EmailVerificationConfig{
+		VerifyURL: "https://app.example.com/email/verify",
+		Expiry:    24 * time.Hour,
+		MailFrom:  "noreply@example.com",
+		Sends: lockout.NewLimiter(lockout.NewMemoryStore(), "email-verification", lockout.Policy{
+			MaxFailures: 3,
+			Lockout:     time.Hour,
+			BaseDelay:   time.Minute,
+			MaxDelay:    time.Minute,
+		}),
+	})
+	return svc, deps
+}
+
+var verifyLinkPattern = regexp.MustCompile(`https://app\.example\.com/email/verify\?token=\S+`)
+
+func (d *emailVerificationTestDeps) expectToken(ctx context.Context, userID uuid.UUID) *model.EmailVerificationToken {
+	stored := &model.EmailVerificationToken{}
+	d.tokenRepo.On("InvalidateForUser", ctx, userID).Return(nil)
+	d.tokenRepo.On("Create", ctx, mock.AnythingOfType("*model.EmailVerificationToken")).
+		Run(func(args mock.Arguments) { *stored = *args.Get(1).(*model.EmailVerificationToken) }).
+		Return(nil)
+	return stored
+}
+
+func TestEmailVerificationService_SendVerification(t *testing.T) {
+	svc, deps := newTestEmailVerificationService()
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Name: "Test User"}
+	stored := deps.expectToken(ctx, user.ID)
+
+	err := svc.SendVerification(ctx, user)
+
+	require.NoError(t, err)
+	require.Len(t, deps.mailer.messages, 1)
+	msg := deps.mailer.messages[0]
+	assert.Equal(t, "test@example.com", msg.To)
+
+	link := verifyLinkPattern.FindString(msg.Body)
+	require.NotEmpty(t, link)
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+
+	assert.Equal(t, user.ID, stored.UserID)
+	assert.Equal(t, "test@example.com", stored.Email)
+	assert.Equal(t, hashToken(parsed.Query().Get("token")), stored.TokenHash)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), stored.ExpiresAt, 2*time.Second)
+}
+
+func TestEmailVerificationService_RequestEmailChange(t *testing.T) {
+	svc, deps := newTestEmailVerificationService()
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "old@example.com", Name: "Test User"}
+	newEmail := "new@example.com"
+	stored := deps.expectToken(ctx, user.ID)
+	deps.userRepo.On("GetByEmail", ctx, newEmail).Return(nil, repository.ErrUserNotFound)
+	deps.userRepo.On("SetPendingEmail", ctx, user.ID, &newEmail).Return(nil)
+
+	err := svc.RequestEmailChange(ctx, user, newEmail)
+
+	require.NoError(t, err)
+	assert.Equal(t, "old@example.com", user.Email)
+	require.NotNil(t, user.PendingEmail)
+	assert.Equal(t, newEmail, *user.PendingEmail)
+	require.Len(t, deps.mailer.messages, 1)
+	assert.Equal(t, newEmail, deps.mailer.messages[0].To, "mail goes to the new address")
+	assert.Equal(t, newEmail, stored.Email)
+}
+
+func TestEmailVerificationService_RequestEmailChange_Rejected(t *testing.T) {
+	user := &model.User{ID: uuid.New(), Email: "old@example.com", Name: "Test User"}
+
+	t.Run("invalid address", func(t *testing.T) {
+		svc, deps := newTestEmailVerificationService()
+
+		for _, email := range []string{"not-an-email", "Someone <someone@example.com>", "a@example.com\nBcc: b@example.com"} {
+			err := svc.RequestEmailChange(context.Background(), user, email)
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr, email)
+			assert.Equal(t, []string{"is not a valid email address"}, validationErr.Fields["email"])
+		}
+		deps.userRepo.AssertNotCalled(t, "SetPendingEmail", mock.Anything, mock.Anything, mock.Anything)
+		assert.Empty(t, deps.mailer.messages)
+	})
+
+	t.Run("address used by another user", func(t *testing.T) {
+		svc, deps := newTestEmailVerificationService()
+
+		ctx := context.Background()
+		deps.userRepo.On("GetByEmail", ctx, "taken@example.com").Return(&model.User{ID: uuid.New()}, nil)
+
+		err := svc.RequestEmailChange(ctx, user, "taken@example.com")
+
+		require.ErrorIs(t, err, ErrEmailTaken)
+		deps.userRepo.AssertNotCalled(t, "SetPendingEmail", mock.Anything, mock.Anything, mock.Anything)
+		assert.Empty(t, deps.mailer.messages)
+	})
+
+	t.Run("lookup failure", func(t *testing.T) {
+		svc, deps := newTestEmailVerificationService()
+
+		ctx := context.Background()
+		deps.userRepo.On("GetByEmail", ctx, "new@example.com").Return(nil, assert.AnError)
+
+		err := svc.RequestEmailChange(ctx, user, "new@example.com")
+
+		require.ErrorIs(t, err, assert.AnError)
+		deps.userRepo.AssertNotCalled(t, "SetPendingEmail", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestEmailVerificationService_Resend_Throttled(t *testing.T) {
+	svc, deps := newTestEmailVerificationService()
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com"}
+	deps.userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	deps.expectToken(ctx, user.ID)
+
+	caller := &auth.Identity{UserID: user.ID}
+	require.NoError(t, svc.Resend(ctx, caller))
+
+	err := svc.Resend(ctx, caller)
+
+	var lockedErr *lockout.LockedError
+	require.ErrorAs(t, err, &lockedErr)
+	assert.InDelta(t, time.Minute.Seconds(), lockedErr.RetryAfter.Seconds(), 2)
+	assert.Len(t, deps.mailer.messages, 1)
+}
+
+func TestEmailVerificationService_Resend_AlreadyVerified(t *testing.T) {
+	svc, deps := newTestEmailVerificationService()
+
+	ctx := context.Background()
+	verifiedAt := time.Now()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", EmailVerifiedAt: &verifiedAt}
+	deps.userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+	err := svc.Resend(ctx, &auth.Identity{UserID: user.ID})
+
+	assert.ErrorIs(t, err, ErrEmailAlreadyVerified)
+	assert.Empty(t, deps.mailer.messages)
+}
+
+func TestEmailVerificationService_Verify(t *testing.T) {
+	t.Run("current email", func(t *testing.T) {
+		svc, deps := newTestEmailVerificationService()
+		ctx := context.Background()
+		token := &model.EmailVerificationToken{ID: uuid.New(), UserID: uuid.New(), Email: "test@example.com", ExpiresAt: time.Now().Add(time.Hour)}
+
+		deps.tokenRepo.On("GetByHash", ctx, hashToken("raw-token")).Return(token, nil)
+		deps.tokenRepo.On("MarkUsed", ctx, token.ID).Return(true, nil)
+		deps.userRepo.On("ApplyPendingEmail", ctx, token.UserID, token.Email).Return(false, nil)
+		deps.userRepo.On("MarkEmailVerified", ctx, token.UserID, token.Email).Return(true, nil)
+
+		err := svc.Verify(ctx, &model.VerifyEmailRequest{Token: "raw-token"})
+
+		require.NoError(t, err)
+		deps.userRepo.AssertExpectations(t)
+	})
+
+	t.Run("pending email", func(t *testing.T) {
+		svc, deps := newTestEmailVerificationService()
+		ctx := context.Background()
+		token := &model.EmailVerificationToken{ID: uuid.New(), UserID: uuid.New(), Email: "new@example.com", ExpiresAt: time.Now().Add(time.Hour)}
+
+		deps.tokenRepo.On("GetByHash", ctx, hashToken("raw-token")).Return(token, nil)
+		deps.tokenRepo.On("MarkUsed", ctx, token.ID).Return(true, nil)
+		deps.userRepo.On("ApplyPendingEmail", ctx, token.UserID, token.Email).Return(true, nil)
+
+		err := svc.Verify(ctx, &model.VerifyEmailRequest{Token: "raw-token"})
+
+		require.NoError(t, err)
+		deps.userRepo.AssertNotCalled(t, "MarkEmailVerified", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestEmailVerificationService_Verify_Rejected(t *testing.T) {
+	usedAt := time.Now()
+	tests := []struct {
+		name    string
+		token   *model.EmailVerificationToken
+		setup   func(ctx context.Context, deps *emailVerificationTestDeps, token *model.EmailVerificationToken)
+		wantErr error
+	}{
+		{
+			// 有効期限はリポジトリが使用済みにする更新と同時に判定する
+			name:  "expired",
+			token: &model.EmailVerificationToken{ID: uuid.New(), UserID: uuid.New(), Email: "test@example.com", ExpiresAt: time.Now().Add(-time.Minute)},
+			setup: func(ctx context.Context, deps *emailVerificationTestDeps, token *model.EmailVerificationToken) {
+				deps.tokenRepo.On("MarkUsed", ctx, token.ID).Return(false, nil)
+			},
+			wantErr: ErrInvalidVerificationToken,
+		},
+		{
+			name:    "already used",
+			token:   &model.EmailVerificationToken{ID: uuid.New(), UserID: uuid.New(), Email: "test@example.com", ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt},
+			wantErr: ErrInvalidVerificationToken,
+		},
+		{
+			name:  "email changed after issue",
+			token: &model.EmailVerificationToken{ID: uuid.New(), UserID: uuid.New(), Email: "stale@example.com", ExpiresAt: time.Now().Add(time.Hour)},
+			setup: func(ctx context.Context, deps *emailVerificationTestDeps, token *model.EmailVerificationToken) {
+				deps.tokenRepo.On("MarkUsed", ctx, token.ID).Return(true, nil)
+				deps.userRepo.On("ApplyPendingEmail", ctx, token.UserID, token.Email).Return(false, nil)
+				deps.userRepo.On("MarkEmailVerified", ctx, token.UserID, token.Email).Return(false, nil)
+			},
+			wantErr: ErrInvalidVerificationToken,
+		},
+		{
+			name:  "pending email taken by another user",
+			token: &model.EmailVerificationToken{ID: uuid.New(), UserID: uuid.New(), Email: "taken@example.com", ExpiresAt: time.Now().Add(time.Hour)},
+			setup: func(ctx context.Context, deps *emailVerificationTestDeps, token *model.EmailVerificationToken) {
+				deps.tokenRepo.On("MarkUsed", ctx, token.ID).Return(true, nil)
+				deps.userRepo.On("ApplyPendingEmail", ctx, token.UserID, token.Email).Return(false, repository.ErrUserConflict)
+			},
+			wantErr: ErrEmailTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deps := newTestEmailVerificationService()
+			ctx := context.Background()
+			deps.tokenRepo.On("GetByHash", ctx, hashToken("raw-token")).Return(tt.token, nil)
+			if tt.setup != nil {
+				tt.setup(ctx, deps, tt.token)
+			}
+
+			err := svc.Verify(ctx, &model.VerifyEmailRequest{Token: "raw-token"})
+
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+)
+
+var ErrMasterDataNotFound = errors.New("master data not found")
+
+type MasterDataService interface {
+	List(ctx context.Context, kind model.MasterDataKind, includeArchived bool) ([]*model.MasterDataItem, error)
+	Get(ctx context.Context, kind model.MasterDataKind, id int) (*model.MasterDataItem, error)
+	Create(ctx context.Context, caller *auth.Identity, kind model.MasterDataKind, req *model.MasterDataRequest) (*model.MasterDataItem, error)
+	Update(ctx context.Context, caller *auth.Identity, kind model.MasterDataKind, id int, req *model.MasterDataRequest) (*model.MasterDataItem, error)
+	// Delete は参照されていない行を削除する。参照されている行はアーカイブし、その行を返す
+	Delete(ctx context.Context, caller *auth.Identity, kind model.MasterDataKind, id int) (*model.MasterDataItem, error)
+	Restore(ctx context.Context, caller *auth.Identity, kind model.MasterDataKind, id int) (*model.MasterDataItem, error)
+	// Seed はシードの内容を名前で突き合わせて作成・更新する。何度適用しても同じ結果になる
+	Seed(ctx context.Context, seed model.MasterDataSeed) (*model.MasterDataSeedResult, error)
+}
+
+type masterDataService struct {
+	repo repository.MasterDataRepository
+	tx   repository.Transactor
+}
+
+func NewMasterDataService(repo repository.MasterDataRepository, tx repository.Transactor) MasterDataService {
+	return &masterDataService{
+		repo: repo,
+		tx:   tx,
+	}
+}
+
+// masterDataNameLimit は列の長さに合わせた名前の最大文字数
+func masterDataNameLimit(kind model.MasterDataKind) int {
+	if kind == model.MasterDataGrades {
+		return 100
+	}
+	return 255
+}
+
+func validateMasterData(kind model.MasterDataKind, req *model.MasterDataRequest) (string, error) {
+	name := strings.TrimSpace(req.Name)
+
+	validationErr := &ValidationError{}
+	switch {
+	case name == "":
+		validationErr.Add("name", "is required")
+	case len([]rune(name)) > masterDataNameLimit(kind):
+		validationErr.Add("name", fmt.Sprintf("must be at most %d characters", masterDataNameLimit(kind)))
+	}
+	if req.SortOrder < 0 {
+		validationErr.Add("sort_order", "must not be negative")
+	}
+
+	return name, validationErr.Err()
+}
+
+func masterDataError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrMasterDataNotFound):
+		return ErrMasterDataNotFound
+	case errors.Is(err, repository.ErrMasterDataConflict):
+		validationErr := &ValidationError{}
+		validationErr.Add("name", "is already used")
+		return validationErr
+	}
+	return err
+}
+
+func (s *masterDataService) List(ctx context.Context, kind model.MasterDataKind, includeArchived bool) ([]*model.MasterDataItem, error) {
+	return s.repo.List(ctx, kind, includeArchived)
+}
+
+func (s *masterDataService) Get(ctx context.Context, kind model.MasterDataKind, id int) (*model.MasterDataItem, error) {
+	item, err := s.repo.GetByID(ctx, kind, id)
+	if err != nil {
+		return nil, masterDataError(err)
+	}
+	return item, nil
+}
+
+func (s *masterDataService) Create(ctx context.Context, caller *auth.Identity, kind model.MasterDataKind, req *model.MasterDataRequest) (*model.MasterDataItem, error) {
+	if !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	name, err := validateMasterData(kind, req)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &model.MasterDataItem{Name: name, SortOrder: req.SortOrder}
+	if err := s.repo.Create(ctx, kind, item); err != nil {
+		return nil, masterDataError(err)
+	}
+
+	return item, nil
+}
+
+func (s *masterDataService) Update(ctx context.Context, caller *auth.Identity, kind model.MasterDataKind, id int, req *model.MasterDataRequest) (*model.MasterDataItem, error) {
+	if !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	name, err := validateMasterData(kind, req)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.repo.GetByID(ctx, kind, id)
+	if err != nil {
+		return nil, masterDataError(err)
+	}
+
+	item.Name = name
+	item.SortOrder = req.SortOrder
+	if err := s.repo.Update(ctx, kind, item); err != nil {
+		return nil, masterDataError(err)
+	}
+
+	return item, nil
+}
+
+func (s *masterDataService) Delete(ctx context.Context, caller *auth.Identity, kind model.MasterDataKind, id int) (*model.MasterDataItem, error) {
+	if !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	archived, err := s.repo.Delete(ctx, kind, id)
+	if err != nil {
+		return nil, masterDataError(err)
+	}
+	if !archived {
+		return nil, nil
+	}
+
+	return s.Get(ctx, kind, id)
+}
+
+func (s *masterDataService) Restore(ctx context.Context, caller *auth.Identity, kind model.MasterDataKind, id int) (*model.MasterDataItem, error) {
+	if !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	if err := s.repo.SetArchived(ctx, kind, id, false); err != nil {
+		return nil, masterDataError(err)
+	}
+
+	return s.Get(ctx, kind, id)
+}
+
+func (s *masterDataService) Seed(ctx context.Context, seed model.MasterDataSeed) (*model.MasterDataSeedResult, error) {
+	validationErr := &ValidationError{}
+	for kind, items := range seed {
+		if !kind.Valid() {
+			validationErr.Add(string(kind), "is not a master data kind")
+			continue
+		}
+
+		seen := make(map[string]bool, len(items))
+		for i := range items {
+			field := fmt.Sprintf("%s[%d]", kind, i)
+			name, err := validateMasterData(kind, &items[i])
+			if err != nil {
+				var itemErr *ValidationError
+				errors.As(err, &itemErr)
+				for f, messages := range itemErr.Fields {
+					validationErr.Add(field+"."+f, messages...)
+				}
+				continue
+			}
+			if seen[name] {
+				validationErr.Add(field+".name", "is duplicated")
+			}
+			seen[name] = true
+			items[i].Name = name
+		}
+	}
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &model.MasterDataSeedResult{}
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		for _, kind := range model.MasterDataKinds {
+			for _, item := range seed[kind] {
+				created, updated, err := s.repo.Upsert(ctx, kind, item.Name, item.SortOrder)
+				if err != nil {
+					return fmt.Errorf("failed to seed %s %q: %w", kind, item.Name, err)
+				}
+				switch {
+				case created:
+					result.Created++
+				case updated:
+					result.Updated++
+				default:
+					result.Unchanged++
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockMasterDataRepository struct {
+	mock.Mock
+}
+
+func (m *MockMasterDataRepository) List(ctx context.Context, kind model.MasterDataKind, includeArchived bool) ([]*model.MasterDataItem, error) {
+	args := m.Called(ctx, kind, includeArchived)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.MasterDataItem), args.Error(1)
+}
+
+func (m *MockMasterDataRepository) GetByID(ctx context.Context, kind model.MasterDataKind, id int) (*model.MasterDataItem, error) {
+	args := m.Called(ctx, kind, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.MasterDataItem), args.Error(1)
+}
+
+func (m *MockMasterDataRepository) Create(ctx context.Context, kind model.MasterDataKind, item *model.MasterDataItem) error {
+	args := m.Called(ctx, kind, item)
+	return args.Error(0)
+}
+
+func (m *MockMasterDataRepository) Update(ctx context.Context, kind model.MasterDataKind, item *model.MasterDataItem) error {
+	args := m.Called(ctx, kind, item)
+	return args.Error(0)
+}
+
+func (m *MockMasterDataRepository) Delete(ctx context.Context, kind model.MasterDataKind, id int) (bool, error) {
+	args := m.Called(ctx, kind, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMasterDataRepository) SetArchived(ctx context.Context, kind model.MasterDataKind, id int, archived bool) error {
+	args := m.Called(ctx, kind, id, archived)
+	return args.Error(0)
+}
+
+func (m *MockMasterDataRepository) Upsert(ctx context.Context, kind model.MasterDataKind, name string, sortOrder int) (bool, bool, error) {
+	args := m.Called(ctx, kind, name, sortOrder)
+	return args.Bool(0), args.Bool(1), args.Error(2)
+}
+
+var testAdmin = &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+
+func TestMasterDataService_Create(t *testing.T) {
+	repo := new(MockMasterDataRepository)
+	svc := NewMasterDataService(repo, &fakeTransactor{})
+
+	ctx := context.Background()
+	repo.On("Create", ctx, model.MasterDataLocations, mock.AnythingOfType("*model.MasterDataItem")).
+		Run(func(args mock.Arguments) { args.Get(2).(*model.MasterDataItem).ID = 7 }).
+		Return(nil)
+
+	item, err := svc.Create(ctx, testAdmin, model.MasterDataLocations, &model.MasterDataRequest{Name: "  部室 ", SortOrder: 2})
+
+	require.NoError(t, err)
+	assert.Equal(t, 7, item.ID)
+	assert.Equal(t, "部室", item.Name)
+	assert.Equal(t, 2, item.SortOrder)
+}
+
+func TestMasterDataService_Create_Rejected(t *testing.T) {
+	tests := []struct {
+		name      string
+		caller    *auth.Identity
+		req       *model.MasterDataRequest
+		createErr error
+		wantErr   error
+		field     string
+		message   string
+	}{
+		{
+			name:    "not admin",
+			caller:  &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff},
+			req:     &model.MasterDataRequest{Name: "1年"},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "missing name",
+			caller:  testAdmin,
+			req:     &model.MasterDataRequest{Name: " "},
+			field:   "name",
+			message: "is required",
+		},
+		{
+			name:    "negative sort order",
+			caller:  testAdmin,
+			req:     &model.MasterDataRequest{Name: "1年", SortOrder: -1},
+			field:   "sort_order",
+			message: "must not be negative",
+		},
+		{
+			name:      "duplicate name",
+			caller:    testAdmin,
+			req:       &model.MasterDataRequest{Name: "1年"},
+			createErr: repository.ErrMasterDataConflict,
+			field:     "name",
+			message:   "is already used",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockMasterDataRepository)
+			svc := NewMasterDataService(repo, &fakeTransactor{})
+
+			ctx := context.Background()
+			repo.On("Create", ctx, model.MasterDataGrades, mock.AnythingOfType("*model.MasterDataItem")).Return(tt.createErr).Maybe()
+
+			item, err := svc.Create(ctx, tt.caller, model.MasterDataGrades, tt.req)
+
+			assert.Nil(t, item)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, []string{tt.message}, validationErr.Fields[tt.field])
+		})
+	}
+}
+
+func TestMasterDataService_Update(t *testing.T) {
+	repo := new(MockMasterDataRepository)
+	svc := NewMasterDataService(repo, &fakeTransactor{})
+
+	ctx := context.Background()
+	existing := &model.MasterDataItem{ID: 3, Name: "Old", SortOrder: 1}
+	repo.On("GetByID", ctx, model.MasterDataOrganizations, 3).Return(existing, nil)
+	repo.On("Update", ctx, model.MasterDataOrganizations, existing).Return(nil)
+
+	item, err := svc.Update(ctx, testAdmin, model.MasterDataOrganizations, 3, &model.MasterDataRequest{Name: "New", SortOrder: 5})
+
+	require.NoError(t, err)
+	assert.Equal(t, "New", item.Name)
+	assert.Equal(t, 5, item.SortOrder)
+}
+
+func TestMasterDataService_Update_NotFound(t *testing.T) {
+	repo := new(MockMasterDataRepository)
+	svc := NewMasterDataService(repo, &fakeTransactor{})
+
+	ctx := context.Background()
+	repo.On("GetByID", ctx, model.MasterDataOrganizations, 3).Return(nil, repository.ErrMasterDataNotFound)
+
+	item, err := svc.Update(ctx, testAdmin, model.MasterDataOrganizations, 3, &model.MasterDataRequest{Name: "New"})
+
+	require.ErrorIs(t, err, ErrMasterDataNotFound)
+	assert.Nil(t, item)
+}
+
+func TestMasterDataService_Delete(t *testing.T) {
+	repo := new(MockMasterDataRepository)
+	svc := NewMasterDataService(repo, &fakeTransactor{})
+
+	ctx := context.Background()
+	repo.On("Delete", ctx, model.MasterDataGrades, 1).Return(false, nil)
+
+	item, err := svc.Delete(ctx, testAdmin, model.MasterDataGrades, 1)
+
+	require.NoError(t, err)
+	assert.Nil(t, item)
+}
+
+func TestMasterDataService_Delete_ArchivesReferencedItem(t *testing.T) {
+	repo := new(MockMasterDataRepository)
+	svc := NewMasterDataService(repo, &fakeTransactor{})
+
+	ctx := context.Background()
+	archivedAt := time.Now()
+	repo.On("Delete", ctx, model.MasterDataGrades, 1).Return(true, nil)
+	repo.On("GetByID", ctx, model.MasterDataGrades, 1).Return(&model.MasterDataItem{ID: 1, Name: "1年", ArchivedAt: &archivedAt}, nil)
+
+	item, err := svc.Delete(ctx, testAdmin, model.MasterDataGrades, 1)
+
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.True(t, item.Archived())
+}
+
+func TestMasterDataService_Restore(t *testing.T) {
+	repo := new(MockMasterDataRepository)
+	svc := NewMasterDataService(repo, &fakeTransactor{})
+
+	ctx := context.Background()
+	repo.On("SetArchived", ctx, model.MasterDataLocations, 4, false).Return(nil)
+	repo.On("GetByID", ctx, model.MasterDataLocations, 4).Return(&model.MasterDataItem{ID: 4, Name: "部室"}, nil)
+
+	item, err := svc.Restore(ctx, testAdmin, model.MasterDataLocations, 4)
+
+	require.NoError(t, err)
+	assert.False(t, item.Archived())
+	repo.AssertExpectations(t)
+}
+
+func TestMasterDataService_Seed(t *testing.T) {
+	repo := new(MockMasterDataRepository)
+	tx := &fakeTransactor{}
+	svc := NewMasterDataService(repo, tx)
+
+	ctx := context.Background()
+	repo.On("Upsert", ctx, model.MasterDataGrades, "1年", 1).Return(true, false, nil)
+	repo.On("Upsert", ctx, model.MasterDataGrades, "2年", 2).Return(false, true, nil)
+	repo.On("Upsert", ctx, model.MasterDataLocations, "部室", 0).Return(false, false, nil)
+
+	result, err := svc.Seed(ctx, model.MasterDataSeed{
+		model.MasterDataGrades:    {{Name: "1年", SortOrder: 1}, {Name: " 2年", SortOrder: 2}},
+		model.MasterDataLocations: {{Name: "部室"}},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &model.MasterDataSeedResult{Created: 1, Updated: 1, Unchanged: 1}, result)
+	assert.Equal(t, 1, tx.calls)
+	repo.AssertExpectations(t)
+}
+
+func TestMasterDataService_Seed_Invalid(t *testing.T) {
+	repo := new(MockMasterDataRepository)
+	svc := NewMasterDataService(repo, &fakeTransactor{})
+
+	result, err := svc.Seed(context.Background(), model.MasterDataSeed{
+		model.MasterDataGrades: {{Name: "1年"}, {Name: "1年"}, {Name: ""}},
+		"rooms":                {{Name: "部室"}},
+	})
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Nil(t, result)
+	assert.Equal(t, []string{"is duplicated"}, validationErr.Fields["grades[1].name"])
+	assert.Equal(t, []string{"is required"}, validationErr.Fields["grades[2].name"])
+	assert.Equal(t, []string{"is not a master data kind"}, validationErr.Fields["rooms"])
+	repo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMasterDataService_Seed_RepositoryError(t *testing.T) {
+	repo := new(MockMasterDataRepository)
+	svc := NewMasterDataService(repo, &fakeTransactor{})
+
+	ctx := context.Background()
+	repo.On("Upsert", ctx, model.MasterDataOrganizations, "情報部", 0).Return(false, false, fmt.Errorf("db error"))
+
+	result, err := svc.Seed(ctx, model.MasterDataSeed{model.MasterDataOrganizations: {{Name: "情報部"}}})
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "failed to seed organizations")
+}
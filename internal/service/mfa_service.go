@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/secretbox"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/totp"
+	"github.com/google/uuid"
+)
+
+const (
+	mfaChallengeTTL   = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFAUnavailable    = errors.New("two-factor authentication is managed by the external identity provider")
+	ErrInvalidMFACode    = errors.New("invalid two-factor authentication code")
+	ErrInvalidMFAToken   = errors.New("invalid or expired mfa token")
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type MFAService interface {
+	Enroll(ctx context.Context, caller *auth.Identity) (*model.MFAEnrollment, error)
+	Confirm(ctx context.Context, caller *auth.Identity, req *model.MFACodeRequest) (*model.MFARecoveryCodesResponse, error)
+	Disable(ctx context.Context, caller *auth.Identity, req *model.MFACodeRequest) error
+	Verify(ctx context.Context, req *model.MFAVerifyRequest) (*model.LoginResponse, error)
+}
+
+type MFAConfig struct {
+	// Issuer は認証アプリに表示されるサービス名
+	Issuer string
+	// Secrets は共有鍵をデータベースに保存する前に暗号化する
+	Secrets *secretbox.Box
+	// Attempts はユーザーごとにコードの入力失敗を制限する
+	Attempts *lockout.Limiter
+}
+
+type mfaService struct {
+	userRepo repository.UserRepository
+	mfaRepo  repository.MFARepository
+	tx       repository.Transactor
+	tokens   *auth.TokenManager
+	sessions SessionManager
+	cfg      MFAConfig
+}
+
+func NewMFAService(
+	userRepo repository.UserRepository,
+	mfaRepo repository.MFARepository,
+	tx repository.Transactor,
+	tokens *auth.TokenManager,
+	sessions SessionManager,
+	cfg MFAConfig,
+) MFAService {
+	return &mfaService{
+		userRepo: userRepo,
+		mfaRepo:  mfaRepo,
+		tx:       tx,
+		tokens:   tokens,
+		sessions: sessions,
+		cfg:      cfg,
+	}
+}
+
+// Enroll は新しい共有鍵を発行する。Confirm で正しいコードを送るまでログインには影響しない
+func (s *mfaService) Enroll(ctx context.Context, caller *auth.Identity) (*model.MFAEnrollment, error) {
+	user, err := s.userRepo.GetByID(ctx, caller.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.HasLocalPassword() {
+		return nil, ErrMFAUnavailable
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := s.cfg.Secrets.Seal([]byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt totp secret: %w", err)
+	}
+
+	saved, err := s.mfaRepo.SavePending(ctx, user.ID, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save totp secret: %w", err)
+	}
+	if !saved {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	return &model.MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.cfg.Issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm は認証アプリのコードで登録を確認して二段階認証を有効にし、リカバリーコードを発行する
+func (s *mfaService) Confirm(ctx context.Context, caller *auth.Identity, req *model.MFACodeRequest) (*model.MFARecoveryCodesResponse, error) {
+	mfa, err := s.mfaRepo.GetByUserID(ctx, caller.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load mfa settings: %w", err)
+	}
+	if mfa == nil {
+		return nil, ErrMFANotEnabled
+	}
+	if mfa.Enabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	if err := s.cfg.Attempts.Check(ctx, caller.UserID.String()); err != nil {
+		return nil, err
+	}
+
+	secret, err := s.cfg.Secrets.Open(mfa.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	step, ok := totp.Verify(string(secret), req.Code, time.Now())
+	if !ok {
+		return nil, s.recordFailure(ctx, caller.UserID)
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.mfaRepo.Enable(ctx, caller.UserID, step); err != nil {
+			return fmt.Errorf("failed to enable mfa: %w", err)
+		}
+		if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, caller.UserID, hashes); err != nil {
+			return fmt.Errorf("failed to save recovery codes: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.resetAttempts(ctx, caller.UserID)
+	return &model.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable は認証アプリのコードかリカバリーコードを確認して二段階認証を無効にする
+func (s *mfaService) Disable(ctx context.Context, caller *auth.Identity, req *model.MFACodeRequest) error {
+	mfa, err := s.mfaRepo.GetByUserID(ctx, caller.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to load mfa settings: %w", err)
+	}
+	if !mfa.Enabled() {
+		return ErrMFANotEnabled
+	}
+
+	if err := s.verifyCode(ctx, mfa, req.Code); err != nil {
+		return err
+	}
+
+	if err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.mfaRepo.Delete(ctx, caller.UserID)
+	}); err != nil {
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+
+	return nil
+}
+
+// Verify は Login が返したチャレンジトークンとコードを確認し、アクセストークンを発行する
+func (s *mfaService) Verify(ctx context.Context, req *model.MFAVerifyRequest) (*model.LoginResponse, error) {
+	userID, err := s.tokens.ParseMFAChallenge(req.MFAToken)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	mfa, err := s.mfaRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load mfa settings: %w", err)
+	}
+	if !mfa.Enabled() {
+		return nil, ErrInvalidMFAToken
+	}
+
+	if err := s.verifyCode(ctx, mfa, req.Code); err != nil {
+		return nil, err
+	}
+
+	return s.sessions.IssueSession(ctx, user)
+}
+
+// verifyCode は TOTP のコードかリカバリーコードを確認する。使用済みのコードは受け付けない
+func (s *mfaService) verifyCode(ctx context.Context, mfa *model.UserMFA, code string) error {
+	if err := s.cfg.Attempts.Check(ctx, mfa.UserID.String()); err != nil {
+		return err
+	}
+
+	secret, err := s.cfg.Secrets.Open(mfa.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	var accepted bool
+	if step, ok := totp.Verify(string(secret), code, time.Now()); ok {
+		accepted, err = s.mfaRepo.UseStep(ctx, mfa.UserID, step)
+	} else if normalized := normalizeRecoveryCode(code); normalized != "" {
+		accepted, err = s.mfaRepo.UseRecoveryCode(ctx, mfa.UserID, hashToken(normalized))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to verify mfa code: %w", err)
+	}
+	if !accepted {
+		return s.recordFailure(ctx, mfa.UserID)
+	}
+
+	s.resetAttempts(ctx, mfa.UserID)
+	return nil
+}
+
+func (s *mfaService) recordFailure(ctx context.Context, userID uuid.UUID) error {
+	if err := s.cfg.Attempts.RecordFailure(ctx, userID.String()); err != nil {
+		return err
+	}
+	return ErrInvalidMFACode
+}
+
+func (s *mfaService) resetAttempts(ctx context.Context, userID uuid.UUID) {
+	if err := s.cfg.Attempts.Reset(ctx, userID.String()); err != nil {
+		// 失敗回数は期限切れで消えるため、ここでは処理を止めない
+		log.Printf("Failed to reset mfa attempts: %v", err)
+	}
+}
+
+// generateRecoveryCodes は "xxxxx-xxxxx" 形式のリカバリーコードと保存用のハッシュを返す
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/secretbox"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/totp"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.UserMFA, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UserMFA), args.Error(1)
+}
+
+func (m *MockMFARepository) SavePending(ctx context.Context, userID uuid.UUID, secret string) (bool, error) {
+	args := m.Called(ctx, userID, secret)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) Enable(ctx context.Context, userID uuid.UUID, step int64) error {
+	args := m.Called(ctx, userID, step)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	args := m.Called(ctx, userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+type mfaTestDeps struct {
+	userRepo *MockUserRepository
+	mfaRepo  *MockMFARepository
+	sessions *MockSessionManager
+	tokens   *auth.TokenManager
+	secrets  *secretbox.Box
+	tx       *fakeTransactor
+}
+
+func newTestMFAService(t *testing.T) (MFAService, *mfaTestDeps) {
+	t.Helper()
+	secrets, err := secretbox.New("test-mfa-key")
+	require.NoError(t, err)
+
+	deps := &mfaTestDeps{
+		userRepo: new(MockUserRepository),
+		mfaRepo:  new(MockMFARepository),
+		sessions: new(MockSessionManager),
+		tokens:   newTestTokenManager(),
+		secrets:  secrets,
+		tx:       &fakeTransactor{},
+	}
+	svc := NewMFAService(deps.userRepo, deps.mfaRepo, deps.tx, deps.tokens, deps.sessions, MFAConfig{
+		Issuer:   "TSUNAGU Link",
+		Secrets:  secrets,
+		Attempts: lockout.NewLimiter(lockout.NewMemoryStore(), "mfa", lockout.Policy{MaxFailures: 3, Lockout: 15 * time.Minute}),
+	})
+	return svc, deps
+}
+
+// enabledMFA は有効化済みの TOTP 設定と、その平文の共有鍵を返す
+func (d *mfaTestDeps) enabledMFA(t *testing.T, userID uuid.UUID) (*model.UserMFA, string) {
+	t.Helper()
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	sealed, err := d.secrets.Seal([]byte(secret))
+	require.NoError(t, err)
+
+	enabledAt := time.Now()
+	return &model.UserMFA{UserID: userID, Secret: sealed, EnabledAt: &enabledAt}, secret
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestMFAService_Enroll(t *testing.T) {
+	svc, deps := newTestMFAService(t)
+
+	ctx := context.Background()
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("correct-horse-battery"), bcrypt.MinCost)
+	user := &model.User{ID: uuid.New(), Email: "admin@example.com", Password: string(hashed), Role: model.RoleAdmin}
+
+	var sealed string
+	deps.userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	deps.mfaRepo.On("SavePending", ctx, user.ID, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { sealed = args.String(2) }).
+		Return(true, nil)
+
+	enrollment, err := svc.Enroll(ctx, &auth.Identity{UserID: user.ID, Role: model.RoleAdmin})
+
+	require.NoError(t, err)
+	assert.NotEqual(t, enrollment.Secret, sealed, "secret must be stored encrypted")
+	opened, err := deps.secrets.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, enrollment.Secret, string(opened))
+
+	uri, err := url.Parse(enrollment.ProvisioningURI)
+	require.NoError(t, err)
+	assert.Equal(t, enrollment.Secret, uri.Query().Get("secret"))
+	assert.Contains(t, uri.Path, "admin@example.com")
+}
+
+func TestMFAService_Enroll_Rejected(t *testing.T) {
+	t.Run("already enabled", func(t *testing.T) {
+		svc, deps := newTestMFAService(t)
+		ctx := context.Background()
+		hashed, _ := bcrypt.GenerateFromPassword([]byte("correct-horse-battery"), bcrypt.MinCost)
+		user := &model.User{ID: uuid.New(), Password: string(hashed)}
+
+		deps.userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+		deps.mfaRepo.On("SavePending", ctx, user.ID, mock.AnythingOfType("string")).Return(false, nil)
+
+		_, err := svc.Enroll(ctx, &auth.Identity{UserID: user.ID})
+		assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+	})
+
+	t.Run("external user", func(t *testing.T) {
+		svc, deps := newTestMFAService(t)
+		ctx := context.Background()
+		user := &model.User{ID: uuid.New(), AuthSource: model.AuthSourceZitadel}
+
+		deps.userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+		_, err := svc.Enroll(ctx, &auth.Identity{UserID: user.ID})
+		assert.ErrorIs(t, err, ErrMFAUnavailable)
+		deps.mfaRepo.AssertNotCalled(t, "SavePending", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestMFAService_Confirm(t *testing.T) {
+	svc, deps := newTestMFAService(t)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	mfa, secret := deps.enabledMFA(t, userID)
+	mfa.EnabledAt = nil
+
+	var hashes []string
+	deps.mfaRepo.On("GetByUserID", ctx, userID).Return(mfa, nil)
+	deps.mfaRepo.On("Enable", ctx, userID, totp.Step(time.Now())).Return(nil)
+	deps.mfaRepo.On("ReplaceRecoveryCodes", ctx, userID, mock.Anything).
+		Run(func(args mock.Arguments) { hashes = args.Get(2).([]string) }).
+		Return(nil)
+
+	resp, err := svc.Confirm(ctx, &auth.Identity{UserID: userID}, &model.MFACodeRequest{Code: currentCode(t, secret)})
+
+	require.NoError(t, err)
+	require.Len(t, resp.RecoveryCodes, recoveryCodeCount)
+	require.Len(t, hashes, recoveryCodeCount)
+	for i, code := range resp.RecoveryCodes {
+		assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, code)
+		assert.Equal(t, hashToken(normalizeRecoveryCode(code)), hashes[i], "only hashes are stored")
+	}
+	assert.Equal(t, 1, deps.tx.calls)
+}
+
+func TestMFAService_Confirm_InvalidCode(t *testing.T) {
+	svc, deps := newTestMFAService(t)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	mfa, _ := deps.enabledMFA(t, userID)
+	mfa.EnabledAt = nil
+	deps.mfaRepo.On("GetByUserID", ctx, userID).Return(mfa, nil)
+
+	_, err := svc.Confirm(ctx, &auth.Identity{UserID: userID}, &model.MFACodeRequest{Code: "000000"})
+
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	deps.mfaRepo.AssertNotCalled(t, "Enable", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMFAService_Verify(t *testing.T) {
+	svc, deps := newTestMFAService(t)
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "admin@example.com"}
+	mfa, secret := deps.enabledMFA(t, user.ID)
+	challenge, err := deps.tokens.GenerateMFAChallenge(user.ID, time.Minute)
+	require.NoError(t, err)
+
+	expected := &model.LoginResponse{Token: "access", RefreshToken: "refresh", User: user}
+	deps.userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	deps.mfaRepo.On("GetByUserID", ctx, user.ID).Return(mfa, nil)
+	deps.mfaRepo.On("UseStep", ctx, user.ID, totp.Step(time.Now())).Return(true, nil)
+	deps.sessions.On("IssueSession", ctx, user).Return(expected, nil)
+
+	resp, err := svc.Verify(ctx, &model.MFAVerifyRequest{MFAToken: challenge, Code: currentCode(t, secret)})
+
+	require.NoError(t, err)
+	assert.Equal(t, expected, resp)
+}
+
+func TestMFAService_Verify_RecoveryCode(t *testing.T) {
+	svc, deps := newTestMFAService(t)
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New()}
+	mfa, _ := deps.enabledMFA(t, user.ID)
+	challenge, err := deps.tokens.GenerateMFAChallenge(user.ID, time.Minute)
+	require.NoError(t, err)
+
+	deps.userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	deps.mfaRepo.On("GetByUserID", ctx, user.ID).Return(mfa, nil)
+	deps.mfaRepo.On("UseRecoveryCode", ctx, user.ID, hashToken("abcdefghij")).Return(true, nil)
+	deps.sessions.On("IssueSession", ctx, user).Return(&model.LoginResponse{Token: "access"}, nil)
+
+	_, err = svc.Verify(ctx, &model.MFAVerifyRequest{MFAToken: challenge, Code: " ABCDE-FGHIJ "})
+
+	require.NoError(t, err)
+	deps.mfaRepo.AssertExpectations(t)
+}
+
+func TestMFAService_Verify_Rejected(t *testing.T) {
+	t.Run("access token instead of challenge", func(t *testing.T) {
+		svc, deps := newTestMFAService(t)
+		accessToken, err := deps.tokens.Generate(&model.User{ID: uuid.New()})
+		require.NoError(t, err)
+
+		_, err = svc.Verify(context.Background(), &model.MFAVerifyRequest{MFAToken: accessToken, Code: "123456"})
+		assert.ErrorIs(t, err, ErrInvalidMFAToken)
+	})
+
+	t.Run("replayed code", func(t *testing.T) {
+		svc, deps := newTestMFAService(t)
+		ctx := context.Background()
+		user := &model.User{ID: uuid.New()}
+		mfa, secret := deps.enabledMFA(t, user.ID)
+		challenge, err := deps.tokens.GenerateMFAChallenge(user.ID, time.Minute)
+		require.NoError(t, err)
+
+		deps.userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+		deps.mfaRepo.On("GetByUserID", ctx, user.ID).Return(mfa, nil)
+		deps.mfaRepo.On("UseStep", ctx, user.ID, mock.Anything).Return(false, nil)
+
+		_, err = svc.Verify(ctx, &model.MFAVerifyRequest{MFAToken: challenge, Code: currentCode(t, secret)})
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+		deps.sessions.AssertNotCalled(t, "IssueSession", mock.Anything, mock.Anything)
+	})
+
+	t.Run("locked after repeated failures", func(t *testing.T) {
+		svc, deps := newTestMFAService(t)
+		ctx := context.Background()
+		user := &model.User{ID: uuid.New()}
+		mfa, secret := deps.enabledMFA(t, user.ID)
+		challenge, err := deps.tokens.GenerateMFAChallenge(user.ID, time.Minute)
+		require.NoError(t, err)
+
+		deps.userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+		deps.mfaRepo.On("GetByUserID", ctx, user.ID).Return(mfa, nil)
+		deps.mfaRepo.On("UseRecoveryCode", ctx, user.ID, mock.Anything).Return(false, nil)
+
+		for i := 0; i < 3; i++ {
+			_, err := svc.Verify(ctx, &model.MFAVerifyRequest{MFAToken: challenge, Code: "wrong-code"})
+			require.ErrorIs(t, err, ErrInvalidMFACode)
+		}
+
+		_, err = svc.Verify(ctx, &model.MFAVerifyRequest{MFAToken: challenge, Code: currentCode(t, secret)})
+		var lockedErr *lockout.LockedError
+		assert.ErrorAs(t, err, &lockedErr)
+		deps.mfaRepo.AssertNotCalled(t, "UseStep", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestMFAService_Disable(t *testing.T) {
+	svc, deps := newTestMFAService(t)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	mfa, secret := deps.enabledMFA(t, userID)
+
+	deps.mfaRepo.On("GetByUserID", ctx, userID).Return(mfa, nil)
+	deps.mfaRepo.On("UseStep", ctx, userID, totp.Step(time.Now())).Return(true, nil)
+	deps.mfaRepo.On("Delete", ctx, userID).Return(nil)
+
+	err := svc.Disable(ctx, &auth.Identity{UserID: userID}, &model.MFACodeRequest{Code: currentCode(t, secret)})
+
+	require.NoError(t, err)
+	deps.mfaRepo.AssertExpectations(t)
+}
+
+func TestMFAService_Disable_NotEnabled(t *testing.T) {
+	svc, deps := newTestMFAService(t)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	deps.mfaRepo.On("GetByUserID", ctx, userID).Return(nil, nil)
+
+	err := svc.Disable(ctx, &auth.Identity{UserID: userID}, &model.MFACodeRequest{Code: "123456"})
+
+	assert.ErrorIs(t, err, ErrMFANotEnabled)
+}
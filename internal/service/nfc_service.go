@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEnrollmentNotFound = errors.New("nfc enrollment not found")
+	// ErrCardAlreadyEnrolled はタップされたカードが他のユーザーに登録済みの場合に返す
+	ErrCardAlreadyEnrolled = errors.New("card is already enrolled to another user")
+	ErrNoCard              = errors.New("user has no card")
+)
+
+// CardEnroller はタップを登録待ちのカードとして扱う。AttendanceService が入退室の記録より先に呼び出す
+type CardEnroller interface {
+	// CompleteEnrollment は場所で待機中の登録があればカードをそのユーザーに登録し、プロフィールを返す。
+	// 待機中の登録がなければ nil を返す
+	CompleteEnrollment(ctx context.Context, locationID int, serial string) (*model.Profile, error)
+}
+
+type NFCService interface {
+	CardEnroller
+	// ArmEnrollment は場所を待機させ、次にタップされたカードを req.UserID に登録する。同じ場所の以前の待機は取り消す
+	ArmEnrollment(ctx context.Context, caller *auth.Identity, req *model.NFCEnrollmentRequest) (*model.NFCEnrollment, error)
+	CancelEnrollment(ctx context.Context, caller *auth.Identity, id int64) error
+	UnbindCard(ctx context.Context, caller *auth.Identity, userID uuid.UUID) error
+	// ReplaceCard は紛失したカードをすぐに無効にし、新しいカードの登録を待機する
+	ReplaceCard(ctx context.Context, caller *auth.Identity, userID uuid.UUID, req *model.NFCReplaceRequest) (*model.NFCEnrollment, error)
+	CardHistory(ctx context.Context, caller *auth.Identity, userID uuid.UUID) ([]*model.NFCCardEvent, error)
+}
+
+type nfcService struct {
+	nfcRepo          repository.NFCRepository
+	profileRepo      repository.ProfileRepository
+	masterRepo       repository.MasterDataRepository
+	tx               repository.Transactor
+	enrollmentExpiry time.Duration
+}
+
+func NewNFCService(
+	nfcRepo repository.NFCRepository,
+	profileRepo repository.ProfileRepository,
+	masterRepo repository.MasterDataRepository,
+	tx repository.Transactor,
+	enrollmentExpiry time.Duration,
+) NFCService {
+	return &nfcService{
+		nfcRepo:          nfcRepo,
+		profileRepo:      profileRepo,
+		masterRepo:       masterRepo,
+		tx:               tx,
+		enrollmentExpiry: enrollmentExpiry,
+	}
+}
+
+func (s *nfcService) ArmEnrollment(ctx context.Context, caller *auth.Identity, req *model.NFCEnrollmentRequest) (*model.NFCEnrollment, error) {
+	if !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	if err := checkLocation(ctx, s.masterRepo, req.LocationID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getProfile(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+
+	var enrollment *model.NFCEnrollment
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		enrollment, err = s.arm(ctx, caller, req.UserID, req.LocationID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+// arm は同じ場所の待機を取り消してから新しい待機を作成する。トランザクション内で呼ぶこと
+func (s *nfcService) arm(ctx context.Context, caller *auth.Identity, userID uuid.UUID, locationID int) (*model.NFCEnrollment, error) {
+	now := time.Now()
+	if err := s.nfcRepo.CancelPendingEnrollments(ctx, locationID, now); err != nil {
+		return nil, fmt.Errorf("failed to cancel pending enrollments: %w", err)
+	}
+
+	enrollment := &model.NFCEnrollment{
+		UserID:     userID,
+		LocationID: locationID,
+		ArmedBy:    &caller.UserID,
+		ExpiresAt:  now.Add(s.enrollmentExpiry),
+		CreatedAt:  now,
+	}
+	if err := s.nfcRepo.CreateEnrollment(ctx, enrollment); err != nil {
+		return nil, fmt.Errorf("failed to create enrollment: %w", err)
+	}
+
+	return enrollment, nil
+}
+
+func (s *nfcService) CompleteEnrollment(ctx context.Context, locationID int, serial string) (*model.Profile, error) {
+	var profile *model.Profile
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		enrollment, err := s.nfcRepo.GetActiveEnrollmentForUpdate(ctx, locationID, now)
+		if errors.Is(err, repository.ErrEnrollmentNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load enrollment: %w", err)
+		}
+
+		owner, err := s.profileRepo.GetByNFCSerial(ctx, serial)
+		switch {
+		case errors.Is(err, repository.ErrProfileNotFound):
+		case err != nil:
+			return fmt.Errorf("failed to load profile: %w", err)
+		case owner.UserID != enrollment.UserID:
+			// 登録待ちは取り消さず、正しいカードのタップを待つ
+			return ErrCardAlreadyEnrolled
+		}
+
+		profile, err = s.profileRepo.GetByUserID(ctx, enrollment.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to load profile: %w", err)
+		}
+
+		if owner == nil {
+			if err := s.bind(ctx, profile, serial, enrollment.ArmedBy, now); err != nil {
+				return err
+			}
+		}
+
+		return s.nfcRepo.CompleteEnrollment(ctx, enrollment.ID, serial, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// bind はプロフィールのカードを serial に置き換え、履歴を残す
+func (s *nfcService) bind(ctx context.Context, profile *model.Profile, serial string, actorID *uuid.UUID, now time.Time) error {
+	if profile.NFCSerial != nil {
+		if err := s.recordEvent(ctx, profile.UserID, *profile.NFCSerial, model.NFCCardReplaced, actorID, now); err != nil {
+			return err
+		}
+	}
+
+	if err := s.nfcRepo.SetCardSerial(ctx, profile.UserID, &serial); err != nil {
+		if errors.Is(err, repository.ErrNFCSerialTaken) {
+			return ErrCardAlreadyEnrolled
+		}
+		return fmt.Errorf("failed to set card: %w", err)
+	}
+	profile.NFCSerial = &serial
+
+	return s.recordEvent(ctx, profile.UserID, serial, model.NFCCardBound, actorID, now)
+}
+
+func (s *nfcService) recordEvent(ctx context.Context, userID uuid.UUID, serial string, event model.NFCCardEventType, actorID *uuid.UUID, now time.Time) error {
+	err := s.nfcRepo.CreateCardEvent(ctx, &model.NFCCardEvent{
+		UserID:    userID,
+		NFCSerial: serial,
+		Event:     event,
+		ActorID:   actorID,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record card event: %w", err)
+	}
+	return nil
+}
+
+func (s *nfcService) CancelEnrollment(ctx context.Context, caller *auth.Identity, id int64) error {
+	if !caller.IsAdmin() {
+		return ErrForbidden
+	}
+
+	err := s.nfcRepo.CancelEnrollment(ctx, id, time.Now())
+	if errors.Is(err, repository.ErrEnrollmentNotFound) {
+		return ErrEnrollmentNotFound
+	}
+
+	return err
+}
+
+func (s *nfcService) UnbindCard(ctx context.Context, caller *auth.Identity, userID uuid.UUID) error {
+	if !caller.IsAdmin() {
+		return ErrForbidden
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.unbind(ctx, caller, userID, model.NFCCardUnbound)
+	})
+}
+
+// unbind はプロフィールのカードを解除し、event として履歴を残す。トランザクション内で呼ぶこと
+func (s *nfcService) unbind(ctx context.Context, caller *auth.Identity, userID uuid.UUID, event model.NFCCardEventType) error {
+	profile, err := s.getProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if profile.NFCSerial == nil {
+		return ErrNoCard
+	}
+
+	if err := s.nfcRepo.SetCardSerial(ctx, userID, nil); err != nil {
+		return fmt.Errorf("failed to unset card: %w", err)
+	}
+
+	return s.recordEvent(ctx, userID, *profile.NFCSerial, event, &caller.UserID, time.Now())
+}
+
+func (s *nfcService) ReplaceCard(ctx context.Context, caller *auth.Identity, userID uuid.UUID, req *model.NFCReplaceRequest) (*model.NFCEnrollment, error) {
+	if !caller.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	if err := checkLocation(ctx, s.masterRepo, req.LocationID); err != nil {
+		return nil, err
+	}
+
+	var enrollment *model.NFCEnrollment
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// カードがなければ紛失の記録は残さず、新しいカードの登録だけを待機する
+		if err := s.unbind(ctx, caller, userID, model.NFCCardLost); err != nil && !errors.Is(err, ErrNoCard) {
+			return err
+		}
+
+		var err error
+		enrollment, err = s.arm(ctx, caller, userID, req.LocationID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+func (s *nfcService) CardHistory(ctx context.Context, caller *auth.Identity, userID uuid.UUID) ([]*model.NFCCardEvent, error) {
+	if !canRead(caller, userID) {
+		return nil, ErrForbidden
+	}
+
+	return s.nfcRepo.ListCardEvents(ctx, userID)
+}
+
+func (s *nfcService) getProfile(ctx context.Context, userID uuid.UUID) (*model.Profile, error) {
+	profile, err := s.profileRepo.GetByUserID(ctx, userID)
+	if errors.Is(err, repository.ErrProfileNotFound) {
+		return nil, ErrProfileNotFound
+	}
+	return profile, err
+}
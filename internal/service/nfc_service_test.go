@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockNFCRepository struct {
+	mock.Mock
+}
+
+func (m *MockNFCRepository) CreateEnrollment(ctx context.Context, enrollment *model.NFCEnrollment) error {
+	args := m.Called(ctx, enrollment)
+	return args.Error(0)
+}
+
+func (m *MockNFCRepository) GetActiveEnrollmentForUpdate(ctx context.Context, locationID int, now time.Time) (*model.NFCEnrollment, error) {
+	args := m.Called(ctx, locationID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.NFCEnrollment), args.Error(1)
+}
+
+func (m *MockNFCRepository) CompleteEnrollment(ctx context.Context, id int64, serial string, completedAt time.Time) error {
+	args := m.Called(ctx, id, serial, completedAt)
+	return args.Error(0)
+}
+
+func (m *MockNFCRepository) CancelEnrollment(ctx context.Context, id int64, cancelledAt time.Time) error {
+	args := m.Called(ctx, id, cancelledAt)
+	return args.Error(0)
+}
+
+func (m *MockNFCRepository) CancelPendingEnrollments(ctx context.Context, locationID int, cancelledAt time.Time) error {
+	args := m.Called(ctx, locationID, cancelledAt)
+	return args.Error(0)
+}
+
+func (m *MockNFCRepository) SetCardSerial(ctx context.Context, userID uuid.UUID, serial *string) error {
+	args := m.Called(ctx, userID, serial)
+	return args.Error(0)
+}
+
+func (m *MockNFCRepository) CreateCardEvent(ctx context.Context, event *model.NFCCardEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockNFCRepository) ListCardEvents(ctx context.Context, userID uuid.UUID) ([]*model.NFCCardEvent, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.NFCCardEvent), args.Error(1)
+}
+
+type nfcTestDeps struct {
+	nfcRepo     *MockNFCRepository
+	profileRepo *MockProfileRepository
+	masterRepo  *MockMasterDataRepository
+}
+
+func newTestNFCService() (NFCService, *nfcTestDeps) {
+	deps := &nfcTestDeps{
+		nfcRepo:     new(MockNFCRepository),
+		profileRepo: new(MockProfileRepository),
+		masterRepo:  new(MockMasterDataRepository),
+	}
+	return NewNFCService(deps.nfcRepo, deps.profileRepo, deps.masterRepo, &fakeTransactor{}, 5*time.Minute), deps
+}
+
+func cardEvent(event model.NFCCardEventType, serial string) interface{} {
+	return mock.MatchedBy(func(e *model.NFCCardEvent) bool {
+		return e.Event == event && e.NFCSerial == serial
+	})
+}
+
+func TestNFCService_ArmEnrollment(t *testing.T) {
+	svc, deps := newTestNFCService()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID}, nil)
+	deps.profileRepo.On("GetByUserID", ctx, userID).Return(&model.Profile{UserID: userID}, nil)
+	deps.nfcRepo.On("CancelPendingEnrollments", ctx, testLocationID, mock.AnythingOfType("time.Time")).Return(nil)
+	deps.nfcRepo.On("CreateEnrollment", ctx, mock.AnythingOfType("*model.NFCEnrollment")).Return(nil)
+
+	enrollment, err := svc.ArmEnrollment(ctx, testAdmin, &model.NFCEnrollmentRequest{UserID: userID, LocationID: testLocationID})
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, enrollment.UserID)
+	assert.Equal(t, testLocationID, enrollment.LocationID)
+	assert.Equal(t, testAdmin.UserID, *enrollment.ArmedBy)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), enrollment.ExpiresAt, time.Second)
+	deps.nfcRepo.AssertExpectations(t)
+}
+
+func TestNFCService_ArmEnrollment_Rejected(t *testing.T) {
+	t.Run("not admin", func(t *testing.T) {
+		svc, _ := newTestNFCService()
+
+		staff := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+		enrollment, err := svc.ArmEnrollment(context.Background(), staff, &model.NFCEnrollmentRequest{UserID: uuid.New(), LocationID: testLocationID})
+
+		require.ErrorIs(t, err, ErrForbidden)
+		assert.Nil(t, enrollment)
+	})
+
+	t.Run("user has no profile", func(t *testing.T) {
+		svc, deps := newTestNFCService()
+
+		ctx := context.Background()
+		userID := uuid.New()
+		deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID}, nil)
+		deps.profileRepo.On("GetByUserID", ctx, userID).Return(nil, repository.ErrProfileNotFound)
+
+		enrollment, err := svc.ArmEnrollment(ctx, testAdmin, &model.NFCEnrollmentRequest{UserID: userID, LocationID: testLocationID})
+
+		require.ErrorIs(t, err, ErrProfileNotFound)
+		assert.Nil(t, enrollment)
+		deps.nfcRepo.AssertNotCalled(t, "CreateEnrollment", mock.Anything, mock.Anything)
+	})
+}
+
+func TestNFCService_CompleteEnrollment_NoPendingEnrollment(t *testing.T) {
+	svc, deps := newTestNFCService()
+
+	ctx := context.Background()
+	deps.nfcRepo.On("GetActiveEnrollmentForUpdate", ctx, testLocationID, mock.AnythingOfType("time.Time")).Return(nil, repository.ErrEnrollmentNotFound)
+
+	profile, err := svc.CompleteEnrollment(ctx, testLocationID, "04A3B2C1")
+
+	require.NoError(t, err)
+	assert.Nil(t, profile)
+}
+
+func TestNFCService_CompleteEnrollment_BindsCard(t *testing.T) {
+	oldSerial := "FFFF0000"
+
+	tests := []struct {
+		name       string
+		current    *string
+		wantEvents []model.NFCCardEventType
+	}{
+		{name: "first card", wantEvents: []model.NFCCardEventType{model.NFCCardBound}},
+		{name: "replaces current card", current: &oldSerial, wantEvents: []model.NFCCardEventType{model.NFCCardReplaced, model.NFCCardBound}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deps := newTestNFCService()
+
+			ctx := context.Background()
+			userID := uuid.New()
+			enrollment := &model.NFCEnrollment{ID: 9, UserID: userID, LocationID: testLocationID, ArmedBy: &testAdmin.UserID}
+			deps.nfcRepo.On("GetActiveEnrollmentForUpdate", ctx, testLocationID, mock.AnythingOfType("time.Time")).Return(enrollment, nil)
+			deps.profileRepo.On("GetByNFCSerial", ctx, "04A3B2C1").Return(nil, repository.ErrProfileNotFound)
+			deps.profileRepo.On("GetByUserID", ctx, userID).Return(&model.Profile{UserID: userID, Name: "山田太郎", NFCSerial: tt.current}, nil)
+			deps.nfcRepo.On("SetCardSerial", ctx, userID, mock.MatchedBy(func(serial *string) bool { return *serial == "04A3B2C1" })).Return(nil)
+			var events []model.NFCCardEventType
+			deps.nfcRepo.On("CreateCardEvent", ctx, mock.AnythingOfType("*model.NFCCardEvent")).
+				Run(func(args mock.Arguments) { events = append(events, args.Get(1).(*model.NFCCardEvent).Event) }).
+				Return(nil)
+			deps.nfcRepo.On("CompleteEnrollment", ctx, int64(9), "04A3B2C1", mock.AnythingOfType("time.Time")).Return(nil)
+
+			profile, err := svc.CompleteEnrollment(ctx, testLocationID, "04A3B2C1")
+
+			require.NoError(t, err)
+			assert.Equal(t, "04A3B2C1", *profile.NFCSerial)
+			assert.Equal(t, tt.wantEvents, events)
+			deps.nfcRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNFCService_CompleteEnrollment_CardOwnedByAnotherUser(t *testing.T) {
+	svc, deps := newTestNFCService()
+
+	ctx := context.Background()
+	enrollment := &model.NFCEnrollment{ID: 9, UserID: uuid.New(), LocationID: testLocationID}
+	deps.nfcRepo.On("GetActiveEnrollmentForUpdate", ctx, testLocationID, mock.AnythingOfType("time.Time")).Return(enrollment, nil)
+	deps.profileRepo.On("GetByNFCSerial", ctx, "04A3B2C1").Return(&model.Profile{UserID: uuid.New()}, nil)
+
+	profile, err := svc.CompleteEnrollment(ctx, testLocationID, "04A3B2C1")
+
+	require.ErrorIs(t, err, ErrCardAlreadyEnrolled)
+	assert.Nil(t, profile)
+	deps.nfcRepo.AssertNotCalled(t, "SetCardSerial", mock.Anything, mock.Anything, mock.Anything)
+	deps.nfcRepo.AssertNotCalled(t, "CompleteEnrollment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestNFCService_CompleteEnrollment_SameCard(t *testing.T) {
+	svc, deps := newTestNFCService()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	serial := "04A3B2C1"
+	enrollment := &model.NFCEnrollment{ID: 9, UserID: userID, LocationID: testLocationID}
+	deps.nfcRepo.On("GetActiveEnrollmentForUpdate", ctx, testLocationID, mock.AnythingOfType("time.Time")).Return(enrollment, nil)
+	deps.profileRepo.On("GetByNFCSerial", ctx, serial).Return(&model.Profile{UserID: userID, NFCSerial: &serial}, nil)
+	deps.profileRepo.On("GetByUserID", ctx, userID).Return(&model.Profile{UserID: userID, NFCSerial: &serial}, nil)
+	deps.nfcRepo.On("CompleteEnrollment", ctx, int64(9), serial, mock.AnythingOfType("time.Time")).Return(nil)
+
+	profile, err := svc.CompleteEnrollment(ctx, testLocationID, serial)
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, profile.UserID)
+	deps.nfcRepo.AssertNotCalled(t, "CreateCardEvent", mock.Anything, mock.Anything)
+}
+
+func TestNFCService_UnbindCard(t *testing.T) {
+	svc, deps := newTestNFCService()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	serial := "04A3B2C1"
+	deps.profileRepo.On("GetByUserID", ctx, userID).Return(&model.Profile{UserID: userID, NFCSerial: &serial}, nil)
+	deps.nfcRepo.On("SetCardSerial", ctx, userID, (*string)(nil)).Return(nil)
+	deps.nfcRepo.On("CreateCardEvent", ctx, cardEvent(model.NFCCardUnbound, serial)).Return(nil)
+
+	err := svc.UnbindCard(ctx, testAdmin, userID)
+
+	require.NoError(t, err)
+	deps.nfcRepo.AssertExpectations(t)
+}
+
+func TestNFCService_UnbindCard_NoCard(t *testing.T) {
+	svc, deps := newTestNFCService()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	deps.profileRepo.On("GetByUserID", ctx, userID).Return(&model.Profile{UserID: userID}, nil)
+
+	err := svc.UnbindCard(ctx, testAdmin, userID)
+
+	require.ErrorIs(t, err, ErrNoCard)
+	deps.nfcRepo.AssertNotCalled(t, "SetCardSerial", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestNFCService_ReplaceCard(t *testing.T) {
+	svc, deps := newTestNFCService()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	serial := "04A3B2C1"
+	deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID}, nil)
+	deps.profileRepo.On("GetByUserID", ctx, userID).Return(&model.Profile{UserID: userID, NFCSerial: &serial}, nil)
+	deps.nfcRepo.On("SetCardSerial", ctx, userID, (*string)(nil)).Return(nil)
+	deps.nfcRepo.On("CreateCardEvent", ctx, cardEvent(model.NFCCardLost, serial)).Return(nil)
+	deps.nfcRepo.On("CancelPendingEnrollments", ctx, testLocationID, mock.AnythingOfType("time.Time")).Return(nil)
+	deps.nfcRepo.On("CreateEnrollment", ctx, mock.AnythingOfType("*model.NFCEnrollment")).Return(nil)
+
+	enrollment, err := svc.ReplaceCard(ctx, testAdmin, userID, &model.NFCReplaceRequest{LocationID: testLocationID})
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, enrollment.UserID)
+	deps.nfcRepo.AssertExpectations(t)
+}
+
+func TestNFCService_CardHistory(t *testing.T) {
+	svc, deps := newTestNFCService()
+
+	ctx := context.Background()
+	member := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	events := []*model.NFCCardEvent{{ID: 1, UserID: member.UserID, Event: model.NFCCardBound}}
+	deps.nfcRepo.On("ListCardEvents", ctx, member.UserID).Return(events, nil)
+
+	got, err := svc.CardHistory(ctx, member, member.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, events, got)
+
+	_, err = svc.CardHistory(ctx, member, uuid.New())
+	require.ErrorIs(t, err, ErrForbidden)
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/oidc"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	oidcStateTTL      = 10 * time.Minute
+	oidcStateAudience = "oidc-login-state"
+)
+
+var (
+	ErrInvalidOIDCState  = errors.New("invalid oidc login state")
+	ErrOIDCUserNotLinked = errors.New("no local account can be linked to this identity")
+)
+
+type OIDCProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier string) (*oidc.Tokens, error)
+	VerifyIDToken(ctx context.Context, raw, nonce string) (*oidc.Claims, error)
+	VerifyAccessToken(ctx context.Context, raw string) (*oidc.Claims, error)
+}
+
+type UserProvisioner interface {
+	// ProvisionExternalUser はログインのたびに呼び、ユーザーの作成・紐付けとメールアドレス・名前の同期を行う
+	ProvisionExternalUser(ctx context.Context, identity *model.ExternalIdentity) (*model.User, error)
+	// GetExternalUser は API リクエストごとに呼ぶため、書き込みを行わない
+	GetExternalUser(ctx context.Context, subject string) (*model.User, error)
+}
+
+type SessionManager interface {
+	IssueSession(ctx context.Context, user *model.User) (*model.LoginResponse, error)
+	CheckRevocation(ctx context.Context, identity *auth.Identity) error
+}
+
+type OIDCService interface {
+	BeginLogin(ctx context.Context) (*model.OIDCLoginStart, error)
+	CompleteLogin(ctx context.Context, req *model.OIDCCallbackRequest) (*model.LoginResponse, error)
+	ValidateAccessToken(ctx context.Context, token string) (*auth.Identity, error)
+}
+
+type oidcService struct {
+	provider    OIDCProvider
+	users       UserProvisioner
+	sessions    SessionManager
+	stateSecret []byte
+}
+
+func NewOIDCService(provider OIDCProvider, users UserProvisioner, sessions SessionManager, stateSecret string) OIDCService {
+	return &oidcService{
+		provider:    provider,
+		users:       users,
+		sessions:    sessions,
+		stateSecret: []byte(stateSecret),
+	}
+}
+
+// oidcStateClaims は認可リクエストとコールバックを結び付ける情報。
+// code_verifier を URL に載せないため、署名付き Cookie としてブラウザに保持させる
+type oidcStateClaims struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	jwt.RegisteredClaims
+}
+
+func (s *oidcService) BeginLogin(ctx context.Context) (*model.OIDCLoginStart, error) {
+	state, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, oidc.S256Challenge(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build authorization url: %w", err)
+	}
+
+	now := time.Now()
+	cookie, err := jwt.NewWithClaims(jwt.SigningMethodHS256, oidcStateClaims{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{oidcStateAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(oidcStateTTL)),
+		},
+	}).SignedString(s.stateSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign oidc state: %w", err)
+	}
+
+	return &model.OIDCLoginStart{
+		AuthorizationURL: authURL,
+		StateCookie:      cookie,
+	}, nil
+}
+
+func (s *oidcService) CompleteLogin(ctx context.Context, req *model.OIDCCallbackRequest) (*model.LoginResponse, error) {
+	state := &oidcStateClaims{}
+	_, err := jwt.ParseWithClaims(req.StateCookie, state, func(token *jwt.Token) (interface{}, error) {
+		return s.stateSecret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(oidcStateAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+
+	if req.State == "" || subtle.ConstantTimeCompare([]byte(req.State), []byte(state.State)) != 1 {
+		return nil, ErrInvalidOIDCState
+	}
+
+	tokens, err := s.provider.Exchange(ctx, req.Code, state.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	claims, err := s.provider.VerifyIDToken(ctx, tokens.IDToken, state.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify id token: %w", err)
+	}
+
+	user, err := s.resolveUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sessions.IssueSession(ctx, user)
+}
+
+func (s *oidcService) ValidateAccessToken(ctx context.Context, token string) (*auth.Identity, error) {
+	claims, err := s.provider.VerifyAccessToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidToken) || errors.Is(err, oidc.ErrInactive) {
+			return nil, fmt.Errorf("%w: %v", auth.ErrInvalidToken, err)
+		}
+		if errors.Is(err, oidc.ErrUnavailable) {
+			return nil, fmt.Errorf("%w: %v", auth.ErrUnavailable, err)
+		}
+		return nil, err
+	}
+
+	// 作成と同期はログイン時のみ行い、ログインしていない利用者のトークンは受け付けない
+	user, err := s.users.GetExternalUser(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, fmt.Errorf("%w: %v", auth.ErrInvalidToken, ErrOIDCUserNotLinked)
+		}
+		return nil, err
+	}
+
+	tokenID := claims.TokenID
+	if tokenID == "" {
+		tokenID = "zitadel:" + hashToken(token)
+	}
+
+	identity := &auth.Identity{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		TokenID:   tokenID,
+		IssuedAt:  claims.IssuedAt,
+		ExpiresAt: claims.ExpiresAt,
+	}
+
+	if err := s.sessions.CheckRevocation(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// resolveUser はログイン時に検証済みクレームからローカルユーザーを取得し、初回であれば作成または紐付けを行う
+func (s *oidcService) resolveUser(ctx context.Context, claims *oidc.Claims) (*model.User, error) {
+	user, err := s.users.ProvisionExternalUser(ctx, &model.ExternalIdentity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	})
+	if err != nil {
+		if errors.Is(err, ErrExternalEmailUnverified) || errors.Is(err, ErrExternalIdentityConflict) {
+			return nil, fmt.Errorf("%w: %v", ErrOIDCUserNotLinked, err)
+		}
+		return nil, fmt.Errorf("failed to provision user: %w", err)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/oidc"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/oidc/oidctest"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/password"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockSessionManager struct {
+	mock.Mock
+}
+
+func (m *MockSessionManager) IssueSession(ctx context.Context, user *model.User) (*model.LoginResponse, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.LoginResponse), args.Error(1)
+}
+
+func (m *MockSessionManager) CheckRevocation(ctx context.Context, identity *auth.Identity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func newTestOIDCService(t *testing.T) (OIDCService, *oidctest.Server, *MockUserRepository, *MockSessionManager) {
+	t.Helper()
+
+	provider := oidctest.NewServer("tsunagu", "secret")
+	t.Cleanup(provider.Close)
+
+	client := oidc.NewClient(oidc.Config{
+		Issuer:       provider.URL,
+		ClientID:     provider.ClientID,
+		ClientSecret: provider.ClientSecret,
+		RedirectURL:  "http://localhost:8080/api/v1/auth/oidc/callback",
+	})
+
+	userRepo := new(MockUserRepository)
+	sessions := new(MockSessionManager)
+	users := NewUserService(userRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+	return NewOIDCService(client, users, sessions, "state-secret"), provider, userRepo, sessions
+}
+
+// beginAndAuthorize はログインを開始し、IdP 上で利用者が認可した後のコールバックリクエストを組み立てる
+func beginAndAuthorize(t *testing.T, svc OIDCService, provider *oidctest.Server, subject, email string) *model.OIDCCallbackRequest {
+	t.Helper()
+
+	start, err := svc.BeginLogin(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, start.StateCookie)
+
+	authURL, err := url.Parse(start.AuthorizationURL)
+	require.NoError(t, err)
+	query := authURL.Query()
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+
+	claims := provider.Claims(subject, email, "Test User")
+	claims["nonce"] = query.Get("nonce")
+	code := provider.IssueCode(query.Get("code_challenge"), claims)
+
+	return &model.OIDCCallbackRequest{
+		Code:        code,
+		State:       query.Get("state"),
+		StateCookie: start.StateCookie,
+	}
+}
+
+func TestOIDCService_CompleteLogin_LinkedUser(t *testing.T) {
+	svc, provider, userRepo, sessions := newTestOIDCService(t)
+
+	ctx := context.Background()
+	zitadelID := "zitadel-user"
+	verifiedAt := time.Now()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Name: "Test User", ZitadelID: &zitadelID, EmailVerifiedAt: &verifiedAt}
+	expected := &model.LoginResponse{Token: "access", RefreshToken: "refresh", User: user}
+
+	userRepo.On("GetByZitadelIDForUpdate", ctx, "zitadel-user").Return(user, nil)
+	sessions.On("IssueSession", ctx, user).Return(expected, nil)
+
+	req := beginAndAuthorize(t, svc, provider, "zitadel-user", "test@example.com")
+	response, err := svc.CompleteLogin(ctx, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, expected, response)
+
+	userRepo.AssertExpectations(t)
+	sessions.AssertExpectations(t)
+}
+
+func TestOIDCService_CompleteLogin_LinksByVerifiedEmail(t *testing.T) {
+	svc, provider, userRepo, sessions := newTestOIDCService(t)
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Name: "Test User"}
+	expected := &model.LoginResponse{Token: "access", RefreshToken: "refresh", User: user}
+
+	userRepo.On("GetByZitadelIDForUpdate", ctx, "zitadel-user").Return(nil, repository.ErrUserNotFound)
+	userRepo.On("GetByEmail", ctx, "test@example.com").Return(user, nil)
+	userRepo.On("LinkZitadelID", ctx, user.ID, "zitadel-user").Return(nil)
+	userRepo.On("MarkEmailVerified", ctx, user.ID, "test@example.com").Return(true, nil)
+	sessions.On("IssueSession", ctx, user).Return(expected, nil)
+
+	req := beginAndAuthorize(t, svc, provider, "zitadel-user", "test@example.com")
+	response, err := svc.CompleteLogin(ctx, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, expected, response)
+	require.NotNil(t, user.ZitadelID)
+	assert.Equal(t, "zitadel-user", *user.ZitadelID)
+
+	userRepo.AssertExpectations(t)
+}
+
+func TestOIDCService_CompleteLogin_ProvisionsNewUser(t *testing.T) {
+	svc, provider, userRepo, sessions := newTestOIDCService(t)
+
+	ctx := context.Background()
+	userRepo.On("GetByZitadelIDForUpdate", ctx, "zitadel-user").Return(nil, repository.ErrUserNotFound)
+	userRepo.On("GetByEmail", ctx, "new@example.com").Return(nil, repository.ErrUserNotFound)
+	userRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+	sessions.On("IssueSession", ctx, mock.MatchedBy(func(user *model.User) bool {
+		return user.Email == "new@example.com" && user.AuthSource == model.AuthSourceZitadel && !user.HasLocalPassword()
+	})).Return(&model.LoginResponse{Token: "access"}, nil)
+
+	req := beginAndAuthorize(t, svc, provider, "zitadel-user", "new@example.com")
+	response, err := svc.CompleteLogin(ctx, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, "access", response.Token)
+
+	userRepo.AssertExpectations(t)
+	sessions.AssertExpectations(t)
+}
+
+func TestOIDCService_CompleteLogin_NotLinked(t *testing.T) {
+	svc, provider, userRepo, sessions := newTestOIDCService(t)
+
+	ctx := context.Background()
+	userRepo.On("GetByZitadelIDForUpdate", ctx, "zitadel-user").Return(nil, repository.ErrUserNotFound)
+
+	start, err := svc.BeginLogin(ctx)
+	require.NoError(t, err)
+	authURL, err := url.Parse(start.AuthorizationURL)
+	require.NoError(t, err)
+	query := authURL.Query()
+
+	claims := provider.Claims("zitadel-user", "unverified@example.com", "Test User")
+	claims["email_verified"] = false
+	claims["nonce"] = query.Get("nonce")
+	code := provider.IssueCode(query.Get("code_challenge"), claims)
+
+	response, err := svc.CompleteLogin(ctx, &model.OIDCCallbackRequest{
+		Code:        code,
+		State:       query.Get("state"),
+		StateCookie: start.StateCookie,
+	})
+
+	require.ErrorIs(t, err, ErrOIDCUserNotLinked)
+	assert.Nil(t, response)
+	sessions.AssertNotCalled(t, "IssueSession", mock.Anything, mock.Anything)
+}
+
+func TestOIDCService_CompleteLogin_InvalidState(t *testing.T) {
+	svc, provider, _, _ := newTestOIDCService(t)
+
+	tests := []struct {
+		name   string
+		modify func(req *model.OIDCCallbackRequest)
+	}{
+		{
+			name:   "state mismatch",
+			modify: func(req *model.OIDCCallbackRequest) { req.State = "forged" },
+		},
+		{
+			name:   "tampered cookie",
+			modify: func(req *model.OIDCCallbackRequest) { req.StateCookie += "x" },
+		},
+		{
+			name:   "missing cookie",
+			modify: func(req *model.OIDCCallbackRequest) { req.StateCookie = "" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := beginAndAuthorize(t, svc, provider, "zitadel-user", "test@example.com")
+			tt.modify(req)
+
+			response, err := svc.CompleteLogin(context.Background(), req)
+
+			require.ErrorIs(t, err, ErrInvalidOIDCState)
+			assert.Nil(t, response)
+		})
+	}
+}
+
+func TestOIDCService_ValidateAccessToken(t *testing.T) {
+	svc, provider, userRepo, sessions := newTestOIDCService(t)
+
+	ctx := context.Background()
+	zitadelID := "zitadel-user"
+	verifiedAt := time.Now()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Name: "Test User", ZitadelID: &zitadelID, EmailVerifiedAt: &verifiedAt}
+
+	claims := provider.Claims(zitadelID, "test@example.com", "Test User")
+	token := provider.SignToken(claims)
+
+	userRepo.On("GetByZitadelID", ctx, zitadelID).Return(user, nil)
+	sessions.On("CheckRevocation", ctx, mock.AnythingOfType("*auth.Identity")).Return(nil)
+
+	identity, err := svc.ValidateAccessToken(ctx, token)
+
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, identity.UserID)
+	assert.Equal(t, user.Email, identity.Email)
+	assert.Equal(t, claims["jti"], identity.TokenID)
+	// API リクエストではユーザーの同期を行わない
+	userRepo.AssertNotCalled(t, "GetByZitadelIDForUpdate", mock.Anything, mock.Anything)
+	userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestOIDCService_ValidateAccessToken_Revoked(t *testing.T) {
+	svc, provider, userRepo, sessions := newTestOIDCService(t)
+
+	ctx := context.Background()
+	zitadelID := "zitadel-user"
+	verifiedAt := time.Now()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Name: "Test User", ZitadelID: &zitadelID, EmailVerifiedAt: &verifiedAt}
+	token := provider.SignToken(provider.Claims(zitadelID, "test@example.com", "Test User"))
+
+	userRepo.On("GetByZitadelID", ctx, zitadelID).Return(user, nil)
+	sessions.On("CheckRevocation", ctx, mock.AnythingOfType("*auth.Identity")).Return(auth.ErrRevokedToken)
+
+	identity, err := svc.ValidateAccessToken(ctx, token)
+
+	require.ErrorIs(t, err, auth.ErrRevokedToken)
+	assert.Nil(t, identity)
+}
+
+func TestOIDCService_ValidateAccessToken_Invalid(t *testing.T) {
+	svc, _, _, _ := newTestOIDCService(t)
+
+	identity, err := svc.ValidateAccessToken(context.Background(), "header.payload.signature")
+
+	require.ErrorIs(t, err, auth.ErrInvalidToken)
+	assert.Nil(t, identity)
+}
+
+func TestOIDCService_ValidateAccessToken_ProviderUnavailable(t *testing.T) {
+	svc, provider, _, _ := newTestOIDCService(t)
+	provider.Close()
+
+	identity, err := svc.ValidateAccessToken(context.Background(), "opaque-access-token")
+
+	require.ErrorIs(t, err, auth.ErrUnavailable)
+	assert.NotErrorIs(t, err, auth.ErrInvalidToken)
+	assert.Nil(t, identity)
+}
+
+func TestOIDCService_ValidateAccessToken_UnlinkedUser(t *testing.T) {
+	svc, provider, userRepo, _ := newTestOIDCService(t)
+
+	ctx := context.Background()
+	// ログインしていない利用者は、メールアドレスが一致するユーザーがいても紐付けない
+	claims := provider.Claims("zitadel-user", "test@example.com", "Test User")
+	token := provider.SignToken(claims)
+
+	userRepo.On("GetByZitadelID", ctx, "zitadel-user").Return(nil, repository.ErrUserNotFound)
+
+	identity, err := svc.ValidateAccessToken(ctx, token)
+
+	require.ErrorIs(t, err, auth.ErrInvalidToken)
+	assert.Nil(t, identity)
+	userRepo.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+	userRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
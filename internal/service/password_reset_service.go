@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/mail"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/password"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+type PasswordResetService interface {
+	RequestReset(ctx context.Context, req *model.ForgotPasswordRequest, clientIP string) error
+	ResetPassword(ctx context.Context, req *model.ResetPasswordRequest) error
+}
+
+type PasswordResetConfig struct {
+	// ResetURL はメールに記載する再設定画面の URL。token クエリパラメーターを付与する
+	ResetURL string
+	Expiry   time.Duration
+	MailFrom string
+	Policy   password.Policy
+	// Sends は再設定メールの送信間隔と回数を制限する
+	Sends PasswordResetLimits
+}
+
+// PasswordResetLimits は再設定メールの送信をメールアドレスごとと接続元IPごとに制限する
+type PasswordResetLimits struct {
+	Account *lockout.Limiter
+	IP      *lockout.Limiter
+}
+
+type passwordResetService struct {
+	userRepo  repository.UserRepository
+	resetRepo repository.PasswordResetTokenRepository
+	tx        repository.Transactor
+	sessions  SessionRevoker
+	mailer    mail.Sender
+	cfg       PasswordResetConfig
+}
+
+func NewPasswordResetService(
+	userRepo repository.UserRepository,
+	resetRepo repository.PasswordResetTokenRepository,
+	tx repository.Transactor,
+	sessions SessionRevoker,
+	mailer mail.Sender,
+	cfg PasswordResetConfig,
+) PasswordResetService {
+	return &passwordResetService{
+		userRepo:  userRepo,
+		resetRepo: resetRepo,
+		tx:        tx,
+		sessions:  sessions,
+		mailer:    mailer,
+		cfg:       cfg,
+	}
+}
+
+// RequestReset は再設定トークンを発行してメールで送る。
+// 登録の有無を推測されないよう、ユーザーが存在しない場合もエラーを返さない。
+// 送信回数の制限も登録の有無に関わらず同じように数える
+func (s *passwordResetService) RequestReset(ctx context.Context, req *model.ForgotPasswordRequest, clientIP string) error {
+	account := normalizeEmail(req.Email)
+	if err := s.checkSendLimits(ctx, account, clientIP); err != nil {
+		return err
+	}
+	s.recordSend(ctx, account, clientIP)
+
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return nil
+	}
+
+	// 外部 IdP で管理するユーザーのパスワードは IdP 側で再設定する
+	if !user.HasLocalPassword() {
+		return nil
+	}
+
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	resetToken := &model.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(s.cfg.Expiry),
+		CreatedAt: now,
+	}
+
+	// 新しいトークンを発行したら、以前に発行した未使用のトークンは使えなくする
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.resetRepo.InvalidateForUser(ctx, user.ID); err != nil {
+			return err
+		}
+		return s.resetRepo.Create(ctx, resetToken)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store password reset token: %w", err)
+	}
+
+	msg := &mail.Message{
+		From:    s.cfg.MailFrom,
+		To:      user.Email,
+		Subject: "パスワード再設定のご案内",
+		Body: fmt.Sprintf(
+			"%s 様\n\n以下のリンクからパスワードを再設定してください。リンクの有効期限は%d分です。\n\n%s\n\nこのメールに心当たりがない場合は破棄してください。\n",
+			user.Name, int(s.cfg.Expiry.Minutes()), s.resetLink(token),
+		),
+	}
+
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("Failed to send password reset mail to user %s: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// checkSendLimits はメールアドレスか接続元IPが送信制限中であれば、待ち時間の長い方の *lockout.LockedError を返す
+func (s *passwordResetService) checkSendLimits(ctx context.Context, account, clientIP string) error {
+	var locked *lockout.LockedError
+	for _, check := range []struct {
+		limiter *lockout.Limiter
+		id      string
+	}{
+		{s.cfg.Sends.Account, account},
+		{s.cfg.Sends.IP, clientIP},
+	} {
+		if check.id == "" {
+			continue
+		}
+
+		err := check.limiter.Check(ctx, check.id)
+		var lockedErr *lockout.LockedError
+		switch {
+		case errors.As(err, &lockedErr):
+			if locked == nil || lockedErr.RetryAfter > locked.RetryAfter {
+				locked = lockedErr
+			}
+		case err != nil:
+			return err
+		}
+	}
+
+	if locked != nil {
+		return locked
+	}
+	return nil
+}
+
+// recordSend は送信の要求を失敗として記録し、Sends のポリシーで次に要求できるまでの時間を決める
+func (s *passwordResetService) recordSend(ctx context.Context, account, clientIP string) {
+	for _, record := range []struct {
+		limiter *lockout.Limiter
+		id      string
+	}{
+		{s.cfg.Sends.Account, account},
+		{s.cfg.Sends.IP, clientIP},
+	} {
+		if record.id == "" {
+			continue
+		}
+		if err := record.limiter.RecordFailure(ctx, record.id); err != nil {
+			log.Printf("Failed to record password reset request: %v", err)
+		}
+	}
+}
+
+func (s *passwordResetService) resetLink(token string) string {
+	u, err := url.Parse(s.cfg.ResetURL)
+	if err != nil {
+		return s.cfg.ResetURL + "?token=" + url.QueryEscape(token)
+	}
+
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// ResetPassword はトークンを消費してパスワードを更新し、既存のセッションを全て失効させる
+func (s *passwordResetService) ResetPassword(ctx context.Context, req *model.ResetPasswordRequest) error {
+	validationErr := &ValidationError{}
+	validationErr.Add("new_password", s.cfg.Policy.Validate(req.NewPassword)...)
+	if err := validationErr.Err(); err != nil {
+		return err
+	}
+
+	resetToken, err := s.resetRepo.GetByHash(ctx, hashToken(req.Token))
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+
+	if resetToken.UsedAt != nil {
+		return ErrInvalidResetToken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// 並行して同じトークンが使われた場合は一方のみ成功させる。有効期限もこの更新で判定する
+		marked, err := s.resetRepo.MarkUsed(ctx, resetToken.ID)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			return ErrInvalidResetToken
+		}
+
+		if err := s.userRepo.UpdatePassword(ctx, resetToken.UserID, string(hashedPassword)); err != nil {
+			return fmt.Errorf("failed to update password: %w", err)
+		}
+
+		return s.resetRepo.InvalidateForUser(ctx, resetToken.UserID)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.sessions.RevokeAllSessions(ctx, resetToken.UserID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
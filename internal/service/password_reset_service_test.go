@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/lockout"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/mail"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/password"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockPasswordResetTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetTokenRepository) Create(ctx context.Context, token *model.PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordResetTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordResetTokenRepository) InvalidateForUser(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// recordingSender は送信されたメールを保持する
+type recordingSender struct {
+	messages []*mail.Message
+	err      error
+}
+
+func (s *recordingSender) Send(_ context.Context, msg *mail.Message) error {
+	s.messages = append(s.messages, msg)
+	return s.err
+}
+
+type passwordResetTestDeps struct {
+	userRepo  *MockUserRepository
+	resetRepo *MockPasswordResetTokenRepository
+	sessions  *MockSessionRevoker
+	mailer    *recordingSender
+}
+
+func newTestPasswordResetService() (PasswordResetService, *passwordResetTestDeps) {
+	deps := &passwordResetTestDeps{
+		userRepo:  new(MockUserRepository),
+		resetRepo: new(MockPasswordResetTokenRepository),
+		sessions:  new(MockSessionRevoker),
+		mailer:    &recordingSender{},
+	}
+	svc := NewPasswordResetService(deps.userRepo, deps.resetRepo, &fakeTransactor{}, deps.sessions, deps.mailer, PasswordResetConfig{
+		ResetURL: "https://app.example.com/password/reset",
+		Expiry:   30 * time.Minute,
+		MailFrom: "noreply@example.com",
+		Policy:   password.DefaultPolicy(),
+		Sends: PasswordResetLimits{
+			Account: lockout.NewLimiter(lockout.NewMemoryStore(), "password-reset", lockout.Policy{
+				MaxFailures: 3,
+				Lockout:     time.Hour,
+				BaseDelay:   time.Minute,
+				MaxDelay:    time.Minute,
+			}),
+			IP: lockout.NewLimiter(lockout.NewMemoryStore(), "password-reset-ip", lockout.Policy{
+				MaxFailures: 10,
+				Lockout:     time.Hour,
+			}),
+		},
+	})
+	return svc, deps
+}
+
+var resetLinkPattern = regexp.MustCompile(`https://app\.example\.com/password/reset\?token=\S+`)
+
+func TestPasswordResetService_RequestReset(t *testing.T) {
+	svc, deps := newTestPasswordResetService()
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Name: "Test User", Password: "hashed"}
+
+	var stored *model.PasswordResetToken
+	deps.userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	deps.resetRepo.On("InvalidateForUser", ctx, user.ID).Return(nil)
+	deps.resetRepo.On("Create", ctx, mock.AnythingOfType("*model.PasswordResetToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*model.PasswordResetToken) }).
+		Return(nil)
+
+	err := svc.RequestReset(ctx, &model.ForgotPasswordRequest{Email: user.Email}, "192.0.2.10")
+
+	require.NoError(t, err)
+	require.Len(t, deps.mailer.messages, 1)
+	msg := deps.mailer.messages[0]
+	assert.Equal(t, user.Email, msg.To)
+	assert.Equal(t, "noreply@example.com", msg.From)
+
+	link := resetLinkPattern.FindString(msg.Body)
+	require.NotEmpty(t, link)
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	token := parsed.Query().Get("token")
+
+	require.NotNil(t, stored)
+	assert.Equal(t, user.ID, stored.UserID)
+	assert.Equal(t, hashToken(token), stored.TokenHash)
+	assert.NotContains(t, stored.TokenHash, token)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), stored.ExpiresAt, 2*time.Second)
+
+	deps.resetRepo.AssertExpectations(t)
+}
+
+func TestPasswordResetService_RequestReset_UnknownEmail(t *testing.T) {
+	svc, deps := newTestPasswordResetService()
+
+	ctx := context.Background()
+	deps.userRepo.On("GetByEmail", ctx, "unknown@example.com").Return(nil, fmt.Errorf("user not found"))
+
+	err := svc.RequestReset(ctx, &model.ForgotPasswordRequest{Email: "unknown@example.com"}, "192.0.2.10")
+
+	require.NoError(t, err)
+	assert.Empty(t, deps.mailer.messages)
+	deps.resetRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestPasswordResetService_RequestReset_ExternallyManagedUser(t *testing.T) {
+	svc, deps := newTestPasswordResetService()
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", AuthSource: model.AuthSourceZitadel}
+	deps.userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+
+	err := svc.RequestReset(ctx, &model.ForgotPasswordRequest{Email: user.Email}, "192.0.2.10")
+
+	require.NoError(t, err)
+	assert.Empty(t, deps.mailer.messages)
+	deps.resetRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestPasswordResetService_RequestReset_ThrottledPerAccount(t *testing.T) {
+	svc, deps := newTestPasswordResetService()
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Name: "Test User", Password: "hashed"}
+	deps.userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	deps.resetRepo.On("InvalidateForUser", ctx, user.ID).Return(nil)
+	deps.resetRepo.On("Create", ctx, mock.AnythingOfType("*model.PasswordResetToken")).Return(nil)
+
+	require.NoError(t, svc.RequestReset(ctx, &model.ForgotPasswordRequest{Email: user.Email}, "192.0.2.10"))
+
+	// 大文字小文字や接続元を変えても同じアカウントとして数える
+	err := svc.RequestReset(ctx, &model.ForgotPasswordRequest{Email: " Test@Example.com"}, "192.0.2.20")
+
+	var lockedErr *lockout.LockedError
+	require.ErrorAs(t, err, &lockedErr)
+	assert.InDelta(t, time.Minute.Seconds(), lockedErr.RetryAfter.Seconds(), 2)
+	assert.Len(t, deps.mailer.messages, 1)
+	deps.userRepo.AssertNumberOfCalls(t, "GetByEmail", 1)
+}
+
+func TestPasswordResetService_RequestReset_UnknownEmailIsThrottled(t *testing.T) {
+	svc, deps := newTestPasswordResetService()
+
+	ctx := context.Background()
+	deps.userRepo.On("GetByEmail", ctx, "unknown@example.com").Return(nil, fmt.Errorf("user not found"))
+
+	require.NoError(t, svc.RequestReset(ctx, &model.ForgotPasswordRequest{Email: "unknown@example.com"}, "192.0.2.10"))
+
+	// 登録されていないアドレスも同じように制限し、応答から登録の有無を推測させない
+	err := svc.RequestReset(ctx, &model.ForgotPasswordRequest{Email: "unknown@example.com"}, "192.0.2.10")
+
+	var lockedErr *lockout.LockedError
+	require.ErrorAs(t, err, &lockedErr)
+	deps.userRepo.AssertNumberOfCalls(t, "GetByEmail", 1)
+}
+
+func TestPasswordResetService_RequestReset_ThrottledPerIP(t *testing.T) {
+	svc, deps := newTestPasswordResetService()
+
+	ctx := context.Background()
+	deps.userRepo.On("GetByEmail", ctx, mock.AnythingOfType("string")).Return(nil, fmt.Errorf("user not found"))
+
+	for i := 0; i < 10; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+		require.NoError(t, svc.RequestReset(ctx, &model.ForgotPasswordRequest{Email: email}, "192.0.2.10"))
+	}
+
+	err := svc.RequestReset(ctx, &model.ForgotPasswordRequest{Email: "another@example.com"}, "192.0.2.10")
+
+	var lockedErr *lockout.LockedError
+	require.ErrorAs(t, err, &lockedErr)
+	assert.InDelta(t, time.Hour.Seconds(), lockedErr.RetryAfter.Seconds(), 2)
+
+	// 別の接続元からは要求できる
+	require.NoError(t, svc.RequestReset(ctx, &model.ForgotPasswordRequest{Email: "another@example.com"}, "192.0.2.20"))
+}
+
+func TestPasswordResetService_RequestReset_MailFailureIsNotReported(t *testing.T) {
+	svc, deps := newTestPasswordResetService()
+	deps.mailer.err = fmt.Errorf("smtp unavailable")
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Password: "hashed"}
+	deps.userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	deps.resetRepo.On("InvalidateForUser", ctx, user.ID).Return(nil)
+	deps.resetRepo.On("Create", ctx, mock.AnythingOfType("*model.PasswordResetToken")).Return(nil)
+
+	err := svc.RequestReset(ctx, &model.ForgotPasswordRequest{Email: user.Email}, "192.0.2.10")
+
+	require.NoError(t, err)
+}
+
+func TestPasswordResetService_ResetPassword(t *testing.T) {
+	svc, deps := newTestPasswordResetService()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	resetToken := &model.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: hashToken("reset-token"),
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+	}
+
+	var newHash string
+	deps.resetRepo.On("GetByHash", ctx, hashToken("reset-token")).Return(resetToken, nil)
+	deps.resetRepo.On("MarkUsed", ctx, resetToken.ID).Return(true, nil)
+	deps.userRepo.On("UpdatePassword", ctx, userID, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { newHash = args.String(2) }).
+		Return(nil)
+	deps.resetRepo.On("InvalidateForUser", ctx, userID).Return(nil)
+	deps.sessions.On("RevokeAllSessions", ctx, userID).Return(nil)
+
+	err := svc.ResetPassword(ctx, &model.ResetPasswordRequest{Token: "reset-token", NewPassword: "new-password"})
+
+	require.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(newHash), []byte("new-password")))
+
+	deps.resetRepo.AssertExpectations(t)
+	deps.userRepo.AssertExpectations(t)
+	deps.sessions.AssertExpectations(t)
+}
+
+func TestPasswordResetService_ResetPassword_Rejected(t *testing.T) {
+	usedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name     string
+		token    *model.PasswordResetToken
+		lookup   error
+		marked   bool
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "unknown token",
+			lookup:   fmt.Errorf("password reset token not found"),
+			password: "new-password",
+			wantErr:  ErrInvalidResetToken,
+		},
+		{
+			name:     "expired token",
+			token:    &model.PasswordResetToken{ID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)},
+			password: "new-password",
+			wantErr:  ErrInvalidResetToken,
+		},
+		{
+			name:     "used token",
+			token:    &model.PasswordResetToken{ID: uuid.New(), ExpiresAt: time.Now().Add(time.Minute), UsedAt: &usedAt},
+			password: "new-password",
+			wantErr:  ErrInvalidResetToken,
+		},
+		{
+			name:     "lost race for the token",
+			token:    &model.PasswordResetToken{ID: uuid.New(), ExpiresAt: time.Now().Add(time.Minute)},
+			marked:   false,
+			password: "new-password",
+			wantErr:  ErrInvalidResetToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deps := newTestPasswordResetService()
+
+			ctx := context.Background()
+			deps.resetRepo.On("GetByHash", ctx, hashToken("reset-token")).Return(tt.token, tt.lookup).Maybe()
+			if tt.token != nil {
+				deps.resetRepo.On("MarkUsed", ctx, tt.token.ID).Return(tt.marked, nil).Maybe()
+			}
+
+			err := svc.ResetPassword(ctx, &model.ResetPasswordRequest{Token: "reset-token", NewPassword: tt.password})
+
+			require.ErrorIs(t, err, tt.wantErr)
+			deps.userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+			deps.sessions.AssertNotCalled(t, "RevokeAllSessions", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestPasswordResetService_ResetPassword_PolicyViolation(t *testing.T) {
+	svc, deps := newTestPasswordResetService()
+
+	err := svc.ResetPassword(context.Background(), &model.ResetPasswordRequest{Token: "reset-token", NewPassword: "password"})
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Contains(t, validationErr.Fields["new_password"], "is too common")
+	deps.resetRepo.AssertNotCalled(t, "GetByHash", mock.Anything, mock.Anything)
+	deps.userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/presence"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+)
+
+type PresenceService interface {
+	// List はその場所に入室中のユーザーを入室の古い順に返す。staff と admin のみ呼び出せる
+	List(ctx context.Context, caller *auth.Identity, locationID int) ([]*model.Presence, error)
+	// Subscribe はその場所の入退室を購読する。ctx が終了するか通知を取りこぼした可能性がある場合はチャネルを閉じる
+	Subscribe(ctx context.Context, caller *auth.Identity, locationID int) (<-chan *model.PresenceEvent, error)
+}
+
+type presenceService struct {
+	attendanceRepo repository.AttendanceRepository
+	profileRepo    repository.ProfileRepository
+	masterRepo     repository.MasterDataRepository
+	bus            presence.Bus
+}
+
+func NewPresenceService(
+	attendanceRepo repository.AttendanceRepository,
+	profileRepo repository.ProfileRepository,
+	masterRepo repository.MasterDataRepository,
+	bus presence.Bus,
+) PresenceService {
+	return &presenceService{
+		attendanceRepo: attendanceRepo,
+		profileRepo:    profileRepo,
+		masterRepo:     masterRepo,
+		bus:            bus,
+	}
+}
+
+func (s *presenceService) authorize(ctx context.Context, caller *auth.Identity, locationID int) error {
+	if !caller.HasRole(model.RoleStaff, model.RoleAdmin) {
+		return ErrForbidden
+	}
+
+	return checkLocation(ctx, s.masterRepo, locationID)
+}
+
+func (s *presenceService) List(ctx context.Context, caller *auth.Identity, locationID int) ([]*model.Presence, error) {
+	if err := s.authorize(ctx, caller, locationID); err != nil {
+		return nil, err
+	}
+
+	presences, err := s.attendanceRepo.ListPresent(ctx, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list presence: %w", err)
+	}
+
+	return presences, nil
+}
+
+func (s *presenceService) Subscribe(ctx context.Context, caller *auth.Identity, locationID int) (<-chan *model.PresenceEvent, error) {
+	if err := s.authorize(ctx, caller, locationID); err != nil {
+		return nil, err
+	}
+
+	events, unsubscribe := s.bus.Subscribe(locationID)
+	out := make(chan *model.PresenceEvent)
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				select {
+				case out <- s.withProfile(ctx, event):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// withProfile は購読者ごとに書き換えないよう、プロフィールの表示用 ID と名前を設定した複製を返す
+func (s *presenceService) withProfile(ctx context.Context, event *model.PresenceEvent) *model.PresenceEvent {
+	enriched := *event
+	profile, err := s.profileRepo.GetByUserID(ctx, event.UserID)
+	switch {
+	case errors.Is(err, repository.ErrProfileNotFound):
+	case err != nil:
+		log.Printf("Failed to load profile for presence event: %v", err)
+	default:
+		enriched.DisplayID = profile.DisplayID
+		enriched.Name = profile.Name
+	}
+	return &enriched
+}
+
+// publishPresence はコミットした入退室の記録を通知する。通知に失敗しても記録は取り消さない
+func publishPresence(ctx context.Context, publisher presence.Publisher, logs ...*model.AttendanceLog) {
+	for _, attendance := range logs {
+		event := &model.PresenceEvent{
+			Type:       model.PresenceJoin,
+			LocationID: attendance.LocationID,
+			UserID:     attendance.UserID,
+			LogID:      attendance.ID,
+			RecordedAt: attendance.RecordedAt,
+			Auto:       attendance.Auto,
+		}
+		if attendance.Type == model.AttendanceCheckOut {
+			event.Type = model.PresenceLeave
+		}
+		if err := publisher.Publish(ctx, event); err != nil {
+			log.Printf("Failed to publish presence event: %v", err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/presence"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPresenceService() (PresenceService, *attendanceTestDeps) {
+	deps := &attendanceTestDeps{
+		attendanceRepo: new(MockAttendanceRepository),
+		profileRepo:    new(MockProfileRepository),
+		masterRepo:     new(MockMasterDataRepository),
+		bus:            presence.NewMemoryBus(),
+	}
+	return NewPresenceService(deps.attendanceRepo, deps.profileRepo, deps.masterRepo, deps.bus), deps
+}
+
+var testStaff = &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+
+func TestPresenceService_List(t *testing.T) {
+	t.Run("staff sees who is present", func(t *testing.T) {
+		svc, deps := newTestPresenceService()
+
+		ctx := context.Background()
+		present := []*model.Presence{{UserID: uuid.New(), DisplayID: "taro", Name: "山田太郎", CheckInLogID: 1, CheckedInAt: time.Now()}}
+		deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID}, nil)
+		deps.attendanceRepo.On("ListPresent", ctx, testLocationID).Return(present, nil)
+
+		result, err := svc.List(ctx, testStaff, testLocationID)
+
+		require.NoError(t, err)
+		assert.Equal(t, present, result)
+	})
+
+	t.Run("member is forbidden", func(t *testing.T) {
+		svc, deps := newTestPresenceService()
+
+		member := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+		result, err := svc.List(context.Background(), member, testLocationID)
+
+		require.ErrorIs(t, err, ErrForbidden)
+		assert.Nil(t, result)
+		deps.attendanceRepo.AssertNotCalled(t, "ListPresent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown location", func(t *testing.T) {
+		svc, deps := newTestPresenceService()
+
+		ctx := context.Background()
+		deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, 99).Return(nil, repository.ErrMasterDataNotFound)
+
+		_, err := svc.List(ctx, testStaff, 99)
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []string{"does not exist"}, validationErr.Fields["location_id"])
+	})
+}
+
+func TestPresenceService_Subscribe(t *testing.T) {
+	svc, deps := newTestPresenceService()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	joined := uuid.New()
+	noProfile := uuid.New()
+	deps.masterRepo.On("GetByID", ctx, model.MasterDataLocations, testLocationID).Return(&model.MasterDataItem{ID: testLocationID}, nil)
+	deps.profileRepo.On("GetByUserID", ctx, joined).Return(&model.Profile{UserID: joined, DisplayID: "taro", Name: "山田太郎"}, nil)
+	deps.profileRepo.On("GetByUserID", ctx, noProfile).Return(nil, repository.ErrProfileNotFound)
+
+	events, err := svc.Subscribe(ctx, testStaff, testLocationID)
+	require.NoError(t, err)
+
+	published := &model.PresenceEvent{Type: model.PresenceJoin, LocationID: testLocationID, UserID: joined, LogID: 1}
+	require.NoError(t, deps.bus.Publish(ctx, published))
+	require.NoError(t, deps.bus.Publish(ctx, &model.PresenceEvent{Type: model.PresenceLeave, LocationID: testLocationID, UserID: noProfile, LogID: 2}))
+
+	event := <-events
+	assert.Equal(t, "taro", event.DisplayID)
+	assert.Equal(t, "山田太郎", event.Name)
+	// 他の購読者に届く通知は書き換えない
+	assert.Empty(t, published.Name)
+	event = <-events
+	assert.Equal(t, noProfile, event.UserID)
+	assert.Empty(t, event.Name)
+
+	cancel()
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestPresenceService_Subscribe_Forbidden(t *testing.T) {
+	svc, _ := newTestPresenceService()
+
+	member := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	events, err := svc.Subscribe(context.Background(), member, testLocationID)
+
+	require.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, events)
+}
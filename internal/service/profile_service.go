@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	ErrUserNotFound    = errors.New("user not found")
+)
+
+// displayIDPattern は表示用 ID の形式。大文字は小文字に揃えてから検証する
+var displayIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{2,31}$`)
+
+type ProfileService interface {
+	ProfileOnboarder
+	// SaveProfile はプロフィールを作成または置き換える。作成した場合は true を返す
+	SaveProfile(ctx context.Context, caller *auth.Identity, userID uuid.UUID, req *model.ProfileRequest) (*model.Profile, bool, error)
+	ListProfiles(ctx context.Context, caller *auth.Identity, filter model.ProfileFilter) ([]*model.Profile, error)
+}
+
+type profileService struct {
+	profileRepo repository.ProfileRepository
+	userRepo    repository.UserRepository
+	masterRepo  repository.MasterDataRepository
+	tx          repository.Transactor
+}
+
+func NewProfileService(
+	profileRepo repository.ProfileRepository,
+	userRepo repository.UserRepository,
+	masterRepo repository.MasterDataRepository,
+	tx repository.Transactor,
+) ProfileService {
+	return &profileService{
+		profileRepo: profileRepo,
+		userRepo:    userRepo,
+		masterRepo:  masterRepo,
+		tx:          tx,
+	}
+}
+
+// CreateProfile はユーザー登録時にプロフィールを作成する。権限の確認は呼び出し元で行う
+func (s *profileService) CreateProfile(ctx context.Context, user *model.User, req *model.ProfileRequest) (*model.Profile, error) {
+	profile, err := buildProfile(user, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkReferences(ctx, profile, nil); err != nil {
+		return nil, err
+	}
+
+	if err := s.profileRepo.Create(ctx, profile); err != nil {
+		return nil, profileError(err)
+	}
+
+	return profile, nil
+}
+
+func (s *profileService) GetProfile(ctx context.Context, caller *auth.Identity, userID uuid.UUID) (*model.Profile, error) {
+	if !canRead(caller, userID) {
+		return nil, ErrForbidden
+	}
+
+	profile, err := s.profileRepo.GetByUserID(ctx, userID)
+	if errors.Is(err, repository.ErrProfileNotFound) {
+		return nil, ErrProfileNotFound
+	}
+
+	return profile, err
+}
+
+func (s *profileService) SaveProfile(ctx context.Context, caller *auth.Identity, userID uuid.UUID, req *model.ProfileRequest) (*model.Profile, bool, error) {
+	if !canWrite(caller, userID) {
+		return nil, false, ErrForbidden
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, false, ErrUserNotFound
+	}
+
+	profile, err := buildProfile(user, req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	created := false
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		existing, err := s.profileRepo.GetByUserID(ctx, userID)
+		if errors.Is(err, repository.ErrProfileNotFound) {
+			existing, err = nil, nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.checkReferences(ctx, profile, existing); err != nil {
+			return err
+		}
+
+		if existing == nil {
+			created = true
+			return s.profileRepo.Create(ctx, profile)
+		}
+
+		profile.CreatedAt = existing.CreatedAt
+		profile.NFCSerial = existing.NFCSerial
+		return s.profileRepo.Update(ctx, profile)
+	})
+	if err != nil {
+		return nil, false, profileError(err)
+	}
+
+	return profile, created, nil
+}
+
+func (s *profileService) ListProfiles(ctx context.Context, caller *auth.Identity, filter model.ProfileFilter) ([]*model.Profile, error) {
+	if !caller.HasRole(model.RoleStaff, model.RoleAdmin) {
+		return nil, ErrForbidden
+	}
+
+	return s.profileRepo.List(ctx, filter)
+}
+
+// checkReferences は新しく指定された学年と所属組織がアーカイブされていないことを確認する。
+// 既に設定済みの値はアーカイブ後もそのまま保存できる
+func (s *profileService) checkReferences(ctx context.Context, profile, existing *model.Profile) error {
+	var currentGrade, currentOrg *int
+	if existing != nil {
+		currentGrade, currentOrg = existing.GradeID, existing.OrgID
+	}
+
+	validationErr := &ValidationError{}
+	for _, ref := range []struct {
+		field   string
+		kind    model.MasterDataKind
+		id      *int
+		current *int
+	}{
+		{"grade_id", model.MasterDataGrades, profile.GradeID, currentGrade},
+		{"org_id", model.MasterDataOrganizations, profile.OrgID, currentOrg},
+	} {
+		if ref.id == nil || (ref.current != nil && *ref.current == *ref.id) {
+			continue
+		}
+
+		item, err := s.masterRepo.GetByID(ctx, ref.kind, *ref.id)
+		if errors.Is(err, repository.ErrMasterDataNotFound) {
+			validationErr.Add(ref.field, "does not exist")
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", ref.kind, err)
+		}
+		if item.Archived() {
+			validationErr.Add(ref.field, "is archived")
+		}
+	}
+
+	return validationErr.Err()
+}
+
+// buildProfile はリクエストを検証し、表示用 ID を正規化したプロフィールを組み立てる
+func buildProfile(user *model.User, req *model.ProfileRequest) (*model.Profile, error) {
+	displayID := strings.ToLower(strings.TrimSpace(req.DisplayID))
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = user.Name
+	}
+
+	validationErr := &ValidationError{}
+	switch {
+	case displayID == "":
+		validationErr.Add("display_id", "is required")
+	case !displayIDPattern.MatchString(displayID):
+		validationErr.Add("display_id", "must be 3 to 32 characters of letters, digits, '-' or '_' starting with a letter or digit")
+	}
+	if len(name) > 255 {
+		validationErr.Add("name", "must be at most 255 characters")
+	}
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &model.Profile{
+		UserID:    user.ID,
+		DisplayID: displayID,
+		Name:      name,
+		GradeID:   req.GradeID,
+		OrgID:     req.OrgID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// profileError は制約違反を項目ごとの検証エラーに変換する
+func profileError(err error) error {
+	validationErr := &ValidationError{}
+	switch {
+	case errors.As(err, &validationErr):
+		return err
+	case errors.Is(err, repository.ErrDisplayIDTaken):
+		validationErr.Add("display_id", "is already taken")
+	case errors.Is(err, repository.ErrGradeNotFound):
+		validationErr.Add("grade_id", "does not exist")
+	case errors.Is(err, repository.ErrOrganizationNotFound):
+		validationErr.Add("org_id", "does not exist")
+	default:
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	return validationErr
+}
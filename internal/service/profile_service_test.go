@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockProfileRepository struct {
+	mock.Mock
+}
+
+func (m *MockProfileRepository) Create(ctx context.Context, profile *model.Profile) error {
+	args := m.Called(ctx, profile)
+	return args.Error(0)
+}
+
+func (m *MockProfileRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.Profile, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Profile), args.Error(1)
+}
+
+func (m *MockProfileRepository) GetByDisplayID(ctx context.Context, displayID string) (*model.Profile, error) {
+	args := m.Called(ctx, displayID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Profile), args.Error(1)
+}
+
+func (m *MockProfileRepository) GetByNFCSerial(ctx context.Context, serial string) (*model.Profile, error) {
+	args := m.Called(ctx, serial)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Profile), args.Error(1)
+}
+
+func (m *MockProfileRepository) Update(ctx context.Context, profile *model.Profile) error {
+	args := m.Called(ctx, profile)
+	return args.Error(0)
+}
+
+func (m *MockProfileRepository) List(ctx context.Context, filter model.ProfileFilter) ([]*model.Profile, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Profile), args.Error(1)
+}
+
+type MockProfileOnboarder struct {
+	mock.Mock
+}
+
+func (m *MockProfileOnboarder) CreateProfile(ctx context.Context, user *model.User, req *model.ProfileRequest) (*model.Profile, error) {
+	args := m.Called(ctx, user, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Profile), args.Error(1)
+}
+
+func (m *MockProfileOnboarder) GetProfile(ctx context.Context, caller *auth.Identity, userID uuid.UUID) (*model.Profile, error) {
+	args := m.Called(ctx, caller, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Profile), args.Error(1)
+}
+
+type profileTestDeps struct {
+	profileRepo *MockProfileRepository
+	userRepo    *MockUserRepository
+	masterRepo  *MockMasterDataRepository
+}
+
+func newTestProfileService() (ProfileService, *profileTestDeps) {
+	deps := &profileTestDeps{
+		profileRepo: new(MockProfileRepository),
+		userRepo:    new(MockUserRepository),
+		masterRepo:  new(MockMasterDataRepository),
+	}
+	return NewProfileService(deps.profileRepo, deps.userRepo, deps.masterRepo, &fakeTransactor{}), deps
+}
+
+func TestProfileService_CreateProfile(t *testing.T) {
+	svc, deps := newTestProfileService()
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Name: "Test User"}
+	orgID := 2
+
+	deps.masterRepo.On("GetByID", ctx, model.MasterDataOrganizations, orgID).Return(&model.MasterDataItem{ID: orgID, Name: "情報部"}, nil)
+	deps.profileRepo.On("Create", ctx, mock.AnythingOfType("*model.Profile")).Return(nil)
+
+	profile, err := svc.CreateProfile(ctx, user, &model.ProfileRequest{DisplayID: "  Test_User ", OrgID: &orgID})
+
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, profile.UserID)
+	assert.Equal(t, "test_user", profile.DisplayID)
+	assert.Equal(t, "Test User", profile.Name)
+	assert.Nil(t, profile.GradeID)
+	assert.Equal(t, &orgID, profile.OrgID)
+	deps.profileRepo.AssertExpectations(t)
+}
+
+func TestProfileService_CreateProfile_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       *model.ProfileRequest
+		createErr error
+		field     string
+		message   string
+	}{
+		{
+			name:    "missing display id",
+			req:     &model.ProfileRequest{},
+			field:   "display_id",
+			message: "is required",
+		},
+		{
+			name:    "malformed display id",
+			req:     &model.ProfileRequest{DisplayID: "-x"},
+			field:   "display_id",
+			message: "must be 3 to 32 characters of letters, digits, '-' or '_' starting with a letter or digit",
+		},
+		{
+			name:      "display id taken",
+			req:       &model.ProfileRequest{DisplayID: "taken"},
+			createErr: repository.ErrDisplayIDTaken,
+			field:     "display_id",
+			message:   "is already taken",
+		},
+		{
+			name:      "unknown grade",
+			req:       &model.ProfileRequest{DisplayID: "test-user"},
+			createErr: repository.ErrGradeNotFound,
+			field:     "grade_id",
+			message:   "does not exist",
+		},
+		{
+			name:      "unknown organization",
+			req:       &model.ProfileRequest{DisplayID: "test-user"},
+			createErr: repository.ErrOrganizationNotFound,
+			field:     "org_id",
+			message:   "does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deps := newTestProfileService()
+
+			ctx := context.Background()
+			deps.profileRepo.On("Create", ctx, mock.AnythingOfType("*model.Profile")).Return(tt.createErr).Maybe()
+
+			profile, err := svc.CreateProfile(ctx, &model.User{ID: uuid.New(), Name: "Test User"}, tt.req)
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Nil(t, profile)
+			assert.Equal(t, []string{tt.message}, validationErr.Fields[tt.field])
+		})
+	}
+}
+
+func TestProfileService_GetProfile(t *testing.T) {
+	svc, deps := newTestProfileService()
+
+	ctx := context.Background()
+	caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	expected := &model.Profile{UserID: caller.UserID, DisplayID: "test-user"}
+	deps.profileRepo.On("GetByUserID", ctx, caller.UserID).Return(expected, nil)
+
+	profile, err := svc.GetProfile(ctx, caller, caller.UserID)
+
+	require.NoError(t, err)
+	assert.Equal(t, expected, profile)
+}
+
+func TestProfileService_GetProfile_NotFound(t *testing.T) {
+	svc, deps := newTestProfileService()
+
+	ctx := context.Background()
+	caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	deps.profileRepo.On("GetByUserID", ctx, caller.UserID).Return(nil, repository.ErrProfileNotFound)
+
+	profile, err := svc.GetProfile(ctx, caller, caller.UserID)
+
+	require.ErrorIs(t, err, ErrProfileNotFound)
+	assert.Nil(t, profile)
+}
+
+func TestProfileService_GetProfile_MemberCannotReadOthers(t *testing.T) {
+	svc, deps := newTestProfileService()
+
+	caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+
+	profile, err := svc.GetProfile(context.Background(), caller, uuid.New())
+
+	require.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, profile)
+	deps.profileRepo.AssertNotCalled(t, "GetByUserID", mock.Anything, mock.Anything)
+}
+
+func TestProfileService_SaveProfile_Creates(t *testing.T) {
+	svc, deps := newTestProfileService()
+
+	ctx := context.Background()
+	caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	user := &model.User{ID: caller.UserID, Name: "Test User"}
+
+	deps.userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	deps.profileRepo.On("GetByUserID", ctx, user.ID).Return(nil, repository.ErrProfileNotFound)
+	deps.profileRepo.On("Create", ctx, mock.AnythingOfType("*model.Profile")).Return(nil)
+
+	profile, created, err := svc.SaveProfile(ctx, caller, user.ID, &model.ProfileRequest{DisplayID: "test-user", Name: "Tester"})
+
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "Tester", profile.Name)
+	deps.profileRepo.AssertExpectations(t)
+}
+
+func TestProfileService_SaveProfile_Replaces(t *testing.T) {
+	svc, deps := newTestProfileService()
+
+	ctx := context.Background()
+	caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	user := &model.User{ID: uuid.New(), Name: "Test User"}
+	serial := "04a1b2c3"
+	gradeID := 1
+	existing := &model.Profile{UserID: user.ID, DisplayID: "old-id", Name: "Old", NFCSerial: &serial, GradeID: &gradeID}
+
+	var updated *model.Profile
+	deps.userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	deps.profileRepo.On("GetByUserID", ctx, user.ID).Return(existing, nil)
+	deps.profileRepo.On("Update", ctx, mock.AnythingOfType("*model.Profile")).
+		Run(func(args mock.Arguments) { updated = args.Get(1).(*model.Profile) }).
+		Return(nil)
+
+	profile, created, err := svc.SaveProfile(ctx, caller, user.ID, &model.ProfileRequest{DisplayID: "new-id"})
+
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, updated, profile)
+	assert.Equal(t, "new-id", profile.DisplayID)
+	assert.Equal(t, "Test User", profile.Name)
+	assert.Nil(t, profile.GradeID, "omitted grade should be cleared")
+	assert.Equal(t, &serial, profile.NFCSerial, "card binding is kept")
+}
+
+func TestProfileService_SaveProfile_ArchivedReferences(t *testing.T) {
+	archivedAt := time.Now()
+	gradeID, orgID := 1, 2
+
+	tests := []struct {
+		name     string
+		existing *model.Profile
+		wantErr  bool
+	}{
+		{name: "newly assigned", existing: nil, wantErr: true},
+		{name: "kept from before archival", existing: &model.Profile{DisplayID: "test-user", GradeID: &gradeID, OrgID: &orgID}, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, deps := newTestProfileService()
+
+			ctx := context.Background()
+			caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+			user := &model.User{ID: caller.UserID, Name: "Test User"}
+			if tt.existing != nil {
+				tt.existing.UserID = user.ID
+				deps.profileRepo.On("GetByUserID", ctx, user.ID).Return(tt.existing, nil)
+				deps.profileRepo.On("Update", ctx, mock.AnythingOfType("*model.Profile")).Return(nil)
+			} else {
+				deps.profileRepo.On("GetByUserID", ctx, user.ID).Return(nil, repository.ErrProfileNotFound)
+			}
+			deps.userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+			deps.masterRepo.On("GetByID", ctx, model.MasterDataGrades, gradeID).Return(&model.MasterDataItem{ID: gradeID, ArchivedAt: &archivedAt}, nil).Maybe()
+			deps.masterRepo.On("GetByID", ctx, model.MasterDataOrganizations, orgID).Return(nil, repository.ErrMasterDataNotFound).Maybe()
+
+			_, _, err := svc.SaveProfile(ctx, caller, user.ID, &model.ProfileRequest{DisplayID: "test-user", GradeID: &gradeID, OrgID: &orgID})
+
+			if !tt.wantErr {
+				require.NoError(t, err)
+				deps.masterRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, []string{"is archived"}, validationErr.Fields["grade_id"])
+			assert.Equal(t, []string{"does not exist"}, validationErr.Fields["org_id"])
+			deps.profileRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestProfileService_SaveProfile_Forbidden(t *testing.T) {
+	svc, deps := newTestProfileService()
+
+	caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}
+
+	profile, _, err := svc.SaveProfile(context.Background(), caller, uuid.New(), &model.ProfileRequest{DisplayID: "test-user"})
+
+	require.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, profile)
+	deps.userRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestProfileService_SaveProfile_UnknownUser(t *testing.T) {
+	svc, deps := newTestProfileService()
+
+	ctx := context.Background()
+	caller := &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}
+	id := uuid.New()
+	deps.userRepo.On("GetByID", ctx, id).Return(nil, fmt.Errorf("user not found"))
+
+	profile, _, err := svc.SaveProfile(ctx, caller, id, &model.ProfileRequest{DisplayID: "test-user"})
+
+	require.ErrorIs(t, err, ErrUserNotFound)
+	assert.Nil(t, profile)
+}
+
+func TestProfileService_ListProfiles(t *testing.T) {
+	svc, deps := newTestProfileService()
+
+	ctx := context.Background()
+	gradeID := 3
+	filter := model.ProfileFilter{GradeID: &gradeID, Limit: 10}
+	expected := []*model.Profile{{DisplayID: "a-user"}, {DisplayID: "b-user"}}
+	deps.profileRepo.On("List", ctx, filter).Return(expected, nil)
+
+	profiles, err := svc.ListProfiles(ctx, &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}, filter)
+
+	require.NoError(t, err)
+	assert.Equal(t, expected, profiles)
+}
+
+func TestProfileService_ListProfiles_Forbidden(t *testing.T) {
+	svc, deps := newTestProfileService()
+
+	profiles, err := svc.ListProfiles(context.Background(), &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}, model.ProfileFilter{})
+
+	require.ErrorIs(t, err, ErrForbidden)
+	assert.Nil(t, profiles)
+	deps.profileRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+)
+
+const reportDateLayout = "2006-01-02"
+
+type ReportService interface {
+	// Attendance は期間の入退室を集計の単位と期間ごとに集計し、単位の順・期間の順に一行ずつ emit に渡す。
+	// 入室と退室が揃ったセッションのみを集計し、日をまたぐ滞在は日ごとに分ける。
+	// 権限と条件は最初の行を渡す前に確認する。staff と admin のみ呼び出せる
+	Attendance(ctx context.Context, caller *auth.Identity, req *model.ReportRequest, emit func(*model.ReportRow) error) error
+}
+
+type ReportConfig struct {
+	// Location はタイムゾーンが指定されなかった場合に日付の区切りに使う
+	Location *time.Location
+}
+
+type reportService struct {
+	reportRepo repository.ReportRepository
+	masterRepo repository.MasterDataRepository
+	cfg        ReportConfig
+}
+
+func NewReportService(reportRepo repository.ReportRepository, masterRepo repository.MasterDataRepository, cfg ReportConfig) ReportService {
+	return &reportService{
+		reportRepo: reportRepo,
+		masterRepo: masterRepo,
+		cfg:        cfg,
+	}
+}
+
+func (s *reportService) Attendance(ctx context.Context, caller *auth.Identity, req *model.ReportRequest, emit func(*model.ReportRow) error) error {
+	if !caller.HasRole(model.RoleStaff, model.RoleAdmin) {
+		return ErrForbidden
+	}
+
+	groupBy, period, loc, from, to, err := s.validate(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	agg := &reportAggregator{
+		groupBy: groupBy,
+		period:  period,
+		loc:     loc,
+		from:    from,
+		to:      to,
+		emit:    emit,
+		buckets: make(map[time.Time]*reportBucket),
+	}
+	filter := model.ReportFilter{
+		GroupBy:    groupBy,
+		From:       from,
+		To:         to,
+		LocationID: req.LocationID,
+	}
+	if err := s.reportRepo.EachSession(ctx, filter, agg.add); err != nil {
+		return fmt.Errorf("failed to aggregate attendance: %w", err)
+	}
+
+	return agg.flushBefore(to)
+}
+
+// validate は集計条件を確認し、省略された単位・期間・タイムゾーンを補う。期間は from の日の始まりから to の翌日の始まりまで
+func (s *reportService) validate(ctx context.Context, req *model.ReportRequest) (model.ReportGroupBy, model.ReportPeriod, *time.Location, time.Time, time.Time, error) {
+	validationErr := &ValidationError{}
+
+	groupBy := req.GroupBy
+	switch groupBy {
+	case "":
+		groupBy = model.ReportByUser
+	case model.ReportByUser, model.ReportByGrade, model.ReportByOrganization:
+	default:
+		validationErr.Add("group_by", "must be one of user, grade, organization")
+	}
+
+	period := req.Period
+	switch period {
+	case "":
+		period = model.ReportDaily
+	case model.ReportDaily, model.ReportWeekly, model.ReportMonthly:
+	default:
+		validationErr.Add("period", "must be one of day, week, month")
+	}
+
+	loc := s.cfg.Location
+	if req.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(req.Timezone); err != nil {
+			validationErr.Add("tz", "is not a valid time zone")
+			loc = s.cfg.Location
+		}
+	}
+
+	var from, to time.Time
+	for _, param := range []struct {
+		name  string
+		value string
+		dest  *time.Time
+	}{
+		{"from", req.From, &from},
+		{"to", req.To, &to},
+	} {
+		if param.value == "" {
+			validationErr.Add(param.name, "is required")
+			continue
+		}
+		date, err := time.ParseInLocation(reportDateLayout, param.value, loc)
+		if err != nil {
+			validationErr.Add(param.name, "must be a date (YYYY-MM-DD)")
+			continue
+		}
+		*param.dest = date
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		validationErr.Add("to", "must not be before from")
+	}
+
+	if req.LocationID != nil {
+		_, err := s.masterRepo.GetByID(ctx, model.MasterDataLocations, *req.LocationID)
+		switch {
+		case errors.Is(err, repository.ErrMasterDataNotFound):
+			validationErr.Add("location_id", "does not exist")
+		case err != nil:
+			return "", "", nil, time.Time{}, time.Time{}, fmt.Errorf("failed to load location: %w", err)
+		}
+	}
+
+	if err := validationErr.Err(); err != nil {
+		return "", "", nil, time.Time{}, time.Time{}, err
+	}
+
+	return groupBy, period, loc, from, addDays(to, 1), nil
+}
+
+// addDays は t の日付から days 日後の 0 時を返す
+func addDays(t time.Time, days int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+days, 0, 0, 0, 0, t.Location())
+}
+
+// reportBucket は一つの単位の一つの期間の集計
+type reportBucket struct {
+	start, end time.Time
+	total      time.Duration
+	users      map[uuid.UUID]bool
+	days       map[reportUserDay]bool
+	firstIn    *time.Time
+	lastOut    *time.Time
+}
+
+type reportUserDay struct {
+	userID uuid.UUID
+	date   string
+}
+
+// reportAggregator は単位ごとに入室の古い順に届くセッションを期間ごとに集計する。
+// 以降のセッションはそれより前の期間に含まれないため、終わった期間から順に出力して保持しない
+type reportAggregator struct {
+	groupBy  model.ReportGroupBy
+	period   model.ReportPeriod
+	loc      *time.Location
+	from, to time.Time
+	emit     func(*model.ReportRow) error
+
+	current *model.ReportSession
+	buckets map[time.Time]*reportBucket
+}
+
+func (a *reportAggregator) add(session *model.ReportSession) error {
+	if a.current != nil && a.current.GroupID != session.GroupID {
+		if err := a.flushBefore(a.to); err != nil {
+			return err
+		}
+	}
+	a.current = session
+
+	start := session.CheckedInAt.In(a.loc)
+	if start.Before(a.from) {
+		start = a.from
+	}
+	end := session.CheckedOutAt.In(a.loc)
+	if end.After(a.to) {
+		end = a.to
+	}
+	if !start.Before(end) {
+		return nil
+	}
+	if err := a.flushBefore(start); err != nil {
+		return err
+	}
+
+	for cur := start; cur.Before(end); {
+		next := addDays(cur, 1)
+		if next.After(end) {
+			next = end
+		}
+		bucket := a.bucket(cur)
+		bucket.total += next.Sub(cur)
+		bucket.users[session.UserID] = true
+		bucket.days[reportUserDay{userID: session.UserID, date: cur.Format(reportDateLayout)}] = true
+		cur = next
+	}
+
+	if in := session.CheckedInAt.In(a.loc); !in.Before(a.from) {
+		if bucket := a.bucket(in); bucket.firstIn == nil || in.Before(*bucket.firstIn) {
+			bucket.firstIn = &in
+		}
+	}
+	if out := session.CheckedOutAt.In(a.loc); !out.After(a.to) {
+		// 0 時ちょうどの退室は前の日の退室として扱う
+		if bucket := a.bucket(out.Add(-time.Nanosecond)); bucket.lastOut == nil || out.After(*bucket.lastOut) {
+			bucket.lastOut = &out
+		}
+	}
+
+	return nil
+}
+
+// bucket は t を含む期間の集計を返す
+func (a *reportAggregator) bucket(t time.Time) *reportBucket {
+	var start, end time.Time
+	switch a.period {
+	case model.ReportWeekly:
+		start = addDays(t, -(int(t.Weekday())+6)%7)
+		end = addDays(start, 7)
+	case model.ReportMonthly:
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, a.loc)
+		end = start.AddDate(0, 1, 0)
+	default:
+		start = addDays(t, 0)
+		end = addDays(t, 1)
+	}
+
+	if bucket, ok := a.buckets[start]; ok {
+		return bucket
+	}
+	bucket := &reportBucket{
+		start: start,
+		end:   end,
+		users: make(map[uuid.UUID]bool),
+		days:  make(map[reportUserDay]bool),
+	}
+	a.buckets[start] = bucket
+	return bucket
+}
+
+// flushBefore は t までに終わる期間の集計を期間の順に出力する
+func (a *reportAggregator) flushBefore(t time.Time) error {
+	var done []*reportBucket
+	for _, bucket := range a.buckets {
+		if !bucket.end.After(t) || !t.Before(a.to) {
+			done = append(done, bucket)
+		}
+	}
+	sort.Slice(done, func(i, j int) bool {
+		return done[i].start.Before(done[j].start)
+	})
+
+	for _, bucket := range done {
+		delete(a.buckets, bucket.start)
+		if err := a.emit(a.row(bucket)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// row は集計を行にする。期間は集計期間の内側に切り詰める
+func (a *reportAggregator) row(bucket *reportBucket) *model.ReportRow {
+	start, end := bucket.start, bucket.end
+	if start.Before(a.from) {
+		start = a.from
+	}
+	if end.After(a.to) {
+		end = a.to
+	}
+
+	row := &model.ReportRow{
+		GroupID:      a.current.GroupID,
+		GroupName:    a.current.GroupName,
+		PeriodStart:  start.Format(reportDateLayout),
+		PeriodEnd:    addDays(end, -1).Format(reportDateLayout),
+		Users:        len(bucket.users),
+		DaysPresent:  len(bucket.days),
+		TotalSeconds: int64(bucket.total / time.Second),
+		FirstIn:      bucket.firstIn,
+		LastOut:      bucket.lastOut,
+	}
+	if a.groupBy == model.ReportByUser {
+		row.DisplayID = a.current.DisplayID
+	}
+	return row
+}
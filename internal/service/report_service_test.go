@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockReportRepository struct {
+	mock.Mock
+}
+
+// EachSession は Return に渡したセッションを順に fn に渡す
+func (m *MockReportRepository) EachSession(ctx context.Context, filter model.ReportFilter, fn func(*model.ReportSession) error) error {
+	args := m.Called(ctx, filter)
+	if sessions, ok := args.Get(0).([]*model.ReportSession); ok {
+		for _, session := range sessions {
+			if err := fn(session); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func newTestReportService() (ReportService, *MockReportRepository, *MockMasterDataRepository) {
+	reportRepo := new(MockReportRepository)
+	masterRepo := new(MockMasterDataRepository)
+	return NewReportService(reportRepo, masterRepo, ReportConfig{Location: testJST}), reportRepo, masterRepo
+}
+
+func jst(day, hour, minute int) time.Time {
+	return time.Date(2026, 10, day, hour, minute, 0, 0, testJST)
+}
+
+// collectReport は集計の行を全て集める
+func collectReport(t *testing.T, svc ReportService, req *model.ReportRequest) []*model.ReportRow {
+	t.Helper()
+	var rows []*model.ReportRow
+	err := svc.Attendance(context.Background(), testStaff, req, func(row *model.ReportRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	require.NoError(t, err)
+	return rows
+}
+
+func TestReportService_Attendance_Daily(t *testing.T) {
+	svc, reportRepo, _ := newTestReportService()
+
+	taro := uuid.New()
+	hanako := uuid.New()
+	reportRepo.On("EachSession", mock.Anything, mock.MatchedBy(func(filter model.ReportFilter) bool {
+		// 日付は集計のタイムゾーンで区切り、翌日の始まりまでを対象にする
+		return filter.GroupBy == model.ReportByUser && filter.From.Equal(jst(1, 0, 0)) && filter.To.Equal(jst(3, 0, 0))
+	})).Return([]*model.ReportSession{
+		// 期間より前に入室したセッションは期間の始まりから数える
+		{GroupID: taro.String(), GroupName: "山田太郎", DisplayID: "taro", UserID: taro, CheckedInAt: jst(0, 20, 0), CheckedOutAt: jst(1, 1, 0)},
+		{GroupID: taro.String(), GroupName: "山田太郎", DisplayID: "taro", UserID: taro, CheckedInAt: jst(1, 9, 0), CheckedOutAt: jst(1, 12, 0)},
+		{GroupID: taro.String(), GroupName: "山田太郎", DisplayID: "taro", UserID: taro, CheckedInAt: jst(1, 22, 0), CheckedOutAt: jst(2, 2, 30)},
+		{GroupID: hanako.String(), GroupName: "鈴木花子", DisplayID: "hanako", UserID: hanako, CheckedInAt: jst(2, 10, 0), CheckedOutAt: jst(2, 11, 0)},
+	}, nil)
+
+	rows := collectReport(t, svc, &model.ReportRequest{From: "2026-10-01", To: "2026-10-02"})
+
+	require.Len(t, rows, 3)
+	first := rows[0]
+	assert.Equal(t, taro.String(), first.GroupID)
+	assert.Equal(t, "taro", first.DisplayID)
+	assert.Equal(t, "2026-10-01", first.PeriodStart)
+	assert.Equal(t, "2026-10-01", first.PeriodEnd)
+	assert.Equal(t, int64((1+3+2)*60*60), first.TotalSeconds)
+	assert.Equal(t, 1, first.DaysPresent)
+	assert.True(t, jst(1, 9, 0).Equal(*first.FirstIn))
+	assert.True(t, jst(1, 12, 0).Equal(*first.LastOut))
+
+	// 日をまたいだ滞在は翌日に残りを数える
+	second := rows[1]
+	assert.Equal(t, "2026-10-02", second.PeriodStart)
+	assert.Equal(t, int64(150*60), second.TotalSeconds)
+	assert.Nil(t, second.FirstIn)
+	assert.True(t, jst(2, 2, 30).Equal(*second.LastOut))
+
+	assert.Equal(t, hanako.String(), rows[2].GroupID)
+	assert.Equal(t, 1, rows[2].Users)
+}
+
+func TestReportService_Attendance_WeeklyByGrade(t *testing.T) {
+	svc, reportRepo, _ := newTestReportService()
+
+	taro := uuid.New()
+	hanako := uuid.New()
+	reportRepo.On("EachSession", mock.Anything, mock.Anything).Return([]*model.ReportSession{
+		{GroupID: "1", GroupName: "B1", UserID: taro, CheckedInAt: jst(14, 10, 0), CheckedOutAt: jst(14, 12, 0)},
+		{GroupID: "1", GroupName: "B1", UserID: hanako, CheckedInAt: jst(14, 13, 0), CheckedOutAt: jst(14, 14, 0)},
+		{GroupID: "1", GroupName: "B1", UserID: taro, CheckedInAt: jst(16, 10, 0), CheckedOutAt: jst(16, 11, 0)},
+		{GroupID: "1", GroupName: "B1", UserID: taro, CheckedInAt: jst(19, 10, 0), CheckedOutAt: jst(19, 11, 0)},
+		{GroupID: "", GroupName: "", UserID: uuid.New(), CheckedInAt: jst(15, 10, 0), CheckedOutAt: jst(15, 11, 0)},
+	}, nil)
+
+	rows := collectReport(t, svc, &model.ReportRequest{
+		GroupBy: model.ReportByGrade,
+		Period:  model.ReportWeekly,
+		From:    "2026-10-14",
+		To:      "2026-10-20",
+	})
+
+	require.Len(t, rows, 3)
+	// 週は月曜日から始め、集計期間の内側に切り詰める
+	assert.Equal(t, "2026-10-14", rows[0].PeriodStart)
+	assert.Equal(t, "2026-10-18", rows[0].PeriodEnd)
+	assert.Equal(t, 2, rows[0].Users)
+	assert.Equal(t, 3, rows[0].DaysPresent)
+	assert.Equal(t, int64(4*60*60), rows[0].TotalSeconds)
+	assert.Empty(t, rows[0].DisplayID)
+	assert.Equal(t, "2026-10-19", rows[1].PeriodStart)
+	assert.Equal(t, "2026-10-20", rows[1].PeriodEnd)
+	assert.Equal(t, 1, rows[1].Users)
+	// 学年が設定されていないユーザー
+	assert.Equal(t, "", rows[2].GroupID)
+}
+
+func TestReportService_Attendance_Monthly(t *testing.T) {
+	svc, reportRepo, _ := newTestReportService()
+
+	taro := uuid.New()
+	reportRepo.On("EachSession", mock.Anything, mock.Anything).Return([]*model.ReportSession{
+		{GroupID: "2", GroupName: "情報部", UserID: taro, CheckedInAt: jst(30, 10, 0), CheckedOutAt: jst(30, 12, 0)},
+		// 0 時ちょうどの退室は前の日の退室とする
+		{GroupID: "2", GroupName: "情報部", UserID: taro, CheckedInAt: jst(31, 22, 0), CheckedOutAt: time.Date(2026, 11, 1, 0, 0, 0, 0, testJST)},
+	}, nil)
+
+	rows := collectReport(t, svc, &model.ReportRequest{
+		GroupBy: model.ReportByOrganization,
+		Period:  model.ReportMonthly,
+		From:    "2026-10-01",
+		To:      "2026-11-30",
+	})
+
+	require.Len(t, rows, 1)
+	assert.Equal(t, "2026-10-01", rows[0].PeriodStart)
+	assert.Equal(t, "2026-10-31", rows[0].PeriodEnd)
+	assert.Equal(t, 2, rows[0].DaysPresent)
+	assert.Equal(t, int64(4*60*60), rows[0].TotalSeconds)
+	assert.True(t, time.Date(2026, 11, 1, 0, 0, 0, 0, testJST).Equal(*rows[0].LastOut))
+}
+
+func TestReportService_Attendance_Timezone(t *testing.T) {
+	svc, reportRepo, _ := newTestReportService()
+
+	taro := uuid.New()
+	reportRepo.On("EachSession", mock.Anything, mock.MatchedBy(func(filter model.ReportFilter) bool {
+		return filter.From.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	})).Return([]*model.ReportSession{
+		// 日本時間では 10月2日 だが、UTC では 10月1日
+		{GroupID: taro.String(), UserID: taro, CheckedInAt: jst(2, 7, 0), CheckedOutAt: jst(2, 8, 0)},
+	}, nil)
+
+	rows := collectReport(t, svc, &model.ReportRequest{From: "2026-10-01", To: "2026-10-01", Timezone: "UTC"})
+
+	require.Len(t, rows, 1)
+	assert.Equal(t, "2026-10-01", rows[0].PeriodStart)
+	assert.Equal(t, time.UTC, rows[0].FirstIn.Location())
+}
+
+func TestReportService_Attendance_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    *model.ReportRequest
+		fields map[string][]string
+	}{
+		{
+			name:   "missing range",
+			req:    &model.ReportRequest{},
+			fields: map[string][]string{"from": {"is required"}, "to": {"is required"}},
+		},
+		{
+			name: "unknown options",
+			req:  &model.ReportRequest{GroupBy: "location", Period: "year", Timezone: "Mars/Olympus", From: "2026-10-01", To: "2026/10/31"},
+			fields: map[string][]string{
+				"group_by": {"must be one of user, grade, organization"},
+				"period":   {"must be one of day, week, month"},
+				"tz":       {"is not a valid time zone"},
+				"to":       {"must be a date (YYYY-MM-DD)"},
+			},
+		},
+		{
+			name:   "reversed range",
+			req:    &model.ReportRequest{From: "2026-10-31", To: "2026-10-01"},
+			fields: map[string][]string{"to": {"must not be before from"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, reportRepo, _ := newTestReportService()
+
+			err := svc.Attendance(context.Background(), testStaff, tt.req, func(*model.ReportRow) error { return nil })
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.fields, validationErr.Fields)
+			reportRepo.AssertNotCalled(t, "EachSession", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestReportService_Attendance_UnknownLocation(t *testing.T) {
+	svc, _, masterRepo := newTestReportService()
+
+	locationID := 99
+	masterRepo.On("GetByID", mock.Anything, model.MasterDataLocations, locationID).Return(nil, repository.ErrMasterDataNotFound)
+
+	err := svc.Attendance(context.Background(), testStaff, &model.ReportRequest{From: "2026-10-01", To: "2026-10-01", LocationID: &locationID}, nil)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{"does not exist"}, validationErr.Fields["location_id"])
+}
+
+func TestReportService_Attendance_Forbidden(t *testing.T) {
+	svc, reportRepo, _ := newTestReportService()
+
+	member := &auth.Identity{UserID: uuid.New(), Role: model.RoleMember}
+	err := svc.Attendance(context.Background(), member, &model.ReportRequest{From: "2026-10-01", To: "2026-10-01"}, nil)
+
+	require.ErrorIs(t, err, ErrForbidden)
+	reportRepo.AssertNotCalled(t, "EachSession", mock.Anything, mock.Anything)
+}
+
+func TestReportService_Attendance_EmitError(t *testing.T) {
+	svc, reportRepo, _ := newTestReportService()
+
+	reportRepo.On("EachSession", mock.Anything, mock.Anything).Return([]*model.ReportSession{
+		{GroupID: "a", UserID: uuid.New(), CheckedInAt: jst(1, 9, 0), CheckedOutAt: jst(1, 10, 0)},
+		{GroupID: "b", UserID: uuid.New(), CheckedInAt: jst(1, 9, 0), CheckedOutAt: jst(1, 10, 0)},
+		{GroupID: "c", UserID: uuid.New(), CheckedInAt: jst(1, 9, 0), CheckedOutAt: jst(1, 10, 0)},
+	}, nil)
+
+	// クライアントが切断した場合などは残りを集計しない
+	disconnected := errors.New("client disconnected")
+	emitted := 0
+	err := svc.Attendance(context.Background(), testStaff, &model.ReportRequest{From: "2026-10-01", To: "2026-10-01"}, func(*model.ReportRow) error {
+		emitted++
+		return disconnected
+	})
+
+	require.ErrorIs(t, err, disconnected)
+	assert.Equal(t, 1, emitted)
+}
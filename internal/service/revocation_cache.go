@@ -0,0 +1,165 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// revocationCacheMaxEntries はトークンとユーザーそれぞれのキャッシュの上限。超えた場合は古いものから捨てる
+const revocationCacheMaxEntries = 10000
+
+type tokenCacheEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+type userCacheEntry struct {
+	revokedAt *time.Time
+	expiresAt time.Time
+}
+
+// boundedCache は追加した順を保持し、上限に達すると最も古いものから捨てる
+type boundedCache[K comparable, V any] struct {
+	max     int
+	entries map[K]*list.Element
+	order   *list.List
+}
+
+type boundedCacheItem[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func newBoundedCache[K comparable, V any](max int) *boundedCache[K, V] {
+	return &boundedCache[K, V]{
+		max:     max,
+		entries: make(map[K]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *boundedCache[K, V]) get(key K) (V, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return elem.Value.(*boundedCacheItem[K, V]).value, true
+}
+
+// set は既存の値を置き換える場合も最も新しいものとして扱う
+func (c *boundedCache[K, V]) set(key K, value V) {
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*boundedCacheItem[K, V]).value = value
+		c.order.MoveToBack(elem)
+		return
+	}
+	for c.order.Len() >= c.max {
+		c.delete(c.order.Front().Value.(*boundedCacheItem[K, V]).key)
+	}
+	c.entries[key] = c.order.PushBack(&boundedCacheItem[K, V]{key: key, value: value})
+}
+
+func (c *boundedCache[K, V]) delete(key K) {
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+func (c *boundedCache[K, V]) len() int {
+	return c.order.Len()
+}
+
+// deleteIf は expired が true を返したものを捨てる
+func (c *boundedCache[K, V]) deleteIf(expired func(V) bool) {
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if item := elem.Value.(*boundedCacheItem[K, V]); expired(item.value) {
+			c.order.Remove(elem)
+			delete(c.entries, item.key)
+		}
+		elem = next
+	}
+}
+
+// revocationCache は失効リストの問い合わせ結果をプロセス内に保持する。
+// このプロセスで行った失効は即座に反映され、他のレプリカで行った失効は ttl 以内に反映される
+type revocationCache struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	tokens *boundedCache[string, tokenCacheEntry]
+	users  *boundedCache[uuid.UUID, userCacheEntry]
+}
+
+func newRevocationCache(ttl time.Duration) *revocationCache {
+	return &revocationCache{
+		ttl:    ttl,
+		tokens: newBoundedCache[string, tokenCacheEntry](revocationCacheMaxEntries),
+		users:  newBoundedCache[uuid.UUID, userCacheEntry](revocationCacheMaxEntries),
+	}
+}
+
+func (c *revocationCache) token(jti string) (revoked bool, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.tokens.get(jti)
+	if !ok || time.Now().After(entry.expiresAt) {
+		return false, false
+	}
+	return entry.revoked, true
+}
+
+// setToken は失効済みのトークンを元の有効期限まで、未失効のトークンを ttl の間キャッシュする
+func (c *revocationCache) setToken(jti string, revoked bool, tokenExpiresAt time.Time) {
+	expiresAt := tokenExpiresAt
+	if !revoked {
+		if ttlExpiry := time.Now().Add(c.ttl); ttlExpiry.Before(expiresAt) {
+			expiresAt = ttlExpiry
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.tokens.len() >= revocationCacheMaxEntries {
+		c.sweepLocked()
+	}
+	c.tokens.set(jti, tokenCacheEntry{revoked: revoked, expiresAt: expiresAt})
+}
+
+func (c *revocationCache) user(userID uuid.UUID) (revokedAt *time.Time, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.users.get(userID)
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.revokedAt, true
+}
+
+func (c *revocationCache) setUser(userID uuid.UUID, revokedAt *time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.users.len() >= revocationCacheMaxEntries {
+		c.sweepLocked()
+	}
+	c.users.set(userID, userCacheEntry{revokedAt: revokedAt, expiresAt: time.Now().Add(c.ttl)})
+}
+
+// sweepLocked は期限切れのものを捨てる。それでも上限に達している場合は set が古いものから捨てる
+func (c *revocationCache) sweepLocked() {
+	now := time.Now()
+	c.tokens.deleteIf(func(entry tokenCacheEntry) bool {
+		return now.After(entry.expiresAt)
+	})
+	c.users.deleteIf(func(entry userCacheEntry) bool {
+		return now.After(entry.expiresAt)
+	})
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevocationCache_EvictsOldestAtCapacity(t *testing.T) {
+	cache := newRevocationCache(time.Hour)
+	expiresAt := time.Now().Add(time.Hour)
+
+	// 期限切れのものがなくても上限を超えて増えない
+	for i := 0; i <= revocationCacheMaxEntries; i++ {
+		cache.setToken(fmt.Sprintf("jti-%d", i), true, expiresAt)
+	}
+
+	assert.Equal(t, revocationCacheMaxEntries, cache.tokens.len())
+	_, found := cache.token("jti-0")
+	assert.False(t, found, "the oldest entry should be evicted")
+	revoked, found := cache.token(fmt.Sprintf("jti-%d", revocationCacheMaxEntries))
+	assert.True(t, found)
+	assert.True(t, revoked)
+}
+
+func TestRevocationCache_SetRefreshesAge(t *testing.T) {
+	cache := newRevocationCache(time.Hour)
+	expiresAt := time.Now().Add(time.Hour)
+
+	for i := 0; i < revocationCacheMaxEntries; i++ {
+		cache.setToken(fmt.Sprintf("jti-%d", i), false, expiresAt)
+	}
+	cache.setToken("jti-0", true, expiresAt)
+	cache.setToken("jti-new", false, expiresAt)
+
+	revoked, found := cache.token("jti-0")
+	assert.True(t, found, "an updated entry becomes the newest")
+	assert.True(t, revoked)
+	_, found = cache.token("jti-1")
+	assert.False(t, found)
+}
@@ -2,122 +2,478 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
 	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/password"
 	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
 	CreateUser(ctx context.Context, req *model.CreateUserRequest) (*model.User, error)
-	GetUser(ctx context.Context, id uuid.UUID) (*model.User, error)
-	UpdateUser(ctx context.Context, id uuid.UUID, req *model.UpdateUserRequest) (*model.User, error)
-	DeleteUser(ctx context.Context, id uuid.UUID) error
-	ListUsers(ctx context.Context, limit, offset int) ([]*model.User, error)
-	Login(ctx context.Context, req *model.LoginRequest) (*model.LoginResponse, error)
+	GetUser(ctx context.Context, caller *auth.Identity, id uuid.UUID) (*model.User, error)
+	GetCurrentUser(ctx context.Context, caller *auth.Identity) (*model.CurrentUser, error)
+	UpdateCurrentUser(ctx context.Context, caller *auth.Identity, req *model.UpdateCurrentUserRequest) (*model.CurrentUser, error)
+	UpdateUser(ctx context.Context, caller *auth.Identity, id uuid.UUID, req *model.UpdateUserRequest) (*model.User, error)
+	UpdateUserRole(ctx context.Context, caller *auth.Identity, id uuid.UUID, role model.Role) (*model.User, error)
+	ChangePassword(ctx context.Context, caller *auth.Identity, req *model.ChangePasswordRequest) error
+	DeleteUser(ctx context.Context, caller *auth.Identity, id uuid.UUID) error
+	ListUsers(ctx context.Context, caller *auth.Identity, limit, offset int) ([]*model.User, error)
+	ProvisionExternalUser(ctx context.Context, identity *model.ExternalIdentity) (*model.User, error)
+	GetExternalUser(ctx context.Context, subject string) (*model.User, error)
+}
+
+var (
+	ErrForbidden                 = errors.New("forbidden")
+	ErrInvalidRole               = errors.New("invalid role")
+	ErrPasswordManagedExternally = errors.New("password is managed by the external identity provider")
+	ErrExternalEmailUnverified   = errors.New("external identity has no verified email")
+	ErrExternalIdentityConflict  = errors.New("email is already linked to another external identity")
+)
+
+type SessionRevoker interface {
+	RevokeAllSessions(ctx context.Context, userID uuid.UUID) error
+}
+
+// EmailVerifier はメールアドレスの所有確認を行う。実装は EmailVerificationService
+type EmailVerifier interface {
+	SendVerification(ctx context.Context, user *model.User) error
+	RequestEmailChange(ctx context.Context, user *model.User, email string) error
+}
+
+// ProfileOnboarder はユーザー登録と同時にプロフィールを作成し、本人の情報に含めるプロフィールを返す。実装は ProfileService
+type ProfileOnboarder interface {
+	CreateProfile(ctx context.Context, user *model.User, req *model.ProfileRequest) (*model.Profile, error)
+	GetProfile(ctx context.Context, caller *auth.Identity, userID uuid.UUID) (*model.Profile, error)
 }
 
 type userService struct {
-	repo      repository.UserRepository
-	jwtSecret string
-	jwtExpiry int
+	repo     repository.UserRepository
+	mfaRepo  repository.MFARepository
+	sessions SessionRevoker
+	verifier EmailVerifier
+	profiles ProfileOnboarder
+	tx       repository.Transactor
+	policy   password.Policy
 }
 
-func NewUserService(repo repository.UserRepository, jwtSecret string, jwtExpiry int) UserService {
+func NewUserService(
+	repo repository.UserRepository,
+	mfaRepo repository.MFARepository,
+	sessions SessionRevoker,
+	verifier EmailVerifier,
+	profiles ProfileOnboarder,
+	tx repository.Transactor,
+	policy password.Policy,
+) UserService {
 	return &userService{
-		repo:      repo,
-		jwtSecret: jwtSecret,
-		jwtExpiry: jwtExpiry,
+		repo:     repo,
+		mfaRepo:  mfaRepo,
+		sessions: sessions,
+		verifier: verifier,
+		profiles: profiles,
+		tx:       tx,
+		policy:   policy,
 	}
 }
 
 func (s *userService) CreateUser(ctx context.Context, req *model.CreateUserRequest) (*model.User, error) {
+	validationErr := &ValidationError{}
+	if req.Email == "" {
+		validationErr.Add("email", "is required")
+	}
+	if req.Name == "" {
+		validationErr.Add("name", "is required")
+	}
+	validationErr.Add("password", s.policy.Validate(req.Password)...)
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
 	user := &model.User{
-		ID:        uuid.New(),
-		Email:     req.Email,
-		Name:      req.Name,
-		Password:  string(hashedPassword),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:         uuid.New(),
+		Email:      req.Email,
+		Name:       req.Name,
+		Password:   string(hashedPassword),
+		AuthSource: model.AuthSourceLocal,
+		Role:       model.RoleMember,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, user); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		if req.Profile == nil {
+			return nil
+		}
+
+		_, err := s.profiles.CreateProfile(ctx, user, req.Profile)
+		return nestValidationError("profile", err)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.Create(ctx, user); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	// 登録自体は完了しているため、確認メールを送れなくても失敗にしない。再送は本人が行える
+	if err := s.verifier.SendVerification(ctx, user); err != nil {
+		log.Printf("Failed to send verification mail to user %s: %v", user.ID, err)
 	}
 
 	return user, nil
 }
 
-func (s *userService) GetUser(ctx context.Context, id uuid.UUID) (*model.User, error) {
+// canRead は member が自分自身のみ、staff と admin が全ユーザーを参照できることを表す
+func canRead(caller *auth.Identity, id uuid.UUID) bool {
+	return caller.UserID == id || caller.HasRole(model.RoleStaff, model.RoleAdmin)
+}
+
+// canWrite は member と staff が自分自身のみ、admin が全ユーザーを更新できることを表す
+func canWrite(caller *auth.Identity, id uuid.UUID) bool {
+	return caller.UserID == id || caller.IsAdmin()
+}
+
+func (s *userService) GetUser(ctx context.Context, caller *auth.Identity, id uuid.UUID) (*model.User, error) {
+	if !canRead(caller, id) {
+		return nil, ErrForbidden
+	}
+
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *userService) UpdateUser(ctx context.Context, id uuid.UUID, req *model.UpdateUserRequest) (*model.User, error) {
+// UpdateUser は名前とメールアドレスの変更を一つのトランザクションで行う。
+// 外部 IdP のユーザーの名前とメールアドレスはログイン時に同期されるため変更できない
+func (s *userService) UpdateUser(ctx context.Context, caller *auth.Identity, id uuid.UUID, req *model.UpdateUserRequest) (*model.User, error) {
+	if !canWrite(caller, id) {
+		return nil, ErrForbidden
+	}
+
+	user, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	validationErr := &ValidationError{}
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		validationErr.Add("name", "must not be empty")
+	}
+	if req.Email != nil && strings.TrimSpace(*req.Email) == "" {
+		validationErr.Add("email", "must not be empty")
+	}
+	if user.AuthSource == model.AuthSourceZitadel {
+		if req.Name != nil {
+			validationErr.Add("name", "is managed by the external identity provider")
+		}
+		if req.Email != nil {
+			validationErr.Add("email", "is managed by the external identity provider")
+		}
+	}
+	if err := validationErr.Err(); err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if req.Name != nil {
+			user.Name = *req.Name
+			user.UpdatedAt = time.Now()
+
+			if err := s.repo.Update(ctx, user); err != nil {
+				return fmt.Errorf("failed to update user: %w", err)
+			}
+		}
+
+		// メールアドレスは確認が済むまで変更せず、確認待ちとして保存する
+		if req.Email == nil {
+			return nil
+		}
+		switch {
+		case *req.Email != user.Email:
+			return s.verifier.RequestEmailChange(ctx, user, *req.Email)
+		case user.PendingEmail != nil:
+			// 現在のアドレスを指定した場合は変更を取り消す
+			if err := s.repo.SetPendingEmail(ctx, user.ID, nil); err != nil {
+				return fmt.Errorf("failed to cancel email change: %w", err)
+			}
+			user.PendingEmail = nil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetCurrentUser はログイン中のユーザー本人の情報を返す
+func (s *userService) GetCurrentUser(ctx context.Context, caller *auth.Identity) (*model.CurrentUser, error) {
+	user, err := s.GetUser(ctx, caller, caller.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.currentUser(ctx, caller, user)
+}
+
+// UpdateCurrentUser は本人が変更できる項目だけを UpdateUser と同じ検証で更新する
+func (s *userService) UpdateCurrentUser(ctx context.Context, caller *auth.Identity, req *model.UpdateCurrentUserRequest) (*model.CurrentUser, error) {
+	updated, err := s.UpdateUser(ctx, caller, caller.UserID, &model.UpdateUserRequest{
+		Name:  req.Name,
+		Email: req.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.currentUser(ctx, caller, updated)
+}
+
+// currentUser は二段階認証の状態とプロフィールを加える。プロフィールが未作成であれば含めない
+func (s *userService) currentUser(ctx context.Context, caller *auth.Identity, user *model.User) (*model.CurrentUser, error) {
+	mfa, err := s.mfaRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load mfa settings: %w", err)
+	}
+
+	profile, err := s.profiles.GetProfile(ctx, caller, user.ID)
+	if err != nil && !errors.Is(err, ErrProfileNotFound) {
+		return nil, fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	return &model.CurrentUser{User: user, MFAEnabled: mfa.Enabled(), Profile: profile}, nil
+}
+
+// UpdateUserRole はユーザーの権限を変更し、古い権限を持つトークンを使えないように全セッションを失効させる
+func (s *userService) UpdateUserRole(ctx context.Context, caller *auth.Identity, id uuid.UUID, role model.Role) (*model.User, error) {
+	// 自分自身の降格で管理者が不在になるのを防ぐため、自分の権限は変更できない
+	if !caller.IsAdmin() || caller.UserID == id {
+		return nil, ErrForbidden
+	}
+
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+
 	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	if req.Name != nil {
-		user.Name = *req.Name
+	if user.Role == role {
+		return user, nil
 	}
-	if req.Email != nil {
-		user.Email = *req.Email
+
+	if err := s.repo.UpdateRole(ctx, id, role); err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
 	}
-	user.UpdatedAt = time.Now()
+	user.Role = role
 
-	if err := s.repo.Update(ctx, user); err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
+	if err := s.sessions.RevokeAllSessions(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
 	}
 
 	return user, nil
 }
 
-func (s *userService) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+// ChangePassword は呼び出し元自身のパスワードを変更し、全セッションを失効させる
+func (s *userService) ChangePassword(ctx context.Context, caller *auth.Identity, req *model.ChangePasswordRequest) error {
+	user, err := s.repo.GetByID(ctx, caller.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !user.HasLocalPassword() {
+		return ErrPasswordManagedExternally
+	}
+
+	validationErr := &ValidationError{}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		validationErr.Add("current_password", "is incorrect")
+	}
+	validationErr.Add("new_password", s.policy.Validate(req.NewPassword)...)
+	if req.NewPassword == req.CurrentPassword {
+		validationErr.Add("new_password", "must differ from the current password")
+	}
+	if err := validationErr.Err(); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.repo.UpdatePassword(ctx, user.ID, string(hashedPassword)); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if err := s.sessions.RevokeAllSessions(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (s *userService) DeleteUser(ctx context.Context, caller *auth.Identity, id uuid.UUID) error {
+	if !caller.IsAdmin() {
+		return ErrForbidden
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.sessions.RevokeAllSessions(ctx, id); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
 }
 
-func (s *userService) ListUsers(ctx context.Context, limit, offset int) ([]*model.User, error) {
+func (s *userService) ListUsers(ctx context.Context, caller *auth.Identity, limit, offset int) ([]*model.User, error) {
+	if !caller.HasRole(model.RoleStaff, model.RoleAdmin) {
+		return nil, ErrForbidden
+	}
+
 	return s.repo.List(ctx, limit, offset)
 }
 
-func (s *userService) Login(ctx context.Context, req *model.LoginRequest) (*model.LoginResponse, error) {
-	user, err := s.repo.GetByEmail(ctx, req.Email)
+// GetExternalUser は外部 IdP の subject に連携済みのユーザーを返す。作成・紐付け・同期は行わない
+func (s *userService) GetExternalUser(ctx context.Context, subject string) (*model.User, error) {
+	user, err := s.repo.GetByZitadelID(ctx, subject)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, ErrUserNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, fmt.Errorf("failed to load user: %w", err)
 	}
+	return user, nil
+}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+// ProvisionExternalUser は外部 IdP の検証済みクレームからローカルユーザーを解決する。
+// 連携済みであればメールアドレスと名前を同期し、未連携であれば同じメールアドレスのユーザーに紐付けるか、
+// パスワードを持たないユーザーとして新規作成する
+func (s *userService) ProvisionExternalUser(ctx context.Context, identity *model.ExternalIdentity) (*model.User, error) {
+	user, err := s.provisionExternalUser(ctx, identity)
+	if errors.Is(err, repository.ErrUserConflict) {
+		// 同じ利用者の初回アクセスが並行した場合は、先に作成された行を使って再試行する
+		user, err = s.provisionExternalUser(ctx, identity)
 	}
+	return user, err
+}
+
+func (s *userService) provisionExternalUser(ctx context.Context, identity *model.ExternalIdentity) (*model.User, error) {
+	var user *model.User
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		linked, err := s.repo.GetByZitadelIDForUpdate(ctx, identity.Subject)
+		if err == nil {
+			user = linked
+			return s.syncExternalProfile(ctx, user, identity)
+		}
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			return fmt.Errorf("failed to get user by external identity: %w", err)
+		}
+
+		if identity.Email == "" || !identity.EmailVerified {
+			return ErrExternalEmailUnverified
+		}
 
-	token, err := s.generateJWT(user)
+		// 検索に失敗した場合は、同じメールアドレスのユーザーを重複して作成しないようエラーにする
+		existing, err := s.repo.GetByEmail(ctx, identity.Email)
+		if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+			return fmt.Errorf("failed to get user by email: %w", err)
+		}
+		if err == nil {
+			if existing.ZitadelID != nil {
+				return ErrExternalIdentityConflict
+			}
+			if err := s.repo.LinkZitadelID(ctx, existing.ID, identity.Subject); err != nil {
+				return fmt.Errorf("failed to link external identity: %w", err)
+			}
+			subject := identity.Subject
+			existing.ZitadelID = &subject
+			user = existing
+			return s.syncExternalProfile(ctx, user, identity)
+		}
+
+		subject := identity.Subject
+		name := identity.Name
+		if name == "" {
+			name = identity.Email
+		}
+		now := time.Now()
+		user = &model.User{
+			ID:              uuid.New(),
+			Email:           identity.Email,
+			Name:            name,
+			AuthSource:      model.AuthSourceZitadel,
+			Role:            model.RoleMember,
+			ZitadelID:       &subject,
+			EmailVerifiedAt: &now,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		return s.repo.Create(ctx, user)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
 
-	return &model.LoginResponse{
-		Token: token,
-		User:  *user,
-	}, nil
+	return user, nil
 }
 
-func (s *userService) generateJWT(user *model.User) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": user.ID.String(),
-		"email":   user.Email,
-		"exp":     time.Now().Add(time.Hour * time.Duration(s.jwtExpiry)).Unix(),
+// syncExternalProfile は IdP 側で変更されたメールアドレスと名前をローカルユーザーに反映する。
+// メールアドレスは検証済みで、かつ他のユーザーが使用していない場合のみ更新する
+func (s *userService) syncExternalProfile(ctx context.Context, user *model.User, identity *model.ExternalIdentity) error {
+	changed := false
+
+	if identity.Name != "" && identity.Name != user.Name {
+		user.Name = identity.Name
+		changed = true
+	}
+
+	if identity.Email != "" && identity.EmailVerified && identity.Email != user.Email {
+		other, err := s.repo.GetByEmail(ctx, identity.Email)
+		if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+			return fmt.Errorf("failed to get user by email: %w", err)
+		}
+		if err != nil || other.ID == user.ID {
+			user.Email = identity.Email
+			changed = true
+		}
+	}
+
+	if changed {
+		user.UpdatedAt = time.Now()
+		if err := s.repo.Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to sync external profile: %w", err)
+		}
+	}
+
+	// IdP で検証済みのアドレスと一致すれば、ローカルでの確認を済んだものとして扱う
+	if identity.EmailVerified && identity.Email == user.Email && (changed || !user.EmailVerified()) {
+		if _, err := s.repo.MarkEmailVerified(ctx, user.ID, user.Email); err != nil {
+			return fmt.Errorf("failed to mark email verified: %w", err)
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	return nil
 }
@@ -6,7 +6,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/auth"
 	"github.com/StepByCode/TSUNAGU-Link-back/internal/model"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/password"
+	"github.com/StepByCode/TSUNAGU-Link-back/internal/repository"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -39,6 +42,22 @@ func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*mod
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByZitadelIDForUpdate(ctx context.Context, zitadelID string) (*model.User, error) {
+	args := m.Called(ctx, zitadelID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
 func (m *MockUserRepository) Update(ctx context.Context, user *model.User) error {
 	args := m.Called(ctx, user)
 	return args.Error(0)
@@ -57,23 +76,83 @@ func (m *MockUserRepository) List(ctx context.Context, limit, offset int) ([]*mo
 	return args.Get(0).([]*model.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetByZitadelID(ctx context.Context, zitadelID string) (*model.User, error) {
+	args := m.Called(ctx, zitadelID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role model.Role) error {
+	args := m.Called(ctx, id, role)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword string) error {
+	args := m.Called(ctx, id, hashedPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetPendingEmail(ctx context.Context, id uuid.UUID, email *string) error {
+	args := m.Called(ctx, id, email)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) (bool, error) {
+	args := m.Called(ctx, id, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) ApplyPendingEmail(ctx context.Context, id uuid.UUID, email string) (bool, error) {
+	args := m.Called(ctx, id, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) LinkZitadelID(ctx context.Context, id uuid.UUID, zitadelID string) error {
+	args := m.Called(ctx, id, zitadelID)
+	return args.Error(0)
+}
+
+// fakeTransactor はトランザクションを張らずに fn をそのまま実行する
+type fakeTransactor struct {
+	calls int
+}
+
+func (t *fakeTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.calls++
+	return fn(ctx)
+}
+
+type MockSessionRevoker struct {
+	mock.Mock
+}
+
+func (m *MockSessionRevoker) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
 func TestUserService_CreateUser(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, "test-secret", 24)
+	mockVerifier := new(MockEmailVerifier)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), mockVerifier, new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
 
 	ctx := context.Background()
 	req := &model.CreateUserRequest{
 		Email:    "test@example.com",
 		Name:     "Test User",
-		Password: "password123",
+		Password: "correct-horse-battery",
 	}
 
 	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+	mockVerifier.On("SendVerification", ctx, mock.AnythingOfType("*model.User")).Return(nil)
 
 	user, err := service.CreateUser(ctx, req)
 
 	require.NoError(t, err)
 	assert.NotNil(t, user)
+	assert.False(t, user.EmailVerified())
 	assert.Equal(t, req.Email, user.Email)
 	assert.Equal(t, req.Name, user.Name)
 	assert.NotEqual(t, req.Password, user.Password)
@@ -82,17 +161,18 @@ func TestUserService_CreateUser(t *testing.T) {
 	assert.NoError(t, err, "password should be hashed correctly")
 
 	mockRepo.AssertExpectations(t)
+	mockVerifier.AssertExpectations(t)
 }
 
 func TestUserService_CreateUser_Error(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, "test-secret", 24)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
 
 	ctx := context.Background()
 	req := &model.CreateUserRequest{
 		Email:    "test@example.com",
 		Name:     "Test User",
-		Password: "password123",
+		Password: "correct-horse-battery",
 	}
 
 	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(fmt.Errorf("db error"))
@@ -106,9 +186,86 @@ func TestUserService_CreateUser_Error(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUserService_CreateUser_WithProfile(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockVerifier := new(MockEmailVerifier)
+	mockProfiles := new(MockProfileOnboarder)
+	tx := &fakeTransactor{}
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), mockVerifier, mockProfiles, tx, password.DefaultPolicy())
+
+	ctx := context.Background()
+	gradeID := 1
+	req := &model.CreateUserRequest{
+		Email:    "test@example.com",
+		Name:     "Test User",
+		Password: "correct-horse-battery",
+		Profile:  &model.ProfileRequest{DisplayID: "test-user", GradeID: &gradeID},
+	}
+
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+	mockProfiles.On("CreateProfile", ctx, mock.AnythingOfType("*model.User"), req.Profile).Return(&model.Profile{DisplayID: "test-user"}, nil)
+	mockVerifier.On("SendVerification", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+
+	user, err := service.CreateUser(ctx, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, req.Email, user.Email)
+	assert.Equal(t, 1, tx.calls)
+
+	mockRepo.AssertExpectations(t)
+	mockProfiles.AssertExpectations(t)
+}
+
+func TestUserService_CreateUser_InvalidProfile(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockVerifier := new(MockEmailVerifier)
+	mockProfiles := new(MockProfileOnboarder)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), mockVerifier, mockProfiles, &fakeTransactor{}, password.DefaultPolicy())
+
+	ctx := context.Background()
+	req := &model.CreateUserRequest{
+		Email:    "test@example.com",
+		Name:     "Test User",
+		Password: "correct-horse-battery",
+		Profile:  &model.ProfileRequest{DisplayID: "taken"},
+	}
+
+	taken := &ValidationError{}
+	taken.Add("display_id", "is already taken")
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+	mockProfiles.On("CreateProfile", ctx, mock.AnythingOfType("*model.User"), req.Profile).Return(nil, taken)
+
+	user, err := service.CreateUser(ctx, req)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Nil(t, user)
+	assert.Equal(t, []string{"is already taken"}, validationErr.Fields["profile.display_id"])
+	mockVerifier.AssertNotCalled(t, "SendVerification", mock.Anything, mock.Anything)
+}
+
+func TestUserService_CreateUser_ValidationError(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+	user, err := service.CreateUser(context.Background(), &model.CreateUserRequest{
+		Email:    "test@example.com",
+		Password: "password123",
+	})
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Nil(t, user)
+	assert.Equal(t, []string{"is required"}, validationErr.Fields["name"])
+	assert.Equal(t, []string{"is too common"}, validationErr.Fields["password"])
+	assert.NotContains(t, validationErr.Fields, "email")
+
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 func TestUserService_GetUser(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, "test-secret", 24)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
 
 	ctx := context.Background()
 	userID := uuid.New()
@@ -122,7 +279,7 @@ func TestUserService_GetUser(t *testing.T) {
 
 	mockRepo.On("GetByID", ctx, userID).Return(expectedUser, nil)
 
-	user, err := service.GetUser(ctx, userID)
+	user, err := service.GetUser(ctx, &auth.Identity{UserID: userID, Role: model.RoleMember}, userID)
 
 	require.NoError(t, err)
 	assert.Equal(t, expectedUser, user)
@@ -132,7 +289,8 @@ func TestUserService_GetUser(t *testing.T) {
 
 func TestUserService_UpdateUser(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, "test-secret", 24)
+	mockVerifier := new(MockEmailVerifier)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), mockVerifier, new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
 
 	ctx := context.Background()
 	userID := uuid.New()
@@ -152,20 +310,48 @@ func TestUserService_UpdateUser(t *testing.T) {
 	}
 
 	mockRepo.On("GetByID", ctx, userID).Return(existingUser, nil)
-	mockRepo.On("Update", ctx, mock.AnythingOfType("*model.User")).Return(nil)
+	mockRepo.On("Update", ctx, mock.MatchedBy(func(u *model.User) bool {
+		return u.Name == newName && u.Email == "old@example.com"
+	})).Return(nil)
+	mockVerifier.On("RequestEmailChange", ctx, existingUser, newEmail).
+		Run(func(args mock.Arguments) { args.Get(1).(*model.User).PendingEmail = &newEmail }).
+		Return(nil)
 
-	user, err := service.UpdateUser(ctx, userID, req)
+	user, err := service.UpdateUser(ctx, &auth.Identity{UserID: userID, Role: model.RoleMember}, userID, req)
 
 	require.NoError(t, err)
 	assert.Equal(t, newName, user.Name)
-	assert.Equal(t, newEmail, user.Email)
+	assert.Equal(t, "old@example.com", user.Email, "email must not change before it is verified")
+	require.NotNil(t, user.PendingEmail)
+	assert.Equal(t, newEmail, *user.PendingEmail)
 
 	mockRepo.AssertExpectations(t)
+	mockVerifier.AssertExpectations(t)
+}
+
+func TestUserService_UpdateUser_CancelsPendingEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockVerifier := new(MockEmailVerifier)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), mockVerifier, new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+	ctx := context.Background()
+	pending := "new@example.com"
+	user := &model.User{ID: uuid.New(), Email: "old@example.com", PendingEmail: &pending}
+	current := "old@example.com"
+
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("SetPendingEmail", ctx, user.ID, (*string)(nil)).Return(nil)
+
+	updated, err := service.UpdateUser(ctx, &auth.Identity{UserID: user.ID, Role: model.RoleMember}, user.ID, &model.UpdateUserRequest{Email: &current})
+
+	require.NoError(t, err)
+	assert.Nil(t, updated.PendingEmail)
+	mockVerifier.AssertNotCalled(t, "RequestEmailChange", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestUserService_UpdateUser_NotFound(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, "test-secret", 24)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
 
 	ctx := context.Background()
 	userID := uuid.New()
@@ -174,35 +360,100 @@ func TestUserService_UpdateUser_NotFound(t *testing.T) {
 		Name: &newName,
 	}
 
-	mockRepo.On("GetByID", ctx, userID).Return(nil, fmt.Errorf("user not found"))
+	mockRepo.On("GetByID", ctx, userID).Return(nil, repository.ErrUserNotFound)
 
-	user, err := service.UpdateUser(ctx, userID, req)
+	user, err := service.UpdateUser(ctx, &auth.Identity{UserID: userID, Role: model.RoleMember}, userID, req)
 
-	require.Error(t, err)
+	require.ErrorIs(t, err, ErrUserNotFound)
 	assert.Nil(t, user)
 
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUserService_UpdateUser_ExternalUserRejected(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockVerifier := new(MockEmailVerifier)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), mockVerifier, new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "old@example.com", Name: "Old Name", AuthSource: model.AuthSourceZitadel}
+	name := "New Name"
+	email := "new@example.com"
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+	// admin が更新する場合も、ログイン時の同期で上書きされる項目は変更させない
+	_, err := service.UpdateUser(ctx, &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}, user.ID, &model.UpdateUserRequest{Name: &name, Email: &email})
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, map[string][]string{
+		"name":  {"is managed by the external identity provider"},
+		"email": {"is managed by the external identity provider"},
+	}, validationErr.Fields)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	mockVerifier.AssertNotCalled(t, "RequestEmailChange", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_UpdateUser_EmailChangeFailureRollsBack(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockVerifier := new(MockEmailVerifier)
+	tx := &fakeTransactor{}
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), mockVerifier, new(MockProfileOnboarder), tx, password.DefaultPolicy())
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "old@example.com", Name: "Old Name", AuthSource: model.AuthSourceLocal}
+	name := "New Name"
+	email := "new@example.com"
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("Update", ctx, user).Return(nil)
+	mockVerifier.On("RequestEmailChange", ctx, user, email).Return(assert.AnError)
+
+	updated, err := service.UpdateUser(ctx, &auth.Identity{UserID: user.ID, Role: model.RoleMember}, user.ID, &model.UpdateUserRequest{Name: &name, Email: &email})
+
+	// 名前の変更とメールアドレスの変更は同じトランザクションで行い、どちらかが失敗すれば両方取り消す
+	require.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, updated)
+	assert.Equal(t, 1, tx.calls)
+}
+
 func TestUserService_DeleteUser(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, "test-secret", 24)
+	mockSessions := new(MockSessionRevoker)
+	service := NewUserService(mockRepo, new(MockMFARepository), mockSessions, new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
 
 	ctx := context.Background()
 	userID := uuid.New()
 
 	mockRepo.On("Delete", ctx, userID).Return(nil)
+	mockSessions.On("RevokeAllSessions", ctx, userID).Return(nil)
 
-	err := service.DeleteUser(ctx, userID)
+	err := service.DeleteUser(ctx, &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}, userID)
 
 	require.NoError(t, err)
 
 	mockRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+}
+
+func TestUserService_DeleteUser_NotFound(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionRevoker)
+	service := NewUserService(mockRepo, new(MockMFARepository), mockSessions, new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockRepo.On("Delete", ctx, userID).Return(fmt.Errorf("user not found"))
+
+	err := service.DeleteUser(ctx, &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}, userID)
+
+	require.Error(t, err)
+	mockSessions.AssertNotCalled(t, "RevokeAllSessions", mock.Anything, mock.Anything)
 }
 
 func TestUserService_ListUsers(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, "test-secret", 24)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
 
 	ctx := context.Background()
 	expectedUsers := []*model.User{
@@ -224,7 +475,7 @@ func TestUserService_ListUsers(t *testing.T) {
 
 	mockRepo.On("List", ctx, 10, 0).Return(expectedUsers, nil)
 
-	users, err := service.ListUsers(ctx, 10, 0)
+	users, err := service.ListUsers(ctx, &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}, 10, 0)
 
 	require.NoError(t, err)
 	assert.Equal(t, expectedUsers, users)
@@ -232,106 +483,670 @@ func TestUserService_ListUsers(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
-func TestUserService_Login_Success(t *testing.T) {
+func TestUserService_AccessControl(t *testing.T) {
+	selfID := uuid.New()
+	otherID := uuid.New()
+	member := &auth.Identity{UserID: selfID, Role: model.RoleMember}
+	staff := &auth.Identity{UserID: selfID, Role: model.RoleStaff}
+	name := "New Name"
+
+	tests := []struct {
+		name string
+		call func(svc UserService) error
+	}{
+		{
+			name: "member cannot read another user",
+			call: func(svc UserService) error {
+				_, err := svc.GetUser(context.Background(), member, otherID)
+				return err
+			},
+		},
+		{
+			name: "member cannot update another user",
+			call: func(svc UserService) error {
+				_, err := svc.UpdateUser(context.Background(), member, otherID, &model.UpdateUserRequest{Name: &name})
+				return err
+			},
+		},
+		{
+			name: "staff cannot update another user",
+			call: func(svc UserService) error {
+				_, err := svc.UpdateUser(context.Background(), staff, otherID, &model.UpdateUserRequest{Name: &name})
+				return err
+			},
+		},
+		{
+			name: "member cannot delete themselves",
+			call: func(svc UserService) error {
+				return svc.DeleteUser(context.Background(), member, selfID)
+			},
+		},
+		{
+			name: "staff cannot delete users",
+			call: func(svc UserService) error {
+				return svc.DeleteUser(context.Background(), staff, otherID)
+			},
+		},
+		{
+			name: "member cannot list users",
+			call: func(svc UserService) error {
+				_, err := svc.ListUsers(context.Background(), member, 10, 0)
+				return err
+			},
+		},
+		{
+			name: "staff cannot change roles",
+			call: func(svc UserService) error {
+				_, err := svc.UpdateUserRole(context.Background(), staff, otherID, model.RoleAdmin)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			svc := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+			err := tt.call(svc)
+
+			require.ErrorIs(t, err, ErrForbidden)
+			mockRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUserService_GetUser_StaffCanReadOthers(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, "test-secret", 24)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
 
 	ctx := context.Background()
-	password := "password123"
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	userID := uuid.New()
+	expectedUser := &model.User{ID: userID, Email: "test@example.com", Role: model.RoleMember}
 
-	user := &model.User{
-		ID:        uuid.New(),
-		Email:     "test@example.com",
-		Name:      "Test User",
-		Password:  string(hashedPassword),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	mockRepo.On("GetByID", ctx, userID).Return(expectedUser, nil)
+
+	user, err := service.GetUser(ctx, &auth.Identity{UserID: uuid.New(), Role: model.RoleStaff}, userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, expectedUser, user)
+}
+
+func TestUserService_UpdateUser_AdminCanUpdateOthers(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+	ctx := context.Background()
+	userID := uuid.New()
+	existingUser := &model.User{ID: userID, Email: "test@example.com", Name: "Old Name"}
+	newName := "New Name"
+
+	mockRepo.On("GetByID", ctx, userID).Return(existingUser, nil)
+	mockRepo.On("Update", ctx, existingUser).Return(nil)
+
+	user, err := service.UpdateUser(ctx, &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}, userID, &model.UpdateUserRequest{Name: &newName})
+
+	require.NoError(t, err)
+	assert.Equal(t, newName, user.Name)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_UpdateUserRole(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionRevoker)
+	service := NewUserService(mockRepo, new(MockMFARepository), mockSessions, new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+	ctx := context.Background()
+	userID := uuid.New()
+	existingUser := &model.User{ID: userID, Email: "test@example.com", Role: model.RoleMember}
+
+	mockRepo.On("GetByID", ctx, userID).Return(existingUser, nil)
+	mockRepo.On("UpdateRole", ctx, userID, model.RoleStaff).Return(nil)
+	mockSessions.On("RevokeAllSessions", ctx, userID).Return(nil)
+
+	user, err := service.UpdateUserRole(ctx, &auth.Identity{UserID: uuid.New(), Role: model.RoleAdmin}, userID, model.RoleStaff)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleStaff, user.Role)
+
+	mockRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+}
+
+func TestUserService_UpdateUserRole_Rejected(t *testing.T) {
+	adminID := uuid.New()
+	admin := &auth.Identity{UserID: adminID, Role: model.RoleAdmin}
+
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		role    model.Role
+		wantErr error
+	}{
+		{
+			name:    "own role",
+			id:      adminID,
+			role:    model.RoleMember,
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "unknown role",
+			id:      uuid.New(),
+			role:    model.Role("owner"),
+			wantErr: ErrInvalidRole,
+		},
 	}
 
-	req := &model.LoginRequest{
-		Email:    "test@example.com",
-		Password: password,
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+			user, err := service.UpdateUserRole(context.Background(), admin, tt.id, tt.role)
+
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Nil(t, user)
+			mockRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUserService_ProvisionExternalUser_CreatesUser(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	tx := &fakeTransactor{}
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), tx, password.DefaultPolicy())
+
+	ctx := context.Background()
+	identity := &model.ExternalIdentity{
+		Subject:       "zitadel-user",
+		Email:         "new@example.com",
+		EmailVerified: true,
+		Name:          "New User",
 	}
 
-	mockRepo.On("GetByEmail", ctx, req.Email).Return(user, nil)
+	mockRepo.On("GetByZitadelIDForUpdate", ctx, "zitadel-user").Return(nil, repository.ErrUserNotFound)
+	mockRepo.On("GetByEmail", ctx, "new@example.com").Return(nil, repository.ErrUserNotFound)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(nil)
 
-	response, err := service.Login(ctx, req)
+	user, err := service.ProvisionExternalUser(ctx, identity)
 
 	require.NoError(t, err)
-	assert.NotNil(t, response)
-	assert.NotEmpty(t, response.Token)
-	assert.Equal(t, user.ID, response.User.ID)
-	assert.Equal(t, user.Email, response.User.Email)
+	assert.Equal(t, "new@example.com", user.Email)
+	assert.Equal(t, "New User", user.Name)
+	assert.Equal(t, model.AuthSourceZitadel, user.AuthSource)
+	assert.Equal(t, model.RoleMember, user.Role)
+	assert.False(t, user.HasLocalPassword())
+	require.NotNil(t, user.ZitadelID)
+	assert.Equal(t, "zitadel-user", *user.ZitadelID)
+	assert.True(t, user.EmailVerified())
+	assert.Equal(t, 1, tx.calls)
 
 	mockRepo.AssertExpectations(t)
 }
 
-func TestUserService_Login_InvalidEmail(t *testing.T) {
+func TestUserService_ProvisionExternalUser_LinksExistingUser(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, "test-secret", 24)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
 
 	ctx := context.Background()
-	req := &model.LoginRequest{
-		Email:    "nonexistent@example.com",
-		Password: "password123",
+	existing := &model.User{
+		ID:         uuid.New(),
+		Email:      "test@example.com",
+		Name:       "Test User",
+		Password:   "hashed",
+		AuthSource: model.AuthSourceLocal,
+	}
+	identity := &model.ExternalIdentity{
+		Subject:       "zitadel-user",
+		Email:         "test@example.com",
+		EmailVerified: true,
+		Name:          "Test User",
 	}
 
-	mockRepo.On("GetByEmail", ctx, req.Email).Return(nil, fmt.Errorf("user not found"))
+	mockRepo.On("GetByZitadelIDForUpdate", ctx, "zitadel-user").Return(nil, repository.ErrUserNotFound)
+	mockRepo.On("GetByEmail", ctx, "test@example.com").Return(existing, nil)
+	mockRepo.On("LinkZitadelID", ctx, existing.ID, "zitadel-user").Return(nil)
+	mockRepo.On("MarkEmailVerified", ctx, existing.ID, "test@example.com").Return(true, nil)
 
-	response, err := service.Login(ctx, req)
+	user, err := service.ProvisionExternalUser(ctx, identity)
 
-	require.Error(t, err)
-	assert.Nil(t, response)
-	assert.Contains(t, err.Error(), "invalid credentials")
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, user.ID)
+	assert.True(t, user.EmailVerified(), "email verified by the IdP counts as verified")
+	assert.Equal(t, model.AuthSourceLocal, user.AuthSource)
+	require.NotNil(t, user.ZitadelID)
+	assert.Equal(t, "zitadel-user", *user.ZitadelID)
 
 	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
 }
 
-func TestUserService_Login_InvalidPassword(t *testing.T) {
+func TestUserService_ProvisionExternalUser_SyncsProfile(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, "test-secret", 24)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
 
 	ctx := context.Background()
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.DefaultCost)
+	zitadelID := "zitadel-user"
+	linked := &model.User{
+		ID:         uuid.New(),
+		Email:      "old@example.com",
+		Name:       "Old Name",
+		AuthSource: model.AuthSourceZitadel,
+		ZitadelID:  &zitadelID,
+	}
+	identity := &model.ExternalIdentity{
+		Subject:       zitadelID,
+		Email:         "new@example.com",
+		EmailVerified: true,
+		Name:          "New Name",
+	}
 
-	user := &model.User{
-		ID:        uuid.New(),
-		Email:     "test@example.com",
-		Name:      "Test User",
-		Password:  string(hashedPassword),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	mockRepo.On("GetByZitadelIDForUpdate", ctx, zitadelID).Return(linked, nil)
+	mockRepo.On("GetByEmail", ctx, "new@example.com").Return(nil, repository.ErrUserNotFound)
+	mockRepo.On("Update", ctx, linked).Return(nil)
+	mockRepo.On("MarkEmailVerified", ctx, linked.ID, "new@example.com").Return(true, nil)
+
+	user, err := service.ProvisionExternalUser(ctx, identity)
+
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", user.Email)
+	assert.Equal(t, "New Name", user.Name)
+	assert.True(t, user.EmailVerified())
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_ProvisionExternalUser_SkipsEmailTakenByAnotherUser(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+	ctx := context.Background()
+	zitadelID := "zitadel-user"
+	linked := &model.User{
+		ID:         uuid.New(),
+		Email:      "old@example.com",
+		Name:       "Same Name",
+		AuthSource: model.AuthSourceZitadel,
+		ZitadelID:  &zitadelID,
+	}
+	identity := &model.ExternalIdentity{
+		Subject:       zitadelID,
+		Email:         "taken@example.com",
+		EmailVerified: true,
+		Name:          "Same Name",
 	}
 
-	req := &model.LoginRequest{
-		Email:    "test@example.com",
-		Password: "wrongpassword",
+	mockRepo.On("GetByZitadelIDForUpdate", ctx, zitadelID).Return(linked, nil)
+	mockRepo.On("GetByEmail", ctx, "taken@example.com").Return(&model.User{ID: uuid.New()}, nil)
+
+	user, err := service.ProvisionExternalUser(ctx, identity)
+
+	require.NoError(t, err)
+	assert.Equal(t, "old@example.com", user.Email)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUserService_ProvisionExternalUser_Rejected(t *testing.T) {
+	otherID := "other-zitadel-user"
+
+	tests := []struct {
+		name     string
+		identity *model.ExternalIdentity
+		existing *model.User
+		wantErr  error
+	}{
+		{
+			name: "unverified email",
+			identity: &model.ExternalIdentity{
+				Subject: "zitadel-user",
+				Email:   "test@example.com",
+			},
+			wantErr: ErrExternalEmailUnverified,
+		},
+		{
+			name: "email linked to another identity",
+			identity: &model.ExternalIdentity{
+				Subject:       "zitadel-user",
+				Email:         "test@example.com",
+				EmailVerified: true,
+			},
+			existing: &model.User{ID: uuid.New(), Email: "test@example.com", ZitadelID: &otherID},
+			wantErr:  ErrExternalIdentityConflict,
+		},
 	}
 
-	mockRepo.On("GetByEmail", ctx, req.Email).Return(user, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
 
-	response, err := service.Login(ctx, req)
+			ctx := context.Background()
+			mockRepo.On("GetByZitadelIDForUpdate", ctx, "zitadel-user").Return(nil, repository.ErrUserNotFound)
+			if tt.existing != nil {
+				mockRepo.On("GetByEmail", ctx, tt.identity.Email).Return(tt.existing, nil)
+			}
 
-	require.Error(t, err)
-	assert.Nil(t, response)
-	assert.Contains(t, err.Error(), "invalid credentials")
+			user, err := service.ProvisionExternalUser(ctx, tt.identity)
+
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Nil(t, user)
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUserService_ProvisionExternalUser_LookupFailure(t *testing.T) {
+	dbErr := fmt.Errorf("connection refused")
+	zitadelID := "zitadel-user"
+	linked := &model.User{ID: uuid.New(), Email: "old@example.com", Name: "Test User", ZitadelID: &zitadelID}
+
+	tests := []struct {
+		name  string
+		setup func(ctx context.Context, mockRepo *MockUserRepository)
+	}{
+		{
+			name: "external identity lookup fails",
+			setup: func(ctx context.Context, mockRepo *MockUserRepository) {
+				mockRepo.On("GetByZitadelIDForUpdate", ctx, zitadelID).Return(nil, dbErr)
+			},
+		},
+		{
+			name: "email lookup fails before linking",
+			setup: func(ctx context.Context, mockRepo *MockUserRepository) {
+				mockRepo.On("GetByZitadelIDForUpdate", ctx, zitadelID).Return(nil, repository.ErrUserNotFound)
+				mockRepo.On("GetByEmail", ctx, "new@example.com").Return(nil, dbErr)
+			},
+		},
+		{
+			name: "email lookup fails while syncing profile",
+			setup: func(ctx context.Context, mockRepo *MockUserRepository) {
+				mockRepo.On("GetByZitadelIDForUpdate", ctx, zitadelID).Return(linked, nil)
+				mockRepo.On("GetByEmail", ctx, "new@example.com").Return(nil, dbErr)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+			ctx := context.Background()
+			tt.setup(ctx, mockRepo)
+
+			user, err := service.ProvisionExternalUser(ctx, &model.ExternalIdentity{
+				Subject:       zitadelID,
+				Email:         "new@example.com",
+				EmailVerified: true,
+				Name:          "Test User",
+			})
+
+			require.ErrorIs(t, err, dbErr)
+			assert.Nil(t, user)
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "LinkZitadelID", mock.Anything, mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUserService_ProvisionExternalUser_RetriesOnConcurrentCreate(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	tx := &fakeTransactor{}
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), tx, password.DefaultPolicy())
+
+	ctx := context.Background()
+	zitadelID := "zitadel-user"
+	verifiedAt := time.Now()
+	created := &model.User{
+		ID:              uuid.New(),
+		Email:           "new@example.com",
+		Name:            "New User",
+		AuthSource:      model.AuthSourceZitadel,
+		ZitadelID:       &zitadelID,
+		EmailVerifiedAt: &verifiedAt,
+	}
+	identity := &model.ExternalIdentity{
+		Subject:       zitadelID,
+		Email:         "new@example.com",
+		EmailVerified: true,
+		Name:          "New User",
+	}
+
+	mockRepo.On("GetByZitadelIDForUpdate", ctx, zitadelID).Return(nil, repository.ErrUserNotFound).Once()
+	mockRepo.On("GetByEmail", ctx, "new@example.com").Return(nil, repository.ErrUserNotFound).Once()
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*model.User")).Return(repository.ErrUserConflict).Once()
+	mockRepo.On("GetByZitadelIDForUpdate", ctx, zitadelID).Return(created, nil).Once()
+
+	user, err := service.ProvisionExternalUser(ctx, identity)
+
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, user.ID)
+	assert.Equal(t, 2, tx.calls)
 
 	mockRepo.AssertExpectations(t)
 }
 
-func TestUserService_GenerateJWT(t *testing.T) {
+func newLocalUser(t *testing.T, plain string) *model.User {
+	t.Helper()
+	hashed, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.MinCost)
+	require.NoError(t, err)
+	return &model.User{ID: uuid.New(), Email: "test@example.com", Password: string(hashed), AuthSource: model.AuthSourceLocal}
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo, "test-secret", 24).(*userService)
+	mockSessions := new(MockSessionRevoker)
+	service := NewUserService(mockRepo, new(MockMFARepository), mockSessions, new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+	ctx := context.Background()
+	user := newLocalUser(t, "correct-horse-battery")
+
+	var newHash string
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("UpdatePassword", ctx, user.ID, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { newHash = args.String(2) }).
+		Return(nil)
+	mockSessions.On("RevokeAllSessions", ctx, user.ID).Return(nil)
 
-	user := &model.User{
-		ID:    uuid.New(),
-		Email: "test@example.com",
-		Name:  "Test User",
+	err := service.ChangePassword(ctx, &auth.Identity{UserID: user.ID}, &model.ChangePasswordRequest{
+		CurrentPassword: "correct-horse-battery",
+		NewPassword:     "staple-orbit-lantern",
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(newHash), []byte("staple-orbit-lantern")))
+
+	mockRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+}
+
+func TestUserService_ChangePassword_Rejected(t *testing.T) {
+	tests := []struct {
+		name       string
+		current    string
+		newPass    string
+		wantFields map[string][]string
+	}{
+		{
+			name:       "wrong current password",
+			current:    "not-my-password",
+			newPass:    "staple-orbit-lantern",
+			wantFields: map[string][]string{"current_password": {"is incorrect"}},
+		},
+		{
+			name:       "common new password",
+			current:    "correct-horse-battery",
+			newPass:    "password123",
+			wantFields: map[string][]string{"new_password": {"is too common"}},
+		},
+		{
+			name:       "same as current password",
+			current:    "correct-horse-battery",
+			newPass:    "correct-horse-battery",
+			wantFields: map[string][]string{"new_password": {"must differ from the current password"}},
+		},
 	}
 
-	token, err := service.generateJWT(user)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockSessions := new(MockSessionRevoker)
+			service := NewUserService(mockRepo, new(MockMFARepository), mockSessions, new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+			ctx := context.Background()
+			user := newLocalUser(t, "correct-horse-battery")
+			mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+			err := service.ChangePassword(ctx, &auth.Identity{UserID: user.ID}, &model.ChangePasswordRequest{
+				CurrentPassword: tt.current,
+				NewPassword:     tt.newPass,
+			})
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.wantFields, validationErr.Fields)
+			mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+			mockSessions.AssertNotCalled(t, "RevokeAllSessions", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUserService_ChangePassword_ExternalUser(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), AuthSource: model.AuthSourceZitadel}
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+	err := service.ChangePassword(ctx, &auth.Identity{UserID: user.ID}, &model.ChangePasswordRequest{
+		CurrentPassword: "anything",
+		NewPassword:     "staple-orbit-lantern",
+	})
+
+	require.ErrorIs(t, err, ErrPasswordManagedExternally)
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_GetCurrentUser(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockMFARepo := new(MockMFARepository)
+	mockProfiles := new(MockProfileOnboarder)
+	service := NewUserService(mockRepo, mockMFARepo, new(MockSessionRevoker), new(MockEmailVerifier), mockProfiles, &fakeTransactor{}, password.DefaultPolicy())
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Name: "Test User", Role: model.RoleStaff}
+	caller := &auth.Identity{UserID: user.ID, Role: model.RoleStaff}
+	profile := &model.Profile{UserID: user.ID, DisplayID: "taro", Name: "山田太郎"}
+	enabledAt := time.Now()
+
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockMFARepo.On("GetByUserID", ctx, user.ID).Return(&model.UserMFA{UserID: user.ID, EnabledAt: &enabledAt}, nil)
+	mockProfiles.On("GetProfile", ctx, caller, user.ID).Return(profile, nil)
+
+	current, err := service.GetCurrentUser(ctx, caller)
+
+	require.NoError(t, err)
+	assert.Equal(t, user, current.User)
+	assert.True(t, current.MFAEnabled)
+	assert.Equal(t, profile, current.Profile)
+}
+
+func TestUserService_GetCurrentUser_WithoutProfile(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockMFARepo := new(MockMFARepository)
+	mockProfiles := new(MockProfileOnboarder)
+	service := NewUserService(mockRepo, mockMFARepo, new(MockSessionRevoker), new(MockEmailVerifier), mockProfiles, &fakeTransactor{}, password.DefaultPolicy())
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Name: "Test User", Role: model.RoleMember}
+	caller := &auth.Identity{UserID: user.ID, Role: model.RoleMember}
+
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockMFARepo.On("GetByUserID", ctx, user.ID).Return(nil, nil)
+	mockProfiles.On("GetProfile", ctx, caller, user.ID).Return(nil, ErrProfileNotFound)
+
+	current, err := service.GetCurrentUser(ctx, caller)
 
 	require.NoError(t, err)
-	assert.NotEmpty(t, token)
+	assert.Nil(t, current.Profile)
+}
+
+func TestUserService_UpdateCurrentUser(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockMFARepo := new(MockMFARepository)
+	mockProfiles := new(MockProfileOnboarder)
+	service := NewUserService(mockRepo, mockMFARepo, new(MockSessionRevoker), new(MockEmailVerifier), mockProfiles, &fakeTransactor{}, password.DefaultPolicy())
+
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Name: "Old Name", AuthSource: model.AuthSourceLocal, Role: model.RoleMember}
+	newName := "New Name"
+	mockProfiles.On("GetProfile", ctx, mock.Anything, user.ID).Return(nil, ErrProfileNotFound)
+
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("Update", ctx, mock.MatchedBy(func(u *model.User) bool {
+		return u.ID == user.ID && u.Name == newName && u.Role == model.RoleMember
+	})).Return(nil)
+	mockMFARepo.On("GetByUserID", ctx, user.ID).Return(nil, nil)
+
+	current, err := service.UpdateCurrentUser(ctx, &auth.Identity{UserID: user.ID, Role: model.RoleMember}, &model.UpdateCurrentUserRequest{Name: &newName})
+
+	require.NoError(t, err)
+	assert.Equal(t, newName, current.Name)
+	assert.Equal(t, "test@example.com", current.Email)
+	assert.False(t, current.MFAEnabled)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_UpdateCurrentUser_Rejected(t *testing.T) {
+	empty := ""
+	name := "New Name"
+	email := "new@example.com"
+
+	tests := []struct {
+		name       string
+		authSource string
+		req        *model.UpdateCurrentUserRequest
+		wantFields map[string][]string
+	}{
+		{
+			name:       "empty name",
+			authSource: model.AuthSourceLocal,
+			req:        &model.UpdateCurrentUserRequest{Name: &empty},
+			wantFields: map[string][]string{"name": {"must not be empty"}},
+		},
+		{
+			name:       "external user",
+			authSource: model.AuthSourceZitadel,
+			req:        &model.UpdateCurrentUserRequest{Name: &name, Email: &email},
+			wantFields: map[string][]string{
+				"name":  {"is managed by the external identity provider"},
+				"email": {"is managed by the external identity provider"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			service := NewUserService(mockRepo, new(MockMFARepository), new(MockSessionRevoker), new(MockEmailVerifier), new(MockProfileOnboarder), &fakeTransactor{}, password.DefaultPolicy())
+
+			ctx := context.Background()
+			user := &model.User{ID: uuid.New(), Name: "Old Name", AuthSource: tt.authSource}
+			mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+			_, err := service.UpdateCurrentUser(ctx, &auth.Identity{UserID: user.ID, Role: model.RoleMember}, tt.req)
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.wantFields, validationErr.Fields)
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
 }
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ValidationError はリクエストの項目ごとの検証エラーを保持する
+type ValidationError struct {
+	Fields map[string][]string `json:"fields"`
+}
+
+func (e *ValidationError) Add(field string, messages ...string) {
+	if len(messages) == 0 {
+		return
+	}
+	if e.Fields == nil {
+		e.Fields = make(map[string][]string)
+	}
+	e.Fields[field] = append(e.Fields[field], messages...)
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, field+" "+strings.Join(e.Fields[field], ", "))
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Err は検証エラーがあれば自身を、なければ nil を返す
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// nestValidationError は検証エラーの項目名を prefix.field に置き換える。検証エラー以外はそのまま返す
+func nestValidationError(prefix string, err error) error {
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	nested := &ValidationError{}
+	for field, messages := range validationErr.Fields {
+		nested.Add(prefix+"."+field, messages...)
+	}
+	return nested
+}
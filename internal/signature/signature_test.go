@@ -0,0 +1,81 @@
+package signature
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRequest() *Request {
+	return &Request{
+		Method:    "post",
+		Path:      "/api/v1/attendance/tap",
+		Timestamp: "1792310400",
+		Nonce:     "3f2b8c1e",
+		Body:      []byte(`{"nfc_serial":"04A3B2C1"}`),
+	}
+}
+
+func TestRequest_Message(t *testing.T) {
+	want := "POST\n/api/v1/attendance/tap\n1792310400\n3f2b8c1e\n" +
+		"7cb92d03850c8639d5b4609f2c3e4b50a7fba5f3089113df48860e59d7127eaf"
+
+	assert.Equal(t, want, testRequest().Message())
+}
+
+// 端末側の実装と突き合わせるための固定値
+func TestRequest_Sign(t *testing.T) {
+	assert.Equal(t, "32d7818e97d2eb54646b87ff0cf6d75e0ebf3ef0f66800c1298b9e4eb757af41", testRequest().Sign("device-secret"))
+}
+
+func TestRequest_Verify(t *testing.T) {
+	signature := testRequest().Sign("device-secret")
+
+	tests := []struct {
+		name      string
+		modify    func(r *Request)
+		secret    string
+		signature string
+		want      bool
+	}{
+		{name: "valid", secret: "device-secret", signature: signature, want: true},
+		{name: "uppercase hex", secret: "device-secret", signature: "32D7818E97D2EB54646B87FF0CF6D75E0EBF3EF0F66800C1298B9E4EB757AF41", want: true},
+		{name: "wrong secret", secret: "other-secret", signature: signature},
+		{name: "not hex", secret: "device-secret", signature: "not-a-signature"},
+		{name: "body changed", modify: func(r *Request) { r.Body = []byte(`{"nfc_serial":"FFFF"}`) }, secret: "device-secret", signature: signature},
+		{name: "path changed", modify: func(r *Request) { r.Path = "/api/v1/attendance/check-in" }, secret: "device-secret", signature: signature},
+		{name: "nonce changed", modify: func(r *Request) { r.Nonce = "other" }, secret: "device-secret", signature: signature},
+		{name: "timestamp changed", modify: func(r *Request) { r.Timestamp = "1792310401" }, secret: "device-secret", signature: signature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testRequest()
+			if tt.modify != nil {
+				tt.modify(req)
+			}
+			assert.Equal(t, tt.want, req.Verify(tt.secret, tt.signature))
+		})
+	}
+}
+
+func TestRequest_Time(t *testing.T) {
+	got, err := testRequest().Time()
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1792310400, 0), got)
+
+	_, err = (&Request{Timestamp: "2026-10-18T00:00:00Z"}).Time()
+	assert.Error(t, err)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	require.NoError(t, err)
+	b, err := GenerateSecret()
+	require.NoError(t, err)
+
+	assert.Len(t, a, 43)
+	assert.NotEqual(t, a, b)
+}
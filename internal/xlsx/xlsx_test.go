@@ -0,0 +1,69 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readPart(t *testing.T, data []byte, name string) string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	f, err := zr.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "入退室 <集計>")
+	require.NoError(t, err)
+	require.NoError(t, w.WriteRow("名前", "時間"))
+	require.NoError(t, w.WriteRow("山田 & 太郎", 1.5, nil, 3))
+	require.NoError(t, w.Close())
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		assert.NotEmpty(t, readPart(t, buf.Bytes(), name), name)
+	}
+	assert.Contains(t, readPart(t, buf.Bytes(), "xl/workbook.xml"), `name="入退室 &lt;集計&gt;"`)
+
+	sheet := readPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml")
+	var parsed struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(sheet), &parsed))
+	require.Len(t, parsed.Rows, 2)
+	assert.Equal(t, "A1", parsed.Rows[0].Cells[0].Ref)
+	assert.Equal(t, "名前", parsed.Rows[0].Cells[0].Inline)
+	cells := parsed.Rows[1].Cells
+	require.Len(t, cells, 3)
+	assert.Equal(t, "山田 & 太郎", cells[0].Inline)
+	assert.Equal(t, "inlineStr", cells[0].Type)
+	assert.Equal(t, "1.5", cells[1].Value)
+	assert.Equal(t, "", cells[1].Type)
+	// nil のセルは省略し、次のセルの位置は変えない
+	assert.Equal(t, "D2", cells[2].Ref)
+	assert.Equal(t, "3", cells[2].Value)
+}
+
+func TestColumnName(t *testing.T) {
+	for index, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, want, columnName(index))
+	}
+}